@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	context "context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/footfish/numan"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//errorDomain is the ErrorInfo domain of numan errors
+const errorDomain = "numan"
+
+//errorCodes maps error kinds to gRPC status codes. The kind is also sent as ErrorInfo detail (kinds can share a code).
+var errorCodes = map[error]codes.Code{
+	numan.ErrInvalidArgument:        codes.InvalidArgument,
+	numan.ErrNotFound:               codes.NotFound,
+	numan.ErrAlreadyExists:          codes.AlreadyExists,
+	numan.ErrConflict:               codes.FailedPrecondition,
+	numan.ErrQuarantined:            codes.FailedPrecondition,
+	numan.ErrUnauthenticated:        codes.Unauthenticated,
+	numan.ErrPermissionDenied:       codes.PermissionDenied,
+	numan.ErrLocked:                 codes.ResourceExhausted,
+	numan.ErrPasswordChangeRequired: codes.FailedPrecondition,
+	numan.ErrUnimplemented:          codes.Unimplemented,
+	numan.ErrRateLimited:            codes.ResourceExhausted,
+}
+
+//errorReason returns the ErrorInfo reason of an error kind (ex. ErrNotFound -> NOT_FOUND)
+func errorReason(kind error) string {
+	return strings.ToUpper(strings.ReplaceAll(kind.Error(), " ", "_"))
+}
+
+//toStatus converts a service error to a gRPC status error with the error kind as ErrorInfo detail.
+//Context errors keep their codes, other errors without a kind are codes.Unknown.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	kind := numan.ErrorKind(err)
+	if kind == nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	st, detailErr := status.New(errorCodes[kind], err.Error()).WithDetails(&errdetails.ErrorInfo{Reason: errorReason(kind), Domain: errorDomain})
+	if detailErr != nil {
+		return status.Error(errorCodes[kind], err.Error())
+	}
+	return st.Err()
+}
+
+//fromStatus converts a gRPC status error back to a numan.Error of the kind in its ErrorInfo detail (or code), so errors.Is works on the client.
+//Errors with no matching kind (ex. codes.Unavailable) are returned as is.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if err == nil || !ok {
+		return err
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == errorDomain {
+			for _, kind := range numan.ErrorKinds {
+				if errorReason(kind) == info.GetReason() {
+					return &numan.Error{Kind: kind, Msg: st.Message()}
+				}
+			}
+		}
+	}
+	for _, kind := range numan.ErrorKinds { //no detail (ex. other servers), first kind with the code
+		if errorCodes[kind] == st.Code() {
+			return &numan.Error{Kind: kind, Msg: st.Message()}
+		}
+	}
+	return err
+}
+
+//errorServerInterceptor converts service errors to gRPC status errors (see toStatus)
+func errorServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatus(err)
+}
+
+//errorConn converts gRPC status errors of calls on a connection back to service errors (see fromStatus).
+//Each call is sent with a request ID (from ctx or new), errors include it (see RequestID).
+type errorConn struct {
+	grpc.ClientConnInterface
+}
+
+//Invoke implements grpc.ClientConnInterface
+func (c errorConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	ctx, id := outgoingRequestID(ctx)
+	if err := fromStatus(c.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)); err != nil {
+		return &requestError{err: err, id: id}
+	}
+	return nil
+}
+
+//NewStream implements grpc.ClientConnInterface
+func (c errorConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, id := outgoingRequestID(ctx)
+	stream, err := c.ClientConnInterface.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		return nil, &requestError{err: fromStatus(err), id: id}
+	}
+	return &errorStream{ClientStream: stream, id: id}, nil
+}
+
+//outgoingRequestID adds the request ID from ctx (or a new one) to the outgoing metadata
+func outgoingRequestID(ctx context.Context) (context.Context, string) {
+	id, ok := ctx.Value(numan.RequestIDField).(string)
+	if !ok || id == "" {
+		id = newRequestID()
+	}
+	return metadata.AppendToOutgoingContext(ctx, numan.RequestIDField, id), id
+}
+
+//errorStream converts gRPC status errors received on a stream back to service errors (see fromStatus)
+type errorStream struct {
+	grpc.ClientStream
+	id string
+}
+
+//RecvMsg implements grpc.ClientStream, io.EOF (end of stream) is returned as is
+func (s *errorStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return &requestError{err: fromStatus(err), id: s.id}
+}
@@ -0,0 +1,93 @@
+package grpc
+
+import (
+	context "context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestStatusConversion(t *testing.T) {
+	for _, kind := range numan.ErrorKinds {
+		err := fmt.Errorf("wrapped, %w", numan.Errorf(kind, "test %s", kind))
+		st := toStatus(err)
+		if got, want := status.Code(st), errorCodes[kind]; got != want {
+			t.Errorf("%v code got %v, want %v", kind, got, want)
+		}
+		back := fromStatus(st)
+		if !errors.Is(back, kind) {
+			t.Errorf("%v converted back to %v", kind, numan.ErrorKind(back))
+		}
+		if back.Error() != err.Error() {
+			t.Errorf("%v message got %q, want %q", kind, back.Error(), err.Error())
+		}
+	}
+	if got := status.Code(toStatus(errors.New("other"))); got != codes.Unknown {
+		t.Errorf("error without kind got %v, want Unknown", got)
+	}
+	if got := status.Code(toStatus(context.DeadlineExceeded)); got != codes.DeadlineExceeded {
+		t.Errorf("deadline got %v, want DeadlineExceeded", got)
+	}
+	unavailable := status.Error(codes.Unavailable, "down")
+	if got := fromStatus(unavailable); got != unavailable {
+		t.Errorf("Unavailable converted to %v", got)
+	}
+	if got := fromStatus(status.Error(codes.NotFound, "no detail")); !errors.Is(got, numan.ErrNotFound) {
+		t.Errorf("NotFound without detail converted to %v", got)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(errorServerInterceptor, authServerInterceptor(service.NewUserService(store), service.NewCertAuthenticator(store), nil, nil)))
+	RegisterNumberingServer(server, NewNumberingServerAdapter(store))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(), grpc.WithUnaryInterceptor(authClientInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	numbering := NewNumberingClientAdapter(conn)
+
+	admin := numan.User{Username: "root", Roles: []string{numan.RoleAdmin}}
+	if err := admin.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	adminCtx := context.WithValue(ctx, numan.AuthTokenField, admin.AccessToken)
+	number := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}
+	ownerID := int64(1)
+
+	if err := numbering.Add(adminCtx, &number); err != nil {
+		t.Fatal(err)
+	}
+	if err := numbering.Add(adminCtx, &number); !errors.Is(err, numan.ErrAlreadyExists) {
+		t.Errorf("Add twice got %v, want ErrAlreadyExists", err)
+	}
+	missing := numan.E164{Cc: "353", Ndc: "01", Sn: "99999999"}
+	if err := numbering.Allocate(adminCtx, &missing, &ownerID); !errors.Is(err, numan.ErrNotFound) {
+		t.Errorf("Allocate missing got %v, want ErrNotFound", err)
+	}
+	if err := numbering.Allocate(ctx, &number.E164, &ownerID); !errors.Is(err, numan.ErrUnauthenticated) {
+		t.Errorf("Allocate without token got %v, want ErrUnauthenticated", err)
+	}
+}
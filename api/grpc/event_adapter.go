@@ -0,0 +1,189 @@
+package grpc
+
+import (
+	context "context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//watchRetryDelay is the delay before a broken Watch stream is reopened
+var watchRetryDelay = time.Second
+
+//eventClientAdapter implements an adapter from EventService to eventClient(gRPC).
+type eventClientAdapter struct {
+	grpc *eventClient
+}
+
+// NewEventClientAdapter instantiates EventClientAdapter
+func NewEventClientAdapter(conn *grpc.ClientConn) numan.EventService {
+	c := NewEventClient(errorConn{conn}) //errors are converted back to service errors
+	return &eventClientAdapter{c.(*eventClient)}
+}
+
+//ListEvents implements EventService.ListEvents()
+func (c *eventClientAdapter) ListEvents(ctx context.Context, filter *numan.EventFilter, limit int) (events []numan.NumberEvent, err error) {
+	if filter == nil {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	listEventsResponse, err := c.grpc.ListEvents(ctx, &ListEventsRequest{Filter: marshalEventFilter(filter), Limit: int32(limit)})
+	if err == nil {
+		for _, e := range listEventsResponse.Event {
+			events = append(events, *unMarshalEvent(e))
+		}
+	}
+	return
+}
+
+//Watch implements EventService.Watch()
+//If the connection to the server is lost the stream is reopened after the last event received (waiting for the server).
+func (c *eventClientAdapter) Watch(ctx context.Context, filter *numan.EventFilter, send func(numan.NumberEvent) error) error {
+	if filter == nil || send == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() //closes the stream
+	next := *filter
+	for {
+		err := c.watch(ctx, &next, send)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !unavailable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
+
+//watch opens a Watch stream for filter & sends the events received, filter.AfterSeq is updated as events are sent
+func (c *eventClientAdapter) watch(ctx context.Context, filter *numan.EventFilter, send func(numan.NumberEvent) error) error {
+	stream, err := c.grpc.Watch(ctx, &WatchRequest{Filter: marshalEventFilter(filter)}, grpc.WaitForReady(true))
+	if err != nil {
+		return err
+	}
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		event := unMarshalEvent(e)
+		if err := send(*event); err != nil {
+			return err
+		}
+		filter.AfterSeq = event.Seq
+	}
+}
+
+//unavailable returns true for a gRPC codes.Unavailable error (ex. connection lost)
+func unavailable(err error) bool {
+	var st interface{ GRPCStatus() *status.Status }
+	return errors.As(err, &st) && st.GRPCStatus().Code() == codes.Unavailable
+}
+
+//eventServerAdapter implements an adapter from EventServer(gRPC) to EventService.
+type eventServerAdapter struct {
+	service numan.EventService
+	UnimplementedEventServer
+}
+
+//  NewEventServerAdapter creates a new EventServerAdapter
+func NewEventServerAdapter(store *datastore.Store) EventServer {
+	return &eventServerAdapter{service: service.NewEventService(store)}
+}
+
+//ListEvents implements EventServer.ListEvents()
+func (s *eventServerAdapter) ListEvents(ctx context.Context, in *ListEventsRequest) (*ListEventsResponse, error) {
+	events, err := s.service.ListEvents(ctx, unMarshalEventFilter(in.Filter), int(in.Limit))
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListEventsResponse{}
+	for i := range events {
+		resp.Event = append(resp.Event, marshalEvent(&events[i]))
+	}
+	return resp, nil
+}
+
+//Watch implements EventServer.Watch()
+func (s *eventServerAdapter) Watch(in *WatchRequest, stream Event_WatchServer) error {
+	return s.service.Watch(stream.Context(), unMarshalEventFilter(in.Filter), func(e numan.NumberEvent) error {
+		return stream.Send(marshalEvent(&e))
+	})
+}
+
+func marshalEventFilter(f *numan.EventFilter) *EventFilter {
+	if f == nil {
+		return &EventFilter{}
+	}
+	return &EventFilter{
+		AfterSeq: f.AfterSeq,
+		Domain:   f.Domain,
+		Carrier:  f.Carrier,
+		Prefix:   f.Prefix,
+		Types:    f.Types,
+	}
+}
+
+func unMarshalEventFilter(f *EventFilter) *numan.EventFilter {
+	if f == nil {
+		return &numan.EventFilter{}
+	}
+	return &numan.EventFilter{
+		AfterSeq: f.AfterSeq,
+		Domain:   f.Domain,
+		Carrier:  f.Carrier,
+		Prefix:   f.Prefix,
+		Types:    f.Types,
+	}
+}
+
+func marshalEvent(e *numan.NumberEvent) *NumberEvent {
+	if e == nil {
+		return &NumberEvent{}
+	}
+	return &NumberEvent{
+		Seq:       e.Seq,
+		Type:      e.Type,
+		E164:      marshalE164(&e.E164),
+		Domain:    e.Domain,
+		Carrier:   e.Carrier,
+		OldState:  e.OldState,
+		NewState:  e.NewState,
+		OwnerID:   e.OwnerID,
+		Actor:     e.Actor,
+		Timestamp: e.Timestamp,
+	}
+}
+
+func unMarshalEvent(e *NumberEvent) *numan.NumberEvent {
+	if e == nil {
+		return &numan.NumberEvent{}
+	}
+	return &numan.NumberEvent{
+		Seq:       e.Seq,
+		Type:      e.Type,
+		E164:      *unMarshalE164(e.E164),
+		Domain:    e.Domain,
+		Carrier:   e.Carrier,
+		OldState:  e.OldState,
+		NewState:  e.NewState,
+		OwnerID:   e.OwnerID,
+		Actor:     e.Actor,
+		Timestamp: e.Timestamp,
+	}
+}
@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	context "context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestWatch(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	defer func(delay time.Duration) { watchRetryDelay = delay }(watchRetryDelay)
+	watchRetryDelay = 10 * time.Millisecond
+
+	//serve starts a server on a new listener, clients dial the latest one
+	var mu sync.Mutex
+	var lis *bufconn.Listener
+	serve := func() *grpc.Server {
+		mu.Lock()
+		defer mu.Unlock()
+		lis = bufconn.Listen(1 << 20)
+		server := NewGrpcServer(nil, store, nil, nil, nil)
+		RegisterNumberingServer(server, NewNumberingServerAdapter(store))
+		RegisterEventServer(server, NewEventServerAdapter(store))
+		go server.Serve(lis)
+		return server
+	}
+	server := serve()
+	defer func() { server.Stop() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return lis.Dial()
+	}), grpc.WithInsecure(), grpc.WithUnaryInterceptor(authClientInterceptor), grpc.WithStreamInterceptor(authStreamClientInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	numbering, events := NewNumberingClientAdapter(conn), NewEventClientAdapter(conn)
+
+	//unauthenticated
+	if err := events.Watch(ctx, &numan.EventFilter{}, func(numan.NumberEvent) error { return nil }); !errors.Is(err, numan.ErrUnauthenticated) {
+		t.Fatalf("Watch without token got %v, want ErrUnauthenticated", err)
+	}
+
+	admin := numan.User{Username: "root", Roles: []string{numan.RoleAdmin}}
+	if err := admin.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	adminCtx := context.WithValue(ctx, numan.AuthTokenField, admin.AccessToken)
+	watchCtx, stopWatch := context.WithCancel(adminCtx)
+	defer stopWatch()
+	received := make(chan numan.NumberEvent, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- events.Watch(watchCtx, &numan.EventFilter{Domain: "test.com", Types: []string{numan.EventAdded}}, func(e numan.NumberEvent) error {
+			received <- e
+			return nil
+		})
+	}()
+	next := func() numan.NumberEvent {
+		t.Helper()
+		select {
+		case e := <-received:
+			return e
+		case err := <-done:
+			t.Fatalf("Watch ended: %v", err)
+		case <-ctx.Done():
+			t.Fatal("no event")
+		}
+		return numan.NumberEvent{}
+	}
+
+	first := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}
+	if err := numbering.Add(adminCtx, &first); err != nil {
+		t.Fatal(err)
+	}
+	e := next()
+	if e.Type != numan.EventAdded || e.E164 != first.E164 || e.Actor != "root" || e.Carrier != "carrier" {
+		t.Fatalf("got %+v, want added %v by root", e, first.E164)
+	}
+
+	//the stream is reopened after the last event when the server is replaced
+	server.Stop()
+	server = serve()
+	second := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345002"}, Domain: "test.com", Carrier: "carrier"}
+	if err := numbering.Add(adminCtx, &second); err != nil {
+		t.Fatal(err)
+	}
+	if e2 := next(); e2.Seq <= e.Seq || e2.E164 != second.E164 {
+		t.Fatalf("got %+v after reconnect, want added %v", e2, second.E164)
+	}
+	stopWatch()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Watch ended with %v, want context.Canceled", err)
+	}
+	if list, err := events.ListEvents(adminCtx, &numan.EventFilter{}, 10); err != nil || len(list) != 2 {
+		t.Fatalf("ListEvents got %d events (err %v), want 2", len(list), err)
+	}
+}
@@ -2,26 +2,68 @@ package grpc
 
 import (
 	context "context"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 
 	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/ratelimit"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
 	grpc "google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
-// NewGrpcServer creates a new grpc.Server
-func NewGrpcServer(creds credentials.TransportCredentials) *grpc.Server {
-	return grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(authServerInterceptor))
+// NewGrpcServer creates a new grpc.Server, store is used to authenticate API keys & client certificates.
+// Service errors are returned as gRPC status errors (see toStatus), request counts & latency are recorded in metrics.
+// oidcAuth authenticates identity provider tokens, nil if OIDC login is not enabled.
+// Each call gets a request ID and a trace span, requestLog receives a JSON line per call (nil not logged).
+// Calls over the rate limits of limiter are refused (nil not limited).
+// Streaming calls (Watch) go through the same interceptors.
+func NewGrpcServer(creds credentials.TransportCredentials, store *datastore.Store, oidcAuth *service.OIDCAuthenticator, requestLog io.Writer, limiter *ratelimit.Limiter) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{requestServerInterceptor(requestLog), metricsServerInterceptor, errorServerInterceptor, authServerInterceptor(service.NewUserService(store), service.NewCertAuthenticator(store), oidcAuth, limiter)}
+	if limiter != nil {
+		interceptors = append(interceptors, rateLimitServerInterceptor(limiter))
+	}
+	var streamInterceptors []grpc.StreamServerInterceptor
+	for _, interceptor := range interceptors {
+		streamInterceptors = append(streamInterceptors, streamInterceptor(interceptor))
+	}
+	return grpc.NewServer(grpc.Creds(creds), grpc.ChainUnaryInterceptor(interceptors...), grpc.ChainStreamInterceptor(streamInterceptors...))
+}
+
+//streamInterceptor runs a unary interceptor for streaming calls (with a nil request), the stream handler gets the ctx set by the interceptor
+func streamInterceptor(interceptor grpc.UnaryServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		_, err := interceptor(ss.Context(), nil, &grpc.UnaryServerInfo{Server: srv, FullMethod: info.FullMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})
+		return err
+	}
+}
+
+//serverStream is a grpc.ServerStream with the ctx set by interceptors
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
 }
 
-// NewGrpcClient creates a new grpc client connection
+//Context implements grpc.ServerStream
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// NewGrpcClient creates a new grpc client connection, waiting until connected. The process exits on failure.
+// Deprecated: use NewClientConn, or the client package which also handles authentication & retries.
 func NewGrpcClient(ctx context.Context, address string, creds credentials.TransportCredentials) *grpc.ClientConn {
 	// Set up a connection to the server.
 	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(creds),
-		grpc.WithUnaryInterceptor(authClientInterceptor), grpc.WithBlock())
+		grpc.WithUnaryInterceptor(authClientInterceptor), grpc.WithStreamInterceptor(authStreamClientInterceptor), grpc.WithBlock())
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			log.Fatalf("gRPC connect timeout (check server is running)")
@@ -32,24 +74,107 @@ func NewGrpcClient(ctx context.Context, address string, creds credentials.Transp
 	return conn
 }
 
-//authServerInterceptor copies a token from gRPC metadata to context
-func authServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
-) (resp interface{}, err error) {
-	meta, ok := metadata.FromIncomingContext(ctx)
-	if ok && len(meta[numan.AuthTokenField]) == 1 {
-		ctx = context.WithValue(ctx, numan.AuthTokenField, meta[numan.AuthTokenField][0])
+// NewClientConn creates a grpc client connection for the client adapters, connecting in the background (calls wait while connecting).
+// A token, API key or OIDC token in the call context is sent as metadata, after the interceptors of opts (so they can add one).
+// creds nil connects without TLS.
+func NewClientConn(address string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	transport := grpc.WithInsecure()
+	if creds != nil {
+		transport = grpc.WithTransportCredentials(creds)
+	}
+	opts = append([]grpc.DialOption{transport}, opts...)
+	opts = append(opts, grpc.WithChainUnaryInterceptor(authClientInterceptor), grpc.WithChainStreamInterceptor(authStreamClientInterceptor))
+	return grpc.Dial(address, opts...)
+}
+
+//authServerInterceptor copies a token from gRPC metadata and the client address to context.
+//An API key (without a token) is authenticated with users and exchanged for a token for the call, as is an OIDC token with oidcAuth.
+//Otherwise a verified client certificate (mutual TLS) is authenticated with certs and exchanged for a token.
+//Failed exchanges are rate limited by source address with limiter, nil not limited (see limitAuth).
+func authServerInterceptor(users numan.UserService, certs *service.CertAuthenticator, oidcAuth *service.OIDCAuthenticator, limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (resp interface{}, err error) {
+		if p, ok := peer.FromContext(ctx); ok { //client address, failed logins are counted by source
+			ctx = context.WithValue(ctx, numan.SourceField, sourceAddress(p.Addr))
+		}
+		meta, ok := metadata.FromIncomingContext(ctx)
+		if ok && len(meta[numan.AuthTokenField]) == 1 {
+			ctx = context.WithValue(ctx, numan.AuthTokenField, meta[numan.AuthTokenField][0])
+			var user numan.User
+			if user.SetUserFromToken(meta[numan.AuthTokenField][0]) == nil {
+				setRequestUser(ctx, user.Username)
+			}
+		} else if ok && len(meta[numan.APIKeyField]) == 1 {
+			user, err := limitAuth(ctx, limiter, info.FullMethod, func() (numan.User, error) { return users.AuthAPIKey(ctx, meta[numan.APIKeyField][0]) })
+			if err != nil {
+				return nil, err
+			}
+			setRequestUser(ctx, user.Username)
+			ctx = context.WithValue(ctx, numan.AuthTokenField, user.AccessToken)
+		} else if ok && len(meta[numan.OIDCTokenField]) == 1 {
+			if oidcAuth == nil {
+				return nil, numan.Errorf(numan.ErrUnauthenticated, "OIDC login not enabled")
+			}
+			user, err := limitAuth(ctx, limiter, info.FullMethod, func() (numan.User, error) { return oidcAuth.AuthOIDC(ctx, meta[numan.OIDCTokenField][0]) })
+			if err != nil {
+				return nil, err
+			}
+			setRequestUser(ctx, user.Username)
+			ctx = context.WithValue(ctx, numan.AuthTokenField, user.AccessToken)
+		} else if cert := clientCert(ctx); cert != nil {
+			user, err := limitAuth(ctx, limiter, info.FullMethod, func() (numan.User, error) { return certs.AuthCert(ctx, cert) })
+			if err != nil {
+				return nil, err
+			}
+			setRequestUser(ctx, user.Username)
+			ctx = context.WithValue(ctx, numan.AuthTokenField, user.AccessToken)
+		}
+		return handler(ctx, req)
+	}
+}
+
+//sourceAddress returns the host of a peer address (without port)
+func sourceAddress(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
 	}
-	return handler(ctx, req)
+	return host
 }
 
-//authClientInterceptor copies a token from context to gRPC metadata
+//clientCert returns the verified client certificate of the call peer OR nil
+func clientCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0][0]
+}
+
+//authClientInterceptor copies a token, API key or OIDC token from context to gRPC metadata
 func authClientInterceptor(ctx context.Context, method string, req interface{}, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
 ) error {
-	// Intercept logic
+	return invoker(authMetadata(ctx), method, req, reply, cc, opts...)
+}
+
+//authStreamClientInterceptor copies a token, API key or OIDC token from context to gRPC metadata of streaming calls
+func authStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	return streamer(authMetadata(ctx), desc, cc, method, opts...)
+}
+
+//authMetadata returns ctx with a token, API key or OIDC token from ctx added to the outgoing metadata
+func authMetadata(ctx context.Context) context.Context {
 	if token := ctx.Value(numan.AuthTokenField); token != nil { //add auth token to RPC metadata
 		ctx = metadata.AppendToOutgoingContext(ctx, numan.AuthTokenField, fmt.Sprintf("%v", token))
+	} else if key := ctx.Value(numan.APIKeyField); key != nil { //or API key
+		ctx = metadata.AppendToOutgoingContext(ctx, numan.APIKeyField, fmt.Sprintf("%v", key))
+	} else if oidcToken := ctx.Value(numan.OIDCTokenField); oidcToken != nil { //or identity provider token
+		ctx = metadata.AppendToOutgoingContext(ctx, numan.OIDCTokenField, fmt.Sprintf("%v", oidcToken))
 	}
-	// Calls the invoker to execute RPC
-	err := invoker(ctx, method, req, reply, cc, opts...)
-	return err
+	return ctx
 }
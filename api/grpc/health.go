@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	context "context"
+	"log"
+	"sync"
+	"time"
+
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"github.com/footfish/numan/internal/service/datastore"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+//Health is the grpc.health.v1 service. It reports each numan service (and "" for the server) as SERVING while the database is reachable.
+type Health struct {
+	*health.Server
+	store    *datastore.Store
+	services []string
+	mu       sync.Mutex
+	serving  bool
+}
+
+//NewHealth registers the health service with server, services start as NOT_SERVING until the first Check
+func NewHealth(server *grpc.Server, store *datastore.Store) *Health {
+	h := &Health{
+		Server:   health.NewServer(),
+		store:    store,
+		services: []string{"", Numbering_ServiceDesc.ServiceName, History_ServiceDesc.ServiceName, User_ServiceDesc.ServiceName, Event_ServiceDesc.ServiceName, Webhook_ServiceDesc.ServiceName, numanv1.Numbering_ServiceDesc.ServiceName, numanv1.History_ServiceDesc.ServiceName, numanv1.User_ServiceDesc.ServiceName, numanv1.Event_ServiceDesc.ServiceName, numanv1.Webhook_ServiceDesc.ServiceName, numanv1.RateLimit_ServiceDesc.ServiceName},
+	}
+	h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, h.Server)
+	return h
+}
+
+//Check pings the database and updates the service status, returns the ping error
+func (h *Health) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	err := h.store.Ping(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case err != nil && h.serving:
+		log.Printf("Health: database unreachable, NOT_SERVING: %v", err)
+		h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	case err == nil && !h.serving:
+		log.Printf("Health: SERVING")
+		h.setStatus(healthpb.HealthCheckResponse_SERVING)
+	}
+	return err
+}
+
+//Run checks every interval until ctx is done
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//setStatus sets the status of all services
+func (h *Health) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.serving = status == healthpb.HealthCheckResponse_SERVING
+	for _, service := range h.services {
+		h.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	context "context"
+	"net"
+	"testing"
+	"time"
+
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"github.com/footfish/numan/internal/service/datastore"
+	grpc "google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestHealth(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	health := NewHealth(server, store)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	checkAll := func(want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		for _, service := range []string{"", Numbering_ServiceDesc.ServiceName, History_ServiceDesc.ServiceName, User_ServiceDesc.ServiceName, Event_ServiceDesc.ServiceName, Webhook_ServiceDesc.ServiceName, numanv1.Numbering_ServiceDesc.ServiceName, numanv1.Webhook_ServiceDesc.ServiceName} {
+			resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.GetStatus() != want {
+				t.Errorf("service %q got %v, want %v", service, resp.GetStatus(), want)
+			}
+		}
+	}
+	checkAll(healthpb.HealthCheckResponse_NOT_SERVING) //until first check
+	if err := health.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkAll(healthpb.HealthCheckResponse_SERVING)
+	store.Close()
+	if err := health.Check(ctx); err == nil {
+		t.Fatal("Check with closed database got no error")
+	}
+	checkAll(healthpb.HealthCheckResponse_NOT_SERVING)
+}
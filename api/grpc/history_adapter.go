@@ -2,7 +2,6 @@ package grpc
 
 import (
 	context "context"
-	"errors"
 
 	"github.com/footfish/numan"
 	"github.com/footfish/numan/internal/service"
@@ -21,22 +20,22 @@ type historyClientAdapter struct {
 
 // NewNumberingClientAdapter instantiates NumberingClientAdaptor
 func NewHistoryClientAdapter(conn *grpc.ClientConn) numan.HistoryService {
-	c := NewHistoryClient(conn)
+	c := NewHistoryClient(errorConn{conn}) //errors are converted back to service errors
 	return &historyClientAdapter{c.(*historyClient)}
 }
 
 //AddHistory  implements HistoryService.AddHistory()
 func (c *historyClientAdapter) AddHistory(ctx context.Context, historyEntry numan.History) error {
-	return errors.New("Method AddHistory not available via gGRPC")
+	return numan.Errorf(numan.ErrUnimplemented, "Method AddHistory not available via gGRPC")
 }
 
 //ListHistoryByNumber implements HistoryService.ListHistoryByNumber()
-func (c *historyClientAdapter) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164) (historyList []numan.History, err error) {
+func (c *historyClientAdapter) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164, archived bool) (historyList []numan.History, err error) {
 	err = phoneNumber.ValidE164()
 	if err != nil {
 		return
 	}
-	listHistoryResponse, err := c.grpc.ListHistoryByNumber(ctx, &ListHistoryByNumberRequest{E164: &E164{Cc: phoneNumber.Cc, Ndc: phoneNumber.Ndc, Sn: phoneNumber.Sn}})
+	listHistoryResponse, err := c.grpc.ListHistoryByNumber(ctx, &ListHistoryByNumberRequest{E164: &E164{Cc: phoneNumber.Cc, Ndc: phoneNumber.Ndc, Sn: phoneNumber.Sn}, Archived: archived})
 	if err == nil {
 		for _, hist := range listHistoryResponse.HistoryEntry {
 			historyList = append(historyList, *unMarshalHistory(hist))
@@ -46,12 +45,12 @@ func (c *historyClientAdapter) ListHistoryByNumber(ctx context.Context, phoneNum
 }
 
 //ListHistoryByOwnerID implements HistoryService.ListHistoryByUserId()
-func (c *historyClientAdapter) ListHistoryByOwnerID(ctx context.Context, ownerID int64) (historyList []numan.History, err error) {
+func (c *historyClientAdapter) ListHistoryByOwnerID(ctx context.Context, ownerID int64, archived bool) (historyList []numan.History, err error) {
 	err = numan.ValidOwnerID(&ownerID)
 	if err != nil {
 		return
 	}
-	listHistoryResponse, err := c.grpc.ListHistoryByOID(ctx, &ListHistoryByOIDRequest{OwnerID: ownerID})
+	listHistoryResponse, err := c.grpc.ListHistoryByOID(ctx, &ListHistoryByOIDRequest{OwnerID: ownerID, Archived: archived})
 	if err == nil {
 		for _, hist := range listHistoryResponse.HistoryEntry {
 			historyList = append(historyList, *unMarshalHistory(hist))
@@ -60,6 +59,11 @@ func (c *historyClientAdapter) ListHistoryByOwnerID(ctx context.Context, ownerID
 	return
 }
 
+//ArchiveHistory implements HistoryService.ArchiveHistory()
+func (c *historyClientAdapter) ArchiveHistory(ctx context.Context, policy numan.RetentionPolicy) (int64, error) {
+	return 0, numan.Errorf(numan.ErrUnimplemented, "Method ArchiveHistory not available via gGRPC")
+}
+
 //historyServerAdapter implements an adapter from HistoryServer(gRPC) to HistoryService.
 type historyServerAdapter struct {
 	service numan.HistoryService
@@ -74,7 +78,7 @@ func NewHistoryServerAdapter(store *datastore.Store) HistoryServer {
 //ListHistoryByNumber implements HistoryServer.ListHistoryByNumber()
 func (h *historyServerAdapter) ListHistoryByNumber(ctx context.Context, in *ListHistoryByNumberRequest) (*ListHistoryResponse, error) {
 
-	historyList, err := h.service.ListHistoryByNumber(ctx, numan.E164{Cc: in.E164.Cc, Ndc: in.E164.Ndc, Sn: in.E164.Sn})
+	historyList, err := h.service.ListHistoryByNumber(ctx, *unMarshalE164(in.E164), in.Archived)
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +92,7 @@ func (h *historyServerAdapter) ListHistoryByNumber(ctx context.Context, in *List
 
 //ListHistoryByOID implements HistoryServer.ListHistoryByOID()
 func (h *historyServerAdapter) ListHistoryByOID(ctx context.Context, in *ListHistoryByOIDRequest) (*ListHistoryResponse, error) {
-	historyList, err := h.service.ListHistoryByOwnerID(ctx, in.OwnerID)
+	historyList, err := h.service.ListHistoryByOwnerID(ctx, in.OwnerID, in.Archived)
 	if err != nil {
 		return nil, err
 	}
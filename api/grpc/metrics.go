@@ -0,0 +1,18 @@
+package grpc
+
+import (
+	context "context"
+	"time"
+
+	"github.com/footfish/numan/internal/metrics"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+//metricsServerInterceptor records request counts by status code & latency (see metrics.ObserveRPC), it runs before errorServerInterceptor to see the status codes
+func metricsServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	metrics.ObserveRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+	return resp, err
+}
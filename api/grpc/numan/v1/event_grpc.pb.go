@@ -0,0 +1,169 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package numanv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EventClient is the client API for Event service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EventClient interface {
+	//ListEvents returns stored number events matching a filter, oldest first
+	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	//Watch streams number events matching a filter, stored events after filter.after_seq then new events as they happen
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Event_WatchClient, error)
+}
+
+type eventClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventClient(cc grpc.ClientConnInterface) EventClient {
+	return &eventClient{cc}
+}
+
+func (c *eventClient) ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error) {
+	out := new(ListEventsResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Event/ListEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Event_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Event_ServiceDesc.Streams[0], "/numan.v1.Event/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Event_WatchClient interface {
+	Recv() (*NumberEvent, error)
+	grpc.ClientStream
+}
+
+type eventWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventWatchClient) Recv() (*NumberEvent, error) {
+	m := new(NumberEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventServer is the server API for Event service.
+// All implementations must embed UnimplementedEventServer
+// for forward compatibility
+type EventServer interface {
+	//ListEvents returns stored number events matching a filter, oldest first
+	ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error)
+	//Watch streams number events matching a filter, stored events after filter.after_seq then new events as they happen
+	Watch(*WatchRequest, Event_WatchServer) error
+	mustEmbedUnimplementedEventServer()
+}
+
+// UnimplementedEventServer must be embedded to have forward compatible implementations.
+type UnimplementedEventServer struct {
+}
+
+func (UnimplementedEventServer) ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
+}
+func (UnimplementedEventServer) Watch(*WatchRequest, Event_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedEventServer) mustEmbedUnimplementedEventServer() {}
+
+// UnsafeEventServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventServer will
+// result in compilation errors.
+type UnsafeEventServer interface {
+	mustEmbedUnimplementedEventServer()
+}
+
+func RegisterEventServer(s grpc.ServiceRegistrar, srv EventServer) {
+	s.RegisterService(&Event_ServiceDesc, srv)
+}
+
+func _Event_ListEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServer).ListEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Event/ListEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServer).ListEvents(ctx, req.(*ListEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Event_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventServer).Watch(m, &eventWatchServer{stream})
+}
+
+type Event_WatchServer interface {
+	Send(*NumberEvent) error
+	grpc.ServerStream
+}
+
+type eventWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventWatchServer) Send(m *NumberEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Event_ServiceDesc is the grpc.ServiceDesc for Event service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Event_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "numan.v1.Event",
+	HandlerType: (*EventServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListEvents",
+			Handler:    _Event_ListEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Event_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "numan/v1/event.proto",
+}
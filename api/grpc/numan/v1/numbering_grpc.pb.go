@@ -0,0 +1,521 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package numanv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// NumberingClient is the client API for Numbering service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NumberingClient interface {
+	//Add adds a new unused number
+	Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*AddResponse, error)
+	//List returns a filtered list of numbers
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	//ListOwnerID returns the numbers of an owner
+	ListOwnerID(ctx context.Context, in *ListOwnerIDRequest, opts ...grpc.CallOption) (*ListOwnerIDResponse, error)
+	//Reserve locks a number to an owner until until_ts (unix timestamp)
+	Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveResponse, error)
+	//Allocate marks a number used by an owner
+	Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error)
+	//DeAllocate releases a number from an owner (number goes to quarantine)
+	DeAllocate(ctx context.Context, in *DeAllocateRequest, opts ...grpc.CallOption) (*DeAllocateResponse, error)
+	//Portout sets a port out date
+	Portout(ctx context.Context, in *PortoutRequest, opts ...grpc.CallOption) (*PortoutResponse, error)
+	//Portin sets a port in date
+	Portin(ctx context.Context, in *PortinRequest, opts ...grpc.CallOption) (*PortinResponse, error)
+	//Delete removes an unused number (history kept)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	//View returns formatted details of a number (with history)
+	View(ctx context.Context, in *ViewRequest, opts ...grpc.CallOption) (*ViewResponse, error)
+	//Summary returns a formatted table of usage stats
+	Summary(ctx context.Context, in *SummaryRequest, opts ...grpc.CallOption) (*SummaryResponse, error)
+	//UpdateNumber sets the fields of a number in update_mask (domain, carrier), returns the updated number
+	UpdateNumber(ctx context.Context, in *UpdateNumberRequest, opts ...grpc.CallOption) (*Number, error)
+}
+
+type numberingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNumberingClient(cc grpc.ClientConnInterface) NumberingClient {
+	return &numberingClient{cc}
+}
+
+func (c *numberingClient) Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*AddResponse, error) {
+	out := new(AddResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/Add", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) ListOwnerID(ctx context.Context, in *ListOwnerIDRequest, opts ...grpc.CallOption) (*ListOwnerIDResponse, error) {
+	out := new(ListOwnerIDResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/ListOwnerID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveResponse, error) {
+	out := new(ReserveResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/Reserve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error) {
+	out := new(AllocateResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/Allocate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) DeAllocate(ctx context.Context, in *DeAllocateRequest, opts ...grpc.CallOption) (*DeAllocateResponse, error) {
+	out := new(DeAllocateResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/DeAllocate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) Portout(ctx context.Context, in *PortoutRequest, opts ...grpc.CallOption) (*PortoutResponse, error) {
+	out := new(PortoutResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/Portout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) Portin(ctx context.Context, in *PortinRequest, opts ...grpc.CallOption) (*PortinResponse, error) {
+	out := new(PortinResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/Portin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) View(ctx context.Context, in *ViewRequest, opts ...grpc.CallOption) (*ViewResponse, error) {
+	out := new(ViewResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/View", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) Summary(ctx context.Context, in *SummaryRequest, opts ...grpc.CallOption) (*SummaryResponse, error) {
+	out := new(SummaryResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/Summary", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *numberingClient) UpdateNumber(ctx context.Context, in *UpdateNumberRequest, opts ...grpc.CallOption) (*Number, error) {
+	out := new(Number)
+	err := c.cc.Invoke(ctx, "/numan.v1.Numbering/UpdateNumber", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NumberingServer is the server API for Numbering service.
+// All implementations must embed UnimplementedNumberingServer
+// for forward compatibility
+type NumberingServer interface {
+	//Add adds a new unused number
+	Add(context.Context, *AddRequest) (*AddResponse, error)
+	//List returns a filtered list of numbers
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	//ListOwnerID returns the numbers of an owner
+	ListOwnerID(context.Context, *ListOwnerIDRequest) (*ListOwnerIDResponse, error)
+	//Reserve locks a number to an owner until until_ts (unix timestamp)
+	Reserve(context.Context, *ReserveRequest) (*ReserveResponse, error)
+	//Allocate marks a number used by an owner
+	Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error)
+	//DeAllocate releases a number from an owner (number goes to quarantine)
+	DeAllocate(context.Context, *DeAllocateRequest) (*DeAllocateResponse, error)
+	//Portout sets a port out date
+	Portout(context.Context, *PortoutRequest) (*PortoutResponse, error)
+	//Portin sets a port in date
+	Portin(context.Context, *PortinRequest) (*PortinResponse, error)
+	//Delete removes an unused number (history kept)
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	//View returns formatted details of a number (with history)
+	View(context.Context, *ViewRequest) (*ViewResponse, error)
+	//Summary returns a formatted table of usage stats
+	Summary(context.Context, *SummaryRequest) (*SummaryResponse, error)
+	//UpdateNumber sets the fields of a number in update_mask (domain, carrier), returns the updated number
+	UpdateNumber(context.Context, *UpdateNumberRequest) (*Number, error)
+	mustEmbedUnimplementedNumberingServer()
+}
+
+// UnimplementedNumberingServer must be embedded to have forward compatible implementations.
+type UnimplementedNumberingServer struct {
+}
+
+func (UnimplementedNumberingServer) Add(context.Context, *AddRequest) (*AddResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (UnimplementedNumberingServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedNumberingServer) ListOwnerID(context.Context, *ListOwnerIDRequest) (*ListOwnerIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOwnerID not implemented")
+}
+func (UnimplementedNumberingServer) Reserve(context.Context, *ReserveRequest) (*ReserveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reserve not implemented")
+}
+func (UnimplementedNumberingServer) Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Allocate not implemented")
+}
+func (UnimplementedNumberingServer) DeAllocate(context.Context, *DeAllocateRequest) (*DeAllocateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeAllocate not implemented")
+}
+func (UnimplementedNumberingServer) Portout(context.Context, *PortoutRequest) (*PortoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Portout not implemented")
+}
+func (UnimplementedNumberingServer) Portin(context.Context, *PortinRequest) (*PortinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Portin not implemented")
+}
+func (UnimplementedNumberingServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedNumberingServer) View(context.Context, *ViewRequest) (*ViewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method View not implemented")
+}
+func (UnimplementedNumberingServer) Summary(context.Context, *SummaryRequest) (*SummaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Summary not implemented")
+}
+func (UnimplementedNumberingServer) UpdateNumber(context.Context, *UpdateNumberRequest) (*Number, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNumber not implemented")
+}
+func (UnimplementedNumberingServer) mustEmbedUnimplementedNumberingServer() {}
+
+// UnsafeNumberingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NumberingServer will
+// result in compilation errors.
+type UnsafeNumberingServer interface {
+	mustEmbedUnimplementedNumberingServer()
+}
+
+func RegisterNumberingServer(s grpc.ServiceRegistrar, srv NumberingServer) {
+	s.RegisterService(&Numbering_ServiceDesc, srv)
+}
+
+func _Numbering_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).Add(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/Add",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).Add(ctx, req.(*AddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_ListOwnerID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOwnerIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).ListOwnerID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/ListOwnerID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).ListOwnerID(ctx, req.(*ListOwnerIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_Reserve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).Reserve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/Reserve",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).Reserve(ctx, req.(*ReserveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_Allocate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).Allocate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/Allocate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).Allocate(ctx, req.(*AllocateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_DeAllocate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeAllocateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).DeAllocate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/DeAllocate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).DeAllocate(ctx, req.(*DeAllocateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_Portout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PortoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).Portout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/Portout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).Portout(ctx, req.(*PortoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_Portin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PortinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).Portin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/Portin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).Portin(ctx, req.(*PortinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_View_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ViewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).View(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/View",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).View(ctx, req.(*ViewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_Summary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).Summary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/Summary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).Summary(ctx, req.(*SummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Numbering_UpdateNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNumberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NumberingServer).UpdateNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.Numbering/UpdateNumber",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NumberingServer).UpdateNumber(ctx, req.(*UpdateNumberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Numbering_ServiceDesc is the grpc.ServiceDesc for Numbering service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Numbering_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "numan.v1.Numbering",
+	HandlerType: (*NumberingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Add",
+			Handler:    _Numbering_Add_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Numbering_List_Handler,
+		},
+		{
+			MethodName: "ListOwnerID",
+			Handler:    _Numbering_ListOwnerID_Handler,
+		},
+		{
+			MethodName: "Reserve",
+			Handler:    _Numbering_Reserve_Handler,
+		},
+		{
+			MethodName: "Allocate",
+			Handler:    _Numbering_Allocate_Handler,
+		},
+		{
+			MethodName: "DeAllocate",
+			Handler:    _Numbering_DeAllocate_Handler,
+		},
+		{
+			MethodName: "Portout",
+			Handler:    _Numbering_Portout_Handler,
+		},
+		{
+			MethodName: "Portin",
+			Handler:    _Numbering_Portin_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Numbering_Delete_Handler,
+		},
+		{
+			MethodName: "View",
+			Handler:    _Numbering_View_Handler,
+		},
+		{
+			MethodName: "Summary",
+			Handler:    _Numbering_Summary_Handler,
+		},
+		{
+			MethodName: "UpdateNumber",
+			Handler:    _Numbering_UpdateNumber_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "numan/v1/numbering.proto",
+}
@@ -0,0 +1,103 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package numanv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// RateLimitClient is the client API for RateLimit service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RateLimitClient interface {
+	//ListRateLimits returns the configured limits and the usage of recent callers
+	ListRateLimits(ctx context.Context, in *ListRateLimitsRequest, opts ...grpc.CallOption) (*ListRateLimitsResponse, error)
+}
+
+type rateLimitClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRateLimitClient(cc grpc.ClientConnInterface) RateLimitClient {
+	return &rateLimitClient{cc}
+}
+
+func (c *rateLimitClient) ListRateLimits(ctx context.Context, in *ListRateLimitsRequest, opts ...grpc.CallOption) (*ListRateLimitsResponse, error) {
+	out := new(ListRateLimitsResponse)
+	err := c.cc.Invoke(ctx, "/numan.v1.RateLimit/ListRateLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RateLimitServer is the server API for RateLimit service.
+// All implementations must embed UnimplementedRateLimitServer
+// for forward compatibility
+type RateLimitServer interface {
+	//ListRateLimits returns the configured limits and the usage of recent callers
+	ListRateLimits(context.Context, *ListRateLimitsRequest) (*ListRateLimitsResponse, error)
+	mustEmbedUnimplementedRateLimitServer()
+}
+
+// UnimplementedRateLimitServer must be embedded to have forward compatible implementations.
+type UnimplementedRateLimitServer struct {
+}
+
+func (UnimplementedRateLimitServer) ListRateLimits(context.Context, *ListRateLimitsRequest) (*ListRateLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRateLimits not implemented")
+}
+func (UnimplementedRateLimitServer) mustEmbedUnimplementedRateLimitServer() {}
+
+// UnsafeRateLimitServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RateLimitServer will
+// result in compilation errors.
+type UnsafeRateLimitServer interface {
+	mustEmbedUnimplementedRateLimitServer()
+}
+
+func RegisterRateLimitServer(s grpc.ServiceRegistrar, srv RateLimitServer) {
+	s.RegisterService(&RateLimit_ServiceDesc, srv)
+}
+
+func _RateLimit_ListRateLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRateLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimitServer).ListRateLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/numan.v1.RateLimit/ListRateLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimitServer).ListRateLimits(ctx, req.(*ListRateLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RateLimit_ServiceDesc is the grpc.ServiceDesc for RateLimit service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RateLimit_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "numan.v1.RateLimit",
+	HandlerType: (*RateLimitServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListRateLimits",
+			Handler:    _RateLimit_ListRateLimits_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "numan/v1/ratelimit.proto",
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 
 	"github.com/footfish/numan"
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
 	"github.com/footfish/numan/internal/service"
 	"github.com/footfish/numan/internal/service/datastore"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 //Adaptors are used to facilitate transparent gRPC transport.
@@ -16,12 +18,13 @@ import (
 //numberingClientAdapter implements an adapter from NumberingService to NumberingClient(gRPC).
 type numberingClientAdapter struct {
 	grpc *numberingClient
+	v1   numanv1.NumberingClient //methods only in the numan.v1 API
 }
 
 // NewNumberingClientAdapter instantiates NumberingClientAdaptor
 func NewNumberingClientAdapter(conn *grpc.ClientConn) numan.NumberingService {
-	c := NewNumberingClient(conn)
-	return &numberingClientAdapter{c.(*numberingClient)}
+	c := NewNumberingClient(errorConn{conn}) //errors are converted back to service errors
+	return &numberingClientAdapter{c.(*numberingClient), numanv1.NewNumberingClient(errorConn{conn})}
 }
 
 // Add implements NumberingService.Add()
@@ -30,9 +33,6 @@ func (c *numberingClientAdapter) Add(ctx context.Context, number *numan.Numberin
 	return err
 }
 
-//AddGroup not implemented
-func (c *numberingClientAdapter) AddGroup(ctx context.Context) {
-}
 
 // List implements NumberingService.List()
 func (c *numberingClientAdapter) List(ctx context.Context, filter *numan.NumberFilter) (numbers []numan.Numbering, err error) {
@@ -111,6 +111,23 @@ func (c *numberingClientAdapter) Summary(ctx context.Context) (string, error) {
 
 }
 
+//Update implements NumberingService.Update() (numan.v1 API)
+func (c *numberingClientAdapter) Update(ctx context.Context, number *numan.Numbering, fields []string) (numan.Numbering, error) {
+	in := &numanv1.Number{}
+	if err := transcode(marshalNumber(number), in); err != nil {
+		return numan.Numbering{}, err
+	}
+	resp, err := c.v1.UpdateNumber(ctx, &numanv1.UpdateNumberRequest{Number: in, UpdateMask: &fieldmaskpb.FieldMask{Paths: fields}})
+	if err != nil {
+		return numan.Numbering{}, err
+	}
+	updated := &Number{}
+	if err := transcode(resp, updated); err != nil {
+		return numan.Numbering{}, err
+	}
+	return *unMarshalNumber(updated), nil
+}
+
 //numberingServerAdapter implements an adapter from NumberingServer(gRPC) to NumberingService.
 type numberingServerAdapter struct {
 	service numan.NumberingService
@@ -270,6 +287,7 @@ func marshalNumber(n *numan.Numbering) *Number {
 		Carrier:     n.Carrier,
 		OwnerID:     n.OwnerID,
 		Allocated:   n.Allocated,
+		Reserved:    n.Reserved,
 		DeAllocated: n.DeAllocated,
 		PortedIn:    n.PortedIn,
 		PortedOut:   n.PortedOut,
@@ -287,6 +305,7 @@ func unMarshalNumber(n *Number) *numan.Numbering {
 		Carrier:     n.Carrier,
 		OwnerID:     n.OwnerID,
 		Allocated:   n.Allocated,
+		Reserved:    n.Reserved,
 		DeAllocated: n.DeAllocated,
 		PortedIn:    n.PortedIn,
 		PortedOut:   n.PortedOut,
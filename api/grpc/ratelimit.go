@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	context "context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+//unlimitedServices are not rate limited (load balancer health checks, reflection)
+var unlimitedServices = map[string]bool{"/grpc.health.v1.Health/": true, "/grpc.reflection.v1alpha.ServerReflection/": true}
+
+//rateLimitServerInterceptor refuses calls over the limits of limiter with ErrRateLimited (RESOURCE_EXHAUSTED).
+//The seconds to wait are sent in the retry-after trailer and as RetryInfo detail.
+//Callers are keyed by API key, user or source address, so it runs after authServerInterceptor.
+func rateLimitServerInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if unlimitedServices[info.FullMethod[:strings.LastIndex(info.FullMethod, "/")+1]] {
+			return handler(ctx, req)
+		}
+		method := ratelimit.Method(info.FullMethod)
+		allowed, wait := limiter.Allow(rateLimitKey(ctx), method)
+		if allowed {
+			return handler(ctx, req)
+		}
+		return nil, rateLimitError(ctx, method, wait)
+	}
+}
+
+//limitAuth exchanges the credentials of a call for a user with auth, failures are limited by the bucket of the call source (source:<address>).
+//Credentials aren't tried while the bucket is empty and each failure takes a token, so guessing API keys is limited as anonymous calls are.
+func limitAuth(ctx context.Context, limiter *ratelimit.Limiter, fullMethod string, auth func() (numan.User, error)) (numan.User, error) {
+	if limiter == nil || unlimitedServices[fullMethod[:strings.LastIndex(fullMethod, "/")+1]] {
+		return auth()
+	}
+	method := ratelimit.Method(fullMethod)
+	source, _ := ctx.Value(numan.SourceField).(string)
+	if ready, wait := limiter.Ready("source:"+source, method); !ready {
+		return numan.User{}, rateLimitError(ctx, method, wait)
+	}
+	user, err := auth()
+	if err != nil {
+		limiter.Allow("source:"+source, method)
+	}
+	return user, err
+}
+
+//rateLimitError returns ErrRateLimited as status error with RetryInfo detail, the seconds to wait are set in the retry-after trailer
+func rateLimitError(ctx context.Context, method string, wait time.Duration) error {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	grpc.SetTrailer(ctx, metadata.Pairs(numan.RetryAfterField, strconv.FormatInt(seconds, 10)))
+	st := status.Convert(toStatus(numan.Errorf(numan.ErrRateLimited, "Rate limit of %s exceeded, retry after %ds", method, seconds)))
+	if withRetry, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = withRetry
+	}
+	return st.Err()
+}
+
+//rateLimitKey returns the caller of a call, apikey:<id>, user:<username> (authenticated) or source:<address>
+func rateLimitKey(ctx context.Context) string {
+	meta, _ := metadata.FromIncomingContext(ctx)
+	if keys := meta.Get(numan.APIKeyField); len(keys) == 1 && len(meta.Get(numan.AuthTokenField)) == 0 {
+		if id, _, err := numan.ParseAPIKey(keys[0]); err == nil {
+			return "apikey:" + id
+		}
+	}
+	if user := requestUser(ctx); user != "" {
+		return "user:" + user
+	}
+	source, _ := ctx.Value(numan.SourceField).(string)
+	return "source:" + source
+}
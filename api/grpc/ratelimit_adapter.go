@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	context "context"
+
+	"github.com/footfish/numan"
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	"google.golang.org/grpc"
+)
+
+//rateLimitClientAdapter implements an adapter from RateLimitService to RateLimitClient(gRPC, numan.v1 API only).
+type rateLimitClientAdapter struct {
+	grpc numanv1.RateLimitClient
+}
+
+// NewRateLimitClientAdapter instantiates RateLimitClientAdapter
+func NewRateLimitClientAdapter(conn *grpc.ClientConn) numan.RateLimitService {
+	return &rateLimitClientAdapter{numanv1.NewRateLimitClient(errorConn{conn})} //errors are converted back to service errors
+}
+
+//ListRateLimits implements RateLimitService.ListRateLimits()
+func (c *rateLimitClientAdapter) ListRateLimits(ctx context.Context) (limits []numan.RateLimit, usage []numan.RateLimitUsage, err error) {
+	resp, err := c.grpc.ListRateLimits(ctx, &numanv1.ListRateLimitsRequest{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, l := range resp.Limits {
+		limits = append(limits, numan.RateLimit{Method: l.Method, Rate: l.Rate, Burst: int(l.Burst)})
+	}
+	for _, u := range resp.Usage {
+		usage = append(usage, numan.RateLimitUsage{Key: u.Key, Method: u.Method, Limit: u.Limit, Tokens: u.Tokens, Allowed: u.Allowed, Limited: u.Limited, LastSeen: u.LastSeen})
+	}
+	return limits, usage, nil
+}
+
+//rateLimitServerAdapter implements an adapter from RateLimitServer(gRPC) to RateLimitService.
+type rateLimitServerAdapter struct {
+	service numan.RateLimitService
+	numanv1.UnimplementedRateLimitServer
+}
+
+// NewRateLimitServerAdapter creates a new RateLimitServerAdapter, showing the limits of limiter
+func NewRateLimitServerAdapter(store *datastore.Store, limiter numan.RateLimitService) numanv1.RateLimitServer {
+	return &rateLimitServerAdapter{service: service.NewRateLimitService(store, limiter)}
+}
+
+//ListRateLimits implements RateLimitServer.ListRateLimits()
+func (s *rateLimitServerAdapter) ListRateLimits(ctx context.Context, in *numanv1.ListRateLimitsRequest) (*numanv1.ListRateLimitsResponse, error) {
+	limits, usage, err := s.service.ListRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &numanv1.ListRateLimitsResponse{}
+	for _, l := range limits {
+		resp.Limits = append(resp.Limits, &numanv1.RateLimitEntry{Method: l.Method, Rate: l.Rate, Burst: int32(l.Burst)})
+	}
+	for _, u := range usage {
+		resp.Usage = append(resp.Usage, &numanv1.RateLimitUsage{Key: u.Key, Method: u.Method, Limit: u.Limit, Tokens: u.Tokens, Allowed: u.Allowed, Limited: u.Limited, LastSeen: u.LastSeen})
+	}
+	return resp, nil
+}
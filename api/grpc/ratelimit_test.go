@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	context "context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"github.com/footfish/numan/internal/ratelimit"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+//TestRateLimit checks calls over the limit are refused per caller & method (shared by API versions) and usage is listed for admins
+func TestRateLimit(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	limiter := ratelimit.NewLimiter([]numan.RateLimit{{Method: "Numbering/List", Rate: 0.5, Burst: 2}})
+	lis := bufconn.Listen(1 << 20)
+	server := NewGrpcServer(nil, store, nil, nil, limiter)
+	RegisterNumberingServer(server, NewNumberingServerAdapter(store))
+	numanv1.RegisterNumberingServer(server, NewNumberingV1ServerAdapter(store))
+	numanv1.RegisterRateLimitServer(server, NewRateLimitServerAdapter(store, limiter))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(), grpc.WithUnaryInterceptor(authClientInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	userCtx := func(u numan.User) context.Context {
+		if err := u.SetNewAccessToken(); err != nil {
+			t.Fatal(err)
+		}
+		return context.WithValue(ctx, numan.AuthTokenField, u.AccessToken)
+	}
+	aliceCtx := userCtx(numan.User{Username: "alice", Roles: []string{numan.RoleUser}})
+	bobCtx := userCtx(numan.User{Username: "bob", Roles: []string{numan.RoleUser}})
+	adminCtx := userCtx(numan.User{Username: "root", Roles: []string{numan.RoleAdmin}})
+	numbering, numberingV1, rateLimits := NewNumberingClientAdapter(conn), numanv1.NewNumberingClient(conn), NewRateLimitClientAdapter(conn)
+
+	//burst, then limited with retry-after in both API versions
+	filter := numan.NumberFilter{E164: numan.E164{Cc: "353"}}
+	for i := 0; i < 2; i++ {
+		if _, err := numbering.List(aliceCtx, &filter); errors.Is(err, numan.ErrRateLimited) {
+			t.Fatalf("call %d limited", i)
+		}
+	}
+	var trailer metadata.MD
+	_, err = numberingV1.List(aliceCtx, &numanv1.ListRequest{NumberFilter: &numanv1.NumberFilter{E164: &numanv1.E164{Cc: "353"}}}, grpc.Trailer(&trailer))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("got %v, want ResourceExhausted", err)
+	}
+	if got := trailer.Get(numan.RetryAfterField); len(got) != 1 || got[0] != "2" {
+		t.Errorf("got %s trailer %v, want 2", numan.RetryAfterField, got)
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	if retryInfo == nil || retryInfo.GetRetryDelay().AsDuration() <= time.Second || retryInfo.GetRetryDelay().AsDuration() > 2*time.Second {
+		t.Errorf("got RetryInfo %v, want 1-2s delay", retryInfo)
+	}
+	if _, err := numbering.List(aliceCtx, &filter); !errors.Is(err, numan.ErrRateLimited) {
+		t.Fatalf("got %v, want ErrRateLimited", err)
+	}
+
+	//other callers & methods are not limited
+	if _, err := numbering.List(bobCtx, &filter); errors.Is(err, numan.ErrRateLimited) {
+		t.Fatal("other caller limited")
+	}
+	if _, err := numbering.Summary(aliceCtx); errors.Is(err, numan.ErrRateLimited) {
+		t.Fatal("other method limited")
+	}
+
+	//admin RPC
+	if _, _, err := rateLimits.ListRateLimits(aliceCtx); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatalf("ListRateLimits by user got %v, want ErrPermissionDenied", err)
+	}
+	limits, usage, err := rateLimits.ListRateLimits(adminCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 1 || limits[0] != (numan.RateLimit{Method: "Numbering/List", Rate: 0.5, Burst: 2}) {
+		t.Fatalf("got limits %+v", limits)
+	}
+	if len(usage) != 2 || usage[0].Key != "user:alice" || usage[0].Method != "Numbering/List" || usage[0].Allowed != 2 || usage[0].Limited != 2 ||
+		usage[1].Key != "user:bob" || usage[1].Allowed != 1 {
+		t.Fatalf("got usage %+v", usage)
+	}
+}
+
+//TestRateLimitAuth checks failed API keys take from the bucket of the source address and no key is tried while it is empty
+func TestRateLimitAuth(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	admin := numan.User{Username: "root", Roles: []string{numan.RoleAdmin}}
+	if err := admin.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	adminCtx := context.WithValue(context.Background(), numan.AuthTokenField, admin.AccessToken)
+	users := service.NewUserService(store)
+	if err := users.AddUser(adminCtx, numan.User{Username: "robot", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+		t.Fatal(err)
+	}
+	key, err := users.AddAPIKey(adminCtx, numan.APIKey{Username: "robot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := ratelimit.NewLimiter([]numan.RateLimit{{Method: "Numbering/Summary", Rate: 0.01, Burst: 2}})
+	lis := bufconn.Listen(1 << 20)
+	server := NewGrpcServer(nil, store, nil, nil, limiter)
+	RegisterNumberingServer(server, NewNumberingServerAdapter(store))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(), grpc.WithUnaryInterceptor(authClientInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	numbering := NewNumberingClientAdapter(conn)
+	keyCtx := func(key string) context.Context { return context.WithValue(ctx, numan.APIKeyField, key) }
+
+	if _, err := numbering.Summary(keyCtx(key.Key)); err != nil {
+		t.Fatal("valid API key refused:", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := numbering.Summary(keyCtx("guess.secret")); !errors.Is(err, numan.ErrUnauthenticated) {
+			t.Fatalf("bad API key %d got %v, want ErrUnauthenticated", i, err)
+		}
+	}
+	if _, err := numbering.Summary(keyCtx("guess.secret")); !errors.Is(err, numan.ErrRateLimited) {
+		t.Fatalf("bad API key over limit got %v, want ErrRateLimited", err)
+	}
+	if _, err := numbering.Summary(keyCtx(key.Key)); !errors.Is(err, numan.ErrRateLimited) {
+		t.Fatalf("valid API key from limited source got %v, want ErrRateLimited", err)
+	}
+}
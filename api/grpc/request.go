@@ -0,0 +1,198 @@
+package grpc
+
+import (
+	context "context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//validRequestID matches request IDs accepted from clients, others are replaced
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+//newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+//requestLogEntry is a request log line (JSON)
+type requestLogEntry struct {
+	Time      string  `json:"time"`
+	Level     string  `json:"level"`
+	Msg       string  `json:"msg"`
+	RequestID string  `json:"request_id"`
+	TraceID   string  `json:"trace_id,omitempty"`
+	Method    string  `json:"method"`
+	User      string  `json:"user,omitempty"`
+	Source    string  `json:"source,omitempty"`
+	Code      string  `json:"code"`
+	Duration  float64 `json:"duration_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+//requestLogger writes request log entries as JSON lines
+type requestLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+//log writes entry
+func (l *requestLogger) log(entry requestLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(entry)
+}
+
+//requestUserKey is the ctx key of the request user (*string), set by authServerInterceptor for the request log
+type requestUserKey struct{}
+
+//setRequestUser records the authenticated user of the request
+func setRequestUser(ctx context.Context, username string) {
+	if user, ok := ctx.Value(requestUserKey{}).(*string); ok {
+		*user = username
+	}
+}
+
+//requestUser returns the user recorded by setRequestUser OR ""
+func requestUser(ctx context.Context) string {
+	if user, ok := ctx.Value(requestUserKey{}).(*string); ok {
+		return *user
+	}
+	return ""
+}
+
+//requestServerInterceptor assigns a request ID (or keeps the client's), returned in the response header.
+//Each call is traced (continuing a trace from the metadata) and logged to requestLog as JSON (nil not logged).
+//It runs before errorServerInterceptor to see the status codes.
+func requestServerInterceptor(requestLog io.Writer) grpc.UnaryServerInterceptor {
+	var logger *requestLogger
+	if requestLog != nil {
+		logger = &requestLogger{enc: json.NewEncoder(requestLog)}
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		meta, _ := metadata.FromIncomingContext(ctx)
+		id := ""
+		if ids := meta.Get(numan.RequestIDField); len(ids) == 1 && validRequestID.MatchString(ids[0]) {
+			id = ids[0]
+		} else {
+			id = newRequestID()
+		}
+		ctx = context.WithValue(ctx, numan.RequestIDField, id)
+		grpc.SetHeader(ctx, metadata.Pairs(numan.RequestIDField, id))
+		var user string
+		ctx = context.WithValue(ctx, requestUserKey{}, &user)
+
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(meta))
+		ctx, span := tracing.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("rpc.system", "grpc"), attribute.String("rpc.method", info.FullMethod), attribute.String("numan.request_id", id)))
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()), attribute.String("enduser.id", user))
+		if err != nil {
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		if logger != nil {
+			entry := requestLogEntry{
+				Time:      start.UTC().Format(time.RFC3339Nano),
+				Level:     "info",
+				Msg:       "rpc",
+				RequestID: id,
+				Method:    info.FullMethod,
+				User:      user,
+				Code:      code.String(),
+				Duration:  float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if span.SpanContext().IsValid() {
+				entry.TraceID = span.SpanContext().TraceID().String()
+			}
+			if p, ok := peer.FromContext(ctx); ok {
+				entry.Source = sourceAddress(p.Addr)
+			}
+			if err != nil {
+				entry.Level, entry.Error = "warn", status.Convert(err).Message()
+				switch code {
+				case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+					entry.Level = "error"
+				}
+			}
+			logger.log(entry)
+		}
+		return resp, err
+	}
+}
+
+//metadataCarrier adapts gRPC metadata for trace context propagation
+type metadataCarrier metadata.MD
+
+//Get implements propagation.TextMapCarrier
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+//Set implements propagation.TextMapCarrier
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+//Keys implements propagation.TextMapCarrier
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+//requestError is a client call error with the request ID (quote it to find the server log entry)
+type requestError struct {
+	err error
+	id  string
+}
+
+//Error implements error
+func (e *requestError) Error() string {
+	return e.err.Error() + " (request id " + e.id + ")"
+}
+
+//Unwrap returns the call error
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+//RequestID returns the request ID of a client adapter call error OR ""
+func RequestID(err error) string {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return reqErr.id
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"bytes"
+	context "context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestRequestLogging(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	var requestLog bytes.Buffer
+	lis := bufconn.Listen(1 << 20)
+	server := NewGrpcServer(nil, store, nil, &requestLog, nil)
+	RegisterNumberingServer(server, NewNumberingServerAdapter(store))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(), grpc.WithUnaryInterceptor(authClientInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	numbering := NewNumberingClientAdapter(conn)
+
+	admin := numan.User{Username: "root", Roles: []string{numan.RoleAdmin}}
+	if err := admin.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	adminCtx := context.WithValue(ctx, numan.AuthTokenField, admin.AccessToken)
+	number := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}
+	if err := numbering.Add(context.WithValue(adminCtx, numan.RequestIDField, "abc-123"), &number); err != nil {
+		t.Fatal(err)
+	}
+	err = numbering.Add(adminCtx, &number)
+	if !errors.Is(err, numan.ErrAlreadyExists) {
+		t.Fatalf("Add twice got %v, want ErrAlreadyExists", err)
+	}
+	id := RequestID(err)
+	if id == "" || !strings.Contains(err.Error(), id) {
+		t.Fatalf("error %q has no request id", err)
+	}
+
+	var entries []requestLogEntry
+	dec := json.NewDecoder(&requestLog)
+	for dec.More() {
+		var entry requestLogEntry
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(entries))
+	}
+	want := requestLogEntry{Level: "info", Msg: "rpc", RequestID: "abc-123", Method: "/grpc.Numbering/Add", User: "root", Code: "OK"}
+	if got := entries[0]; got.Level != want.Level || got.Msg != want.Msg || got.RequestID != want.RequestID || got.Method != want.Method || got.User != want.User || got.Code != want.Code || got.TraceID == "" {
+		t.Errorf("log entry got %+v, want %+v", got, want)
+	}
+	if got := entries[1]; got.RequestID != id || got.Code != "AlreadyExists" || got.Level != "warn" || got.Error != "Unable to add number, already exists" {
+		t.Errorf("error log entry got %+v", got)
+	}
+
+	//one trace per call, covering the service layers
+	names := map[string]bool{}
+	for _, span := range spans.Ended() {
+		if span.SpanContext().TraceID().String() == entries[0].TraceID {
+			names[span.Name()] = true
+		}
+	}
+	for _, name := range []string{"/grpc.Numbering/Add", "service.Numbering.Add", "auth.Numbering.Add", "datastore.Numbering.Add"} {
+		if !names[name] {
+			t.Errorf("no span %s in trace, got %v", name, names)
+		}
+	}
+}
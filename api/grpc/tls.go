@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// ServerTLS holds the server certificate & client CAs, Reload replaces them without a restart (new connections use the new files).
+type ServerTLS struct {
+	mu        sync.RWMutex
+	cert      tls.Certificate
+	clientCAs *x509.CertPool //nil if client certificates are not verified
+}
+
+// NewServerTLS loads the server TLS config from cert & key files.
+// If clientCAFile is set, clients must present a certificate signed by it (mutual TLS).
+func NewServerTLS(certFile string, keyFile string, clientCAFile string) (*ServerTLS, error) {
+	s := &ServerTLS{}
+	if err := s.Reload(certFile, keyFile, clientCAFile); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload loads the cert, key & client CA files, the current config is kept on error.
+func (s *ServerTLS) Reload(certFile string, keyFile string, clientCAFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	var clientCAs *x509.CertPool
+	if clientCAFile != "" {
+		if clientCAs, err = loadCertPool(clientCAFile); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cert, s.clientCAs = cert, clientCAs
+	return nil
+}
+
+// Config returns a tls.Config using the current certificates.
+// A client certificate is required if requireClientCert (and client CAs are set), otherwise only verified if given (ex. browsers).
+func (s *ServerTLS) Config(requireClientCert bool, nextProtos ...string) *tls.Config {
+	return &tls.Config{GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		config := &tls.Config{Certificates: []tls.Certificate{s.cert}, NextProtos: nextProtos}
+		if s.clientCAs != nil {
+			config.ClientCAs = s.clientCAs
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+			if requireClientCert {
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+		return config, nil
+	}}
+}
+
+// Credentials returns gRPC server transport credentials using the current certificates.
+func (s *ServerTLS) Credentials() credentials.TransportCredentials {
+	return credentials.NewTLS(s.Config(true, "h2"))
+}
+
+// NewClientTLS creates client transport credentials.
+// caFile is the server cert (or its CA) if not trusted by the system. certFile & keyFile are an optional client certificate (mutual TLS).
+func NewClientTLS(caFile string, certFile string, keyFile string) (credentials.TransportCredentials, error) {
+	config := &tls.Config{}
+	if caFile != "" {
+		rootCAs, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = rootCAs
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+//loadCertPool loads a cert pool from a PEM file
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + file)
+	}
+	return pool, nil
+}
@@ -20,7 +20,7 @@ type userClientAdapter struct {
 
 // NewUserClientAdapter instantiates userClientAdaptor
 func NewUserClientAdapter(conn *grpc.ClientConn) numan.UserService {
-	c := NewUserClient(conn)
+	c := NewUserClient(errorConn{conn}) //errors are converted back to service errors
 	return &userClientAdapter{c.(*userClient)}
 }
 
@@ -28,14 +28,29 @@ func NewUserClientAdapter(conn *grpc.ClientConn) numan.UserService {
 func (c *userClientAdapter) Auth(ctx context.Context, username string, password string) (user numan.User, err error) {
 	resp, err := c.grpc.Auth(ctx, &AuthRequest{Username: username, Password: password})
 	if err == nil {
-		user = numan.User{UID: resp.Uid, Username: resp.Username, Password: resp.Passwordhash, AccessToken: resp.Token, Role: resp.Role}
+		user = unMarshalAuthResponse(resp)
 	}
 	return user, err
 }
 
+// Refresh implements UserService.Refresh()
+func (c *userClientAdapter) Refresh(ctx context.Context, refreshToken string) (user numan.User, err error) {
+	resp, err := c.grpc.Refresh(ctx, &RefreshRequest{RefreshToken: refreshToken})
+	if err == nil {
+		user = unMarshalAuthResponse(resp)
+	}
+	return user, err
+}
+
+// Logout implements UserService.Logout()
+func (c *userClientAdapter) Logout(ctx context.Context, refreshToken string) (err error) {
+	_, err = c.grpc.Logout(ctx, &LogoutRequest{RefreshToken: refreshToken})
+	return err
+}
+
 //AddUser implements UserService.AddUser()
 func (c *userClientAdapter) AddUser(ctx context.Context, user numan.User) (err error) {
-	_, err = c.grpc.AddUser(ctx, &AddUserRequest{Username: user.Username, Password: user.Password, Role: user.Role})
+	_, err = c.grpc.AddUser(ctx, &AddUserRequest{Username: user.Username, Password: user.Password, Roles: user.Roles, Scope: marshalScope(user.Scope), Status: marshalStatus(user.Status)})
 	return err
 }
 
@@ -51,18 +66,143 @@ func (c *userClientAdapter) ListUsers(ctx context.Context, userfilter string) (u
 	listUsersResponse, err := c.grpc.ListUsers(ctx, &listUsersRequest)
 	if err == nil {
 		for _, user := range listUsersResponse.Userlist {
-			userlist = append(userlist, numan.User{Username: user.Username, Role: user.Role})
+			userlist = append(userlist, numan.User{Username: user.Username, Roles: user.Roles, Scope: unMarshalScope(user.Scope), Status: unMarshalStatus(user.Status), LastLogin: user.LastLogin})
 		}
 	}
 	return
 }
 
-//ChangePassword implements UserService.ChangePassword()
+//SetPassword implements UserService.SetPassword()
 func (c *userClientAdapter) SetPassword(ctx context.Context, username string, newPassword string) (err error) {
 	_, err = c.grpc.SetPassword(ctx, &SetPasswordRequest{Username: username, Password: newPassword})
 	return err
 }
 
+//ChangePassword implements UserService.ChangePassword()
+func (c *userClientAdapter) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) (err error) {
+	_, err = c.grpc.ChangePassword(ctx, &ChangePasswordRequest{Username: username, OldPassword: oldPassword, NewPassword: newPassword})
+	return err
+}
+
+//SetStatus implements UserService.SetStatus()
+func (c *userClientAdapter) SetStatus(ctx context.Context, username string, status numan.AccountStatus) (err error) {
+	_, err = c.grpc.SetStatus(ctx, &SetStatusRequest{Username: username, Status: marshalStatus(status)})
+	return err
+}
+
+//RotateKey implements UserService.RotateKey()
+func (c *userClientAdapter) RotateKey(ctx context.Context, algorithm string) (key numan.SigningKey, err error) {
+	resp, err := c.grpc.RotateKey(ctx, &RotateKeyRequest{Algorithm: algorithm})
+	if err == nil {
+		key = unMarshalSigningKey(resp)
+	}
+	return key, err
+}
+
+//ListKeys implements UserService.ListKeys()
+func (c *userClientAdapter) ListKeys(ctx context.Context) (keys []numan.SigningKey, err error) {
+	resp, err := c.grpc.ListKeys(ctx, &ListKeysRequest{})
+	if err == nil {
+		for _, key := range resp.Keys {
+			keys = append(keys, unMarshalSigningKey(key))
+		}
+	}
+	return keys, err
+}
+
+//SetRole implements UserService.SetRole()
+func (c *userClientAdapter) SetRole(ctx context.Context, role numan.Role) (err error) {
+	_, err = c.grpc.SetRole(ctx, &RoleEntry{Name: role.Name, Permissions: role.Permissions})
+	return err
+}
+
+//DeleteRole implements UserService.DeleteRole()
+func (c *userClientAdapter) DeleteRole(ctx context.Context, name string) (err error) {
+	_, err = c.grpc.DeleteRole(ctx, &DeleteRoleRequest{Name: name})
+	return err
+}
+
+//ListRoles implements UserService.ListRoles()
+func (c *userClientAdapter) ListRoles(ctx context.Context) (roles []numan.Role, err error) {
+	resp, err := c.grpc.ListRoles(ctx, &ListRolesRequest{})
+	if err == nil {
+		for _, role := range resp.Roles {
+			roles = append(roles, numan.Role{Name: role.Name, Permissions: role.Permissions})
+		}
+	}
+	return roles, err
+}
+
+//GrantRole implements UserService.GrantRole()
+func (c *userClientAdapter) GrantRole(ctx context.Context, username string, role string) (err error) {
+	_, err = c.grpc.GrantRole(ctx, &GrantRoleRequest{Username: username, Role: role})
+	return err
+}
+
+//RevokeRole implements UserService.RevokeRole()
+func (c *userClientAdapter) RevokeRole(ctx context.Context, username string, role string) (err error) {
+	_, err = c.grpc.RevokeRole(ctx, &RevokeRoleRequest{Username: username, Role: role})
+	return err
+}
+
+//SetScope implements UserService.SetScope()
+func (c *userClientAdapter) SetScope(ctx context.Context, username string, scope numan.Scope) (err error) {
+	_, err = c.grpc.SetScope(ctx, &SetScopeRequest{Username: username, Scope: marshalScope(scope)})
+	return err
+}
+
+//AddAPIKey implements UserService.AddAPIKey()
+func (c *userClientAdapter) AddAPIKey(ctx context.Context, key numan.APIKey) (numan.APIKey, error) {
+	resp, err := c.grpc.AddAPIKey(ctx, marshalAPIKey(key))
+	if err != nil {
+		return numan.APIKey{}, err
+	}
+	return unMarshalAPIKey(resp), nil
+}
+
+//ListAPIKeys implements UserService.ListAPIKeys()
+func (c *userClientAdapter) ListAPIKeys(ctx context.Context, userfilter string) (keys []numan.APIKey, err error) {
+	resp, err := c.grpc.ListAPIKeys(ctx, &ListAPIKeysRequest{Userfilter: userfilter})
+	if err == nil {
+		for _, key := range resp.Keys {
+			keys = append(keys, unMarshalAPIKey(key))
+		}
+	}
+	return keys, err
+}
+
+//DeleteAPIKey implements UserService.DeleteAPIKey()
+func (c *userClientAdapter) DeleteAPIKey(ctx context.Context, id string) (err error) {
+	_, err = c.grpc.DeleteAPIKey(ctx, &DeleteAPIKeyRequest{Id: id})
+	return err
+}
+
+//AuthAPIKey implements UserService.AuthAPIKey()
+func (c *userClientAdapter) AuthAPIKey(ctx context.Context, key string) (user numan.User, err error) {
+	resp, err := c.grpc.AuthAPIKey(ctx, &AuthAPIKeyRequest{Key: key})
+	if err == nil {
+		user = unMarshalAuthResponse(resp)
+	}
+	return user, err
+}
+
+//Unlock implements UserService.Unlock()
+func (c *userClientAdapter) Unlock(ctx context.Context, name string) (err error) {
+	_, err = c.grpc.Unlock(ctx, &UnlockRequest{Name: name})
+	return err
+}
+
+//ListAudit implements UserService.ListAudit()
+func (c *userClientAdapter) ListAudit(ctx context.Context, userfilter string) (entries []numan.AuditEntry, err error) {
+	resp, err := c.grpc.ListAudit(ctx, &ListAuditRequest{Userfilter: userfilter})
+	if err == nil {
+		for _, e := range resp.Entries {
+			entries = append(entries, numan.AuditEntry{Timestamp: e.Timestamp, Username: e.Username, Source: e.Source, Action: e.Action, Notes: e.Notes})
+		}
+	}
+	return entries, err
+}
+
 //userServerAdapter implements an Adapter from UserServer(grpc) to UserService.
 type userServerAdapter struct {
 	service numan.UserService
@@ -77,12 +217,26 @@ func NewUserServerAdapter(store *datastore.Store) UserServer {
 //Auth implements UserServer.Auth()
 func (s *userServerAdapter) Auth(ctx context.Context, auth *AuthRequest) (resp *AuthResponse, err error) {
 	user, err := s.service.Auth(ctx, auth.Username, auth.Password)
-	return &AuthResponse{Uid: user.UID, Username: user.Username, Passwordhash: user.Password, Token: user.AccessToken}, err
+	return marshalAuthResponse(user), err
+}
+
+//Refresh implements UserServer.Refresh()
+func (s *userServerAdapter) Refresh(ctx context.Context, in *RefreshRequest) (*AuthResponse, error) {
+	user, err := s.service.Refresh(ctx, in.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return marshalAuthResponse(user), nil
+}
+
+//Logout implements UserServer.Logout()
+func (s *userServerAdapter) Logout(ctx context.Context, in *LogoutRequest) (*LogoutResponse, error) {
+	return &LogoutResponse{}, s.service.Logout(ctx, in.RefreshToken)
 }
 
 //AddUser implements UserServer.AddUser()
 func (s *userServerAdapter) AddUser(ctx context.Context, in *AddUserRequest) (resp *AddUserResponse, err error) {
-	return &AddUserResponse{}, s.service.AddUser(ctx, numan.User{Username: in.Username, Password: in.Password, Role: in.Role})
+	return &AddUserResponse{}, s.service.AddUser(ctx, numan.User{Username: in.Username, Password: in.Password, Roles: in.Roles, Scope: unMarshalScope(in.Scope), Status: unMarshalStatus(in.Status)})
 }
 
 //ListsUsers implements UserServer.ListsUsers()
@@ -94,7 +248,7 @@ func (s *userServerAdapter) ListUsers(ctx context.Context, in *ListUsersRequest)
 
 	var resp ListUsersResponse
 	for _, userEntry := range userList {
-		resp.Userlist = append(resp.Userlist, &UserEntry{Username: userEntry.Username, Role: userEntry.Role})
+		resp.Userlist = append(resp.Userlist, &UserEntry{Username: userEntry.Username, Roles: userEntry.Roles, Scope: marshalScope(userEntry.Scope), Status: marshalStatus(userEntry.Status), LastLogin: userEntry.LastLogin})
 	}
 
 	return &resp, err
@@ -109,3 +263,177 @@ func (s *userServerAdapter) DeleteUser(ctx context.Context, in *DeleteUserReques
 func (s *userServerAdapter) SetPassword(ctx context.Context, in *SetPasswordRequest) (resp *SetPasswordResponse, err error) {
 	return &SetPasswordResponse{}, s.service.SetPassword(ctx, in.Username, in.Password)
 }
+
+//ChangePassword implements UserServer.ChangePassword()
+func (s *userServerAdapter) ChangePassword(ctx context.Context, in *ChangePasswordRequest) (*ChangePasswordResponse, error) {
+	return &ChangePasswordResponse{}, s.service.ChangePassword(ctx, in.Username, in.OldPassword, in.NewPassword)
+}
+
+//SetStatus implements UserServer.SetStatus()
+func (s *userServerAdapter) SetStatus(ctx context.Context, in *SetStatusRequest) (*SetStatusResponse, error) {
+	return &SetStatusResponse{}, s.service.SetStatus(ctx, in.Username, unMarshalStatus(in.Status))
+}
+
+//RotateKey implements UserServer.RotateKey()
+func (s *userServerAdapter) RotateKey(ctx context.Context, in *RotateKeyRequest) (*SigningKeyEntry, error) {
+	key, err := s.service.RotateKey(ctx, in.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return marshalSigningKey(key), nil
+}
+
+//ListKeys implements UserServer.ListKeys()
+func (s *userServerAdapter) ListKeys(ctx context.Context, in *ListKeysRequest) (*ListKeysResponse, error) {
+	keys, err := s.service.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp ListKeysResponse
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, marshalSigningKey(key))
+	}
+	return &resp, nil
+}
+
+//SetRole implements UserServer.SetRole()
+func (s *userServerAdapter) SetRole(ctx context.Context, in *RoleEntry) (*SetRoleResponse, error) {
+	return &SetRoleResponse{}, s.service.SetRole(ctx, numan.Role{Name: in.Name, Permissions: in.Permissions})
+}
+
+//DeleteRole implements UserServer.DeleteRole()
+func (s *userServerAdapter) DeleteRole(ctx context.Context, in *DeleteRoleRequest) (*DeleteRoleResponse, error) {
+	return &DeleteRoleResponse{}, s.service.DeleteRole(ctx, in.Name)
+}
+
+//ListRoles implements UserServer.ListRoles()
+func (s *userServerAdapter) ListRoles(ctx context.Context, in *ListRolesRequest) (*ListRolesResponse, error) {
+	roles, err := s.service.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resp ListRolesResponse
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, &RoleEntry{Name: role.Name, Permissions: role.Permissions})
+	}
+	return &resp, nil
+}
+
+//GrantRole implements UserServer.GrantRole()
+func (s *userServerAdapter) GrantRole(ctx context.Context, in *GrantRoleRequest) (*GrantRoleResponse, error) {
+	return &GrantRoleResponse{}, s.service.GrantRole(ctx, in.Username, in.Role)
+}
+
+//RevokeRole implements UserServer.RevokeRole()
+func (s *userServerAdapter) RevokeRole(ctx context.Context, in *RevokeRoleRequest) (*RevokeRoleResponse, error) {
+	return &RevokeRoleResponse{}, s.service.RevokeRole(ctx, in.Username, in.Role)
+}
+
+//SetScope implements UserServer.SetScope()
+func (s *userServerAdapter) SetScope(ctx context.Context, in *SetScopeRequest) (*SetScopeResponse, error) {
+	return &SetScopeResponse{}, s.service.SetScope(ctx, in.Username, unMarshalScope(in.Scope))
+}
+
+//AddAPIKey implements UserServer.AddAPIKey()
+func (s *userServerAdapter) AddAPIKey(ctx context.Context, in *APIKeyEntry) (*APIKeyEntry, error) {
+	key, err := s.service.AddAPIKey(ctx, unMarshalAPIKey(in))
+	if err != nil {
+		return nil, err
+	}
+	return marshalAPIKey(key), nil
+}
+
+//ListAPIKeys implements UserServer.ListAPIKeys()
+func (s *userServerAdapter) ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	keys, err := s.service.ListAPIKeys(ctx, in.Userfilter)
+	if err != nil {
+		return nil, err
+	}
+	var resp ListAPIKeysResponse
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, marshalAPIKey(key))
+	}
+	return &resp, nil
+}
+
+//DeleteAPIKey implements UserServer.DeleteAPIKey()
+func (s *userServerAdapter) DeleteAPIKey(ctx context.Context, in *DeleteAPIKeyRequest) (*DeleteAPIKeyResponse, error) {
+	return &DeleteAPIKeyResponse{}, s.service.DeleteAPIKey(ctx, in.Id)
+}
+
+//AuthAPIKey implements UserServer.AuthAPIKey()
+func (s *userServerAdapter) AuthAPIKey(ctx context.Context, in *AuthAPIKeyRequest) (*AuthResponse, error) {
+	user, err := s.service.AuthAPIKey(ctx, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	return marshalAuthResponse(user), nil
+}
+
+//Unlock implements UserServer.Unlock()
+func (s *userServerAdapter) Unlock(ctx context.Context, in *UnlockRequest) (*UnlockResponse, error) {
+	return &UnlockResponse{}, s.service.Unlock(ctx, in.Name)
+}
+
+//ListAudit implements UserServer.ListAudit()
+func (s *userServerAdapter) ListAudit(ctx context.Context, in *ListAuditRequest) (*ListAuditResponse, error) {
+	entries, err := s.service.ListAudit(ctx, in.Userfilter)
+	if err != nil {
+		return nil, err
+	}
+	var resp ListAuditResponse
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, &AuditEntry{Timestamp: e.Timestamp, Username: e.Username, Source: e.Source, Action: e.Action, Notes: e.Notes})
+	}
+	return &resp, nil
+}
+
+//marshalAPIKey converts numan.APIKey to APIKeyEntry
+func marshalAPIKey(key numan.APIKey) *APIKeyEntry {
+	return &APIKeyEntry{Id: key.ID, Username: key.Username, Key: key.Key, Expires: key.Expires, Created: key.Created, LastUsed: key.LastUsed, Roles: key.Roles, Scope: marshalScope(key.Scope)}
+}
+
+//unMarshalAPIKey converts APIKeyEntry to numan.APIKey
+func unMarshalAPIKey(key *APIKeyEntry) numan.APIKey {
+	return numan.APIKey{ID: key.Id, Username: key.Username, Key: key.Key, Expires: key.Expires, Created: key.Created, LastUsed: key.LastUsed, Roles: key.Roles, Scope: unMarshalScope(key.Scope)}
+}
+
+//marshalScope converts numan.Scope to Scope
+func marshalScope(scope numan.Scope) *Scope {
+	return &Scope{Domains: scope.Domains, Carriers: scope.Carriers, Prefixes: scope.Prefixes}
+}
+
+//unMarshalScope converts Scope to numan.Scope
+func unMarshalScope(scope *Scope) numan.Scope {
+	return numan.Scope{Domains: scope.GetDomains(), Carriers: scope.GetCarriers(), Prefixes: scope.GetPrefixes()}
+}
+
+//marshalStatus converts numan.AccountStatus to AccountStatus
+func marshalStatus(status numan.AccountStatus) *AccountStatus {
+	return &AccountStatus{Disabled: status.Disabled, PasswordExpires: status.PasswordExpires, MustChangePassword: status.MustChangePassword}
+}
+
+//unMarshalStatus converts AccountStatus to numan.AccountStatus
+func unMarshalStatus(status *AccountStatus) numan.AccountStatus {
+	return numan.AccountStatus{Disabled: status.GetDisabled(), PasswordExpires: status.GetPasswordExpires(), MustChangePassword: status.GetMustChangePassword()}
+}
+
+//marshalSigningKey converts numan.SigningKey to SigningKeyEntry
+func marshalSigningKey(key numan.SigningKey) *SigningKeyEntry {
+	return &SigningKeyEntry{Kid: key.Kid, Algorithm: key.Algorithm, Created: key.Created, Retired: key.Retired, Publickey: key.PublicKey}
+}
+
+//unMarshalSigningKey converts SigningKeyEntry to numan.SigningKey
+func unMarshalSigningKey(key *SigningKeyEntry) numan.SigningKey {
+	return numan.SigningKey{Kid: key.Kid, Algorithm: key.Algorithm, Created: key.Created, Retired: key.Retired, PublicKey: key.Publickey}
+}
+
+//marshalAuthResponse converts authenticated numan.User to AuthResponse
+func marshalAuthResponse(user numan.User) *AuthResponse {
+	return &AuthResponse{Uid: user.UID, Username: user.Username, Passwordhash: user.Password, Roles: user.Roles, Scope: marshalScope(user.Scope), Token: user.AccessToken, RefreshToken: user.RefreshToken}
+}
+
+//unMarshalAuthResponse converts AuthResponse to numan.User
+func unMarshalAuthResponse(resp *AuthResponse) numan.User {
+	return numan.User{UID: resp.Uid, Username: resp.Username, Password: resp.Passwordhash, AccessToken: resp.Token, RefreshToken: resp.RefreshToken, Roles: resp.Roles, Scope: unMarshalScope(resp.Scope)}
+}
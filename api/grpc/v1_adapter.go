@@ -0,0 +1,384 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/footfish/numan"
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	"google.golang.org/protobuf/proto"
+)
+
+//The numan.v1 API (api/grpc/numan/v1) is served alongside the original grpc package API.
+//Its messages are wire compatible, so v1 server adapters transcode requests & responses and call the original server adapters.
+
+//transcode copies message in to out, a wire compatible message of the other API version. Unknown fields (ex. fields removed in v1) are dropped.
+func transcode(in proto.Message, out proto.Message) error {
+	b, err := proto.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("Unable to transcode %s, %w", in.ProtoReflect().Descriptor().FullName(), err)
+	}
+	if err := (proto.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, out); err != nil {
+		return fmt.Errorf("Unable to transcode %s, %w", in.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return nil
+}
+
+//unaryV1 transcodes v1 request in to req, calls the original server adapter & transcodes its response to out
+func unaryV1(in proto.Message, req proto.Message, out proto.Message, call func() (proto.Message, error)) error {
+	if err := transcode(in, req); err != nil {
+		return err
+	}
+	resp, err := call()
+	if err != nil {
+		return err
+	}
+	return transcode(resp, out)
+}
+
+//numberingV1ServerAdapter implements numan.v1 NumberingServer(gRPC) with the original NumberingServer.
+type numberingV1ServerAdapter struct {
+	legacy  NumberingServer
+	service numan.NumberingService
+	numanv1.UnimplementedNumberingServer
+}
+
+//NewNumberingV1ServerAdapter creates a new numan.v1 NumberingServer
+func NewNumberingV1ServerAdapter(store *datastore.Store) numanv1.NumberingServer {
+	return &numberingV1ServerAdapter{legacy: NewNumberingServerAdapter(store), service: service.NewNumberingService(store)}
+}
+
+//Add implements numan.v1 NumberingServer.Add()
+func (s *numberingV1ServerAdapter) Add(ctx context.Context, in *numanv1.AddRequest) (*numanv1.AddResponse, error) {
+	req, out := &AddRequest{}, &numanv1.AddResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Add(ctx, req) })
+}
+
+//List implements numan.v1 NumberingServer.List()
+func (s *numberingV1ServerAdapter) List(ctx context.Context, in *numanv1.ListRequest) (*numanv1.ListResponse, error) {
+	req, out := &ListRequest{}, &numanv1.ListResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.List(ctx, req) })
+}
+
+//ListOwnerID implements numan.v1 NumberingServer.ListOwnerID()
+func (s *numberingV1ServerAdapter) ListOwnerID(ctx context.Context, in *numanv1.ListOwnerIDRequest) (*numanv1.ListOwnerIDResponse, error) {
+	req, out := &ListOwnerIDRequest{}, &numanv1.ListOwnerIDResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListOwnerID(ctx, req) })
+}
+
+//Reserve implements numan.v1 NumberingServer.Reserve()
+func (s *numberingV1ServerAdapter) Reserve(ctx context.Context, in *numanv1.ReserveRequest) (*numanv1.ReserveResponse, error) {
+	req, out := &ReserveRequest{}, &numanv1.ReserveResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Reserve(ctx, req) })
+}
+
+//Allocate implements numan.v1 NumberingServer.Allocate()
+func (s *numberingV1ServerAdapter) Allocate(ctx context.Context, in *numanv1.AllocateRequest) (*numanv1.AllocateResponse, error) {
+	req, out := &AllocateRequest{}, &numanv1.AllocateResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Allocate(ctx, req) })
+}
+
+//DeAllocate implements numan.v1 NumberingServer.DeAllocate()
+func (s *numberingV1ServerAdapter) DeAllocate(ctx context.Context, in *numanv1.DeAllocateRequest) (*numanv1.DeAllocateResponse, error) {
+	req, out := &DeAllocateRequest{}, &numanv1.DeAllocateResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.DeAllocate(ctx, req) })
+}
+
+//Portout implements numan.v1 NumberingServer.Portout()
+func (s *numberingV1ServerAdapter) Portout(ctx context.Context, in *numanv1.PortoutRequest) (*numanv1.PortoutResponse, error) {
+	req, out := &PortoutRequest{}, &numanv1.PortoutResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Portout(ctx, req) })
+}
+
+//Portin implements numan.v1 NumberingServer.Portin()
+func (s *numberingV1ServerAdapter) Portin(ctx context.Context, in *numanv1.PortinRequest) (*numanv1.PortinResponse, error) {
+	req, out := &PortinRequest{}, &numanv1.PortinResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Portin(ctx, req) })
+}
+
+//Delete implements numan.v1 NumberingServer.Delete()
+func (s *numberingV1ServerAdapter) Delete(ctx context.Context, in *numanv1.DeleteRequest) (*numanv1.DeleteResponse, error) {
+	req, out := &DeleteRequest{}, &numanv1.DeleteResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Delete(ctx, req) })
+}
+
+//View implements numan.v1 NumberingServer.View()
+func (s *numberingV1ServerAdapter) View(ctx context.Context, in *numanv1.ViewRequest) (*numanv1.ViewResponse, error) {
+	req, out := &ViewRequest{}, &numanv1.ViewResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.View(ctx, req) })
+}
+
+//Summary implements numan.v1 NumberingServer.Summary()
+func (s *numberingV1ServerAdapter) Summary(ctx context.Context, in *numanv1.SummaryRequest) (*numanv1.SummaryResponse, error) {
+	req, out := &SummaryRequest{}, &numanv1.SummaryResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Summary(ctx, req) })
+}
+
+//UpdateNumber implements numan.v1 NumberingServer.UpdateNumber()
+func (s *numberingV1ServerAdapter) UpdateNumber(ctx context.Context, in *numanv1.UpdateNumberRequest) (*numanv1.Number, error) {
+	number := &Number{}
+	if err := transcode(in.GetNumber(), number); err != nil {
+		return nil, err
+	}
+	updated, err := s.service.Update(ctx, unMarshalNumber(number), in.GetUpdateMask().GetPaths())
+	if err != nil {
+		return nil, err
+	}
+	out := &numanv1.Number{}
+	return out, transcode(marshalNumber(&updated), out)
+}
+
+//historyV1ServerAdapter implements numan.v1 HistoryServer(gRPC) with the original HistoryServer.
+type historyV1ServerAdapter struct {
+	legacy HistoryServer
+	numanv1.UnimplementedHistoryServer
+}
+
+//NewHistoryV1ServerAdapter creates a new numan.v1 HistoryServer
+func NewHistoryV1ServerAdapter(store *datastore.Store) numanv1.HistoryServer {
+	return &historyV1ServerAdapter{legacy: NewHistoryServerAdapter(store)}
+}
+
+//ListHistoryByNumber implements numan.v1 HistoryServer.ListHistoryByNumber()
+func (s *historyV1ServerAdapter) ListHistoryByNumber(ctx context.Context, in *numanv1.ListHistoryByNumberRequest) (*numanv1.ListHistoryResponse, error) {
+	req, out := &ListHistoryByNumberRequest{}, &numanv1.ListHistoryResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListHistoryByNumber(ctx, req) })
+}
+
+//ListHistoryByOID implements numan.v1 HistoryServer.ListHistoryByOID()
+func (s *historyV1ServerAdapter) ListHistoryByOID(ctx context.Context, in *numanv1.ListHistoryByOIDRequest) (*numanv1.ListHistoryResponse, error) {
+	req, out := &ListHistoryByOIDRequest{}, &numanv1.ListHistoryResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListHistoryByOID(ctx, req) })
+}
+
+//userV1ServerAdapter implements numan.v1 UserServer(gRPC) with the original UserServer.
+type userV1ServerAdapter struct {
+	legacy UserServer
+	numanv1.UnimplementedUserServer
+}
+
+//NewUserV1ServerAdapter creates a new numan.v1 UserServer
+func NewUserV1ServerAdapter(store *datastore.Store) numanv1.UserServer {
+	return &userV1ServerAdapter{legacy: NewUserServerAdapter(store)}
+}
+
+//Auth implements numan.v1 UserServer.Auth()
+func (s *userV1ServerAdapter) Auth(ctx context.Context, in *numanv1.AuthRequest) (*numanv1.AuthResponse, error) {
+	req, out := &AuthRequest{}, &numanv1.AuthResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Auth(ctx, req) })
+}
+
+//AddUser implements numan.v1 UserServer.AddUser()
+func (s *userV1ServerAdapter) AddUser(ctx context.Context, in *numanv1.AddUserRequest) (*numanv1.AddUserResponse, error) {
+	req, out := &AddUserRequest{}, &numanv1.AddUserResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.AddUser(ctx, req) })
+}
+
+//ListUsers implements numan.v1 UserServer.ListUsers()
+func (s *userV1ServerAdapter) ListUsers(ctx context.Context, in *numanv1.ListUsersRequest) (*numanv1.ListUsersResponse, error) {
+	req, out := &ListUsersRequest{}, &numanv1.ListUsersResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListUsers(ctx, req) })
+}
+
+//DeleteUser implements numan.v1 UserServer.DeleteUser()
+func (s *userV1ServerAdapter) DeleteUser(ctx context.Context, in *numanv1.DeleteUserRequest) (*numanv1.DeleteUserResponse, error) {
+	req, out := &DeleteUserRequest{}, &numanv1.DeleteUserResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.DeleteUser(ctx, req) })
+}
+
+//SetPassword implements numan.v1 UserServer.SetPassword()
+func (s *userV1ServerAdapter) SetPassword(ctx context.Context, in *numanv1.SetPasswordRequest) (*numanv1.SetPasswordResponse, error) {
+	req, out := &SetPasswordRequest{}, &numanv1.SetPasswordResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.SetPassword(ctx, req) })
+}
+
+//ChangePassword implements numan.v1 UserServer.ChangePassword()
+func (s *userV1ServerAdapter) ChangePassword(ctx context.Context, in *numanv1.ChangePasswordRequest) (*numanv1.ChangePasswordResponse, error) {
+	req, out := &ChangePasswordRequest{}, &numanv1.ChangePasswordResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ChangePassword(ctx, req) })
+}
+
+//SetStatus implements numan.v1 UserServer.SetStatus()
+func (s *userV1ServerAdapter) SetStatus(ctx context.Context, in *numanv1.SetStatusRequest) (*numanv1.SetStatusResponse, error) {
+	req, out := &SetStatusRequest{}, &numanv1.SetStatusResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.SetStatus(ctx, req) })
+}
+
+//RotateKey implements numan.v1 UserServer.RotateKey()
+func (s *userV1ServerAdapter) RotateKey(ctx context.Context, in *numanv1.RotateKeyRequest) (*numanv1.SigningKeyEntry, error) {
+	req, out := &RotateKeyRequest{}, &numanv1.SigningKeyEntry{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.RotateKey(ctx, req) })
+}
+
+//ListKeys implements numan.v1 UserServer.ListKeys()
+func (s *userV1ServerAdapter) ListKeys(ctx context.Context, in *numanv1.ListKeysRequest) (*numanv1.ListKeysResponse, error) {
+	req, out := &ListKeysRequest{}, &numanv1.ListKeysResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListKeys(ctx, req) })
+}
+
+//Refresh implements numan.v1 UserServer.Refresh()
+func (s *userV1ServerAdapter) Refresh(ctx context.Context, in *numanv1.RefreshRequest) (*numanv1.AuthResponse, error) {
+	req, out := &RefreshRequest{}, &numanv1.AuthResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Refresh(ctx, req) })
+}
+
+//Logout implements numan.v1 UserServer.Logout()
+func (s *userV1ServerAdapter) Logout(ctx context.Context, in *numanv1.LogoutRequest) (*numanv1.LogoutResponse, error) {
+	req, out := &LogoutRequest{}, &numanv1.LogoutResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Logout(ctx, req) })
+}
+
+//SetRole implements numan.v1 UserServer.SetRole()
+func (s *userV1ServerAdapter) SetRole(ctx context.Context, in *numanv1.RoleEntry) (*numanv1.SetRoleResponse, error) {
+	req, out := &RoleEntry{}, &numanv1.SetRoleResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.SetRole(ctx, req) })
+}
+
+//DeleteRole implements numan.v1 UserServer.DeleteRole()
+func (s *userV1ServerAdapter) DeleteRole(ctx context.Context, in *numanv1.DeleteRoleRequest) (*numanv1.DeleteRoleResponse, error) {
+	req, out := &DeleteRoleRequest{}, &numanv1.DeleteRoleResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.DeleteRole(ctx, req) })
+}
+
+//ListRoles implements numan.v1 UserServer.ListRoles()
+func (s *userV1ServerAdapter) ListRoles(ctx context.Context, in *numanv1.ListRolesRequest) (*numanv1.ListRolesResponse, error) {
+	req, out := &ListRolesRequest{}, &numanv1.ListRolesResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListRoles(ctx, req) })
+}
+
+//GrantRole implements numan.v1 UserServer.GrantRole()
+func (s *userV1ServerAdapter) GrantRole(ctx context.Context, in *numanv1.GrantRoleRequest) (*numanv1.GrantRoleResponse, error) {
+	req, out := &GrantRoleRequest{}, &numanv1.GrantRoleResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.GrantRole(ctx, req) })
+}
+
+//RevokeRole implements numan.v1 UserServer.RevokeRole()
+func (s *userV1ServerAdapter) RevokeRole(ctx context.Context, in *numanv1.RevokeRoleRequest) (*numanv1.RevokeRoleResponse, error) {
+	req, out := &RevokeRoleRequest{}, &numanv1.RevokeRoleResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.RevokeRole(ctx, req) })
+}
+
+//SetScope implements numan.v1 UserServer.SetScope()
+func (s *userV1ServerAdapter) SetScope(ctx context.Context, in *numanv1.SetScopeRequest) (*numanv1.SetScopeResponse, error) {
+	req, out := &SetScopeRequest{}, &numanv1.SetScopeResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.SetScope(ctx, req) })
+}
+
+//AddAPIKey implements numan.v1 UserServer.AddAPIKey()
+func (s *userV1ServerAdapter) AddAPIKey(ctx context.Context, in *numanv1.APIKeyEntry) (*numanv1.APIKeyEntry, error) {
+	req, out := &APIKeyEntry{}, &numanv1.APIKeyEntry{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.AddAPIKey(ctx, req) })
+}
+
+//ListAPIKeys implements numan.v1 UserServer.ListAPIKeys()
+func (s *userV1ServerAdapter) ListAPIKeys(ctx context.Context, in *numanv1.ListAPIKeysRequest) (*numanv1.ListAPIKeysResponse, error) {
+	req, out := &ListAPIKeysRequest{}, &numanv1.ListAPIKeysResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListAPIKeys(ctx, req) })
+}
+
+//DeleteAPIKey implements numan.v1 UserServer.DeleteAPIKey()
+func (s *userV1ServerAdapter) DeleteAPIKey(ctx context.Context, in *numanv1.DeleteAPIKeyRequest) (*numanv1.DeleteAPIKeyResponse, error) {
+	req, out := &DeleteAPIKeyRequest{}, &numanv1.DeleteAPIKeyResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.DeleteAPIKey(ctx, req) })
+}
+
+//AuthAPIKey implements numan.v1 UserServer.AuthAPIKey()
+func (s *userV1ServerAdapter) AuthAPIKey(ctx context.Context, in *numanv1.AuthAPIKeyRequest) (*numanv1.AuthResponse, error) {
+	req, out := &AuthAPIKeyRequest{}, &numanv1.AuthResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.AuthAPIKey(ctx, req) })
+}
+
+//Unlock implements numan.v1 UserServer.Unlock()
+func (s *userV1ServerAdapter) Unlock(ctx context.Context, in *numanv1.UnlockRequest) (*numanv1.UnlockResponse, error) {
+	req, out := &UnlockRequest{}, &numanv1.UnlockResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.Unlock(ctx, req) })
+}
+
+//ListAudit implements numan.v1 UserServer.ListAudit()
+func (s *userV1ServerAdapter) ListAudit(ctx context.Context, in *numanv1.ListAuditRequest) (*numanv1.ListAuditResponse, error) {
+	req, out := &ListAuditRequest{}, &numanv1.ListAuditResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListAudit(ctx, req) })
+}
+
+//eventV1ServerAdapter implements numan.v1 EventServer(gRPC) with the original EventServer.
+type eventV1ServerAdapter struct {
+	legacy EventServer
+	numanv1.UnimplementedEventServer
+}
+
+//NewEventV1ServerAdapter creates a new numan.v1 EventServer
+func NewEventV1ServerAdapter(store *datastore.Store) numanv1.EventServer {
+	return &eventV1ServerAdapter{legacy: NewEventServerAdapter(store)}
+}
+
+//ListEvents implements numan.v1 EventServer.ListEvents()
+func (s *eventV1ServerAdapter) ListEvents(ctx context.Context, in *numanv1.ListEventsRequest) (*numanv1.ListEventsResponse, error) {
+	req, out := &ListEventsRequest{}, &numanv1.ListEventsResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListEvents(ctx, req) })
+}
+
+//Watch implements numan.v1 EventServer.Watch()
+func (s *eventV1ServerAdapter) Watch(in *numanv1.WatchRequest, stream numanv1.Event_WatchServer) error {
+	req := &WatchRequest{}
+	if err := transcode(in, req); err != nil {
+		return err
+	}
+	return s.legacy.Watch(req, watchV1Stream{stream})
+}
+
+//watchV1Stream adapts a numan.v1 Watch stream to the original Event_WatchServer
+type watchV1Stream struct {
+	numanv1.Event_WatchServer
+}
+
+//Send transcodes & sends an event
+func (s watchV1Stream) Send(e *NumberEvent) error {
+	out := &numanv1.NumberEvent{}
+	if err := transcode(e, out); err != nil {
+		return err
+	}
+	return s.Event_WatchServer.Send(out)
+}
+
+//webhookV1ServerAdapter implements numan.v1 WebhookServer(gRPC) with the original WebhookServer.
+type webhookV1ServerAdapter struct {
+	legacy WebhookServer
+	numanv1.UnimplementedWebhookServer
+}
+
+//NewWebhookV1ServerAdapter creates a new numan.v1 WebhookServer
+func NewWebhookV1ServerAdapter(store *datastore.Store) numanv1.WebhookServer {
+	return &webhookV1ServerAdapter{legacy: NewWebhookServerAdapter(store)}
+}
+
+//AddWebhook implements numan.v1 WebhookServer.AddWebhook()
+func (s *webhookV1ServerAdapter) AddWebhook(ctx context.Context, in *numanv1.AddWebhookRequest) (*numanv1.AddWebhookResponse, error) {
+	req, out := &AddWebhookRequest{}, &numanv1.AddWebhookResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.AddWebhook(ctx, req) })
+}
+
+//ListWebhooks implements numan.v1 WebhookServer.ListWebhooks()
+func (s *webhookV1ServerAdapter) ListWebhooks(ctx context.Context, in *numanv1.ListWebhooksRequest) (*numanv1.ListWebhooksResponse, error) {
+	req, out := &ListWebhooksRequest{}, &numanv1.ListWebhooksResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListWebhooks(ctx, req) })
+}
+
+//DeleteWebhook implements numan.v1 WebhookServer.DeleteWebhook()
+func (s *webhookV1ServerAdapter) DeleteWebhook(ctx context.Context, in *numanv1.DeleteWebhookRequest) (*numanv1.DeleteWebhookResponse, error) {
+	req, out := &DeleteWebhookRequest{}, &numanv1.DeleteWebhookResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.DeleteWebhook(ctx, req) })
+}
+
+//ListDeadLetters implements numan.v1 WebhookServer.ListDeadLetters()
+func (s *webhookV1ServerAdapter) ListDeadLetters(ctx context.Context, in *numanv1.ListDeadLettersRequest) (*numanv1.ListDeadLettersResponse, error) {
+	req, out := &ListDeadLettersRequest{}, &numanv1.ListDeadLettersResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.ListDeadLetters(ctx, req) })
+}
+
+//RedeliverDeadLetters implements numan.v1 WebhookServer.RedeliverDeadLetters()
+func (s *webhookV1ServerAdapter) RedeliverDeadLetters(ctx context.Context, in *numanv1.RedeliverDeadLettersRequest) (*numanv1.RedeliverDeadLettersResponse, error) {
+	req, out := &RedeliverDeadLettersRequest{}, &numanv1.RedeliverDeadLettersResponse{}
+	return out, unaryV1(in, req, out, func() (proto.Message, error) { return s.legacy.RedeliverDeadLetters(ctx, req) })
+}
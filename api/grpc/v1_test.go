@@ -0,0 +1,311 @@
+package grpc
+
+import (
+	context "context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"github.com/footfish/numan/internal/service/datastore"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+//v1Files pairs the original proto files with their numan.v1 versions
+var v1Files = map[string]string{
+	"numbering.proto": "numan/v1/numbering.proto",
+	"history.proto":   "numan/v1/history.proto",
+	"user.proto":      "numan/v1/user.proto",
+	"event.proto":     "numan/v1/event.proto",
+	"webhook.proto":   "numan/v1/webhook.proto",
+}
+
+//v1Removed lists the original methods & messages not in numan.v1
+var v1Removed = map[string]bool{"AddGroup": true, "AddGroupRequest": true, "AddGroupResponse": true}
+
+//TestV1WireCompat checks every original message has a numan.v1 message of the same name with the same field numbers, kinds & cardinality.
+//Fields removed in v1 must be reserved. Every original method must have a v1 method with the same (wire compatible) request & response.
+func TestV1WireCompat(t *testing.T) {
+	for legacyPath, v1Path := range v1Files {
+		legacy, err := protoregistry.GlobalFiles.FindFileByPath(legacyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v1, err := protoregistry.GlobalFiles.FindFileByPath(v1Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v1.Package() != "numan.v1" {
+			t.Errorf("%s package %s, want numan.v1", v1Path, v1.Package())
+		}
+		for i := 0; i < legacy.Messages().Len(); i++ {
+			m := legacy.Messages().Get(i)
+			if v1Removed[string(m.Name())] {
+				continue
+			}
+			v1m := v1.Messages().ByName(m.Name())
+			if v1m == nil {
+				t.Errorf("%s: no numan.v1 message", m.FullName())
+				continue
+			}
+			compareFields(t, m, v1m)
+		}
+		for i := 0; i < legacy.Services().Len(); i++ {
+			s := legacy.Services().Get(i)
+			v1s := v1.Services().ByName(s.Name())
+			if v1s == nil {
+				t.Errorf("%s: no numan.v1 service", s.FullName())
+				continue
+			}
+			for j := 0; j < s.Methods().Len(); j++ {
+				m := s.Methods().Get(j)
+				v1m := v1s.Methods().ByName(m.Name())
+				switch {
+				case v1Removed[string(m.Name())]:
+					if v1m != nil {
+						t.Errorf("%s: removed method in numan.v1", m.FullName())
+					}
+				case v1m == nil:
+					t.Errorf("%s: no numan.v1 method", m.FullName())
+				case v1m.Input().Name() != m.Input().Name() || v1m.Output().Name() != m.Output().Name() || v1m.IsStreamingServer() != m.IsStreamingServer():
+					t.Errorf("%s: numan.v1 method %s(%s) %s, want %s(%s) %s", m.FullName(), v1m.Name(), v1m.Input().Name(), v1m.Output().Name(), m.Name(), m.Input().Name(), m.Output().Name())
+				}
+			}
+		}
+	}
+}
+
+//compareFields checks message v1 is wire compatible with the original message m
+func compareFields(t *testing.T, m protoreflect.MessageDescriptor, v1 protoreflect.MessageDescriptor) {
+	t.Helper()
+	for i := 0; i < m.Fields().Len(); i++ {
+		f := m.Fields().Get(i)
+		v1f := v1.Fields().ByNumber(f.Number())
+		switch {
+		case v1f == nil:
+			if !v1.ReservedRanges().Has(f.Number()) {
+				t.Errorf("%s: field %d removed in numan.v1 but not reserved", f.FullName(), f.Number())
+			}
+		case v1f.Kind() != f.Kind() || v1f.Cardinality() != f.Cardinality():
+			t.Errorf("%s: numan.v1 field %s is %v %v, want %v %v", f.FullName(), v1f.Name(), v1f.Cardinality(), v1f.Kind(), f.Cardinality(), f.Kind())
+		case f.Message() != nil && v1f.Message().Name() != f.Message().Name():
+			t.Errorf("%s: numan.v1 field %s is %s, want %s", f.FullName(), v1f.Name(), v1f.Message().Name(), f.Message().Name())
+		}
+	}
+	for i := 0; i < v1.Fields().Len(); i++ {
+		if f := v1.Fields().Get(i); m.Fields().ByNumber(f.Number()) == nil {
+			t.Errorf("%s: field %d not in the original message", f.FullName(), f.Number())
+		}
+	}
+}
+
+//TestV1Transcode checks populated original messages are unchanged after transcoding to numan.v1 and back, except fields removed in v1
+func TestV1Transcode(t *testing.T) {
+	for legacyPath := range v1Files {
+		legacy, err := protoregistry.GlobalFiles.FindFileByPath(legacyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < legacy.Messages().Len(); i++ {
+			md := legacy.Messages().Get(i)
+			if v1Removed[string(md.Name())] {
+				continue
+			}
+			mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName())
+			if err != nil {
+				t.Fatal(err)
+			}
+			v1t, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName("numan.v1." + md.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := mt.New()
+			populate(in, 2)
+			v1, out := v1t.New().Interface(), mt.New().Interface()
+			if err := transcode(in.Interface(), v1); err != nil {
+				t.Fatal(err)
+			}
+			if err := transcode(v1, out); err != nil {
+				t.Fatal(err)
+			}
+			want := proto.Clone(in.Interface())
+			clearRemoved(want.ProtoReflect(), v1t.Descriptor())
+			if !proto.Equal(out, want) {
+				t.Errorf("%s: transcoded got %v, want %v", md.FullName(), out, want)
+			}
+		}
+	}
+	//password hashes are not returned by numan.v1
+	v1 := &numanv1.AuthResponse{}
+	if err := transcode(&AuthResponse{Username: "alice", Passwordhash: "hash"}, v1); err != nil {
+		t.Fatal(err)
+	} else if b, _ := proto.Marshal(v1); v1.Username != "alice" || len(v1.ProtoReflect().GetUnknown()) != 0 || len(b) != len("alice")+2 {
+		t.Fatalf("numan.v1 AuthResponse got %v", v1)
+	}
+}
+
+//populate sets every field of m to a non-zero value, messages are populated to depth
+func populate(m protoreflect.Message, depth int) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		var v protoreflect.Value
+		switch f.Kind() {
+		case protoreflect.BoolKind:
+			v = protoreflect.ValueOfBool(true)
+		case protoreflect.Int32Kind:
+			v = protoreflect.ValueOfInt32(int32(f.Number()))
+		case protoreflect.Int64Kind:
+			v = protoreflect.ValueOfInt64(int64(f.Number()) << 40)
+		case protoreflect.StringKind:
+			v = protoreflect.ValueOfString(string(f.FullName()))
+		case protoreflect.MessageKind:
+			if depth == 0 {
+				continue
+			}
+			sub := m.NewField(f)
+			if f.IsList() {
+				sub = protoreflect.ValueOfMessage(m.NewField(f).List().NewElement().Message())
+			}
+			populate(sub.Message(), depth-1)
+			v = sub
+		default:
+			panic("unexpected kind " + f.Kind().String())
+		}
+		if f.IsList() {
+			list := m.Mutable(f).List()
+			list.Append(v)
+			list.Append(v)
+			continue
+		}
+		m.Set(f, v)
+	}
+}
+
+//clearRemoved clears the fields of m (recursively) not in the v1 message
+func clearRemoved(m protoreflect.Message, v1 protoreflect.MessageDescriptor) {
+	m.Range(func(f protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		v1f := v1.Fields().ByNumber(f.Number())
+		switch {
+		case v1f == nil:
+			m.Clear(f)
+		case f.IsList() && f.Message() != nil:
+			for i := 0; i < v.List().Len(); i++ {
+				clearRemoved(v.List().Get(i).Message(), v1f.Message())
+			}
+		case f.Message() != nil:
+			clearRemoved(v.Message(), v1f.Message())
+		}
+		return true
+	})
+}
+
+//TestV1Server checks the numan.v1 services are served alongside the original services
+func TestV1Server(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	lis := bufconn.Listen(1 << 20)
+	server := NewGrpcServer(nil, store, nil, nil, nil)
+	RegisterNumberingServer(server, NewNumberingServerAdapter(store))
+	RegisterUserServer(server, NewUserServerAdapter(store))
+	numanv1.RegisterNumberingServer(server, NewNumberingV1ServerAdapter(store))
+	numanv1.RegisterUserServer(server, NewUserV1ServerAdapter(store))
+	numanv1.RegisterEventServer(server, NewEventV1ServerAdapter(store))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(), grpc.WithUnaryInterceptor(authClientInterceptor), grpc.WithStreamInterceptor(authStreamClientInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	admin := numan.User{Username: "root", Roles: []string{numan.RoleAdmin}}
+	if err := admin.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	adminCtx := context.WithValue(ctx, numan.AuthTokenField, admin.AccessToken)
+	numbering, users := NewNumberingClientAdapter(conn), NewUserClientAdapter(conn)
+	numberingV1, usersV1, eventsV1 := numanv1.NewNumberingClient(conn), numanv1.NewUserClient(conn), numanv1.NewEventClient(conn)
+
+	number := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "a.com", Carrier: "carrier"}
+	if err := numbering.Add(adminCtx, &number); err != nil {
+		t.Fatal(err)
+	}
+	watch, err := eventsV1.Watch(adminCtx, &numanv1.WatchRequest{Filter: &numanv1.EventFilter{Types: []string{numan.EventUpdated}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//original client adapter updates with the numan.v1 API
+	number.Domain = "b.com"
+	updated, err := numbering.Update(adminCtx, &number, []string{"domain"})
+	if err != nil {
+		t.Fatal(err)
+	} else if updated.Domain != "b.com" || updated.Carrier != "carrier" || updated.ID == 0 {
+		t.Fatalf("Update got %+v", updated)
+	}
+	if e, err := watch.Recv(); err != nil {
+		t.Fatal(err)
+	} else if e.GetType() != numan.EventUpdated || e.GetDomain() != "b.com" || e.GetE164().GetSn() != "12345001" || e.GetActor() != "root" {
+		t.Fatalf("numan.v1 Watch got %v", e)
+	}
+	if _, err := numbering.Update(adminCtx, &number, []string{"used"}); !errors.Is(err, numan.ErrInvalidArgument) {
+		t.Fatalf("Update of used got %v, want ErrInvalidArgument", err)
+	}
+
+	//numan.v1 UpdateNumber
+	resp, err := numberingV1.UpdateNumber(adminCtx, &numanv1.UpdateNumberRequest{
+		Number:     &numanv1.Number{E164: &numanv1.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "ignored.com", Carrier: "other"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"carrier"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.GetDomain() != "b.com" || resp.GetCarrier() != "other" {
+		t.Fatalf("UpdateNumber got %v", resp)
+	}
+	if _, err := numberingV1.UpdateNumber(adminCtx, &numanv1.UpdateNumberRequest{Number: &numanv1.Number{E164: &numanv1.E164{Cc: "353", Ndc: "01", Sn: "99999999"}, Carrier: "other"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"carrier"}}}); status.Code(err) != codes.NotFound {
+		t.Fatalf("UpdateNumber of missing number got %v, want NotFound", err)
+	}
+	if _, err := numberingV1.UpdateNumber(ctx, &numanv1.UpdateNumberRequest{Number: &numanv1.Number{E164: &numanv1.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Carrier: "other"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"carrier"}}}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("UpdateNumber without token got %v, want Unauthenticated", err)
+	}
+	list, err := numberingV1.List(adminCtx, &numanv1.ListRequest{NumberFilter: &numanv1.NumberFilter{Domain: "b.com"}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(list.GetNumber()) != 1 || list.GetNumber()[0].GetCarrier() != "other" {
+		t.Fatalf("numan.v1 List got %v", list)
+	}
+
+	//AddGroup is only in the original API (unimplemented)
+	if err := conn.Invoke(adminCtx, "/numan.v1.Numbering/AddGroup", &AddGroupRequest{}, &AddGroupResponse{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("numan.v1 AddGroup got %v, want Unimplemented", err)
+	}
+
+	//numan.v1 Auth doesn't return the password hash
+	if err := users.AddUser(adminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := usersV1.Auth(ctx, &numanv1.AuthRequest{Username: "alice", Password: "secret123"})
+	if err != nil {
+		t.Fatal(err)
+	} else if auth.GetToken() == "" || auth.GetRefreshToken() == "" || len(auth.ProtoReflect().GetUnknown()) != 0 {
+		t.Fatalf("numan.v1 Auth got %v", auth)
+	}
+}
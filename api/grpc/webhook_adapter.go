@@ -0,0 +1,187 @@
+package grpc
+
+import (
+	context "context"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	"google.golang.org/grpc"
+)
+
+//webhookClientAdapter implements an adapter from WebhookService to webhookClient(gRPC).
+type webhookClientAdapter struct {
+	grpc *webhookClient
+}
+
+// NewWebhookClientAdapter instantiates WebhookClientAdapter
+func NewWebhookClientAdapter(conn *grpc.ClientConn) numan.WebhookService {
+	c := NewWebhookClient(errorConn{conn}) //errors are converted back to service errors
+	return &webhookClientAdapter{c.(*webhookClient)}
+}
+
+//AddWebhook implements WebhookService.AddWebhook()
+func (c *webhookClientAdapter) AddWebhook(ctx context.Context, hook numan.Webhook) (numan.Webhook, error) {
+	addWebhookResponse, err := c.grpc.AddWebhook(ctx, &AddWebhookRequest{Webhook: marshalWebhook(&hook)})
+	if err != nil {
+		return numan.Webhook{}, err
+	}
+	return *unMarshalWebhook(addWebhookResponse.Webhook), nil
+}
+
+//ListWebhooks implements WebhookService.ListWebhooks()
+func (c *webhookClientAdapter) ListWebhooks(ctx context.Context) (hooks []numan.Webhook, err error) {
+	listWebhooksResponse, err := c.grpc.ListWebhooks(ctx, &ListWebhooksRequest{})
+	if err == nil {
+		for _, hook := range listWebhooksResponse.Webhook {
+			hooks = append(hooks, *unMarshalWebhook(hook))
+		}
+	}
+	return
+}
+
+//DeleteWebhook implements WebhookService.DeleteWebhook()
+func (c *webhookClientAdapter) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := c.grpc.DeleteWebhook(ctx, &DeleteWebhookRequest{Id: id})
+	return err
+}
+
+//ListDeadLetters implements WebhookService.ListDeadLetters()
+func (c *webhookClientAdapter) ListDeadLetters(ctx context.Context, webhookID int64) (deliveries []numan.WebhookDelivery, err error) {
+	listDeadLettersResponse, err := c.grpc.ListDeadLetters(ctx, &ListDeadLettersRequest{WebhookId: webhookID})
+	if err == nil {
+		for _, d := range listDeadLettersResponse.Delivery {
+			deliveries = append(deliveries, *unMarshalWebhookDelivery(d))
+		}
+	}
+	return
+}
+
+//RedeliverDeadLetters implements WebhookService.RedeliverDeadLetters()
+func (c *webhookClientAdapter) RedeliverDeadLetters(ctx context.Context, webhookID int64) (int64, error) {
+	redeliverDeadLettersResponse, err := c.grpc.RedeliverDeadLetters(ctx, &RedeliverDeadLettersRequest{WebhookId: webhookID})
+	if err != nil {
+		return 0, err
+	}
+	return redeliverDeadLettersResponse.Queued, nil
+}
+
+//webhookServerAdapter implements an adapter from WebhookServer(gRPC) to WebhookService.
+type webhookServerAdapter struct {
+	service numan.WebhookService
+	UnimplementedWebhookServer
+}
+
+//  NewWebhookServerAdapter creates a new WebhookServerAdapter
+func NewWebhookServerAdapter(store *datastore.Store) WebhookServer {
+	return &webhookServerAdapter{service: service.NewWebhookService(store)}
+}
+
+//AddWebhook implements WebhookServer.AddWebhook()
+func (s *webhookServerAdapter) AddWebhook(ctx context.Context, in *AddWebhookRequest) (*AddWebhookResponse, error) {
+	hook, err := s.service.AddWebhook(ctx, *unMarshalWebhook(in.Webhook))
+	if err != nil {
+		return nil, err
+	}
+	return &AddWebhookResponse{Webhook: marshalWebhook(&hook)}, nil
+}
+
+//ListWebhooks implements WebhookServer.ListWebhooks()
+func (s *webhookServerAdapter) ListWebhooks(ctx context.Context, in *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	hooks, err := s.service.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListWebhooksResponse{}
+	for i := range hooks {
+		resp.Webhook = append(resp.Webhook, marshalWebhook(&hooks[i]))
+	}
+	return resp, nil
+}
+
+//DeleteWebhook implements WebhookServer.DeleteWebhook()
+func (s *webhookServerAdapter) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest) (*DeleteWebhookResponse, error) {
+	return &DeleteWebhookResponse{}, s.service.DeleteWebhook(ctx, in.Id)
+}
+
+//ListDeadLetters implements WebhookServer.ListDeadLetters()
+func (s *webhookServerAdapter) ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest) (*ListDeadLettersResponse, error) {
+	deliveries, err := s.service.ListDeadLetters(ctx, in.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListDeadLettersResponse{}
+	for i := range deliveries {
+		resp.Delivery = append(resp.Delivery, marshalWebhookDelivery(&deliveries[i]))
+	}
+	return resp, nil
+}
+
+//RedeliverDeadLetters implements WebhookServer.RedeliverDeadLetters()
+func (s *webhookServerAdapter) RedeliverDeadLetters(ctx context.Context, in *RedeliverDeadLettersRequest) (*RedeliverDeadLettersResponse, error) {
+	queued, err := s.service.RedeliverDeadLetters(ctx, in.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+	return &RedeliverDeadLettersResponse{Queued: queued}, nil
+}
+
+func marshalWebhook(w *numan.Webhook) *WebhookEntry {
+	if w == nil {
+		return &WebhookEntry{}
+	}
+	return &WebhookEntry{
+		Id:      w.ID,
+		Url:     w.URL,
+		Filter:  marshalEventFilter(&w.Filter),
+		Secret:  w.Secret,
+		Created: w.Created,
+		Pending: w.Pending,
+		Dead:    w.Dead,
+	}
+}
+
+func unMarshalWebhook(w *WebhookEntry) *numan.Webhook {
+	if w == nil {
+		return &numan.Webhook{}
+	}
+	return &numan.Webhook{
+		ID:      w.Id,
+		URL:     w.Url,
+		Filter:  *unMarshalEventFilter(w.Filter),
+		Secret:  w.Secret,
+		Created: w.Created,
+		Pending: w.Pending,
+		Dead:    w.Dead,
+	}
+}
+
+func marshalWebhookDelivery(d *numan.WebhookDelivery) *WebhookDelivery {
+	if d == nil {
+		return &WebhookDelivery{}
+	}
+	event := d.Payload.Event()
+	return &WebhookDelivery{
+		Id:        d.ID,
+		WebhookId: d.WebhookID,
+		Event:     marshalEvent(&event),
+		Attempts:  int32(d.Attempts),
+		LastError: d.LastError,
+		Created:   d.Created,
+	}
+}
+
+func unMarshalWebhookDelivery(d *WebhookDelivery) *numan.WebhookDelivery {
+	if d == nil {
+		return &numan.WebhookDelivery{}
+	}
+	return &numan.WebhookDelivery{
+		ID:        d.Id,
+		WebhookID: d.WebhookId,
+		Payload:   numan.NewWebhookPayload(*unMarshalEvent(d.Event)),
+		Attempts:  int(d.Attempts),
+		LastError: d.LastError,
+		Dead:      true,
+		Created:   d.Created,
+	}
+}
@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/footfish/numan"
+)
+
+//defaultEventLimit is the number of events listed if limit is not set
+const defaultEventLimit = 100
+
+//event is the JSON form of numan.NumberEvent, the number is formatted cc-ndc-sn
+type event struct {
+	Seq       int64  `json:"seq"`
+	Type      string `json:"type"`
+	Number    string `json:"number"`
+	Domain    string `json:"domain"`
+	Carrier   string `json:"carrier"`
+	OldState  string `json:"oldState"`
+	NewState  string `json:"newState"`
+	OwnerID   int64  `json:"ownerId"`
+	Actor     string `json:"actor"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+//eventRoutes returns the EventService routes (Watch is gRPC only, poll with after set to the last seq)
+func (h *Handler) eventRoutes() []route {
+	return []route{
+		{method: http.MethodGet, path: "/v1/events", tag: "Events", rpc: "Event/ListEvents", summary: "Lists number events after a sequence number, oldest first. type is a comma separated list",
+			query: []string{"after", "domain", "carrier", "prefix", "type", "limit"}, response: []event{}, handle: h.listEvents},
+	}
+}
+
+func (h *Handler) listEvents(r *http.Request, p params) (interface{}, error) {
+	q := r.URL.Query()
+	filter := numan.EventFilter{Domain: q.Get("domain"), Carrier: q.Get("carrier"), Prefix: q.Get("prefix")}
+	if q.Get("type") != "" {
+		filter.Types = strings.Split(q.Get("type"), ",")
+	}
+	if q.Get("after") != "" {
+		after, err := strconv.ParseInt(q.Get("after"), 10, 64)
+		if err != nil {
+			return nil, numan.Errorf(numan.ErrInvalidArgument, "invalid after")
+		}
+		filter.AfterSeq = after
+	}
+	limit := defaultEventLimit
+	if q.Get("limit") != "" {
+		var err error
+		if limit, err = strconv.Atoi(q.Get("limit")); err != nil {
+			return nil, numan.Errorf(numan.ErrInvalidArgument, "invalid limit")
+		}
+	}
+	list, err := h.events.ListEvents(r.Context(), &filter, limit)
+	return marshalEvents(list), err
+}
+
+//marshalEvents converts a list of numan.NumberEvent to JSON form (never nil)
+func marshalEvents(list []numan.NumberEvent) []event {
+	events := []event{}
+	for _, e := range list {
+		events = append(events, event{Seq: e.Seq, Type: e.Type, Number: formatE164(e.E164), Domain: e.Domain, Carrier: e.Carrier,
+			OldState: e.OldState, NewState: e.NewState, OwnerID: e.OwnerID, Actor: e.Actor, Timestamp: e.Timestamp})
+	}
+	return events
+}
@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/footfish/numan"
+)
+
+//history is the JSON form of numan.History, the number is formatted cc-ndc-sn
+type history struct {
+	Timestamp int64  `json:"timestamp"`
+	Number    string `json:"number"`
+	OwnerID   int64  `json:"ownerId"`
+	Action    string `json:"action"`
+	Notes     string `json:"notes"`
+}
+
+//addHistoryRequest adds a history entry
+type addHistoryRequest struct {
+	Number  string `json:"number"`
+	OwnerID int64  `json:"ownerId"`
+	Action  string `json:"action"`
+	Notes   string `json:"notes"`
+}
+
+//archiveRequest is a history retention policy
+type archiveRequest struct {
+	Before   int64 `json:"before"`
+	KeepLast int   `json:"keepLast"`
+}
+
+//archiveResponse counts archived history entries
+type archiveResponse struct {
+	Archived int64 `json:"archived"`
+}
+
+//historyRoutes returns the HistoryService routes
+func (h *Handler) historyRoutes() []route {
+	return []route{
+		{method: http.MethodGet, path: "/v1/numbers/{number}/history", tag: "History", rpc: "History/ListHistoryByNumber", summary: "Lists history for a number. Set archived=true to include archived history",
+			query: []string{"archived"}, response: []history{}, handle: h.listNumberHistory},
+		{method: http.MethodGet, path: "/v1/owners/{ownerId}/history", tag: "History", rpc: "History/ListHistoryByOID", summary: "Lists history for an owner. Set archived=true to include archived history",
+			query: []string{"archived"}, response: []history{}, handle: h.listOwnerHistory},
+		{method: http.MethodPost, path: "/v1/history", tag: "History", rpc: "History/AddHistory", summary: "Adds a history entry",
+			request: addHistoryRequest{}, handle: h.addHistory},
+		{method: http.MethodPost, path: "/v1/history/archive", tag: "History", rpc: "History/ArchiveHistory", summary: "Archives history logged before a timestamp, keeping the last entries per number",
+			request: archiveRequest{}, response: archiveResponse{}, handle: h.archiveHistory},
+	}
+}
+
+func (h *Handler) listNumberHistory(r *http.Request, p params) (interface{}, error) {
+	e164, err := parseE164(p["number"])
+	if err != nil {
+		return nil, err
+	}
+	list, err := h.history.ListHistoryByNumber(r.Context(), e164, r.URL.Query().Get("archived") == "true")
+	return marshalHistory(list), err
+}
+
+func (h *Handler) listOwnerHistory(r *http.Request, p params) (interface{}, error) {
+	oid, err := parseOwnerID(p["ownerId"])
+	if err != nil {
+		return nil, err
+	}
+	list, err := h.history.ListHistoryByOwnerID(r.Context(), oid, r.URL.Query().Get("archived") == "true")
+	return marshalHistory(list), err
+}
+
+func (h *Handler) addHistory(r *http.Request, p params) (interface{}, error) {
+	var req addHistoryRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	e164, err := parseE164(req.Number)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.history.AddHistory(r.Context(), numan.History{E164: e164, OwnerID: req.OwnerID, Action: req.Action, Notes: req.Notes})
+}
+
+func (h *Handler) archiveHistory(r *http.Request, p params) (interface{}, error) {
+	var req archiveRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	archived, err := h.history.ArchiveHistory(r.Context(), numan.RetentionPolicy{Before: req.Before, KeepLast: req.KeepLast})
+	if err != nil {
+		return nil, err
+	}
+	return archiveResponse{Archived: archived}, nil
+}
+
+//marshalHistory converts a list of numan.History to JSON form (never nil)
+func marshalHistory(list []numan.History) []history {
+	entries := []history{}
+	for _, e := range list {
+		entries = append(entries, history{Timestamp: e.Timestamp, Number: formatE164(e.E164), OwnerID: e.OwnerID, Action: e.Action, Notes: e.Notes})
+	}
+	return entries
+}
@@ -0,0 +1,283 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/footfish/numan"
+)
+
+//number is the JSON form of numan.Numbering, the number is formatted cc-ndc-sn
+type number struct {
+	ID          int64  `json:"id"`
+	Number      string `json:"number"`
+	Used        bool   `json:"used"`
+	Domain      string `json:"domain"`
+	Carrier     string `json:"carrier"`
+	OwnerID     int64  `json:"ownerId"`
+	Allocated   int64  `json:"allocated"`
+	Reserved    int64  `json:"reserved"`
+	DeAllocated int64  `json:"deallocated"`
+	PortedIn    int64  `json:"portedIn"`
+	PortedOut   int64  `json:"portedOut"`
+}
+
+//addNumberRequest adds a number
+type addNumberRequest struct {
+	Number  string `json:"number"`
+	Domain  string `json:"domain"`
+	Carrier string `json:"carrier"`
+}
+
+//ownerRequest identifies an owner (allocate & de-allocate)
+type ownerRequest struct {
+	OwnerID int64 `json:"ownerId"`
+}
+
+//reserveRequest reserves a number for an owner until a timestamp
+type reserveRequest struct {
+	OwnerID int64 `json:"ownerId"`
+	Until   int64 `json:"until"`
+}
+
+//dateRequest sets a port date (timestamp)
+type dateRequest struct {
+	Date int64 `json:"date"`
+}
+
+//textResponse is a formatted text report
+type textResponse struct {
+	Text string `json:"text"`
+}
+
+//numberingRoutes returns the NumberingService routes
+func (h *Handler) numberingRoutes() []route {
+	return []route{
+		{method: http.MethodGet, path: "/v1/numbers", tag: "Numbering", rpc: "Numbering/List", summary: "Lists numbers matching a filter. number is cc-ndc-sn, partial numbers are accepted. state is free or used",
+			query: []string{"number", "domain", "carrier", "state", "ownerId"}, response: []number{}, handle: h.listNumbers},
+		{method: http.MethodPost, path: "/v1/numbers", tag: "Numbering", rpc: "Numbering/Add", summary: "Adds a new number", status: http.StatusCreated,
+			request: addNumberRequest{}, response: number{}, handle: h.addNumber},
+		{method: http.MethodGet, path: "/v1/numbers/{number}", tag: "Numbering", rpc: "Numbering/List", summary: "Returns a number",
+			response: number{}, handle: h.getNumber},
+		{method: http.MethodDelete, path: "/v1/numbers/{number}", tag: "Numbering", rpc: "Numbering/Delete", summary: "Deletes a number permanently (history retained)",
+			handle: h.deleteNumber},
+		{method: http.MethodGet, path: "/v1/numbers/{number}/view", tag: "Numbering", rpc: "Numbering/View", summary: "Returns formatted details of a number",
+			response: textResponse{}, handle: h.viewNumber},
+		{method: http.MethodPost, path: "/v1/numbers/{number}/reserve", tag: "Numbering", rpc: "Numbering/Reserve", summary: "Reserves a number for an owner until a timestamp",
+			request: reserveRequest{}, handle: h.reserveNumber},
+		{method: http.MethodPost, path: "/v1/numbers/{number}/allocate", tag: "Numbering", rpc: "Numbering/Allocate", summary: "Allocates a number to an owner",
+			request: ownerRequest{}, handle: h.allocateNumber},
+		{method: http.MethodPost, path: "/v1/numbers/{number}/deallocate", tag: "Numbering", rpc: "Numbering/DeAllocate", summary: "De-allocates a number from an owner",
+			request: ownerRequest{}, handle: h.deallocateNumber},
+		{method: http.MethodPost, path: "/v1/numbers/{number}/portin", tag: "Numbering", rpc: "Numbering/Portin", summary: "Sets a porting in date",
+			request: dateRequest{}, handle: h.portinNumber},
+		{method: http.MethodPost, path: "/v1/numbers/{number}/portout", tag: "Numbering", rpc: "Numbering/Portout", summary: "Sets a porting out date",
+			request: dateRequest{}, handle: h.portoutNumber},
+		{method: http.MethodGet, path: "/v1/owners/{ownerId}/numbers", tag: "Numbering", rpc: "Numbering/ListOwnerID", summary: "Lists numbers attached to an owner",
+			response: []number{}, handle: h.listOwnerNumbers},
+		{method: http.MethodGet, path: "/v1/summary", tag: "Numbering", rpc: "Numbering/Summary", summary: "Returns a formatted summary of the number database",
+			response: textResponse{}, handle: h.summary},
+	}
+}
+
+func (h *Handler) listNumbers(r *http.Request, p params) (interface{}, error) {
+	q := r.URL.Query()
+	filter := numan.NumberFilter{Domain: q.Get("domain"), Carrier: q.Get("carrier")}
+	if q.Get("number") != "" {
+		parts := strings.SplitN(q.Get("number"), "-", 3)
+		filter.E164.Cc = parts[0]
+		if len(parts) > 1 {
+			filter.E164.Ndc = parts[1]
+		}
+		if len(parts) > 2 {
+			filter.E164.Sn = parts[2]
+		}
+	}
+	switch q.Get("state") {
+	case "":
+	case "free":
+		filter.State = 1
+	case "used":
+		filter.State = 2
+	default:
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "state must be free or used")
+	}
+	if q.Get("ownerId") != "" {
+		oid, err := strconv.ParseInt(q.Get("ownerId"), 10, 64)
+		if err != nil {
+			return nil, numan.Errorf(numan.ErrInvalidArgument, "invalid ownerId")
+		}
+		filter.OwnerID = oid
+	}
+	list, err := h.numbering.List(r.Context(), &filter)
+	return marshalNumbers(list), err
+}
+
+func (h *Handler) addNumber(r *http.Request, p params) (interface{}, error) {
+	var req addNumberRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	e164, err := parseE164(req.Number)
+	if err != nil {
+		return nil, err
+	}
+	n := numan.Numbering{E164: e164, Domain: req.Domain, Carrier: req.Carrier}
+	if err := h.numbering.Add(r.Context(), &n); err != nil {
+		return nil, err
+	}
+	return h.findNumber(r, e164)
+}
+
+func (h *Handler) getNumber(r *http.Request, p params) (interface{}, error) {
+	e164, err := parseE164(p["number"])
+	if err != nil {
+		return nil, err
+	}
+	return h.findNumber(r, e164)
+}
+
+//findNumber returns the stored number e164
+func (h *Handler) findNumber(r *http.Request, e164 numan.E164) (interface{}, error) {
+	list, err := h.numbering.List(r.Context(), &numan.NumberFilter{E164: e164})
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range list {
+		if n.E164 == e164 {
+			return marshalNumber(n), nil
+		}
+	}
+	return nil, numan.Errorf(numan.ErrNotFound, "Number not found")
+}
+
+func (h *Handler) deleteNumber(r *http.Request, p params) (interface{}, error) {
+	e164, err := parseE164(p["number"])
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.numbering.Delete(r.Context(), &e164)
+}
+
+func (h *Handler) viewNumber(r *http.Request, p params) (interface{}, error) {
+	e164, err := parseE164(p["number"])
+	if err != nil {
+		return nil, err
+	}
+	view, err := h.numbering.View(r.Context(), &e164)
+	if err != nil {
+		return nil, err
+	}
+	return textResponse{Text: view}, nil
+}
+
+func (h *Handler) reserveNumber(r *http.Request, p params) (interface{}, error) {
+	var req reserveRequest
+	e164, err := parseE164Request(r, p, &req)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.numbering.Reserve(r.Context(), &e164, &req.OwnerID, &req.Until)
+}
+
+func (h *Handler) allocateNumber(r *http.Request, p params) (interface{}, error) {
+	var req ownerRequest
+	e164, err := parseE164Request(r, p, &req)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.numbering.Allocate(r.Context(), &e164, &req.OwnerID)
+}
+
+func (h *Handler) deallocateNumber(r *http.Request, p params) (interface{}, error) {
+	var req ownerRequest
+	e164, err := parseE164Request(r, p, &req)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.numbering.DeAllocate(r.Context(), &e164, &req.OwnerID)
+}
+
+func (h *Handler) portinNumber(r *http.Request, p params) (interface{}, error) {
+	var req dateRequest
+	e164, err := parseE164Request(r, p, &req)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.numbering.Portin(r.Context(), &e164, &req.Date)
+}
+
+func (h *Handler) portoutNumber(r *http.Request, p params) (interface{}, error) {
+	var req dateRequest
+	e164, err := parseE164Request(r, p, &req)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.numbering.Portout(r.Context(), &e164, &req.Date)
+}
+
+func (h *Handler) listOwnerNumbers(r *http.Request, p params) (interface{}, error) {
+	oid, err := parseOwnerID(p["ownerId"])
+	if err != nil {
+		return nil, err
+	}
+	list, err := h.numbering.ListOwnerID(r.Context(), oid)
+	return marshalNumbers(list), err
+}
+
+func (h *Handler) summary(r *http.Request, p params) (interface{}, error) {
+	summary, err := h.numbering.Summary(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return textResponse{Text: summary}, nil
+}
+
+//parseE164 parses a cc-ndc-sn number
+func parseE164(s string) (numan.E164, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 3 {
+		return numan.E164{}, numan.Errorf(numan.ErrInvalidArgument, "Invalid phone number, use cc-ndc-sn")
+	}
+	e164 := numan.E164{Cc: parts[0], Ndc: parts[1], Sn: parts[2]}
+	return e164, e164.ValidE164()
+}
+
+//parseE164Request parses the number path parameter and decodes the request body into req
+func parseE164Request(r *http.Request, p params, req interface{}) (numan.E164, error) {
+	e164, err := parseE164(p["number"])
+	if err != nil {
+		return e164, err
+	}
+	return e164, decode(r, req)
+}
+
+//parseOwnerID parses an owner id path parameter
+func parseOwnerID(s string) (int64, error) {
+	oid, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || oid <= 0 {
+		return 0, numan.Errorf(numan.ErrInvalidArgument, "invalid ownerId")
+	}
+	return oid, nil
+}
+
+//formatE164 formats a number as cc-ndc-sn
+func formatE164(n numan.E164) string {
+	return n.Cc + "-" + n.Ndc + "-" + n.Sn
+}
+
+//marshalNumber converts numan.Numbering to JSON form
+func marshalNumber(n numan.Numbering) number {
+	return number{ID: n.ID, Number: formatE164(n.E164), Used: n.Used, Domain: n.Domain, Carrier: n.Carrier, OwnerID: n.OwnerID,
+		Allocated: n.Allocated, Reserved: n.Reserved, DeAllocated: n.DeAllocated, PortedIn: n.PortedIn, PortedOut: n.PortedOut}
+}
+
+//marshalNumbers converts a list of numan.Numbering to JSON form (never nil)
+func marshalNumbers(list []numan.Numbering) []number {
+	numbers := []number{}
+	for _, n := range list {
+		numbers = append(numbers, marshalNumber(n))
+	}
+	return numbers
+}
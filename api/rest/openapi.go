@@ -0,0 +1,121 @@
+package rest
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/footfish/numan"
+)
+
+//object is a JSON object of the OpenAPI document
+type object = map[string]interface{}
+
+//OpenAPI returns the OpenAPI 3 document describing the API, generated from the routes (served at /openapi.json)
+func (h *Handler) OpenAPI() map[string]interface{} {
+	schemas := object{}
+	errorSchema := schema(reflect.TypeOf(apiError{}), schemas)
+	paths := object{}
+	for _, rt := range h.routes {
+		op := object{
+			"tags":        []string{rt.tag},
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+		}
+		var parameters []object
+		for _, part := range strings.Split(rt.path, "/") {
+			if strings.HasPrefix(part, "{") {
+				parameters = append(parameters, object{"name": strings.Trim(part, "{}"), "in": "path", "required": true, "schema": object{"type": "string"}})
+			}
+		}
+		for _, q := range rt.query {
+			parameters = append(parameters, object{"name": q, "in": "query", "schema": object{"type": "string"}})
+		}
+		if len(parameters) > 0 {
+			op["parameters"] = parameters
+		}
+		if rt.request != nil {
+			op["requestBody"] = object{"required": true, "content": object{"application/json": object{"schema": schema(reflect.TypeOf(rt.request), schemas)}}}
+		}
+		status := rt.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		responses := object{}
+		if rt.response != nil {
+			responses[strconv.Itoa(status)] = object{"description": http.StatusText(status), "content": object{"application/json": object{"schema": schema(reflect.TypeOf(rt.response), schemas)}}}
+		} else {
+			responses[strconv.Itoa(http.StatusNoContent)] = object{"description": http.StatusText(http.StatusNoContent)}
+		}
+		responses["default"] = object{"description": "Error", "content": object{"application/json": object{"schema": errorSchema}}}
+		op["responses"] = responses
+		if rt.public {
+			op["security"] = []object{}
+		}
+		path, ok := paths[rt.path].(object)
+		if !ok {
+			path = object{}
+			paths[rt.path] = path
+		}
+		path[strings.ToLower(rt.method)] = op
+	}
+	return object{
+		"openapi": "3.0.3",
+		"info":    object{"title": "numan", "description": "Numbering manager API", "version": "v1"},
+		"paths":   paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"bearer":    object{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey":    object{"type": "apiKey", "in": "header", "name": numan.APIKeyField},
+				"oidcToken": object{"type": "apiKey", "in": "header", "name": numan.OIDCTokenField},
+			},
+		},
+		"security": []object{{"bearer": []string{}}, {"apiKey": []string{}}, {"oidcToken": []string{}}},
+	}
+}
+
+//schema returns the JSON schema of t, struct types are added to schemas and referenced
+func schema(t reflect.Type, schemas object) object {
+	switch t.Kind() {
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int32:
+		return object{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice:
+		return object{"type": "array", "items": schema(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := schemas[name]; !ok {
+			properties := object{}
+			schemas[name] = object{"type": "object", "properties": properties}
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				tag := strings.Split(f.Tag.Get("json"), ",")[0]
+				if tag == "" || tag == "-" {
+					continue
+				}
+				properties[tag] = schema(f.Type, schemas)
+			}
+		}
+		return object{"$ref": "#/components/schemas/" + name}
+	}
+	return object{}
+}
+
+//operationID returns an operation id for a route, ex. GET /v1/numbers/{number}/history -> getNumbersNumberHistory
+func operationID(rt route) string {
+	id := strings.ToLower(rt.method)
+	for _, part := range strings.Split(strings.TrimPrefix(rt.path, "/v1"), "/") {
+		part = strings.Trim(part, "{}")
+		if part != "" {
+			id += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return id
+}
@@ -0,0 +1,333 @@
+//Package rest is the HTTP/JSON API of numd, for clients which can't use gRPC (ex. browsers).
+//Handlers call the same services as the gRPC adapters, routes are listed in an OpenAPI document (see OpenAPI).
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/ratelimit"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+)
+
+//maxBody is the maximum request body size
+const maxBody = 1 << 20
+
+//Adaptors are used to facilitate transparent HTTP/JSON transport.
+//ie. HTTP client -> Handler -> route -> Service Interface (service)
+
+//route is an API operation, path segments in braces are parameters (ex. /v1/numbers/{number})
+type route struct {
+	method   string
+	path     string
+	tag      string //OpenAPI tag (service)
+	rpc      string //gRPC method of the operation, Service/Method (shares its rate limit)
+	summary  string
+	public   bool        //no authentication required
+	status   int         //success status, default 200 (204 if no response)
+	query    []string    //query parameters (for docs)
+	request  interface{} //JSON request body type (for docs) OR nil
+	response interface{} //JSON response body type (for docs) OR nil
+	handle   func(r *http.Request, p params) (interface{}, error)
+}
+
+//params are the path parameters of a request
+type params map[string]string
+
+//Handler serves the API
+type Handler struct {
+	routes    []route
+	numbering numan.NumberingService
+	history   numan.HistoryService
+	users     numan.UserService
+	events    numan.EventService
+	certs     *service.CertAuthenticator
+	oidc      *service.OIDCAuthenticator
+	origins   []string //allowed CORS origins, * for any
+	limiter   *ratelimit.Limiter
+}
+
+//NewHandler instantiates the API handler. oidcAuth authenticates identity provider tokens, nil if OIDC login is not enabled.
+//Browsers are allowed cross origin requests from corsOrigins (* for any).
+//Requests over the rate limits of limiter are refused (nil not limited), routes share the limits & buckets of their gRPC method.
+func NewHandler(store *datastore.Store, oidcAuth *service.OIDCAuthenticator, corsOrigins []string, limiter *ratelimit.Limiter) *Handler {
+	h := &Handler{
+		numbering: service.NewNumberingService(store),
+		history:   service.NewHistoryService(store),
+		users:     service.NewUserService(store),
+		events:    service.NewEventService(store),
+		certs:     service.NewCertAuthenticator(store),
+		oidc:      oidcAuth,
+		origins:   corsOrigins,
+		limiter:   limiter,
+	}
+	h.routes = append(h.routes, h.numberingRoutes()...)
+	h.routes = append(h.routes, h.historyRoutes()...)
+	h.routes = append(h.routes, h.userRoutes()...)
+	h.routes = append(h.routes, h.eventRoutes()...)
+	return h
+}
+
+//NewServer creates an HTTPS server for handler. config should verify client certificates only if presented (browsers have none).
+func NewServer(addr string, handler http.Handler, config *tls.Config) *http.Server {
+	return &http.Server{Addr: addr, Handler: handler, TLSConfig: config}
+}
+
+//ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.cors(w, r) {
+		return
+	}
+	if r.Method == http.MethodGet && r.URL.Path == "/openapi.json" {
+		writeJSON(w, http.StatusOK, h.OpenAPI())
+		return
+	}
+	rt, p, allowed := h.match(r.Method, r.URL.Path)
+	if rt == nil {
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		writeError(w, http.StatusNotFound, numan.Errorf(numan.ErrNotFound, "not found"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	ctx, caller, err := h.authenticate(w, r, rt)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	if err := h.rateLimit(w, caller, rt.rpc); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	resp, err := rt.handle(r.WithContext(ctx), p)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	status := rt.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, status, resp)
+}
+
+//match returns the route & path parameters for method and path OR nil and the methods allowed for path
+func (h *Handler) match(method string, path string) (*route, params, []string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var allowed []string
+	for i := range h.routes {
+		rt := &h.routes[i]
+		p, ok := matchPath(rt.path, segments)
+		if !ok {
+			continue
+		}
+		if rt.method == method {
+			return rt, p, nil
+		}
+		allowed = append(allowed, rt.method)
+	}
+	return nil, nil, allowed
+}
+
+//matchPath returns the parameters of path segments if they match pattern
+func matchPath(pattern string, segments []string) (params, bool) {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	p := params{}
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			p[strings.Trim(part, "{}")] = segments[i]
+		} else if part != segments[i] {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+//authenticate returns the request context with the client address and access token, and the caller for rate limits (as for gRPC).
+//A bearer token is used as is, an API key, OIDC token or verified client certificate is exchanged for a token (as for gRPC).
+//The caller is apikey:<id>, user:<username> (authenticated) or source:<address>.
+//Failed authentication is rate limited by source, credentials aren't tried while its bucket for the route method is empty (as for gRPC).
+func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request, rt *route) (context.Context, string, error) {
+	ctx := r.Context()
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if host != "" { //client address, failed logins are counted by caller
+		ctx = context.WithValue(ctx, numan.SourceField, host)
+	}
+	source := "source:" + host
+	caller := source
+	var exchange func() (numan.User, error)
+	switch auth := r.Header.Get("Authorization"); {
+	case strings.HasPrefix(auth, "Bearer "):
+		token := strings.TrimPrefix(auth, "Bearer ")
+		var user numan.User
+		if user.SetUserFromToken(token) == nil {
+			caller = "user:" + user.Username
+		}
+		return context.WithValue(ctx, numan.AuthTokenField, token), caller, nil
+	case r.Header.Get(numan.APIKeyField) != "":
+		exchange = func() (numan.User, error) { return h.users.AuthAPIKey(ctx, r.Header.Get(numan.APIKeyField)) }
+		if id, _, err := numan.ParseAPIKey(r.Header.Get(numan.APIKeyField)); err == nil {
+			caller = "apikey:" + id
+		}
+	case r.Header.Get(numan.OIDCTokenField) != "":
+		if h.oidc == nil {
+			return ctx, caller, numan.Errorf(numan.ErrUnauthenticated, "Auth error: OIDC login not enabled")
+		}
+		exchange = func() (numan.User, error) { return h.oidc.AuthOIDC(ctx, r.Header.Get(numan.OIDCTokenField)) }
+	case r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0:
+		exchange = func() (numan.User, error) { return h.certs.AuthCert(ctx, r.TLS.VerifiedChains[0][0]) }
+	case rt.public:
+		return ctx, caller, nil
+	default:
+		return ctx, caller, h.failAuth(w, source, rt.rpc, numan.Errorf(numan.ErrUnauthenticated, "Auth error: bearer token, API key or client certificate required"))
+	}
+	if h.limiter != nil {
+		if ready, wait := h.limiter.Ready(source, rt.rpc); !ready {
+			return ctx, caller, rateLimited(w, rt.rpc, wait)
+		}
+	}
+	user, err := exchange()
+	if err != nil {
+		return ctx, caller, h.failAuth(w, source, rt.rpc, err)
+	}
+	if !strings.HasPrefix(caller, "apikey:") {
+		caller = "user:" + user.Username
+	}
+	return context.WithValue(ctx, numan.AuthTokenField, user.AccessToken), caller, nil
+}
+
+//failAuth takes a token for a failed authentication from the bucket of source for method, returns err OR ErrRateLimited if empty
+func (h *Handler) failAuth(w http.ResponseWriter, source string, method string, err error) error {
+	if limitErr := h.rateLimit(w, source, method); limitErr != nil {
+		return limitErr
+	}
+	return err
+}
+
+//rateLimit takes a token for caller from the bucket of method, ErrRateLimited (429) with the seconds to wait in the Retry-After header if empty
+func (h *Handler) rateLimit(w http.ResponseWriter, caller string, method string) error {
+	if h.limiter == nil {
+		return nil
+	}
+	allowed, wait := h.limiter.Allow(caller, method)
+	if allowed {
+		return nil
+	}
+	return rateLimited(w, method, wait)
+}
+
+//rateLimited returns ErrRateLimited for method, the seconds to wait are set in the Retry-After header
+func rateLimited(w http.ResponseWriter, method string, wait time.Duration) error {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	return numan.Errorf(numan.ErrRateLimited, "Rate limit of %s exceeded, retry after %ds", method, seconds)
+}
+
+//cors sets the CORS headers for an allowed origin, returns true if the request was a preflight request (answered)
+func (h *Handler) cors(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !(contains(h.origins, "*") || contains(h.origins, origin)) {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Add("Vary", "Origin")
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+numan.APIKeyField+", "+numan.OIDCTokenField)
+	w.Header().Set("Access-Control-Max-Age", "600")
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+//errorStatuses maps error kinds to HTTP status codes
+var errorStatuses = map[error]int{
+	numan.ErrInvalidArgument:        http.StatusBadRequest,
+	numan.ErrNotFound:               http.StatusNotFound,
+	numan.ErrAlreadyExists:          http.StatusConflict,
+	numan.ErrConflict:               http.StatusConflict,
+	numan.ErrQuarantined:            http.StatusConflict,
+	numan.ErrUnauthenticated:        http.StatusUnauthorized,
+	numan.ErrPermissionDenied:       http.StatusForbidden,
+	numan.ErrLocked:                 http.StatusTooManyRequests,
+	numan.ErrPasswordChangeRequired: http.StatusForbidden,
+	numan.ErrUnimplemented:          http.StatusNotImplemented,
+	numan.ErrRateLimited:            http.StatusTooManyRequests,
+}
+
+//errorStatus returns the HTTP status for a service error, errors without a kind are 500
+func errorStatus(err error) int {
+	if strings.Contains(err.Error(), "request body too large") { //http.MaxBytesReader
+		return http.StatusRequestEntityTooLarge
+	}
+	if status, ok := errorStatuses[numan.ErrorKind(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+//apiError is the JSON body of an error response, code is the error kind (ex. NOT_FOUND)
+type apiError struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+//writeError writes an error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	e := apiError{Error: err.Error()}
+	if kind := numan.ErrorKind(err); kind != nil {
+		e.Code = strings.ToUpper(strings.ReplaceAll(kind.Error(), " ", "_"))
+	}
+	writeJSON(w, status, e)
+}
+
+//writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+//decode decodes the JSON request body into v, unknown fields are refused
+func decode(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "invalid JSON request: %w", err)
+	}
+	return nil
+}
+
+//contains returns true if list contains v
+func contains(list []string, v string) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+	return false
+}
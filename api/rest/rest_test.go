@@ -0,0 +1,316 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/footfish/numan"
+	numangrpc "github.com/footfish/numan/api/grpc"
+	"github.com/footfish/numan/api/rest"
+	"github.com/footfish/numan/internal/ratelimit"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	"google.golang.org/grpc"
+)
+
+var update = flag.Bool("update", false, "update openapi.json")
+
+//helperServer returns a test server with users admin (admin) and viewer (viewer), limiter nil is not limited
+func helperServer(t *testing.T, limiter *ratelimit.Limiter) *httptest.Server {
+	t.Helper()
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	admin := numan.User{Username: "root", Roles: []string{numan.RoleAdmin}}
+	if err := admin.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), numan.AuthTokenField, admin.AccessToken)
+	users := service.NewUserService(store)
+	for _, u := range []numan.User{
+		{Username: "admin", Password: "secret123", Roles: []string{numan.RoleAdmin}},
+		{Username: "viewer", Password: "secret123", Roles: []string{numan.RoleViewer}},
+	} {
+		if err := users.AddUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := httptest.NewServer(rest.NewHandler(store, nil, []string{"https://example.com"}, limiter))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+//helperDo sends a JSON request, decodes the JSON response into resp (if set) and returns the status
+func helperDo(t *testing.T, srv *httptest.Server, method string, path string, token string, req interface{}, resp interface{}) int {
+	t.Helper()
+	var body bytes.Buffer
+	if req != nil {
+		if err := json.NewEncoder(&body).Encode(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := http.NewRequest(method, srv.URL+path, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := srv.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if resp != nil && res.StatusCode < 300 {
+		if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res.StatusCode
+}
+
+//helperLogin returns an access token for username
+func helperLogin(t *testing.T, srv *httptest.Server, username string) string {
+	t.Helper()
+	var token struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if status := helperDo(t, srv, http.MethodPost, "/v1/auth/login", "", map[string]string{"username": username, "password": "secret123"}, &token); status != http.StatusOK {
+		t.Fatalf("login status %d", status)
+	}
+	return token.AccessToken
+}
+
+func TestNumbers(t *testing.T) {
+	srv := helperServer(t, nil)
+	admin, viewer := helperLogin(t, srv, "admin"), helperLogin(t, srv, "viewer")
+
+	var added struct {
+		Number string `json:"number"`
+		Domain string `json:"domain"`
+	}
+	req := map[string]string{"number": "22-02-22222222", "domain": "test.com", "carrier": "carrier"}
+	if status := helperDo(t, srv, http.MethodPost, "/v1/numbers", admin, req, &added); status != http.StatusCreated {
+		t.Fatalf("add status %d", status)
+	}
+	if added.Number != "22-02-22222222" || added.Domain != "test.com" {
+		t.Fatalf("added %+v", added)
+	}
+	if status := helperDo(t, srv, http.MethodPost, "/v1/numbers/22-02-22222222/allocate", admin, map[string]int64{"ownerId": 7}, nil); status != http.StatusNoContent {
+		t.Fatalf("allocate status %d", status)
+	}
+
+	var list []struct {
+		Number  string `json:"number"`
+		OwnerID int64  `json:"ownerId"`
+	}
+	if status := helperDo(t, srv, http.MethodGet, "/v1/numbers?state=used", viewer, nil, &list); status != http.StatusOK {
+		t.Fatalf("list status %d", status)
+	}
+	if len(list) != 1 || list[0].OwnerID != 7 {
+		t.Fatalf("listed %+v", list)
+	}
+	var events []struct {
+		Seq      int64  `json:"seq"`
+		Type     string `json:"type"`
+		Number   string `json:"number"`
+		NewState string `json:"newState"`
+		Actor    string `json:"actor"`
+	}
+	if status := helperDo(t, srv, http.MethodGet, "/v1/events?prefix=22-02&type=allocated", viewer, nil, &events); status != http.StatusOK {
+		t.Fatalf("events status %d", status)
+	}
+	if len(events) != 1 || events[0].Number != "22-02-22222222" || events[0].NewState != "allocated" || events[0].Actor != "admin" {
+		t.Fatalf("events %+v", events)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		req    interface{}
+		want   int
+	}{
+		{"NoToken", http.MethodGet, "/v1/numbers", "", nil, http.StatusUnauthorized},
+		{"BadToken", http.MethodGet, "/v1/numbers", "bad", nil, http.StatusUnauthorized},
+		{"ViewerAdd", http.MethodPost, "/v1/numbers", viewer, req, http.StatusForbidden},
+		{"NotFound", http.MethodGet, "/v1/numbers/22-02-33333333", viewer, nil, http.StatusNotFound},
+		{"BadNumber", http.MethodGet, "/v1/numbers/22-02", viewer, nil, http.StatusBadRequest},
+		{"InvalidNumber", http.MethodGet, "/v1/numbers/22-02-2222222A", viewer, nil, http.StatusBadRequest},
+		{"UnknownField", http.MethodPost, "/v1/numbers", admin, map[string]string{"e164": "22-02-22222222"}, http.StatusBadRequest},
+		{"AllocateTwice", http.MethodPost, "/v1/numbers/22-02-22222222/allocate", admin, map[string]int64{"ownerId": 8}, http.StatusConflict},
+		{"MethodNotAllowed", http.MethodPut, "/v1/numbers", admin, nil, http.StatusMethodNotAllowed},
+		{"BadEventType", http.MethodGet, "/v1/events?type=bogus", viewer, nil, http.StatusBadRequest},
+		{"BadEventLimit", http.MethodGet, "/v1/events?limit=0", viewer, nil, http.StatusBadRequest},
+		{"BadLogin", http.MethodPost, "/v1/auth/login", "", map[string]string{"username": "admin", "password": "wrong"}, http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if status := helperDo(t, srv, tc.method, tc.path, tc.token, tc.req, nil); status != tc.want {
+				t.Errorf("status %d, want %d", status, tc.want)
+			}
+		})
+	}
+}
+
+//TestKeys checks anyone can list the public signing keys, only admins rotate them
+func TestKeys(t *testing.T) {
+	srv := helperServer(t, nil)
+	var keys []struct {
+		Kid string `json:"kid"`
+	}
+	if status := helperDo(t, srv, http.MethodGet, "/v1/keys", "", nil, &keys); status != http.StatusOK || len(keys) == 0 {
+		t.Fatalf("anonymous GET /v1/keys got %d %+v, want the keys", status, keys)
+	}
+	if status := helperDo(t, srv, http.MethodPost, "/v1/keys/rotate", helperLogin(t, srv, "viewer"), map[string]string{}, nil); status != http.StatusForbidden {
+		t.Fatalf("viewer POST /v1/keys/rotate got %d, want 403", status)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	srv := helperServer(t, nil)
+	for origin, allowed := range map[string]bool{"https://example.com": true, "https://evil.com": false} {
+		r, _ := http.NewRequest(http.MethodOptions, srv.URL+"/v1/numbers", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		res, err := srv.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got := res.Header.Get("Access-Control-Allow-Origin") == origin; got != allowed {
+			t.Errorf("origin %s allowed %v, want %v", origin, got, allowed)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter([]numan.RateLimit{{Method: "Numbering/List", Rate: 0.5, Burst: 2}})
+	srv := helperServer(t, limiter)
+	viewer, admin := helperLogin(t, srv, "viewer"), helperLogin(t, srv, "admin")
+	//GET /v1/numbers & /v1/numbers/{number} are Numbering/List, they share its bucket
+	for _, path := range []string{"/v1/numbers", "/v1/numbers/353-01-12345001"} {
+		if status := helperDo(t, srv, http.MethodGet, path, viewer, nil, nil); status == http.StatusTooManyRequests {
+			t.Fatalf("GET %s within burst got %d", path, status)
+		}
+	}
+	r, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/numbers", nil)
+	r.Header.Set("Authorization", "Bearer "+viewer)
+	res, err := srv.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apiErr struct{ Code string }
+	json.NewDecoder(res.Body).Decode(&apiErr)
+	res.Body.Close()
+	if res.StatusCode != http.StatusTooManyRequests || apiErr.Code != "RATE_LIMITED" {
+		t.Fatalf("GET over limit got %d %s, want 429 RATE_LIMITED", res.StatusCode, apiErr.Code)
+	}
+	if got := res.Header.Get("Retry-After"); got != "2" {
+		t.Errorf("got Retry-After %s, want 2", got)
+	}
+	//other methods & callers are not limited by the bucket
+	if status := helperDo(t, srv, http.MethodGet, "/v1/summary", viewer, nil, nil); status != http.StatusOK {
+		t.Errorf("GET /v1/summary got %d", status)
+	}
+	if status := helperDo(t, srv, http.MethodGet, "/v1/numbers", admin, nil, nil); status != http.StatusOK {
+		t.Errorf("GET /v1/numbers by other user got %d", status)
+	}
+	_, usage, _ := limiter.ListRateLimits(context.Background())
+	if len(usage) != 2 || usage[1].Key != "user:viewer" || usage[1].Method != "Numbering/List" || usage[1].Limited != 1 {
+		t.Errorf("ListRateLimits got usage %+v", usage)
+	}
+}
+
+//TestRateLimitSource checks anonymous calls & failed authentication are limited by source address in the bucket shared with gRPC
+func TestRateLimitSource(t *testing.T) {
+	limiter := ratelimit.NewLimiter([]numan.RateLimit{{Method: "Numbering/List", Rate: 0.01, Burst: 3}})
+	srv := helperServer(t, limiter)
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := numangrpc.NewGrpcServer(nil, store, nil, nil, limiter)
+	numangrpc.RegisterNumberingServer(server, numangrpc.NewNumberingServerAdapter(store))
+	go server.Serve(lis)
+	defer server.Stop()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	numbering := numangrpc.NewNumberingClientAdapter(conn)
+	withKey := func(key string) int {
+		r, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/numbers", nil)
+		r.Header.Set(numan.APIKeyField, key)
+		res, err := srv.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	//gRPC & REST anonymous calls and bad API keys take from the same bucket
+	if _, err := numbering.List(context.Background(), &numan.NumberFilter{}); !errors.Is(err, numan.ErrUnauthenticated) {
+		t.Fatalf("anonymous gRPC call got %v, want ErrUnauthenticated", err)
+	}
+	if status := helperDo(t, srv, http.MethodGet, "/v1/numbers", "", nil, nil); status != http.StatusUnauthorized {
+		t.Fatalf("anonymous GET got %d, want 401", status)
+	}
+	if status := withKey("guess.secret"); status != http.StatusUnauthorized {
+		t.Fatalf("bad API key got %d, want 401", status)
+	}
+	if status := withKey("guess.secret2"); status != http.StatusTooManyRequests {
+		t.Fatalf("bad API key over limit got %d, want 429", status)
+	}
+	if _, err := numbering.List(context.Background(), &numan.NumberFilter{}); !errors.Is(err, numan.ErrRateLimited) {
+		t.Fatalf("anonymous gRPC call over limit got %v, want ErrRateLimited", err)
+	}
+	_, usage, _ := limiter.ListRateLimits(context.Background())
+	if len(usage) != 1 || usage[0].Key != "source:127.0.0.1" || usage[0].Method != "Numbering/List" || usage[0].Allowed != 3 || usage[0].Limited != 2 {
+		t.Errorf("ListRateLimits got usage %+v", usage)
+	}
+}
+
+//TestOpenAPI checks openapi.json is up to date (go test -update to regenerate)
+func TestOpenAPI(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	doc, err := json.MarshalIndent(rest.NewHandler(store, nil, nil, nil).OpenAPI(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc = append(doc, '\n')
+	if *update {
+		if err := ioutil.WriteFile("openapi.json", doc, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := ioutil.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(doc, golden) {
+		t.Fatal("openapi.json is out of date, run go test ./api/rest -update")
+	}
+}
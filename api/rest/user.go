@@ -0,0 +1,323 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/footfish/numan"
+)
+
+//loginRequest authenticates by password
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+//refreshRequest holds a refresh token (refresh & logout)
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+//tokenResponse is an issued access token (and refresh token for logins)
+type tokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	TokenType    string `json:"tokenType"`
+}
+
+//changePasswordRequest changes a users own password
+type changePasswordRequest struct {
+	Username    string `json:"username"`
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+//status is the JSON form of numan.AccountStatus
+type status struct {
+	Disabled           bool  `json:"disabled"`
+	PasswordExpires    int64 `json:"passwordExpires"`
+	MustChangePassword bool  `json:"mustChangePassword"`
+}
+
+//user is the JSON form of numan.User (no password or tokens)
+type user struct {
+	Username  string      `json:"username"`
+	Password  string      `json:"password,omitempty"` //add only
+	Roles     []string    `json:"roles"`
+	Scope     numan.Scope `json:"scope"`
+	Status    status      `json:"status"`
+	LastLogin int64       `json:"lastLogin,omitempty"`
+}
+
+//passwordRequest sets a users password
+type passwordRequest struct {
+	Password string `json:"password"`
+}
+
+//role is the JSON form of numan.Role
+type role struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+//rotateKeyRequest rotates the token signing key
+type rotateKeyRequest struct {
+	Algorithm string `json:"algorithm"`
+}
+
+//signingKey is the JSON form of numan.SigningKey (public part)
+type signingKey struct {
+	Kid       string `json:"kid"`
+	Algorithm string `json:"algorithm"`
+	Created   int64  `json:"created"`
+	Retired   int64  `json:"retired"`
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+//apiKey is the JSON form of numan.APIKey, the secret key is only set when issued
+type apiKey struct {
+	ID       string      `json:"id"`
+	Username string      `json:"username"`
+	Key      string      `json:"key,omitempty"`
+	Expires  int64       `json:"expires"`
+	Created  int64       `json:"created"`
+	LastUsed int64       `json:"lastUsed"`
+	Roles    []string    `json:"roles,omitempty"` //some of the users roles, omit for all
+	Scope    numan.Scope `json:"scope"`           //within the users scope, omit for the users scope
+}
+
+//auditEntry is the JSON form of numan.AuditEntry
+type auditEntry struct {
+	Timestamp int64  `json:"timestamp"`
+	Username  string `json:"username"`
+	Source    string `json:"source"`
+	Action    string `json:"action"`
+	Notes     string `json:"notes"`
+}
+
+//userRoutes returns the UserService routes
+func (h *Handler) userRoutes() []route {
+	return []route{
+		{method: http.MethodPost, path: "/v1/auth/login", tag: "User", rpc: "User/Auth", summary: "Authenticates by password, returns an access token & refresh token", public: true,
+			request: loginRequest{}, response: tokenResponse{}, handle: h.login},
+		{method: http.MethodPost, path: "/v1/auth/refresh", tag: "User", rpc: "User/Refresh", summary: "Exchanges a refresh token for a new access token & refresh token", public: true,
+			request: refreshRequest{}, response: tokenResponse{}, handle: h.refresh},
+		{method: http.MethodPost, path: "/v1/auth/logout", tag: "User", rpc: "User/Logout", summary: "Revokes the refresh token and the access token used", public: true,
+			request: refreshRequest{}, handle: h.logout},
+		{method: http.MethodPost, path: "/v1/auth/password", tag: "User", rpc: "User/ChangePassword", summary: "Changes your own password, authenticated by the old password", public: true,
+			request: changePasswordRequest{}, handle: h.changePassword},
+		{method: http.MethodGet, path: "/v1/users", tag: "User", rpc: "User/ListUsers", summary: "Lists users matching filter",
+			query: []string{"filter"}, response: []user{}, handle: h.listUsers},
+		{method: http.MethodPost, path: "/v1/users", tag: "User", rpc: "User/AddUser", summary: "Adds a user",
+			request: user{}, handle: h.addUser},
+		{method: http.MethodDelete, path: "/v1/users/{username}", tag: "User", rpc: "User/DeleteUser", summary: "Deletes a user",
+			handle: h.deleteUser},
+		{method: http.MethodPut, path: "/v1/users/{username}/password", tag: "User", rpc: "User/SetPassword", summary: "Sets a users password",
+			request: passwordRequest{}, handle: h.setPassword},
+		{method: http.MethodPut, path: "/v1/users/{username}/status", tag: "User", rpc: "User/SetStatus", summary: "Sets a users account status, their access tokens are revoked",
+			request: status{}, handle: h.setStatus},
+		{method: http.MethodPut, path: "/v1/users/{username}/scope", tag: "User", rpc: "User/SetScope", summary: "Sets the numbers a user can access, their access tokens are revoked",
+			request: numan.Scope{}, handle: h.setScope},
+		{method: http.MethodPut, path: "/v1/users/{username}/roles/{role}", tag: "User", rpc: "User/GrantRole", summary: "Grants a role to a user",
+			handle: h.grantRole},
+		{method: http.MethodDelete, path: "/v1/users/{username}/roles/{role}", tag: "User", rpc: "User/RevokeRole", summary: "Revokes a role from a user",
+			handle: h.revokeRole},
+		{method: http.MethodGet, path: "/v1/roles", tag: "User", rpc: "User/ListRoles", summary: "Lists roles and their permissions",
+			response: []role{}, handle: h.listRoles},
+		{method: http.MethodPut, path: "/v1/roles/{role}", tag: "User", rpc: "User/SetRole", summary: "Adds a role or replaces its permissions",
+			request: role{}, handle: h.setRole},
+		{method: http.MethodDelete, path: "/v1/roles/{role}", tag: "User", rpc: "User/DeleteRole", summary: "Deletes a role, it must not be granted to any users",
+			handle: h.deleteRole},
+		{method: http.MethodGet, path: "/v1/keys", tag: "User", rpc: "User/ListKeys", summary: "Lists the token signing keys (public part only)", public: true,
+			response: []signingKey{}, handle: h.listKeys},
+		{method: http.MethodPost, path: "/v1/keys/rotate", tag: "User", rpc: "User/RotateKey", summary: "Adds a new token signing key, previous keys verify tokens until they expire",
+			request: rotateKeyRequest{}, response: signingKey{}, handle: h.rotateKey},
+		{method: http.MethodGet, path: "/v1/apikeys", tag: "User", rpc: "User/ListAPIKeys", summary: "Lists API keys of users matching filter",
+			query: []string{"filter"}, response: []apiKey{}, handle: h.listAPIKeys},
+		{method: http.MethodPost, path: "/v1/apikeys", tag: "User", rpc: "User/AddAPIKey", summary: "Issues an API key, the secret key is only returned here", status: http.StatusCreated,
+			request: apiKey{}, response: apiKey{}, handle: h.addAPIKey},
+		{method: http.MethodDelete, path: "/v1/apikeys/{id}", tag: "User", rpc: "User/DeleteAPIKey", summary: "Revokes an API key",
+			handle: h.deleteAPIKey},
+		{method: http.MethodDelete, path: "/v1/lockouts/{name}", tag: "User", rpc: "User/Unlock", summary: "Clears the failed logins and lockout of a username or source address",
+			handle: h.unlock},
+		{method: http.MethodGet, path: "/v1/audit", tag: "User", rpc: "User/ListAudit", summary: "Lists the audit log of users matching filter, most recent first",
+			query: []string{"filter"}, response: []auditEntry{}, handle: h.listAudit},
+	}
+}
+
+func (h *Handler) login(r *http.Request, p params) (interface{}, error) {
+	var req loginRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	u, err := h.users.Auth(r.Context(), req.Username, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	return tokenResponse{AccessToken: u.AccessToken, RefreshToken: u.RefreshToken, TokenType: "Bearer"}, nil
+}
+
+func (h *Handler) refresh(r *http.Request, p params) (interface{}, error) {
+	var req refreshRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	u, err := h.users.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return tokenResponse{AccessToken: u.AccessToken, RefreshToken: u.RefreshToken, TokenType: "Bearer"}, nil
+}
+
+func (h *Handler) logout(r *http.Request, p params) (interface{}, error) {
+	var req refreshRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, h.users.Logout(r.Context(), req.RefreshToken)
+}
+
+func (h *Handler) changePassword(r *http.Request, p params) (interface{}, error) {
+	var req changePasswordRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, h.users.ChangePassword(r.Context(), req.Username, req.OldPassword, req.NewPassword)
+}
+
+func (h *Handler) listUsers(r *http.Request, p params) (interface{}, error) {
+	list, err := h.users.ListUsers(r.Context(), r.URL.Query().Get("filter"))
+	users := []user{}
+	for _, u := range list {
+		users = append(users, user{Username: u.Username, Roles: u.Roles, Scope: u.Scope, Status: status(u.Status), LastLogin: u.LastLogin})
+	}
+	return users, err
+}
+
+func (h *Handler) addUser(r *http.Request, p params) (interface{}, error) {
+	var req user
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, h.users.AddUser(r.Context(), numan.User{Username: req.Username, Password: req.Password, Roles: req.Roles, Scope: req.Scope, Status: numan.AccountStatus(req.Status)})
+}
+
+func (h *Handler) deleteUser(r *http.Request, p params) (interface{}, error) {
+	return nil, h.users.DeleteUser(r.Context(), p["username"])
+}
+
+func (h *Handler) setPassword(r *http.Request, p params) (interface{}, error) {
+	var req passwordRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, h.users.SetPassword(r.Context(), p["username"], req.Password)
+}
+
+func (h *Handler) setStatus(r *http.Request, p params) (interface{}, error) {
+	var req status
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, h.users.SetStatus(r.Context(), p["username"], numan.AccountStatus(req))
+}
+
+func (h *Handler) setScope(r *http.Request, p params) (interface{}, error) {
+	var req numan.Scope
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, h.users.SetScope(r.Context(), p["username"], req)
+}
+
+func (h *Handler) grantRole(r *http.Request, p params) (interface{}, error) {
+	return nil, h.users.GrantRole(r.Context(), p["username"], p["role"])
+}
+
+func (h *Handler) revokeRole(r *http.Request, p params) (interface{}, error) {
+	return nil, h.users.RevokeRole(r.Context(), p["username"], p["role"])
+}
+
+func (h *Handler) listRoles(r *http.Request, p params) (interface{}, error) {
+	list, err := h.users.ListRoles(r.Context())
+	roles := []role{}
+	for _, rl := range list {
+		roles = append(roles, role{Name: rl.Name, Permissions: rl.Permissions})
+	}
+	return roles, err
+}
+
+func (h *Handler) setRole(r *http.Request, p params) (interface{}, error) {
+	var req role
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, h.users.SetRole(r.Context(), numan.Role{Name: p["role"], Permissions: req.Permissions})
+}
+
+func (h *Handler) deleteRole(r *http.Request, p params) (interface{}, error) {
+	return nil, h.users.DeleteRole(r.Context(), p["role"])
+}
+
+func (h *Handler) listKeys(r *http.Request, p params) (interface{}, error) {
+	list, err := h.users.ListKeys(r.Context())
+	keys := []signingKey{}
+	for _, k := range list {
+		keys = append(keys, marshalSigningKey(k))
+	}
+	return keys, err
+}
+
+func (h *Handler) rotateKey(r *http.Request, p params) (interface{}, error) {
+	var req rotateKeyRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	key, err := h.users.RotateKey(r.Context(), req.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return marshalSigningKey(key), nil
+}
+
+func (h *Handler) listAPIKeys(r *http.Request, p params) (interface{}, error) {
+	list, err := h.users.ListAPIKeys(r.Context(), r.URL.Query().Get("filter"))
+	keys := []apiKey{}
+	for _, k := range list {
+		keys = append(keys, apiKey(k))
+	}
+	return keys, err
+}
+
+func (h *Handler) addAPIKey(r *http.Request, p params) (interface{}, error) {
+	var req apiKey
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	key, err := h.users.AddAPIKey(r.Context(), numan.APIKey{Username: req.Username, Expires: req.Expires, Roles: req.Roles, Scope: req.Scope})
+	if err != nil {
+		return nil, err
+	}
+	return apiKey(key), nil
+}
+
+func (h *Handler) deleteAPIKey(r *http.Request, p params) (interface{}, error) {
+	return nil, h.users.DeleteAPIKey(r.Context(), p["id"])
+}
+
+func (h *Handler) unlock(r *http.Request, p params) (interface{}, error) {
+	return nil, h.users.Unlock(r.Context(), p["name"])
+}
+
+func (h *Handler) listAudit(r *http.Request, p params) (interface{}, error) {
+	list, err := h.users.ListAudit(r.Context(), r.URL.Query().Get("filter"))
+	entries := []auditEntry{}
+	for _, e := range list {
+		entries = append(entries, auditEntry(e))
+	}
+	return entries, err
+}
+
+//marshalSigningKey converts numan.SigningKey to JSON form
+func marshalSigningKey(k numan.SigningKey) signingKey {
+	return signingKey{Kid: k.Kid, Algorithm: k.Algorithm, Created: k.Created, Retired: k.Retired, PublicKey: k.PublicKey}
+}
@@ -0,0 +1,76 @@
+package numan
+
+import (
+	"strings"
+)
+
+//APIKeyField is the gRPC metadata field (and ctx key) for sending an API key instead of an access token
+const APIKeyField = "x-api-key"
+
+//APIKey is a long lived key for non-interactive (machine) clients.
+//A key acts as its user (a service account), limited to the key roles & scope if set (see Limit). Keys are stored hashed (see HashToken).
+type APIKey struct {
+	ID       string   //public key id, the first part of the key
+	Username string   //user the key acts as
+	Key      string   //secret API key, only set when issued
+	Expires  int64    //timestamp key expires OR 0 never
+	Created  int64    //timestamp key was issued
+	LastUsed int64    //timestamp key was last used (to the minute) OR 0 never
+	Roles    []string //roles of the key, some of the user's OR empty for all of them
+	Scope    Scope    //scope of the key, within the user's OR unrestricted for the user's
+}
+
+//SetNewKey generates a new key id & secret key in APIKey
+func (k *APIKey) SetNewKey() (err error) {
+	if k.ID, err = randomToken(9); err != nil {
+		return err
+	}
+	secret, err := randomToken(32)
+	k.Key = k.ID + "." + secret
+	return err
+}
+
+//Within checks the key roles & scope are within those of its user
+func (k APIKey) Within(user User) error {
+	for _, role := range k.Roles {
+		if !contains(user.Roles, role) {
+			return Errorf(ErrInvalidArgument, "'%s' is not a role of user '%s'", role, user.Username)
+		}
+	}
+	if _, ok := k.Scope.Narrow(user.Scope); !ok {
+		return Errorf(ErrInvalidArgument, "API key scope is not within the scope of user '%s'", user.Username)
+	}
+	return nil
+}
+
+//Limit returns user limited to the roles & scope of the key, the key can't have more than its user.
+//Fails if the user no longer has any of the key roles or the key scope isn't within the user's.
+func (k APIKey) Limit(user User) (User, error) {
+	if len(k.Roles) > 0 {
+		var roles []string
+		for _, role := range k.Roles {
+			if contains(user.Roles, role) {
+				roles = append(roles, role)
+			}
+		}
+		if len(roles) == 0 {
+			return User{}, Errorf(ErrPermissionDenied, "API key roles %s are not roles of user '%s'", strings.Join(k.Roles, ","), user.Username)
+		}
+		user.Roles, user.RolesLimited = roles, true
+	}
+	scope, ok := k.Scope.Narrow(user.Scope)
+	if !ok {
+		return User{}, Errorf(ErrPermissionDenied, "API key scope is not within the scope of user '%s'", user.Username)
+	}
+	user.Scope = scope
+	return user, nil
+}
+
+//ParseAPIKey splits an API key into its id and secret
+func ParseAPIKey(key string) (id string, secret string, err error) {
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", Errorf(ErrUnauthenticated, "invalid API key")
+	}
+	return parts[0], parts[1], nil
+}
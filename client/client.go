@@ -0,0 +1,218 @@
+//Package client connects to a numd server, returning numan services ready to use.
+//It logs in & refreshes tokens, sets call deadlines and retries idempotent calls while the server is unavailable.
+//Errors are returned as numan errors (see numan.ErrorKind), nothing exits the process.
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/footfish/numan"
+	numangrpc "github.com/footfish/numan/api/grpc"
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//Defaults of Config
+const (
+	DefaultTimeout     = 30 * time.Second
+	DefaultMaxAttempts = 4
+	DefaultBackoff     = 200 * time.Millisecond
+	maxBackoff         = 5 * time.Second
+)
+
+//Config of a Client. Calls are authenticated by (first set) APIKey, Username & Password, OR a client certificate.
+//A token, API key or OIDC token in the context of a call is sent instead.
+type Config struct {
+	Address       string //host:port of numd
+	TLSCert       string //CA certificate of the server, empty uses the trusted CAs
+	TLSClientCert string //client certificate for mutual TLS
+	TLSClientKey  string
+	Insecure      bool //no TLS, for local testing only
+
+	Username  string
+	Password  string //not needed while a cached refresh token is valid
+	APIKey    string
+	TokenFile string //caches access & refresh tokens between processes, not cached if empty
+
+	Timeout     time.Duration //deadline of calls without one, DefaultTimeout if 0 (not Watch)
+	MaxAttempts int           //attempts of idempotent calls while the server is unavailable, DefaultMaxAttempts if 0
+	Backoff     time.Duration //delay before the first retry, doubling each retry, DefaultBackoff if 0
+
+	DialOptions []grpc.DialOption //extra options (ex. interceptors, a custom dialer)
+}
+
+//Client is a connection to numd with its services
+type Client struct {
+	Numbering  numan.NumberingService
+	History    numan.HistoryService
+	User       numan.UserService
+	Events     numan.EventService
+	Webhooks   numan.WebhookService
+	RateLimits numan.RateLimitService
+
+	conf   Config
+	conn   *grpc.ClientConn
+	tokens *TokenSource //nil if not logging in
+}
+
+//New returns a Client of the numd server at conf.Address. It connects in the background, so a server not running is reported by calls.
+func New(conf Config) (*Client, error) {
+	if conf.Timeout == 0 {
+		conf.Timeout = DefaultTimeout
+	}
+	if conf.MaxAttempts == 0 {
+		conf.MaxAttempts = DefaultMaxAttempts
+	}
+	if conf.Backoff == 0 {
+		conf.Backoff = DefaultBackoff
+	}
+	c := &Client{conf: conf}
+	var err error
+	opts := append(append([]grpc.DialOption{}, conf.DialOptions...), grpc.WithChainUnaryInterceptor(c.unaryInterceptor), grpc.WithChainStreamInterceptor(c.streamInterceptor))
+	if conf.Insecure {
+		c.conn, err = numangrpc.NewClientConn(conf.Address, nil, opts...)
+	} else {
+		creds, tlsErr := numangrpc.NewClientTLS(conf.TLSCert, conf.TLSClientCert, conf.TLSClientKey) //TLSCert empty uses trusted CAs
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+		c.conn, err = numangrpc.NewClientConn(conf.Address, creds, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Numbering = numangrpc.NewNumberingClientAdapter(c.conn)
+	c.History = numangrpc.NewHistoryClientAdapter(c.conn)
+	c.User = numangrpc.NewUserClientAdapter(c.conn)
+	c.Events = numangrpc.NewEventClientAdapter(c.conn)
+	c.Webhooks = numangrpc.NewWebhookClientAdapter(c.conn)
+	c.RateLimits = numangrpc.NewRateLimitClientAdapter(c.conn)
+	if conf.APIKey == "" && conf.Username != "" {
+		c.tokens = NewTokenSource(c.User, conf.Username, conf.Password, conf.TokenFile)
+	}
+	return c, nil
+}
+
+//Login gets an access token (logging in if no valid token is cached), to report bad credentials before the first call
+func (c *Client) Login(ctx context.Context) error {
+	if c.tokens == nil {
+		return nil
+	}
+	_, err := c.tokens.Token(ctx)
+	return err
+}
+
+//Logout revokes the refresh token and deletes the cached tokens
+func (c *Client) Logout(ctx context.Context) error {
+	if c.tokens == nil {
+		return nil
+	}
+	return c.tokens.Logout(ctx)
+}
+
+//Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+//authenticate returns ctx with the credentials of the client & the access token added ("" if none)
+func (c *Client) authenticate(ctx context.Context) (context.Context, string, error) {
+	if ctx.Value(noAuthKey{}) != nil || ctx.Value(numan.AuthTokenField) != nil || ctx.Value(numan.APIKeyField) != nil || ctx.Value(numan.OIDCTokenField) != nil {
+		return ctx, "", nil
+	}
+	if c.conf.APIKey != "" {
+		return context.WithValue(ctx, numan.APIKeyField, c.conf.APIKey), "", nil
+	}
+	if c.tokens == nil {
+		return ctx, "", nil //client certificate OR public call
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return ctx, "", err
+	}
+	return context.WithValue(ctx, numan.AuthTokenField, token), token, nil
+}
+
+//unaryInterceptor authenticates calls, sets a deadline & retries idempotent calls while the server is unavailable.
+//A call with a rejected access token (ex. revoked) is retried once with a new token.
+func (c *Client) unaryInterceptor(ctx context.Context, method string, req interface{}, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.conf.Timeout)
+		defer cancel()
+	}
+	attempts := 1
+	if idempotent[method] {
+		attempts = c.conf.MaxAttempts
+	}
+	renewed := false
+	for attempt := 1; ; {
+		callCtx, token, err := c.authenticate(ctx)
+		if err != nil {
+			return err
+		}
+		err = invoker(callCtx, method, req, reply, cc, opts...)
+		switch {
+		case err == nil:
+			return nil
+		case status.Code(err) == codes.Unauthenticated && token != "" && !renewed:
+			c.tokens.Expire(token)
+			renewed = true
+		case status.Code(err) == codes.Unavailable && attempt < attempts:
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff(c.conf.Backoff, attempt)):
+			}
+			attempt++
+		default:
+			return err
+		}
+	}
+}
+
+//streamInterceptor authenticates streaming calls (Watch reopens its stream itself)
+func (c *Client) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	ctx, _, err := c.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+//backoff returns the delay after a number of failed attempts
+func backoff(delay time.Duration, attempts int) time.Duration {
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+//idempotent lists the calls retried, they change nothing
+var idempotent = methods(map[string][]string{
+	numangrpc.Numbering_ServiceDesc.ServiceName: {"List", "ListOwnerID", "View", "Summary"},
+	numangrpc.History_ServiceDesc.ServiceName:   {"ListHistoryByNumber", "ListHistoryByOID"},
+	numangrpc.User_ServiceDesc.ServiceName:      {"ListUsers", "ListKeys", "ListRoles", "ListAPIKeys", "ListAudit"},
+	numangrpc.Event_ServiceDesc.ServiceName:     {"ListEvents"},
+	numangrpc.Webhook_ServiceDesc.ServiceName:   {"ListWebhooks", "ListDeadLetters"},
+	numanv1.RateLimit_ServiceDesc.ServiceName:   {"ListRateLimits"},
+})
+
+//methods returns a set of full method names (/service/method) of service methods
+func methods(serviceMethods map[string][]string) map[string]bool {
+	set := map[string]bool{}
+	for service, names := range serviceMethods {
+		for _, name := range names {
+			set["/"+service+"/"+name] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	numangrpc "github.com/footfish/numan/api/grpc"
+	"github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/internal/service/datastore"
+	"google.golang.org/grpc"
+	grpcbackoff "google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestClient(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	admin := numan.User{Username: "root", Roles: []string{numan.RoleAdmin}}
+	if err := admin.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := service.NewUserService(store).AddUser(context.WithValue(ctx, numan.AuthTokenField, admin.AccessToken), numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleAdmin}}); err != nil {
+		t.Fatal(err)
+	}
+
+	//serve starts a server on a new listener, the client dials the latest one
+	var mu sync.Mutex
+	var lis *bufconn.Listener
+	serve := func() *grpc.Server {
+		mu.Lock()
+		defer mu.Unlock()
+		lis = bufconn.Listen(1 << 20)
+		server := numangrpc.NewGrpcServer(nil, store, nil, nil, nil)
+		numangrpc.RegisterNumberingServer(server, numangrpc.NewNumberingServerAdapter(store))
+		numangrpc.RegisterUserServer(server, numangrpc.NewUserServerAdapter(store))
+		go server.Serve(lis)
+		return server
+	}
+	server := serve()
+	defer func() { server.Stop() }()
+	dialer := grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return lis.Dial()
+	})
+
+	reconnect := grpc.WithConnectParams(grpc.ConnectParams{Backoff: grpcbackoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1, MaxDelay: 10 * time.Millisecond}})
+
+	tokenFile := t.TempDir() + "/tokens"
+	c, err := New(Config{Address: "bufnet", Insecure: true, Username: "alice", Password: "secret123", TokenFile: tokenFile, Backoff: 10 * time.Millisecond, MaxAttempts: 20, DialOptions: []grpc.DialOption{dialer, reconnect}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	//logs in on the first call, tokens are cached
+	number := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}
+	if err := c.Numbering.Add(ctx, &number); err != nil {
+		t.Fatal(err)
+	}
+	fileData, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := strings.Split(string(fileData), "\n")
+	if len(tokens) != 2 {
+		t.Fatalf("token file %q, want access & refresh token lines", fileData)
+	}
+
+	//a revoked token is renewed
+	if err := c.User.Logout(context.WithValue(ctx, numan.AuthTokenField, tokens[0]), tokens[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Numbering.Summary(ctx); err != nil {
+		t.Fatal("Summary with revoked token:", err)
+	}
+
+	//idempotent calls are retried while the server is unavailable
+	server.Stop()
+	listed := make(chan error, 1)
+	go func() {
+		_, err := c.Numbering.List(ctx, &numan.NumberFilter{E164: numan.E164{Cc: "353"}})
+		listed <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	server = serve()
+	if err := <-listed; err != nil {
+		t.Fatal("List not retried:", err)
+	}
+
+	//errors are returned as numan errors
+	if err := c.Numbering.Add(ctx, &number); !errors.Is(err, numan.ErrAlreadyExists) {
+		t.Fatalf("Add twice got %v, want ErrAlreadyExists", err)
+	}
+	bad, err := New(Config{Address: "bufnet", Insecure: true, Username: "alice", Password: "wrong", MaxAttempts: 1, DialOptions: []grpc.DialOption{dialer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if err := bad.Login(ctx); !errors.Is(err, numan.ErrUnauthenticated) {
+		t.Fatalf("Login with bad password got %v, want ErrUnauthenticated", err)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadFile(tokenFile); err == nil {
+		t.Fatal("token file not deleted")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: maxBackoff, 10: maxBackoff} {
+		if got := backoff(time.Second, attempts); got != want {
+			t.Errorf("backoff(%d) got %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/footfish/numan"
+)
+
+//noAuthKey marks the context of calls made by a TokenSource, the client sends no credentials with them
+type noAuthKey struct{}
+
+//TokenSource returns the access token of a user, logging in with the password or API key when needed.
+//Tokens are cached in memory and optionally in a file (access token & refresh token lines) shared between processes.
+//An expired access token is refreshed with the refresh token, or by logging in again.
+type TokenSource struct {
+	users    numan.UserService
+	username string
+	password string
+	apiKey   string
+	file     string
+
+	mu   sync.Mutex
+	auth numan.User
+}
+
+//NewTokenSource returns a TokenSource of username logging in with users, file caches tokens ("" not cached).
+//password may be empty if a valid refresh token is cached.
+func NewTokenSource(users numan.UserService, username string, password string, file string) *TokenSource {
+	t := &TokenSource{users: users, username: username, password: password, file: file}
+	if file != "" {
+		if fileData, err := ioutil.ReadFile(file); err == nil {
+			tokens := strings.SplitN(strings.TrimSpace(string(fileData)), "\n", 2)
+			t.auth.AccessToken = tokens[0]
+			if len(tokens) == 2 {
+				t.auth.RefreshToken = tokens[1]
+			}
+		}
+	}
+	return t
+}
+
+//NewAPIKeyTokenSource returns a TokenSource exchanging an API key for access tokens with users
+func NewAPIKeyTokenSource(users numan.UserService, apiKey string) *TokenSource {
+	return &TokenSource{users: users, apiKey: apiKey}
+}
+
+//Token returns a valid access token, refreshed or logging in if expired
+func (t *TokenSource) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.auth.AuthRefreshRequired() {
+		return t.auth.AccessToken, nil
+	}
+	ctx = context.WithValue(ctx, noAuthKey{}, true)
+	if t.apiKey != "" {
+		auth, err := t.users.AuthAPIKey(ctx, t.apiKey)
+		if err != nil {
+			return "", err
+		}
+		t.auth = auth
+		return t.auth.AccessToken, nil
+	}
+	refreshed := false
+	if t.auth.RefreshToken != "" {
+		if auth, err := t.users.Refresh(ctx, t.auth.RefreshToken); err == nil {
+			t.auth, refreshed = auth, true
+		}
+	}
+	if !refreshed {
+		if t.password == "" {
+			return "", numan.Errorf(numan.ErrUnauthenticated, "session expired, login required (no password)")
+		}
+		auth, err := t.users.Auth(ctx, t.username, t.password)
+		if err != nil {
+			return "", err
+		}
+		t.auth = auth
+	}
+	return t.auth.AccessToken, t.save()
+}
+
+//Expire discards token (ex. rejected as revoked), the next Token call refreshes
+func (t *TokenSource) Expire(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.auth.AccessToken == token {
+		t.auth.AccessToken = ""
+	}
+}
+
+//Logout revokes the refresh token and deletes the cached tokens
+func (t *TokenSource) Logout(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ctx = context.WithValue(ctx, noAuthKey{}, true)
+	if t.auth.AccessToken != "" { //also revoked
+		ctx = context.WithValue(ctx, numan.AuthTokenField, t.auth.AccessToken)
+	}
+	if err := t.users.Logout(ctx, t.auth.RefreshToken); err != nil {
+		return err
+	}
+	t.auth = numan.User{}
+	if t.file != "" {
+		if err := os.Remove(t.file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+//save writes the tokens to the cache file (if set), caller must hold lock
+func (t *TokenSource) save() error {
+	if t.file == "" {
+		return nil
+	}
+	if err := ioutil.WriteFile(t.file, []byte(t.auth.AccessToken+"\n"+t.auth.RefreshToken), 0600); err != nil {
+		return fmt.Errorf("can't write token file: %w", err)
+	}
+	return nil
+}
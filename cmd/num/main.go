@@ -3,42 +3,63 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/footfish/numan"
-	"github.com/footfish/numan/api/grpc"
+	numanclient "github.com/footfish/numan/client"
 	"github.com/footfish/numan/internal/cmdcli"
+	"github.com/footfish/numan/internal/oidc"
 	"github.com/footfish/numan/internal/service"
 	"github.com/footfish/numan/internal/service/datastore"
 	"github.com/gookit/color"
 	"github.com/joho/godotenv"
 	"github.com/lensesio/tableprinter"
 	"github.com/vrischmann/envconfig"
-	"google.golang.org/grpc/credentials"
 )
 
 type client struct {
 	numbering numan.NumberingService
 	history   numan.HistoryService
 	user      numan.UserService
+	events    numan.EventService
 	ctx       context.Context //ctx ok here in structs as no scope issues. https://go.dev/blog/context-and-structs
+	oidc      *oidc.Provider  //external identity provider OR nil
 
-	auth numan.User
+	tokens *numanclient.TokenSource //nil if not logged in with a password or API key
 }
 
 var conf struct {
 	Dsn           string
 	ServerAddress string `envconfig:"optional"` //if ommitted works in standalone mode
 	TlsCert       string `envconfig:"optional"` //if ommitted trusted Certificate Authority is needed
+	TlsClientCert string `envconfig:"optional"` //client certificate for mutual TLS, used instead of user/password
+	TlsClientKey  string `envconfig:"optional"`
 	TokenFile     string `envconfig:"default=.num_auth"`
-	User          string
-	Password      string
+	User          string `envconfig:"optional"` //not needed with an API key or client certificate
+	Password      string `envconfig:"optional"` //only needed to login, a cached refresh token is used after
+	ApiKey        string `envconfig:"optional"` //service account API key, used instead of user/password
+	//External identity provider (SSO), 'num login' runs the device login (client-server mode only)
+	OidcIssuer    string `envconfig:"optional"`
+	OidcClientId  string `envconfig:"optional"`
+	OidcScopes    string `envconfig:"default=openid profile offline_access"`
+	OidcTokenFile string `envconfig:"default=.num_oidc"`
+	//Token signing keys (standalone mode only)
+	JwtSecret    string `envconfig:"optional"`
+	JwtKeyFile   string `envconfig:"default=numan-keys.json"`
+	JwtAlgorithm string `envconfig:"default=EdDSA"`
+	//Password policy (standalone mode only)
+	PasswordMinLength  int    `envconfig:"default=8"`
+	PasswordMinClasses int    `envconfig:"default=2"`
+	PasswordHistory    int    `envconfig:"default=0"`
+	PasswordDenylist   string `envconfig:"optional"`
 }
 
 func main() {
@@ -57,59 +78,97 @@ func main() {
 
 	//Init services
 	if conf.ServerAddress == "" { //standalone servicelication with local db connection
-		store := datastore.NewStore(conf.Dsn)
+		keys, err := numan.NewKeySetFromConfig(conf.JwtSecret, conf.JwtKeyFile, conf.JwtAlgorithm)
+		if err != nil {
+			log.Fatalf("Signing key error: %v", err)
+		}
+		numan.SetKeySet(keys)
+		passwordPolicy, err := numan.NewPasswordPolicyFromConfig(conf.PasswordMinLength, conf.PasswordMinClasses, conf.PasswordHistory, conf.PasswordDenylist)
+		if err != nil {
+			log.Fatalf("Password policy error: %v", err)
+		}
+		numan.SetPasswordPolicy(passwordPolicy)
+		store, err := datastore.NewStore(conf.Dsn)
+		if err != nil {
+			log.Fatalf("Database error: %v", err)
+		}
 		defer store.Close()
 		c.numbering = service.NewNumberingService(store)
 		c.history = service.NewHistoryService(store)
 		c.user = service.NewUserService(store)
+		c.events = service.NewEventService(store)
 	} else { //via gRPC
-		var creds credentials.TransportCredentials
-		if conf.TlsCert == "" { //Using trusted CA, no need to load client cert
-			creds = credentials.NewTLS(&tls.Config{})
-		} else { //use self-signed cert
-			var err error
-			creds, err = credentials.NewClientTLSFromFile(conf.TlsCert, "")
-			if err != nil {
-				log.Fatalf("cert load error: %s", err)
+		cl, err := numanclient.New(numanclient.Config{Address: conf.ServerAddress, TLSCert: conf.TlsCert, TLSClientCert: conf.TlsClientCert, TLSClientKey: conf.TlsClientKey}) //TlsCert empty uses trusted CAs
+		if err != nil {
+			log.Fatalf("gRPC client error: %s", err)
+		}
+		defer cl.Close()
+		c.numbering, c.history, c.user, c.events = cl.Numbering, cl.History, cl.User, cl.Events
+		if conf.OidcIssuer != "" {
+			if c.oidc, err = oidc.NewProvider(oidc.Config{Issuer: conf.OidcIssuer}); err != nil {
+				log.Fatalf("OIDC error: %v", err)
 			}
 		}
-		grpcClient := grpc.NewGrpcClient(c.ctx, conf.ServerAddress, creds)
-		c.numbering = grpc.NewNumberingClientAdapter(grpcClient)
-		c.history = grpc.NewHistoryClientAdapter(grpcClient)
-		c.user = grpc.NewUserClientAdapter(grpcClient)
 	}
 
 	//Init authentication
-	if err := c.setAuthToken(); err != nil { //load auth token from cached file or refresh
-		color.Error.Println("Authentication error -", err)
-		os.Exit(1)
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "change_password": //authenticated by the old password
+	case len(os.Args) > 1 && os.Args[1] == "login": //device login with the identity provider
+	case conf.ApiKey != "" && conf.ServerAddress != "": //API key sent with each call
+		c.ctx = context.WithValue(c.ctx, numan.APIKeyField, conf.ApiKey)
+	case conf.TlsClientCert != "" && conf.ServerAddress != "" && conf.User == "": //authenticated by client certificate
+	case c.oidc != nil && conf.User == "": //identity provider token sent with each call
+		token, err := c.oidcToken()
+		if err != nil {
+			color.Error.Println("Authentication error -", err)
+			os.Exit(1)
+		}
+		c.ctx = context.WithValue(c.ctx, numan.OIDCTokenField, token)
+	default:
+		c.tokens = numanclient.NewTokenSource(c.user, conf.User, conf.Password, conf.TokenFile)
+		if conf.ApiKey != "" { //standalone, exchanged for a token locally
+			c.tokens = numanclient.NewAPIKeyTokenSource(c.user, conf.ApiKey)
+		}
+		token, err := c.tokens.Token(c.ctx) //cached token OR refreshed
+		if err != nil {
+			color.Error.Println("Authentication error -", err)
+			os.Exit(1)
+		}
+		c.ctx = context.WithValue(c.ctx, "token", token) //add auth token to context
 	}
-	c.ctx = context.WithValue(c.ctx, "token", c.auth.AccessToken) //add auth token to context
 
 	//Run command line application
 	c.initCli().Run()
 }
 
-func (c *client) setAuthToken() (err error) {
-	//load file token
-	if fileData, err := ioutil.ReadFile(conf.TokenFile); err == nil {
-		c.auth.AccessToken = string(fileData)
-		//fmt.Println("Loaded token:", c.auth.AccessToken)
+//oidcToken loads the cached identity provider tokens, an expired token is refreshed with the provider refresh token.
+func (c *client) oidcToken() (string, error) {
+	fileData, err := ioutil.ReadFile(conf.OidcTokenFile)
+	if err != nil {
+		return "", errors.New("login required (num login)")
 	}
-	if c.auth.AuthRefreshRequired() {
-		//need to fetch a token.
-		if c.auth, err = c.user.Auth(c.ctx, conf.User, conf.Password); err != nil {
-			return err
-		}
+	tokens := strings.SplitN(strings.TrimSpace(string(fileData)), "\n", 2)
+	if !oidc.Expired(tokens[0]) {
+		return tokens[0], nil
+	}
+	if len(tokens) < 2 || tokens[1] == "" {
+		return "", errors.New("session expired, login required (num login)")
+	}
+	token, err := c.oidc.Refresh(c.ctx, conf.OidcClientId, tokens[1])
+	if err != nil {
+		return "", fmt.Errorf("session expired, login required (num login): %w", err)
+	}
+	saveOIDCToken(token)
+	return token.Raw(), nil
+}
 
-		//Cache token
-		if err := ioutil.WriteFile(conf.TokenFile, []byte(c.auth.AccessToken), 0600); err != nil {
-			color.Error.Println("Can't write to file -", conf.TokenFile)
-			os.Exit(1)
-		}
-		return nil
+//saveOIDCToken caches identity provider tokens (token & refresh token lines)
+func saveOIDCToken(token oidc.Token) {
+	if err := ioutil.WriteFile(conf.OidcTokenFile, []byte(token.Raw()+"\n"+token.RefreshToken), 0600); err != nil {
+		color.Error.Println("Can't write to file -", conf.OidcTokenFile)
+		os.Exit(1)
 	}
-	return nil
 }
 
 //InitCli setup command configurations
@@ -132,9 +191,15 @@ func (c *client) initCli() cmdcli.CommandConfigs {
 	cmd.NewStringParameter("phonenumber", true).SetRegexp(`^([1-9]\d{0,2}\-[01]\d{0,4}\-\d{0,13})|([1-9]\d{0,2}\-[01]\d{0,4})$`)
 	cmd.NewStringParameter("domain", false)
 
-	cmdDescription = "Lists numbers attached to owner & any history"
+	cmdDescription = "Lists numbers attached to owner & any history. Use 'archived' to include archived history"
 	cmd = cli.NewCommand("owner", c.listOwner, cmdDescription)
 	cmd.NewIntParameter("oid", true)
+	cmd.NewStringParameter("archived", false).SetRegexp("^archived$")
+
+	cmdDescription = "Lists history for a number. Use 'archived' to include archived history"
+	cmd = cli.NewCommand("history", c.listHistory, cmdDescription)
+	cmd.NewStringParameter("phonenumber", true).SetRegexp(`^[1-9]\d{0,2}\-[01]\d{1,4}\-\d{5,13}$`)
+	cmd.NewStringParameter("archived", false).SetRegexp("^archived$")
 
 	cmdDescription = "Deletes a number permanently (history retained)"
 	cmd = cli.NewCommand("delete", c.delete, cmdDescription)
@@ -156,6 +221,12 @@ func (c *client) initCli() cmdcli.CommandConfigs {
 	cmd.NewStringParameter("phonenumber", true).SetRegexp(`^[1-9]\d{0,2}\-[01]\d{1,4}\-\d{5,13}$`)
 	cmd.NewDateParameter("date", true)
 
+	cmdDescription = "Updates the domain or carrier of a number"
+	cmd = cli.NewCommand("update", c.update, cmdDescription)
+	cmd.NewStringParameter("phonenumber", true).SetRegexp(`^[1-9]\d{0,2}\-[01]\d{1,4}\-\d{5,13}$`)
+	cmd.NewStringParameter("field", true).SetRegexp("^(" + strings.Join(numan.UpdatableFields, "|") + ")$")
+	cmd.NewStringParameter("value", true)
+
 	cmdDescription = "Allocates a number to an owner"
 	cmd = cli.NewCommand("allocate", c.allocate, cmdDescription)
 	cmd.NewStringParameter("phonenumber", true).SetRegexp(`^[1-9]\d{0,2}\-[01]\d{1,4}\-\d{5,13}$`)
@@ -166,12 +237,84 @@ func (c *client) initCli() cmdcli.CommandConfigs {
 	cmd.NewStringParameter("phonenumber", true).SetRegexp(`^[1-9]\d{0,2}\-[01]\d{1,4}\-\d{5,13}$`)
 	cmd.NewIntParameter("oid", true)
 
+	cmdDescription = "Watches number changes (Ctrl-C to stop). Prefix format is cc[-ndc[-sn]], use 'after' to resume after an event sequence number"
+	cmd = cli.NewCommand("watch", c.watch, cmdDescription)
+	cmd.NewStringParameter("prefix", true).SetRegexp(numan.PatternPrefix)
+	cmd.NewStringParameter("domain", false)
+	cmd.NewIntParameter("after", false)
+
 	cmdDescription = "Provides a summary of number database"
 	cmd = cli.NewCommand("summary", c.summary, cmdDescription)
 
+	cmdDescription = "Logs in with the identity provider (SSO) by device login, needs OIDC_ISSUER & OIDC_CLIENT_ID"
+	cli.NewCommand("login", c.login, cmdDescription)
+
+	cmdDescription = "Logs out, revoking the cached tokens"
+	cli.NewCommand("logout", c.logout, cmdDescription)
+
+	cmdDescription = "Changes your own password (USER), authenticated by the old password. Works with an expired password."
+	cmd = cli.NewCommand("change_password", c.changePassword, cmdDescription)
+	cmd.NewStringParameter("old", true).SetRegexp(numan.PatternRawPassword) //mandatory params first.
+	cmd.NewStringParameter("new", true).SetRegexp(numan.PatternRawPassword)
+
 	return cli
 }
 
+//login
+func (c *client) login(p cmdcli.RxParameters) {
+	if c.oidc == nil || conf.OidcClientId == "" {
+		color.Warn.Println("SSO login requires OIDC_ISSUER, OIDC_CLIENT_ID & SERVER_ADDRESS")
+		os.Exit(1)
+	}
+	auth, err := c.oidc.StartDeviceLogin(c.ctx, conf.OidcClientId, strings.Fields(conf.OidcScopes))
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if auth.VerificationURIComplete != "" {
+		color.Info.Printf("To login visit %s\n", auth.VerificationURIComplete)
+	} else {
+		color.Info.Printf("To login visit %s and enter code %s\n", auth.VerificationURI, auth.UserCode)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(auth.ExpiresIn)*time.Second)
+	defer cancel()
+	token, err := c.oidc.PollDeviceToken(ctx, conf.OidcClientId, auth)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	saveOIDCToken(token)
+	color.Info.Println("Logged in")
+}
+
+//logout
+func (c *client) logout(p cmdcli.RxParameters) {
+	if c.ctx.Value(numan.OIDCTokenField) != nil { //numan issues no session for identity provider tokens
+		os.Remove(conf.OidcTokenFile)
+		color.Info.Println("Logged out (identity provider session is not ended)")
+		return
+	}
+	if c.tokens == nil {
+		color.Warn.Println("Not logged in with a password")
+		os.Exit(1)
+	}
+	if err := c.tokens.Logout(c.ctx); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Logged out")
+}
+
+//change_password <old> <new>
+func (c *client) changePassword(p cmdcli.RxParameters) {
+	if err := c.user.ChangePassword(c.ctx, conf.User, p["old"].(string), p["new"].(string)); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	os.Remove(conf.TokenFile)
+	color.Info.Println("Password changed for username '" + conf.User + "', login again with the new password")
+}
+
 //add <phonenumber> <domain> <carrier>
 func (c *client) add(p cmdcli.RxParameters) {
 	splitNumber := strings.Split(p["phonenumber"].(string), "-")
@@ -218,7 +361,7 @@ func (c *client) list(p cmdcli.RxParameters) {
 			color.Warn.Println("No numbers found")
 		}
 		if len(numberList) == 1 { //print number history if there is only one result.
-			if historyList, err := c.history.ListHistoryByNumber(c.ctx, numberList[0].E164); err != nil {
+			if historyList, err := c.history.ListHistoryByNumber(c.ctx, numberList[0].E164, false); err != nil {
 				color.Warn.Println(err)
 				os.Exit(1)
 			} else {
@@ -266,6 +409,29 @@ func (c *client) portin(p cmdcli.RxParameters) {
 	}
 }
 
+//update <phonenumber> <field> <value>
+func (c *client) update(p cmdcli.RxParameters) {
+	splitNumber := strings.Split(p["phonenumber"].(string), "-")
+	number := numan.Numbering{E164: numan.E164{
+		Cc:  splitNumber[0],
+		Ndc: splitNumber[1],
+		Sn:  splitNumber[2]}}
+	field := p["field"].(string)
+	switch field {
+	case "domain":
+		number.Domain = p["value"].(string)
+	case "carrier":
+		number.Carrier = p["value"].(string)
+	}
+
+	if updated, err := c.numbering.Update(c.ctx, &number, []string{field}); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	} else {
+		color.Info.Printf("Updated, Domain: %v, Carrier: %v", updated.Domain, updated.Carrier)
+	}
+}
+
 //list_free <phonenumber>
 func (c *client) listFree(p cmdcli.RxParameters) {
 	var filter numan.NumberFilter
@@ -396,9 +562,70 @@ func (c *client) deallocate(p cmdcli.RxParameters) {
 	}
 }
 
-//	list_owner <oid>
+//history <phonenumber> [archived]
+func (c *client) listHistory(p cmdcli.RxParameters) {
+	splitNumber := strings.Split(p["phonenumber"].(string), "-")
+	number := numan.E164{
+		Cc:  splitNumber[0],
+		Ndc: splitNumber[1],
+		Sn:  splitNumber[2]}
+	_, archived := p["archived"].(string)
+
+	if historyList, err := c.history.ListHistoryByNumber(c.ctx, number, archived); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	} else {
+		if len(historyList) == 0 {
+			color.Warn.Println("No history found for number")
+		} else {
+			printHistoryList(historyList)
+		}
+	}
+}
+
+//watch <prefix> [domain] [after]
+func (c *client) watch(p cmdcli.RxParameters) {
+	filter := numan.EventFilter{Prefix: p["prefix"].(string)}
+	if domain, ok := p["domain"].(string); ok {
+		filter.Domain = domain
+	}
+	if after, ok := p["after"].(int64); ok {
+		filter.AfterSeq = after
+	}
+	ctx, stop := signal.NotifyContext(withoutCancel{c.ctx}, os.Interrupt, syscall.SIGTERM) //no command timeout
+	defer stop()
+	err := c.events.Watch(ctx, &filter, func(e numan.NumberEvent) error {
+		oldState, newState := e.OldState, e.NewState
+		if oldState == "" {
+			oldState = "-"
+		}
+		if newState == "" {
+			newState = "-"
+		}
+		fmt.Printf("%d %v %-11v %v-%v-%v %v %v %v->%v owner:%d by:%v\n", e.Seq, time.Unix(e.Timestamp, 0).Format(numan.TIMESTAMPPRINTFORMAT), e.Type,
+			e.E164.Cc, e.E164.Ndc, e.E164.Sn, e.Domain, e.Carrier, oldState, newState, e.OwnerID, e.Actor)
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+}
+
+//withoutCancel is a context with the values of a parent context, but not its deadline or cancellation
+type withoutCancel struct {
+	parent context.Context
+}
+
+func (withoutCancel) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (withoutCancel) Done() <-chan struct{}               { return nil }
+func (withoutCancel) Err() error                          { return nil }
+func (c withoutCancel) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// list_owner <oid> [archived]
 func (c *client) listOwner(p cmdcli.RxParameters) {
 	ownerID := p["oid"].(int64)
+	_, archived := p["archived"].(string)
 
 	if numberList, err := c.numbering.ListOwnerID(c.ctx, ownerID); err != nil {
 		color.Warn.Println(err)
@@ -411,7 +638,7 @@ func (c *client) listOwner(p cmdcli.RxParameters) {
 		}
 	}
 
-	if historyList, err := c.history.ListHistoryByOwnerID(c.ctx, ownerID); err != nil {
+	if historyList, err := c.history.ListHistoryByOwnerID(c.ctx, ownerID, archived); err != nil {
 		color.Warn.Println(err)
 		os.Exit(1)
 	} else {
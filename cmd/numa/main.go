@@ -3,14 +3,16 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"io/ioutil"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/footfish/numan"
-	"github.com/footfish/numan/api/grpc"
+	numanclient "github.com/footfish/numan/client"
 	"github.com/footfish/numan/internal/cmdcli"
 	"github.com/footfish/numan/internal/service"
 	"github.com/footfish/numan/internal/service/datastore"
@@ -18,22 +20,38 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/lensesio/tableprinter"
 	"github.com/vrischmann/envconfig"
-	"google.golang.org/grpc/credentials"
 )
 
+//patternRoleList is a comma separated list of roles
+var patternRoleList = "^" + strings.Trim(numan.PatternRole, "^$") + "(," + strings.Trim(numan.PatternRole, "^$") + ")*$"
+
 type client struct {
 	user numan.UserService
 	ctx  context.Context //ctx ok here in structs as no scope issues. https://go.dev/blog/context-and-structs
-	auth numan.User
+
+	tokens     *numanclient.TokenSource //nil if authenticated by client certificate
+	webhooks   numan.WebhookService
+	rateLimits numan.RateLimitService //nil in standalone mode (limits are applied by numd)
 }
 
 var conf struct {
 	Dsn           string
 	ServerAddress string `envconfig:"optional"` //if ommitted works in standalone mode
 	TlsCert       string `envconfig:"optional"` //if ommitted trusted Certificate Authority is needed
+	TlsClientCert string `envconfig:"optional"` //client certificate for mutual TLS, used instead of user/password
+	TlsClientKey  string `envconfig:"optional"`
 	TokenFile     string `envconfig:"default=.numa_auth"`
-	User          string
-	Password      string
+	User          string `envconfig:"optional"` //not needed with a client certificate
+	Password      string `envconfig:"optional"` //only needed to login, a cached refresh token is used after
+	//Token signing keys (standalone mode only)
+	JwtSecret    string `envconfig:"optional"`
+	JwtKeyFile   string `envconfig:"default=numan-keys.json"`
+	JwtAlgorithm string `envconfig:"default=EdDSA"`
+	//Password policy (standalone mode only)
+	PasswordMinLength  int    `envconfig:"default=8"`
+	PasswordMinClasses int    `envconfig:"default=2"`
+	PasswordHistory    int    `envconfig:"default=0"`
+	PasswordDenylist   string `envconfig:"optional"`
 }
 
 func main() {
@@ -52,55 +70,49 @@ func main() {
 
 	//Init services
 	if conf.ServerAddress == "" { //standalone servicelication with local db connection
-		store := datastore.NewStore(conf.Dsn)
+		keys, err := numan.NewKeySetFromConfig(conf.JwtSecret, conf.JwtKeyFile, conf.JwtAlgorithm)
+		if err != nil {
+			log.Fatalf("Signing key error: %v", err)
+		}
+		numan.SetKeySet(keys)
+		passwordPolicy, err := numan.NewPasswordPolicyFromConfig(conf.PasswordMinLength, conf.PasswordMinClasses, conf.PasswordHistory, conf.PasswordDenylist)
+		if err != nil {
+			log.Fatalf("Password policy error: %v", err)
+		}
+		numan.SetPasswordPolicy(passwordPolicy)
+		store, err := datastore.NewStore(conf.Dsn)
+		if err != nil {
+			log.Fatalf("Database error: %v", err)
+		}
 		defer store.Close()
 		c.user = service.NewUserService(store)
+		c.webhooks = service.NewWebhookService(store)
 	} else { //via gRPC
-		var creds credentials.TransportCredentials
-		if conf.TlsCert == "" { //Using trusted CA, no need to load client cert
-			creds = credentials.NewTLS(&tls.Config{})
-		} else { //use self-signed cert
-			var err error
-			creds, err = credentials.NewClientTLSFromFile(conf.TlsCert, "")
-			if err != nil {
-				log.Fatalf("cert load error: %s", err)
-			}
+		cl, err := numanclient.New(numanclient.Config{Address: conf.ServerAddress, TLSCert: conf.TlsCert, TLSClientCert: conf.TlsClientCert, TLSClientKey: conf.TlsClientKey}) //TlsCert empty uses trusted CAs
+		if err != nil {
+			log.Fatalf("gRPC client error: %s", err)
 		}
-		grpcClient := grpc.NewGrpcClient(c.ctx, conf.ServerAddress, creds)
-		c.user = grpc.NewUserClientAdapter(grpcClient)
+		defer cl.Close()
+		c.user, c.webhooks, c.rateLimits = cl.User, cl.Webhooks, cl.RateLimits
 	}
 
 	//Init authentication
-	if err := c.setAuthToken(); err != nil { //load auth token from cached file or refresh
-		color.Error.Println("Authentication error -", err)
-		os.Exit(1)
-	}
-	c.ctx = context.WithValue(c.ctx, "token", c.auth.AccessToken) //add auth token to context
-
-	//Run command line application
-	c.initCli().Run()
-}
-
-func (c *client) setAuthToken() (err error) {
-	//load file token
-	if fileData, err := ioutil.ReadFile(conf.TokenFile); err == nil {
-		c.auth.AccessToken = string(fileData)
-		//fmt.Println("Loaded token:", c.auth.AccessToken)
+	if len(os.Args) > 1 && os.Args[1] == "change_password" { //authenticated by the old password
+		c.initCli().Run()
+		return
 	}
-	if c.auth.AuthRefreshRequired() {
-		//need to fetch a token.
-		if c.auth, err = c.user.Auth(c.ctx, conf.User, conf.Password); err != nil {
-			return err
-		}
-
-		//Cache token
-		if err := ioutil.WriteFile(conf.TokenFile, []byte(c.auth.AccessToken), 0600); err != nil {
-			color.Error.Println("Can't write to file -", conf.TokenFile)
+	if conf.TlsClientCert == "" || conf.ServerAddress == "" || conf.User != "" { //otherwise authenticated by client certificate
+		c.tokens = numanclient.NewTokenSource(c.user, conf.User, conf.Password, conf.TokenFile)
+		token, err := c.tokens.Token(c.ctx) //cached token OR refreshed
+		if err != nil {
+			color.Error.Println("Authentication error -", err)
 			os.Exit(1)
 		}
-		return nil
+		c.ctx = context.WithValue(c.ctx, "token", token) //add auth token to context
 	}
-	return nil
+
+	//Run command line application
+	c.initCli().Run()
 }
 
 //InitCli setup command configurations
@@ -111,7 +123,7 @@ func (c *client) initCli() cmdcli.CommandConfigs {
 	cmd := cli.NewCommand("add", c.add, cmdDescription)
 	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
 	cmd.NewStringParameter("password", true).SetRegexp(numan.PatternRawPassword)
-	cmd.NewStringParameter("role", true).SetRegexp(`^(` + numan.RoleAdmin + `)|(` + numan.RoleUser + `)$`)
+	cmd.NewStringParameter("roles", true).SetRegexp(patternRoleList)
 
 	cmdDescription = "Lists users. Will search partial usernames or list all."
 	cmd = cli.NewCommand("list", c.list, cmdDescription)
@@ -126,15 +138,145 @@ func (c *client) initCli() cmdcli.CommandConfigs {
 	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
 	cmd.NewStringParameter("password", true).SetRegexp(numan.PatternRawPassword)
 
+	cmdDescription = "Changes your own password (USER), authenticated by the old password. Works with an expired password."
+	cmd = cli.NewCommand("change_password", c.changePassword, cmdDescription)
+	cmd.NewStringParameter("old", true).SetRegexp(numan.PatternRawPassword) //mandatory params first.
+	cmd.NewStringParameter("new", true).SetRegexp(numan.PatternRawPassword)
+
+	cmdDescription = "Disables a user, login, tokens & API keys are refused"
+	cmd = cli.NewCommand("disable", c.disable, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser)
+
+	cmdDescription = "Enables a disabled user"
+	cmd = cli.NewCommand("enable", c.enable, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser)
+
+	cmdDescription = "Sets a users password to expire after days (0 never), an expired password must be changed before login"
+	cmd = cli.NewCommand("password_expiry", c.passwordExpiry, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
+	cmd.NewIntParameter("days", true)
+
+	cmdDescription = "Forces a user to change their password before next login"
+	cmd = cli.NewCommand("must_change", c.mustChange, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser)
+
+	cmdDescription = "Lists roles and their permissions"
+	cli.NewCommand("roles", c.roles, cmdDescription)
+
+	cmdDescription = "Adds a role or replaces its permissions. Permissions are comma separated (" + strings.Join(numan.Permissions, ",") + " or * for all)."
+	cmd = cli.NewCommand("role_set", c.roleSet, cmdDescription)
+	cmd.NewStringParameter("role", true).SetRegexp(numan.PatternRole) //mandatory params first.
+	cmd.NewStringParameter("permissions", true).SetRegexp(`^(\*|[a-z]+:[a-z]+)(,(\*|[a-z]+:[a-z]+))*$`)
+
+	cmdDescription = "Deletes a role, it must not be granted to any user"
+	cmd = cli.NewCommand("role_delete", c.roleDelete, cmdDescription)
+	cmd.NewStringParameter("role", true).SetRegexp(numan.PatternRole)
+
+	cmdDescription = "Grants a role to a user"
+	cmd = cli.NewCommand("grant", c.grant, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
+	cmd.NewStringParameter("role", true).SetRegexp(numan.PatternRole)
+
+	cmdDescription = "Revokes a role from a user"
+	cmd = cli.NewCommand("revoke", c.revoke, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
+	cmd.NewStringParameter("role", true).SetRegexp(numan.PatternRole)
+
+	cmdDescription = "Sets the numbers a user can access, comma separated domains, carriers & prefixes (cc[-ndc[-sn]]). Use * or omit for any. Revokes the users access tokens."
+	cmd = cli.NewCommand("scope", c.scope, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
+	cmd.NewStringParameter("domains", false).SetRegexp(`^(\*|[^,]+(,[^,]+)*)$`)
+	cmd.NewStringParameter("carriers", false).SetRegexp(`^(\*|[^,]+(,[^,]+)*)$`)
+	cmd.NewStringParameter("prefixes", false).SetRegexp(`^(\*|[0-9-]+(,[0-9-]+)*)$`)
+
+	cmdDescription = "Clears the failed logins & lockout of a username or source address"
+	cmd = cli.NewCommand("unlock", c.unlock, cmdDescription)
+	cmd.NewStringParameter("name", true)
+
+	cmdDescription = "Lists the audit log (failed logins, lockouts), most recent first. Will search partial usernames or list all."
+	cmd = cli.NewCommand("audit", c.audit, cmdDescription)
+	cmd.NewStringParameter("username", false)
+
+	cmdDescription = "Adds a service account, a user which can't login with a password (see apikey_add)"
+	cmd = cli.NewCommand("add_service", c.addService, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
+	cmd.NewStringParameter("roles", true).SetRegexp(patternRoleList)
+
+	cmdDescription = "Lists API keys. Will search partial usernames or list all."
+	cmd = cli.NewCommand("apikeys", c.apiKeys, cmdDescription)
+	cmd.NewStringParameter("username", false)
+
+	cmdDescription = "Issues an API key for a user (service account), expiring after days (default 365, 0 never). The key can be limited to some of the users roles and a scope within the users (comma separated domains, carriers & prefixes), use * or omit for the users. The key is only shown once."
+	cmd = cli.NewCommand("apikey_add", c.apiKeyAdd, cmdDescription)
+	cmd.NewStringParameter("username", true).SetRegexp(numan.PatternUser) //mandatory params first.
+	cmd.NewIntParameter("days", false)
+	cmd.NewStringParameter("roles", false).SetRegexp(`^(\*|` + strings.Trim(patternRoleList, "^$") + `)$`)
+	cmd.NewStringParameter("domains", false).SetRegexp(`^(\*|[^,]+(,[^,]+)*)$`)
+	cmd.NewStringParameter("carriers", false).SetRegexp(`^(\*|[^,]+(,[^,]+)*)$`)
+	cmd.NewStringParameter("prefixes", false).SetRegexp(`^(\*|[0-9-]+(,[0-9-]+)*)$`)
+
+	cmdDescription = "Revokes an API key"
+	cmd = cli.NewCommand("apikey_delete", c.apiKeyDelete, cmdDescription)
+	cmd.NewStringParameter("id", true)
+
+	cmdDescription = "Lists the token signing keys"
+	cli.NewCommand("keys", c.keys, cmdDescription)
+
+	cmdDescription = "Adds a new token signing key, tokens signed with older keys stay valid until they expire. Algorithm defaults to the current key algorithm. Only the numd serving the call saves the key file, reload (SIGHUP) the other instances sharing it."
+	cmd = cli.NewCommand("rotate_key", c.rotateKey, cmdDescription)
+	cmd.NewStringParameter("algorithm", false).SetRegexp(`^(` + numan.AlgHS256 + `|` + numan.AlgRS256 + `|` + numan.AlgEdDSA + `)$`)
+
+	cmdDescription = "Lists webhooks with their pending & dead deliveries"
+	cli.NewCommand("webhooks", c.listWebhooks, cmdDescription)
+
+	cmdDescription = "Adds a webhook, number events are POSTed to url as signed JSON. Filter by comma separated event types (" + strings.Join(numan.EventTypes, ",") + " or * for all), prefix (cc[-ndc[-sn]]), domain & carrier. The signing secret is only shown once."
+	cmd = cli.NewCommand("webhook_add", c.webhookAdd, cmdDescription)
+	cmd.NewStringParameter("url", true).SetRegexp(`^https?://.+$`) //mandatory params first.
+	cmd.NewStringParameter("types", false).SetRegexp(`^(\*|[a-z_]+(,[a-z_]+)*)$`)
+	cmd.NewStringParameter("prefix", false).SetRegexp(`^(\*|[0-9-]+)$`)
+	cmd.NewStringParameter("domain", false)
+	cmd.NewStringParameter("carrier", false)
+
+	cmdDescription = "Deletes a webhook & its queued deliveries"
+	cmd = cli.NewCommand("webhook_delete", c.webhookDelete, cmdDescription)
+	cmd.NewIntParameter("id", true)
+
+	cmdDescription = "Lists the dead-letter list (deliveries failed after all attempts) of a webhook or all webhooks"
+	cmd = cli.NewCommand("webhook_dead", c.webhookDead, cmdDescription)
+	cmd.NewIntParameter("id", false)
+
+	cmdDescription = "Queues the dead-letter list of a webhook or all webhooks for delivery again"
+	cmd = cli.NewCommand("webhook_redeliver", c.webhookRedeliver, cmdDescription)
+	cmd.NewIntParameter("id", false)
+
+	cmdDescription = "Lists the rate limits of numd & their usage by recent callers (client-server mode only)"
+	cli.NewCommand("rate_limits", c.listRateLimits, cmdDescription)
+
+	cmdDescription = "Logs out, revoking the cached tokens"
+	cli.NewCommand("logout", c.logout, cmdDescription)
+
 	return cli
 }
 
-//add <username> <password> <role>
+//logout
+func (c *client) logout(p cmdcli.RxParameters) {
+	if c.tokens == nil {
+		color.Warn.Println("Not logged in with a password")
+		os.Exit(1)
+	}
+	if err := c.tokens.Logout(c.ctx); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Logged out")
+}
+
+//add <username> <password> <roles>
 func (c *client) add(p cmdcli.RxParameters) {
 
 	newUser := numan.User{
 		Username: p["username"].(string),
-		Role:     p["role"].(string),
+		Roles:    strings.Split(p["roles"].(string), ","),
 		Password: p["password"].(string),
 	}
 
@@ -187,13 +329,624 @@ func (c *client) password(p cmdcli.RxParameters) {
 	color.Info.Println("New password set for username '" + username + "'")
 }
 
+//roles
+func (c *client) roles(p cmdcli.RxParameters) {
+	roles, err := c.user.ListRoles(c.ctx)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	printRoleList(roles)
+}
+
+//role_set <role> <permissions>
+func (c *client) roleSet(p cmdcli.RxParameters) {
+	role := numan.Role{
+		Name:        p["role"].(string),
+		Permissions: strings.Split(p["permissions"].(string), ","),
+	}
+	if err := c.user.SetRole(c.ctx, role); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Role '" + role.Name + "' set")
+}
+
+//role_delete <role>
+func (c *client) roleDelete(p cmdcli.RxParameters) {
+	role := p["role"].(string)
+	if err := c.user.DeleteRole(c.ctx, role); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Deleted role '" + role + "'")
+}
+
+//grant <username> <role>
+func (c *client) grant(p cmdcli.RxParameters) {
+	username, role := p["username"].(string), p["role"].(string)
+	if err := c.user.GrantRole(c.ctx, username, role); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Role '" + role + "' granted to username '" + username + "'")
+}
+
+//revoke <username> <role>
+func (c *client) revoke(p cmdcli.RxParameters) {
+	username, role := p["username"].(string), p["role"].(string)
+	if err := c.user.RevokeRole(c.ctx, username, role); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Role '" + role + "' revoked from username '" + username + "'")
+}
+
+//unlock <name>
+func (c *client) unlock(p cmdcli.RxParameters) {
+	name := p["name"].(string)
+	if err := c.user.Unlock(c.ctx, name); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Unlocked '" + name + "'")
+}
+
+//audit [username]
+func (c *client) audit(p cmdcli.RxParameters) {
+	username, ok := p["username"].(string)
+	if !ok {
+		username = ""
+	}
+	entries, err := c.user.ListAudit(c.ctx, username)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if len(entries) == 0 {
+		color.Warn.Println("None found")
+		os.Exit(1)
+	}
+	printAuditList(entries)
+}
+
+//add_service <username> <roles>
+func (c *client) addService(p cmdcli.RxParameters) {
+	//random password, never shown, login is by API key
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	newUser := numan.User{
+		Username: p["username"].(string),
+		Roles:    strings.Split(p["roles"].(string), ","),
+		Password: base64.RawURLEncoding.EncodeToString(b),
+	}
+	if err := newUser.HashPassword(); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if err := c.user.AddUser(c.ctx, newUser); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Success, service account '" + newUser.Username + "' added")
+}
+
+//apikeys [username]
+func (c *client) apiKeys(p cmdcli.RxParameters) {
+	username, ok := p["username"].(string)
+	if !ok {
+		username = ""
+	}
+	keys, err := c.user.ListAPIKeys(c.ctx, username)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if len(keys) == 0 {
+		color.Warn.Println("None found")
+		os.Exit(1)
+	}
+	printAPIKeyList(keys)
+}
+
+//apikey_add <username> [days] [roles] [domains] [carriers] [prefixes]
+func (c *client) apiKeyAdd(p cmdcli.RxParameters) {
+	key := numan.APIKey{Username: p["username"].(string), Roles: listParam(p, "roles"), Scope: scopeParams(p)}
+	days, ok := p["days"].(int64)
+	if !ok {
+		days = 365
+	}
+	if days > 0 {
+		key.Expires = time.Now().AddDate(0, 0, int(days)).Unix()
+	}
+	key, err := c.user.AddAPIKey(c.ctx, key)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("API key '" + key.ID + "' issued for username '" + key.Username + "', keep it safe (it is not shown again):")
+	fmt.Println(key.Key)
+}
+
+//apikey_delete <id>
+func (c *client) apiKeyDelete(p cmdcli.RxParameters) {
+	id := p["id"].(string)
+	if err := c.user.DeleteAPIKey(c.ctx, id); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Revoked API key '" + id + "'")
+}
+
+//change_password <old> <new>
+func (c *client) changePassword(p cmdcli.RxParameters) {
+	if err := c.user.ChangePassword(c.ctx, conf.User, p["old"].(string), p["new"].(string)); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	os.Remove(conf.TokenFile)
+	color.Info.Println("Password changed for username '" + conf.User + "', login again with the new password")
+}
+
+//disable <username>
+func (c *client) disable(p cmdcli.RxParameters) {
+	c.setStatus(p["username"].(string), func(status *numan.AccountStatus) { status.Disabled = true })
+}
+
+//enable <username>
+func (c *client) enable(p cmdcli.RxParameters) {
+	c.setStatus(p["username"].(string), func(status *numan.AccountStatus) { status.Disabled = false })
+}
+
+//password_expiry <username> <days>
+func (c *client) passwordExpiry(p cmdcli.RxParameters) {
+	days := p["days"].(int64)
+	c.setStatus(p["username"].(string), func(status *numan.AccountStatus) {
+		status.PasswordExpires = 0
+		if days > 0 {
+			status.PasswordExpires = time.Now().AddDate(0, 0, int(days)).Unix()
+		}
+	})
+}
+
+//must_change <username>
+func (c *client) mustChange(p cmdcli.RxParameters) {
+	c.setStatus(p["username"].(string), func(status *numan.AccountStatus) { status.MustChangePassword = true })
+}
+
+//setStatus changes the account status of username with change
+func (c *client) setStatus(username string, change func(status *numan.AccountStatus)) {
+	users, err := c.user.ListUsers(c.ctx, username)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	for _, user := range users {
+		if user.Username != username {
+			continue
+		}
+		change(&user.Status)
+		if err := c.user.SetStatus(c.ctx, username, user.Status); err != nil {
+			color.Warn.Println(err)
+			os.Exit(1)
+		}
+		color.Info.Println("Status set for username '" + username + "' (" + formatStatus(user.Status) + ")")
+		return
+	}
+	color.Warn.Println("Unable to set status, check the username exists")
+	os.Exit(1)
+}
+
+//scope <username> [domains] [carriers] [prefixes]
+func (c *client) scope(p cmdcli.RxParameters) {
+	username := p["username"].(string)
+	if err := c.user.SetScope(c.ctx, username, scopeParams(p)); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Scope set for username '" + username + "'")
+}
+
+//scopeParams returns the scope of the domains, carriers & prefixes parameters
+func scopeParams(p cmdcli.RxParameters) numan.Scope {
+	return numan.Scope{Domains: listParam(p, "domains"), Carriers: listParam(p, "carriers"), Prefixes: listParam(p, "prefixes")}
+}
+
+//listParam splits a comma separated list parameter, * or omitted is none (any)
+func listParam(p cmdcli.RxParameters, param string) []string {
+	list, ok := p[param].(string)
+	if !ok || list == "*" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+//keys
+func (c *client) keys(p cmdcli.RxParameters) {
+	keys, err := c.user.ListKeys(c.ctx)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	printKeyList(keys)
+}
+
+//rotate_key [algorithm]
+func (c *client) rotateKey(p cmdcli.RxParameters) {
+	algorithm, ok := p["algorithm"].(string)
+	if !ok {
+		algorithm = ""
+	}
+	key, err := c.user.RotateKey(c.ctx, algorithm)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("New " + key.Algorithm + " signing key '" + key.Kid + "' in use")
+	if key.PublicKey != "" {
+		fmt.Print(key.PublicKey)
+	}
+}
+
+//webhooks
+func (c *client) listWebhooks(p cmdcli.RxParameters) {
+	hooks, err := c.webhooks.ListWebhooks(c.ctx)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if len(hooks) == 0 {
+		color.Warn.Println("None found")
+		os.Exit(1)
+	}
+	printWebhookList(hooks)
+}
+
+//webhook_add <url> [types] [prefix] [domain] [carrier]
+func (c *client) webhookAdd(p cmdcli.RxParameters) {
+	hook := numan.Webhook{URL: p["url"].(string)}
+	if types, ok := p["types"].(string); ok && types != "*" {
+		hook.Filter.Types = strings.Split(types, ",")
+	}
+	if prefix, ok := p["prefix"].(string); ok && prefix != "*" {
+		hook.Filter.Prefix = prefix
+	}
+	hook.Filter.Domain, _ = p["domain"].(string)
+	hook.Filter.Carrier, _ = p["carrier"].(string)
+	hook, err := c.webhooks.AddWebhook(c.ctx, hook)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Webhook " + fmt.Sprint(hook.ID) + " added, verify the " + numan.WebhookSignatureHeader + " header with this secret (it is not shown again):")
+	fmt.Println(hook.Secret)
+}
+
+//webhook_delete <id>
+func (c *client) webhookDelete(p cmdcli.RxParameters) {
+	id := p["id"].(int64)
+	if err := c.webhooks.DeleteWebhook(c.ctx, id); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Deleted webhook " + fmt.Sprint(id))
+}
+
+//webhook_dead [id]
+func (c *client) webhookDead(p cmdcli.RxParameters) {
+	id, _ := p["id"].(int64)
+	deliveries, err := c.webhooks.ListDeadLetters(c.ctx, id)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if len(deliveries) == 0 {
+		color.Warn.Println("None found")
+		os.Exit(1)
+	}
+	printDeliveryList(deliveries)
+}
+
+//webhook_redeliver [id]
+func (c *client) webhookRedeliver(p cmdcli.RxParameters) {
+	id, _ := p["id"].(int64)
+	queued, err := c.webhooks.RedeliverDeadLetters(c.ctx, id)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println(fmt.Sprint(queued) + " deliveries queued")
+}
+
+//rate_limits
+func (c *client) listRateLimits(p cmdcli.RxParameters) {
+	if c.rateLimits == nil {
+		color.Warn.Println("Rate limits are applied by numd, set SERVER_ADDRESS")
+		os.Exit(1)
+	}
+	limits, usage, err := c.rateLimits.ListRateLimits(c.ctx)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if len(limits) == 0 {
+		color.Warn.Println("No rate limits, calls are not limited")
+		os.Exit(1)
+	}
+	printRateLimitList(limits, usage)
+}
+
+//printWebhookList prints slice of numan.Webhook as a table
+func printWebhookList(hooks []numan.Webhook) {
+	printer := tableprinter.New(os.Stdout)
+
+	type tableRow struct {
+		ID      int64  `header:"Id"`
+		URL     string `header:"URL"`
+		Filter  string `header:"Filter"`
+		Created string `header:"Created"`
+		Pending int64  `header:"Pending"`
+		Dead    int64  `header:"Dead"`
+	}
+	table := []tableRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	for _, h := range hooks {
+		var filter []string
+		if len(h.Filter.Types) > 0 {
+			filter = append(filter, "types: "+strings.Join(h.Filter.Types, ","))
+		}
+		if h.Filter.Prefix != "" {
+			filter = append(filter, "prefix: "+h.Filter.Prefix)
+		}
+		if h.Filter.Domain != "" {
+			filter = append(filter, "domain: "+h.Filter.Domain)
+		}
+		if h.Filter.Carrier != "" {
+			filter = append(filter, "carrier: "+h.Filter.Carrier)
+		}
+		if len(filter) == 0 {
+			filter = append(filter, "all events")
+		}
+		table = append(table, tableRow{ID: h.ID, URL: h.URL, Filter: strings.Join(filter, "; "), Created: time.Unix(h.Created, 0).Format(numan.TIMESTAMPPRINTFORMAT), Pending: h.Pending, Dead: h.Dead})
+	}
+	printer.Print(table)
+}
+
+//printRateLimitList prints slices of numan.RateLimit & numan.RateLimitUsage as tables
+func printRateLimitList(limits []numan.RateLimit, usage []numan.RateLimitUsage) {
+	printer := tableprinter.New(os.Stdout)
+
+	type limitRow struct {
+		Method string  `header:"Method"`
+		Rate   float64 `header:"Rate (/s)"`
+		Burst  int     `header:"Burst"`
+	}
+	type usageRow struct {
+		Key      string `header:"Caller"`
+		Method   string `header:"Method"`
+		Limit    string `header:"Limit"`
+		Tokens   string `header:"Tokens"`
+		Allowed  int64  `header:"Allowed"`
+		Limited  int64  `header:"Limited"`
+		LastSeen string `header:"Last Seen"`
+	}
+	limitTable, usageTable := []limitRow{}, []usageRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	for _, l := range limits {
+		limitTable = append(limitTable, limitRow{Method: l.Method, Rate: l.Rate, Burst: l.Burst})
+	}
+	printer.Print(limitTable)
+	if len(usage) == 0 {
+		color.Info.Println("No recent callers")
+		return
+	}
+	for _, u := range usage {
+		usageTable = append(usageTable, usageRow{Key: u.Key, Method: u.Method, Limit: u.Limit, Tokens: fmt.Sprintf("%.1f", u.Tokens),
+			Allowed: u.Allowed, Limited: u.Limited, LastSeen: time.Unix(u.LastSeen, 0).Format(numan.TIMESTAMPPRINTFORMAT)})
+	}
+	printer.Print(usageTable)
+}
+
+//printDeliveryList prints slice of numan.WebhookDelivery as a table
+func printDeliveryList(deliveries []numan.WebhookDelivery) {
+	printer := tableprinter.New(os.Stdout)
+
+	type tableRow struct {
+		ID        int64  `header:"Id"`
+		WebhookID int64  `header:"Webhook"`
+		Event     string `header:"Event"`
+		Number    string `header:"Number"`
+		Created   string `header:"Created"`
+		Attempts  int    `header:"Attempts"`
+		LastError string `header:"Last Error"`
+	}
+	table := []tableRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	for _, d := range deliveries {
+		table = append(table, tableRow{ID: d.ID, WebhookID: d.WebhookID, Event: d.Payload.Type, Number: d.Payload.Number,
+			Created: time.Unix(d.Created, 0).Format(numan.TIMESTAMPPRINTFORMAT), Attempts: d.Attempts, LastError: d.LastError})
+	}
+	printer.Print(table)
+}
+
+//printKeyList prints slice of numan.SigningKey as a table
+func printKeyList(keyList []numan.SigningKey) {
+	printer := tableprinter.New(os.Stdout)
+
+	type tableRow struct {
+		Kid       string `header:"Kid"`
+		Algorithm string `header:"Algorithm"`
+		Created   string `header:"Created"`
+		Retired   string `header:"Retired"`
+	}
+	table := []tableRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	for _, k := range keyList {
+		row := tableRow{Kid: k.Kid, Algorithm: k.Algorithm, Retired: "in use"}
+		if k.Created > 0 {
+			row.Created = time.Unix(k.Created, 0).Format(numan.TIMESTAMPPRINTFORMAT)
+		}
+		if k.Retired > 0 {
+			row.Retired = time.Unix(k.Retired, 0).Format(numan.TIMESTAMPPRINTFORMAT)
+		}
+		table = append(table, row)
+	}
+	printer.Print(table)
+}
+
+//printAuditList prints slice of numan.AuditEntry as a table
+func printAuditList(entries []numan.AuditEntry) {
+	printer := tableprinter.New(os.Stdout)
+
+	type tableRow struct {
+		Timestamp string `header:"Timestamp"`
+		Username  string `header:"Username"`
+		Source    string `header:"Source"`
+		Action    string `header:"Action"`
+		Notes     string `header:"Notes"`
+	}
+	table := []tableRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	for _, e := range entries {
+		table = append(table, tableRow{Timestamp: time.Unix(e.Timestamp, 0).Format(numan.TIMESTAMPPRINTFORMAT), Username: e.Username, Source: e.Source, Action: e.Action, Notes: e.Notes})
+	}
+	printer.Print(table)
+}
+
+//printAPIKeyList prints slice of numan.APIKey as a table
+func printAPIKeyList(keyList []numan.APIKey) {
+	printer := tableprinter.New(os.Stdout)
+
+	type tableRow struct {
+		ID       string `header:"Id"`
+		Username string `header:"Username"`
+		Created  string `header:"Created"`
+		Expires  string `header:"Expires"`
+		LastUsed string `header:"Last Used"`
+		Roles    string `header:"Roles"`
+		Scope    string `header:"Scope"`
+	}
+	table := []tableRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	for _, k := range keyList {
+		row := tableRow{ID: k.ID, Username: k.Username, Created: time.Unix(k.Created, 0).Format(numan.TIMESTAMPPRINTFORMAT), Expires: "never", LastUsed: "never", Roles: "users", Scope: "users"}
+		if len(k.Roles) > 0 {
+			row.Roles = strings.Join(k.Roles, ", ")
+		}
+		if !k.Scope.Unrestricted() {
+			row.Scope = formatScope(k.Scope)
+		}
+		if k.Expires > 0 {
+			row.Expires = time.Unix(k.Expires, 0).Format(numan.TIMESTAMPPRINTFORMAT)
+		}
+		if k.LastUsed > 0 {
+			row.LastUsed = time.Unix(k.LastUsed, 0).Format(numan.TIMESTAMPPRINTFORMAT)
+		}
+		table = append(table, row)
+	}
+	printer.Print(table)
+}
+
+//formatStatus formats an account status for printing
+func formatStatus(status numan.AccountStatus) string {
+	formatted := "active"
+	if status.Disabled {
+		formatted = "disabled"
+	}
+	if status.MustChangePassword {
+		formatted += ", must change password"
+	}
+	if status.PasswordExpires > 0 {
+		formatted += ", password expires " + time.Unix(status.PasswordExpires, 0).Format(numan.TIMESTAMPPRINTFORMAT)
+	}
+	return formatted
+}
+
+//formatScope formats a users scope for printing
+func formatScope(scope numan.Scope) string {
+	if scope.Unrestricted() {
+		return "any"
+	}
+	var parts []string
+	if len(scope.Domains) > 0 {
+		parts = append(parts, "domains: "+strings.Join(scope.Domains, ","))
+	}
+	if len(scope.Carriers) > 0 {
+		parts = append(parts, "carriers: "+strings.Join(scope.Carriers, ","))
+	}
+	if len(scope.Prefixes) > 0 {
+		parts = append(parts, "prefixes: "+strings.Join(scope.Prefixes, ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
+//printRoleList prints slice of numan.Role as a table
+func printRoleList(roleList []numan.Role) {
+	printer := tableprinter.New(os.Stdout)
+
+	type tableRow struct {
+		Role        string `header:"Role"`
+		Permissions string `header:"Permissions"`
+	}
+	table := []tableRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	for _, r := range roleList {
+		table = append(table, tableRow{
+			Role:        r.Name,
+			Permissions: strings.Join(r.Permissions, ", "),
+		})
+	}
+	printer.Print(table)
+}
+
 //printUserList prints slice of numan.User as a table
 func printUserList(userList []numan.User) {
 	printer := tableprinter.New(os.Stdout)
 
 	type tableRow struct {
-		Username string `header:"Username"`
-		Role     string `header:"Role"`
+		Username  string `header:"Username"`
+		Roles     string `header:"Roles"`
+		Scope     string `header:"Scope"`
+		Status    string `header:"Status"`
+		LastLogin string `header:"Last Login"`
 	}
 	table := []tableRow{}
 
@@ -204,9 +957,15 @@ func printUserList(userList []numan.User) {
 
 	for _, n := range userList {
 		table = append(table, tableRow{
-			Username: n.Username,
-			Role:     n.Role,
+			Username:  n.Username,
+			Roles:     strings.Join(n.Roles, ", "),
+			Scope:     formatScope(n.Scope),
+			Status:    formatStatus(n.Status),
+			LastLogin: "never",
 		})
+		if n.LastLogin > 0 {
+			table[len(table)-1].LastLogin = time.Unix(n.LastLogin, 0).Format(numan.TIMESTAMPPRINTFORMAT)
+		}
 	}
 	printer.Print(table)
 }
@@ -2,38 +2,171 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/footfish/numan"
 	"github.com/footfish/numan/api/grpc"
+	numanv1 "github.com/footfish/numan/api/grpc/numan/v1"
+	"github.com/footfish/numan/api/rest"
+	"github.com/footfish/numan/internal/metrics"
+	"github.com/footfish/numan/internal/oidc"
+	"github.com/footfish/numan/internal/ratelimit"
+	"github.com/footfish/numan/internal/service"
 	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+	"github.com/footfish/numan/internal/webhook"
 	"github.com/joho/godotenv"
 	"github.com/vrischmann/envconfig"
-	"google.golang.org/grpc/credentials"
+	grpclib "google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
-func main() {
-	var conf struct {
-		Dsn     string
-		Port    int `envconfig:"default=50051"`
-		TlsCert string
-		TlsKey  string
-	}
+//config is the server config, from environmental vars (or numd.env)
+type config struct {
+	Dsn     string
+	Port    int `envconfig:"default=50051"`
+	TlsCert string
+	TlsKey  string
+	//Mutual TLS, clients must present a certificate signed by TLS_CLIENT_CA (mapped to the user named by the whole TLS_CLIENT_CERT_USERNAME field, cn, dns or email)
+	TlsClientCa           string `envconfig:"optional"`
+	TlsClientCertUsername string `envconfig:"default=cn"`
+	//Graceful shutdown (SIGTERM/SIGINT) waits up to SHUTDOWN_TIMEOUT for in flight requests, health checks ping the database every HEALTH_INTERVAL
+	ShutdownTimeout time.Duration `envconfig:"default=30s"`
+	HealthInterval  time.Duration `envconfig:"default=10s"`
+	//HTTP/JSON API (same TLS config as gRPC), disabled if REST_PORT is 0. Browsers are allowed cross origin requests from REST_CORS_ORIGINS (* for any)
+	RestPort        int    `envconfig:"default=0"`
+	RestCorsOrigins string `envconfig:"optional"` //origin,...
+	//Request log, a JSON line per gRPC call on stdout
+	LogRequests bool `envconfig:"default=true"`
+	//OpenTelemetry tracing, TRACE_EXPORTER stdout or otlp (gRPC to a collector at TRACE_OTLP_ENDPOINT, no TLS), disabled if not set
+	TraceExporter     string  `envconfig:"optional"`
+	TraceOtlpEndpoint string  `envconfig:"default=localhost:4317"`
+	TraceSampleRatio  float64 `envconfig:"default=1"`
+	//Prometheus metrics (plain HTTP GET /metrics), disabled if METRICS_PORT is 0
+	MetricsPort int `envconfig:"default=0"`
+	//External identity provider (SSO), disabled if OIDC_ISSUER is not set. Keys are fetched from the discovered jwks_uri unless OIDC_JWKS_URL or OIDC_JWKS_FILE is set
+	OidcIssuer        string `envconfig:"optional"`
+	OidcAudience      string `envconfig:"optional"`
+	OidcJwksUrl       string `envconfig:"optional"`
+	OidcJwksFile      string `envconfig:"optional"`
+	OidcUsernameClaim string `envconfig:"default=preferred_username"`
+	OidcGroupsClaim   string `envconfig:"default=groups"`
+	OidcGroupRoles    string `envconfig:"optional"` //group=role,...
+	OidcGroupScopes   string `envconfig:"optional"` //group=kind:value,...
+	OidcDefaultRoles  string `envconfig:"optional"` //role,...
+	OidcProvision     bool   `envconfig:"default=true"`
+	//Token signing keys, JWT_SECRET (HS256) or a key file created on first use.
+	//Instances sharing a database must share the key file, after a rotation every other instance must reload it (SIGHUP) to accept the new key
+	JwtSecret    string `envconfig:"optional"`
+	JwtKeyFile   string `envconfig:"default=numan-keys.json"`
+	JwtAlgorithm string `envconfig:"default=EdDSA"`
+	//Failed login lockout, disabled if LOGIN_MAX_FAILURES is 0
+	LoginMaxFailures int           `envconfig:"default=5"`
+	LoginLockout     time.Duration `envconfig:"default=1m"`
+	LoginMaxLockout  time.Duration `envconfig:"default=1h"`
+	//Password policy, checked when passwords are set. PASSWORD_DENYLIST is a file of refused passwords (one per line)
+	PasswordMinLength  int    `envconfig:"default=8"`
+	PasswordMinClasses int    `envconfig:"default=2"`
+	PasswordHistory    int    `envconfig:"default=0"`
+	PasswordDenylist   string `envconfig:"optional"`
+	//History retention, disabled if HISTORY_RETENTION_YEARS is 0
+	HistoryRetentionYears    int           `envconfig:"default=0"`
+	HistoryKeepLast          int           `envconfig:"default=0"`
+	HistoryRetentionInterval time.Duration `envconfig:"default=24h"`
+	//Number events older than EVENT_RETENTION are deleted (watchers can't resume before), disabled if 0
+	EventRetention time.Duration `envconfig:"default=168h"`
+	//Webhook deliveries are retried after WEBHOOK_BACKOFF doubling up to WEBHOOK_MAX_BACKOFF, dead after WEBHOOK_MAX_ATTEMPTS. Queued deliveries are checked every WEBHOOK_INTERVAL
+	WebhookMaxAttempts int           `envconfig:"default=10"`
+	WebhookBackoff     time.Duration `envconfig:"default=10s"`
+	WebhookMaxBackoff  time.Duration `envconfig:"default=1h"`
+	WebhookTimeout     time.Duration `envconfig:"default=10s"`
+	WebhookInterval    time.Duration `envconfig:"default=5s"`
+	//Rate limits per caller (user, API key or source address) & method, method=rate[:burst],... (ex. *=20:40,Numbering/List=2:10), disabled if not set
+	RateLimits string `envconfig:"optional"`
+	//Database connection tuning (busy timeout, WAL & foreign keys are sqlite only)
+	DbMaxOpenConns    int           `envconfig:"default=0"`
+	DbMaxIdleConns    int           `envconfig:"default=0"`
+	DbConnMaxLifetime time.Duration `envconfig:"default=0s"`
+	DbBusyTimeout     time.Duration `envconfig:"default=0s"`
+	DbWal             bool          `envconfig:"default=false"`
+	DbForeignKeys     bool          `envconfig:"default=false"`
+}
 
+var conf config
+
+func main() {
 	//Init conf from environmental vars
 	godotenv.Load("numd.env")
 	if err := envconfig.Init(&conf); err != nil {
 		log.Fatalf("Failed to load required environmental variables for config: %v", err)
 	}
 
-	//Database
-	store := datastore.NewStore(conf.Dsn)
+	//Sub commands (ex. numd migrate up), otherwise run server
+	if len(os.Args) > 1 {
+		initCli().Run()
+		return
+	}
+
+	//Signing keys, login & password policy
+	if err := applySettings(&conf); err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+
+	//Rate limits
+	limits, err := numan.ParseRateLimits(conf.RateLimits)
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+	limiter := ratelimit.NewLimiter(limits)
+
+	//Database (refuses to run against an unmigrated database)
+	store, err := datastore.NewStore(conf.Dsn, append(storeOptions(), datastore.WithQueryObserver(metrics.ObserveQuery))...)
+	if err != nil {
+		log.Fatalf("Database error: %v", err)
+	}
 	defer store.Close()
 
+	//Tracing
+	shutdownTracing := func(context.Context) error { return nil }
+	if conf.TraceExporter != "" {
+		if shutdownTracing, err = tracing.Setup(context.Background(), conf.TraceExporter, conf.TraceOtlpEndpoint, conf.TraceSampleRatio); err != nil {
+			log.Fatalf("Tracing error: %v", err)
+		}
+	}
+
+	//History retention
+	if conf.HistoryRetentionYears > 0 {
+		go runHistoryRetention(store, conf.HistoryRetentionYears, conf.HistoryKeepLast, conf.HistoryRetentionInterval)
+	}
+
+	//Number event retention
+	if conf.EventRetention > 0 {
+		go runEventRetention(store, conf.EventRetention, conf.HistoryRetentionInterval)
+	}
+
+	//Webhook deliveries
+	dispatcher := webhook.NewDispatcher(store, webhook.Policy{MaxAttempts: conf.WebhookMaxAttempts, Backoff: conf.WebhookBackoff, MaxBackoff: conf.WebhookMaxBackoff, Timeout: conf.WebhookTimeout}, metrics.ObserveWebhook)
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	defer stopDispatch()
+	dispatched := make(chan struct{})
+	go func() {
+		dispatcher.Run(dispatchCtx, conf.WebhookInterval)
+		close(dispatched)
+	}()
+
 	//Prep server
-	creds, err := credentials.NewServerTLSFromFile(conf.TlsCert, conf.TlsKey)
+	serverTLS, err := grpc.NewServerTLS(conf.TlsCert, conf.TlsKey, conf.TlsClientCa)
 	if err != nil {
 		log.Fatalf("Failed to setup tls: %v", err)
 	}
@@ -43,22 +176,252 @@ func main() {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
+	//External identity provider
+	var oidcAuth *service.OIDCAuthenticator
+	if conf.OidcIssuer != "" {
+		if oidcAuth, err = newOIDCAuthenticator(store); err != nil {
+			log.Fatalf("OIDC error: %v", err)
+		}
+	}
+
+	//HTTP/JSON API
+	var restServer *http.Server
+	if conf.RestPort != 0 {
+		var origins []string
+		for _, origin := range strings.Split(conf.RestCorsOrigins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				origins = append(origins, origin)
+			}
+		}
+		restServer = rest.NewServer(fmt.Sprintf(":%d", conf.RestPort), rest.NewHandler(store, oidcAuth, origins, limiter), serverTLS.Config(false, "h2", "http/1.1"))
+		go func() {
+			log.Printf("Starting REST service on %s...\n", restServer.Addr)
+			if err := restServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("REST server failed to serve: %v", err)
+			}
+		}()
+	}
+
+	//Metrics
+	var metricsServer *http.Server
+	if conf.MetricsPort != 0 {
+		metricsServer = &http.Server{Addr: fmt.Sprintf(":%d", conf.MetricsPort), Handler: metrics.Handler(metrics.NewRegistry(store))}
+		go func() {
+			log.Printf("Starting metrics service on %s...\n", metricsServer.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Metrics server failed to serve: %v", err)
+			}
+		}()
+	}
+
 	//GRPC
 	log.Printf("Starting gRPC user service on %s...\n", lis.Addr().String())
-	grpcServer := grpc.NewGrpcServer(creds)
+	var requestLog io.Writer
+	if conf.LogRequests {
+		requestLog = os.Stdout
+	}
+	grpcServer := grpc.NewGrpcServer(serverTLS.Credentials(), store, oidcAuth, requestLog, limiter)
 
 	numberingServerAdapter := grpc.NewNumberingServerAdapter(store)
 	historyServerAdapter := grpc.NewHistoryServerAdapter(store)
 	userServerAdapter := grpc.NewUserServerAdapter(store)
+	eventServerAdapter := grpc.NewEventServerAdapter(store)
+	webhookServerAdapter := grpc.NewWebhookServerAdapter(store)
 
 	grpc.RegisterNumberingServer(grpcServer, numberingServerAdapter)
 	grpc.RegisterHistoryServer(grpcServer, historyServerAdapter)
 	grpc.RegisterUserServer(grpcServer, userServerAdapter)
+	grpc.RegisterEventServer(grpcServer, eventServerAdapter)
+	grpc.RegisterWebhookServer(grpcServer, webhookServerAdapter)
+	numanv1.RegisterNumberingServer(grpcServer, grpc.NewNumberingV1ServerAdapter(store)) //versioned API, alongside the original for existing clients
+	numanv1.RegisterHistoryServer(grpcServer, grpc.NewHistoryV1ServerAdapter(store))
+	numanv1.RegisterUserServer(grpcServer, grpc.NewUserV1ServerAdapter(store))
+	numanv1.RegisterEventServer(grpcServer, grpc.NewEventV1ServerAdapter(store))
+	numanv1.RegisterWebhookServer(grpcServer, grpc.NewWebhookV1ServerAdapter(store))
+	numanv1.RegisterRateLimitServer(grpcServer, grpc.NewRateLimitServerAdapter(store, limiter))
 
 	reflection.Register(grpcServer)
 
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("gRPC server failed to serve: %v", err)
+	//Health checks
+	health := grpc.NewHealth(grpcServer, store)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	go health.Run(healthCtx, conf.HealthInterval)
+
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("gRPC server failed to serve: %v", err)
+		}
+	}()
+
+	//Signals, SIGHUP reloads config & TLS certificates, SIGTERM/SIGINT shut down
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			reload(serverTLS, limiter)
+			continue
+		}
+		log.Printf("Received %v, shutting down...", sig)
+		stopHealth()
+		health.Shutdown() //NOT_SERVING, so load balancers stop sending requests
+		shutdown(grpcServer, conf.ShutdownTimeout, restServer, metricsServer)
+		stopDispatch() //deliveries in flight are retried after their lease
+		<-dispatched
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("Tracing shutdown: %v", err)
+		}
+		return
 	}
+}
 
+//shutdown stops the servers (nil HTTP servers are not running), waiting up to timeout for in flight requests to complete.
+func shutdown(grpcServer *grpclib.Server, timeout time.Duration, httpServers ...*http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	for _, server := range httpServers {
+		if server == nil {
+			continue
+		}
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server %s shutdown: %v", server.Addr, err)
+		}
+	}
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("Shutdown timeout, closing remaining connections")
+		grpcServer.Stop()
+	}
+}
+
+//applySettings sets the token signing keys, login & password policy and client certificate username field from c
+func applySettings(c *config) error {
+	keys, err := numan.NewKeySetFromConfig(c.JwtSecret, c.JwtKeyFile, c.JwtAlgorithm)
+	if err != nil {
+		return fmt.Errorf("signing key error: %w", err)
+	}
+	passwordPolicy, err := numan.NewPasswordPolicyFromConfig(c.PasswordMinLength, c.PasswordMinClasses, c.PasswordHistory, c.PasswordDenylist)
+	if err != nil {
+		return fmt.Errorf("password policy error: %w", err)
+	}
+	if err := numan.SetCertUsername(c.TlsClientCertUsername); err != nil {
+		return fmt.Errorf("TLS error: %w", err)
+	}
+	numan.SetKeySet(keys)
+	numan.SetLoginPolicy(numan.LoginPolicy{MaxFailures: c.LoginMaxFailures, Lockout: c.LoginLockout, MaxLockout: c.LoginMaxLockout})
+	numan.SetPasswordPolicy(passwordPolicy)
+	return nil
+}
+
+//reload re-reads the config (numd.env overrides the environment) and applies TLS certificates, signing keys, login & password policy and rate limits.
+//Other settings (ex. DSN, ports, OIDC) need a restart. The current config is kept on error.
+func reload(serverTLS *grpc.ServerTLS, limiter *ratelimit.Limiter) {
+	godotenv.Overload("numd.env")
+	var c config
+	if err := envconfig.Init(&c); err != nil {
+		log.Printf("Reload failed, config error: %v", err)
+		return
+	}
+	limits, err := numan.ParseRateLimits(c.RateLimits)
+	if err != nil {
+		log.Printf("Reload failed, config error: %v", err)
+		return
+	}
+	if err := serverTLS.Reload(c.TlsCert, c.TlsKey, c.TlsClientCa); err != nil {
+		log.Printf("Reload failed, TLS error: %v", err)
+		return
+	}
+	if err := applySettings(&c); err != nil {
+		log.Printf("Reload failed, %v", err)
+		return
+	}
+	limiter.SetLimits(limits)
+	log.Printf("Reloaded TLS certificates, signing keys, login & password policy, rate limits")
+}
+
+//storeOptions returns database options from config
+func storeOptions() []datastore.Option {
+	opts := []datastore.Option{
+		datastore.WithMaxOpenConns(conf.DbMaxOpenConns),
+		datastore.WithConnMaxLifetime(conf.DbConnMaxLifetime),
+		datastore.WithBusyTimeout(conf.DbBusyTimeout),
+	}
+	if conf.DbMaxIdleConns != 0 {
+		opts = append(opts, datastore.WithMaxIdleConns(conf.DbMaxIdleConns))
+	}
+	if conf.DbWal {
+		opts = append(opts, datastore.WithWAL())
+	}
+	if conf.DbForeignKeys {
+		opts = append(opts, datastore.WithForeignKeys())
+	}
+	return opts
+}
+
+//newOIDCAuthenticator returns an OIDCAuthenticator from config
+func newOIDCAuthenticator(store *datastore.Store) (*service.OIDCAuthenticator, error) {
+	if conf.OidcAudience == "" { //otherwise tokens issued to any client of the provider are accepted
+		return nil, errors.New("OIDC_AUDIENCE (client id) required")
+	}
+	provider, err := oidc.NewProvider(oidc.Config{Issuer: conf.OidcIssuer, Audience: conf.OidcAudience, JWKSURL: conf.OidcJwksUrl, JWKSFile: conf.OidcJwksFile})
+	if err != nil {
+		return nil, err
+	}
+	mapping := oidc.Mapping{UsernameClaim: conf.OidcUsernameClaim, GroupsClaim: conf.OidcGroupsClaim}
+	if mapping.GroupRoles, err = oidc.ParseGroupRoles(conf.OidcGroupRoles); err != nil {
+		return nil, err
+	}
+	if mapping.GroupScopes, err = oidc.ParseGroupScopes(conf.OidcGroupScopes); err != nil {
+		return nil, err
+	}
+	for _, role := range strings.Split(conf.OidcDefaultRoles, ",") {
+		if role = strings.TrimSpace(role); role == "" {
+			continue
+		}
+		if !numan.ValidRoleName(role) {
+			return nil, fmt.Errorf("invalid default role '%s'", role)
+		}
+		mapping.DefaultRoles = append(mapping.DefaultRoles, role)
+	}
+	log.Printf("OIDC login enabled for issuer %s", provider.Issuer())
+	return service.NewOIDCAuthenticator(store, provider, mapping, conf.OidcProvision), nil
+}
+
+//runHistoryRetention archives history older than retentionYears (keeping keepLast entries per number) every interval.
+func runHistoryRetention(store *datastore.Store, retentionYears int, keepLast int, interval time.Duration) {
+	history := service.NewHistoryService(store)
+	for {
+		//retention runs as an internal admin user
+		sysUser := numan.User{Username: "numd", Roles: []string{numan.RoleAdmin}}
+		if err := sysUser.SetNewAccessToken(); err != nil {
+			log.Printf("History retention failed: %v", err)
+		} else {
+			ctx := context.WithValue(context.Background(), numan.AuthTokenField, sysUser.AccessToken)
+			policy := numan.RetentionPolicy{Before: time.Now().AddDate(-retentionYears, 0, 0).Unix(), KeepLast: keepLast}
+			if archived, err := history.ArchiveHistory(ctx, policy); err != nil {
+				log.Printf("History retention failed: %v", err)
+			} else {
+				log.Printf("History retention archived %d entries", archived)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+//runEventRetention deletes number events older than retention every interval.
+func runEventRetention(store *datastore.Store, retention time.Duration, interval time.Duration) {
+	for {
+		if purged, err := store.PurgeEvents(time.Now().Add(-retention).Unix()); err != nil {
+			log.Printf("Event retention failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Event retention deleted %d events", purged)
+		}
+		time.Sleep(interval)
+	}
 }
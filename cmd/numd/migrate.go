@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/cmdcli"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/gookit/color"
+	"github.com/lensesio/tableprinter"
+)
+
+//initCli setup sub command configurations
+func initCli() cmdcli.CommandConfigs {
+	cli := cmdcli.NewCli()
+
+	cmdDescription := "Database schema migrations. 'status' lists migrations, 'up' migrates to latest (or version), 'down' reverts to previous (or version)"
+	cmd := cli.NewCommand("migrate", migrate, cmdDescription)
+	cmd.NewStringParameter("action", true).SetRegexp("^(status|up|down)$")
+	cmd.NewIntParameter("version", false)
+
+	cmdDescription = "Clears the failed logins & lockout of a username or source address (recovers a locked out admin)"
+	cmd = cli.NewCommand("unlock", unlock, cmdDescription)
+	cmd.NewStringParameter("name", true)
+
+	return cli
+}
+
+//migrate <status|up|down> [version]
+func migrate(p cmdcli.RxParameters) {
+	m, err := datastore.NewMigrator(conf.Dsn, storeOptions()...)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	defer m.Close()
+
+	var changed []datastore.MigrationStatus
+	switch p["action"].(string) {
+	case "status":
+		status, err := m.Status()
+		if err != nil {
+			color.Warn.Println(err)
+			os.Exit(1)
+		}
+		printMigrationList(status)
+		return
+	case "up":
+		version, _ := p["version"].(int64) //0 if ommitted, latest
+		changed, err = m.Up(int(version))
+	case "down":
+		version, ok := p["version"].(int64)
+		if !ok {
+			version = -1 //previous
+		}
+		changed, err = m.Down(int(version))
+	}
+	for _, c := range changed {
+		color.Info.Printf("Migrated %s %d_%s\n", p["action"].(string), c.Version, c.Description)
+	}
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	version, _ := m.Version()
+	color.Info.Printf("Database schema is version %d (latest %d)\n", version, m.LatestVersion())
+}
+
+//printMigrationList prints slice of datastore.MigrationStatus as a table
+func printMigrationList(migrationList []datastore.MigrationStatus) {
+	printer := tableprinter.New(os.Stdout)
+
+	type tableRow struct {
+		Version     int    `header:"Version"`
+		Description string `header:"Description"`
+		Applied     string `header:"Applied"`
+	}
+	table := []tableRow{}
+
+	printer.BorderTop, printer.BorderBottom, printer.BorderLeft, printer.BorderRight = true, true, true, true
+	printer.CenterSeparator = "│"
+	printer.ColumnSeparator = "│"
+	printer.RowSeparator = "─"
+
+	dateConv := func(unixTime int64) string {
+		if unixTime == 0 {
+			return "pending"
+		}
+		return time.Unix(unixTime, 0).Format(numan.TIMESTAMPPRINTFORMAT)
+	}
+
+	for _, m := range migrationList {
+		table = append(table, tableRow{
+			Version:     m.Version,
+			Description: m.Description,
+			Applied:     dateConv(m.Applied),
+		})
+	}
+	printer.Print(table)
+}
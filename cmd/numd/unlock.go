@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/cmdcli"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/gookit/color"
+)
+
+//unlock <name>
+//Runs against the database directly (no login), so an admin locked out of numa can recover.
+func unlock(p cmdcli.RxParameters) {
+	store, err := datastore.NewStore(conf.Dsn, storeOptions()...)
+	if err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	name := p["name"].(string)
+	if err := datastore.NewUserService(store).Unlock(context.Background(), name); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	if err := datastore.NewLoginStore(store).AddAudit(numan.AuditEntry{Username: name, Action: numan.AuditUnlock, Notes: "by numd"}); err != nil {
+		color.Warn.Println(err)
+		os.Exit(1)
+	}
+	color.Info.Println("Unlocked '" + name + "'")
+}
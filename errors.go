@@ -0,0 +1,68 @@
+package numan
+
+import (
+	"errors"
+	"fmt"
+)
+
+//Error kinds. Service errors wrap one of these, test with errors.Is (ex. errors.Is(err, numan.ErrNotFound)).
+//The kind is carried over gRPC (status code & error details) so errors.Is also works on the client side.
+var (
+	ErrInvalidArgument        = errors.New("invalid argument")         //bad input (format, range, missing field)
+	ErrNotFound               = errors.New("not found")                //number, user, role, key etc. doesn't exist
+	ErrAlreadyExists          = errors.New("already exists")           //added twice
+	ErrConflict               = errors.New("conflict")                 //state doesn't allow it (ex. already allocated, role in use)
+	ErrQuarantined            = errors.New("quarantined")              //number de-allocated within QUARANTINE
+	ErrUnauthenticated        = errors.New("unauthenticated")          //missing, invalid or revoked credentials
+	ErrPermissionDenied       = errors.New("permission denied")        //insufficient privileges, out of scope or account disabled
+	ErrLocked                 = errors.New("locked")                   //too many failed logins
+	ErrPasswordChangeRequired = errors.New("password change required") //password expired or must be changed before login
+	ErrUnimplemented          = errors.New("unimplemented")            //not available (ex. via this transport)
+	ErrRateLimited            = errors.New("rate limited")             //too many calls, retry later
+)
+
+//ErrorKinds lists the error kinds (ex. for mapping to transport codes)
+var ErrorKinds = []error{ErrInvalidArgument, ErrNotFound, ErrAlreadyExists, ErrConflict, ErrQuarantined, ErrUnauthenticated,
+	ErrPermissionDenied, ErrLocked, ErrPasswordChangeRequired, ErrUnimplemented, ErrRateLimited}
+
+//Error is an error of a kind with a message, errors.Is(err, Kind) is true.
+type Error struct {
+	Kind  error
+	Msg   string
+	cause error //wrapped error (%w) OR nil
+}
+
+//Error implements error, returns the message only
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+//Is returns true if target is the kind of e
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+//Unwrap returns the wrapped error OR nil
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+//Errorf returns an error of kind with a formatted message, %w wraps an error as with fmt.Errorf (ex. Errorf(ErrNotFound, "no user '%s'", name))
+func Errorf(kind error, format string, a ...interface{}) error {
+	err := fmt.Errorf(format, a...)
+	return &Error{Kind: kind, Msg: err.Error(), cause: errors.Unwrap(err)}
+}
+
+//ErrorKind returns the kind of err (the outermost if wrapped) OR nil if it has none
+func ErrorKind(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	for _, kind := range ErrorKinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package numan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestErrorf(t *testing.T) {
+	err := Errorf(ErrNotFound, "no user '%s'", "alice")
+	if err.Error() != "no user 'alice'" {
+		t.Fatalf("Error got %q", err.Error())
+	}
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+		t.Fatal("Is does not match kind")
+	}
+	//%w wraps the cause
+	err = Errorf(ErrInvalidArgument, "can't read: %w", io.EOF)
+	if !errors.Is(err, io.EOF) || !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Wrapped cause or kind lost")
+	}
+	//the outermost kind wins
+	err = fmt.Errorf("outer, %w", Errorf(ErrUnauthenticated, "Auth error, %w", Errorf(ErrNotFound, "no user")))
+	if got := ErrorKind(err); got != ErrUnauthenticated {
+		t.Fatalf("ErrorKind got %v, want %v", got, ErrUnauthenticated)
+	}
+	if ErrorKind(errors.New("other")) != nil {
+		t.Fatal("ErrorKind of error without kind not nil")
+	}
+}
@@ -0,0 +1,118 @@
+package numan
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+//Number event types
+const (
+	EventAdded       = "added"
+	EventUpdated     = "updated"
+	EventReserved    = "reserved"
+	EventAllocated   = "allocated"
+	EventDeAllocated = "deallocated"
+	EventPortedIn    = "ported_in"
+	EventPortedOut   = "ported_out"
+	EventDeleted     = "deleted"
+)
+
+//EventTypes lists the number event types
+var EventTypes = []string{EventAdded, EventUpdated, EventReserved, EventAllocated, EventDeAllocated, EventPortedIn, EventPortedOut, EventDeleted}
+
+//Number states
+const (
+	StateFree        = "free"
+	StateReserved    = "reserved"
+	StateAllocated   = "allocated"
+	StateQuarantined = "quarantined"
+)
+
+//ActorField is the ctx field holding the username making a change (set by the auth layer), recorded with number events
+const ActorField = "actor"
+
+//NumberEvent is a change of a stored number
+type NumberEvent struct {
+	Seq       int64  //event sequence number, increasing (watching resumes after it)
+	Type      string //see EventTypes
+	E164      E164
+	Domain    string
+	Carrier   string
+	OldState  string //number state before the change, "" if added
+	NewState  string //number state after the change, "" if deleted
+	OwnerID   int64  //owner after the change (before for de-allocate)
+	Actor     string //user making the change
+	Timestamp int64
+}
+
+//NewNumberEvent returns the event for a change of number from before to after (nil if not stored), Seq is set when stored
+func NewNumberEvent(eventType string, number E164, before *Numbering, after *Numbering, actor string) NumberEvent {
+	event := NumberEvent{Type: eventType, E164: number, Actor: actor, Timestamp: time.Now().Unix()}
+	for _, n := range []*Numbering{before, after} {
+		if n != nil {
+			event.Domain, event.Carrier, event.OwnerID = n.Domain, n.Carrier, n.OwnerID
+		}
+	}
+	if before != nil {
+		event.OldState = before.State()
+	}
+	if after != nil {
+		event.NewState = after.State()
+	}
+	if eventType == EventDeAllocated && before != nil {
+		event.OwnerID = before.OwnerID
+	}
+	return event
+}
+
+//EventFilter selects number events, empty fields match any event
+type EventFilter struct {
+	AfterSeq int64    //events after this sequence number (0 - from the oldest kept)
+	Domain   string   //domain using the number
+	Carrier  string   //block owner
+	Prefix   string   //number prefix cc[-ndc[-sn]], see PatternPrefix
+	Types    []string //see EventTypes
+}
+
+//Valid checks the filter prefix & types are valid
+func (f EventFilter) Valid() error {
+	if res, _ := regexp.MatchString(PatternPrefix, f.Prefix); f.Prefix != "" && !res {
+		return Errorf(ErrInvalidArgument, "invalid prefix '%s' (cc[-ndc[-sn]])", f.Prefix)
+	}
+	for _, t := range f.Types {
+		if !contains(EventTypes, t) {
+			return Errorf(ErrInvalidArgument, "invalid event type '%s'", t)
+		}
+	}
+	return nil
+}
+
+//Matches returns true if the event matches the filter (AfterSeq is not checked)
+func (f EventFilter) Matches(e NumberEvent) bool {
+	return (f.Domain == "" || f.Domain == e.Domain) &&
+		(f.Carrier == "" || f.Carrier == e.Carrier) &&
+		(f.Prefix == "" || Scope{Prefixes: []string{f.Prefix}}.AllowsE164(e.E164)) &&
+		(len(f.Types) == 0 || contains(f.Types, e.Type))
+}
+
+//State returns the number state, free, reserved, allocated or quarantined
+func (n Numbering) State() string {
+	switch {
+	case n.Used && n.Reserved > 0:
+		return StateReserved
+	case n.Used:
+		return StateAllocated
+	case n.Quarantined():
+		return StateQuarantined
+	}
+	return StateFree
+}
+
+//EventService exposes number change events
+type EventService interface {
+	//ListEvents returns up to limit events matching filter (after filter.AfterSeq), oldest first
+	ListEvents(ctx context.Context, filter *EventFilter, limit int) ([]NumberEvent, error)
+	//Watch sends the events matching filter after filter.AfterSeq, then new events as they are stored, until ctx is done or send returns an error
+	Watch(ctx context.Context, filter *EventFilter, send func(NumberEvent) error) error
+}
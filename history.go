@@ -11,12 +11,20 @@ type History struct {
 	Notes     string //additional notes
 }
 
+//RetentionPolicy represents a history retention rule
+type RetentionPolicy struct {
+	Before   int64 //entries logged before this timestamp are archived
+	KeepLast int   //minimum number of most recent entries kept per number (0 - keep none)
+}
+
 //HistoryService exposes interface for number history
 type HistoryService interface {
 	//AddHistory adds history for a specific phone number
 	AddHistory(ctx context.Context, historyEntry History) error
-	//ListHistoryByNumber gets history log for a specific phone number
-	ListHistoryByNumber(ctx context.Context, phoneNumber E164) ([]History, error)
-	//ListHistoryByOwnerID gets history log for a specific OwnerID
-	ListHistoryByOwnerID(ctx context.Context, ownerID int64) ([]History, error)
+	//ListHistoryByNumber gets history log for a specific phone number (archived entries are included if set)
+	ListHistoryByNumber(ctx context.Context, phoneNumber E164, archived bool) ([]History, error)
+	//ListHistoryByOwnerID gets history log for a specific OwnerID (archived entries are included if set)
+	ListHistoryByOwnerID(ctx context.Context, ownerID int64, archived bool) ([]History, error)
+	//ArchiveHistory moves history entries matching the retention policy to the archive, returns number of entries moved.
+	ArchiveHistory(ctx context.Context, policy RetentionPolicy) (int64, error)
 }
@@ -0,0 +1,111 @@
+//Package metrics exposes numd metrics in Prometheus format: gRPC & database timings, webhook deliveries, number counts and Go runtime stats.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "numan"
+
+var (
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "grpc", Name: "requests_total",
+		Help: "gRPC requests by method & status code.",
+	}, []string{"method", "code"})
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "grpc", Name: "request_duration_seconds",
+		Help:    "gRPC request latency by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "db", Name: "query_duration_seconds",
+		Help:    "Database query latency by statement (select, insert, update, delete).",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"op"})
+	queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "db", Name: "query_errors_total",
+		Help: "Failed database queries by statement.",
+	}, []string{"op"})
+	webhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "webhook", Name: "deliveries_total",
+		Help: "Webhook delivery attempts by result (delivered, failed, dead).",
+	}, []string{"result"})
+)
+
+//ObserveRPC records a gRPC request
+func ObserveRPC(method string, code string, elapsed time.Duration) {
+	rpcRequests.WithLabelValues(method, code).Inc()
+	rpcDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+}
+
+//ObserveQuery records a database query, it is a datastore.QueryObserver
+func ObserveQuery(op string, elapsed time.Duration, err error) {
+	queryDuration.WithLabelValues(op).Observe(elapsed.Seconds())
+	if err != nil {
+		queryErrors.WithLabelValues(op).Inc()
+	}
+}
+
+//ObserveWebhook records a webhook delivery attempt, it is a webhook.Observer
+func ObserveWebhook(result string) {
+	webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+//NewRegistry returns a registry of the gRPC, database, number, Go runtime & process metrics. Number counts are read from store at each scrape.
+func NewRegistry(store *datastore.Store) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(rpcRequests, rpcDuration, queryDuration, queryErrors, webhookDeliveries, newNumberCollector(store),
+		prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	return registry
+}
+
+//Handler returns the HTTP handler serving registry metrics (GET /metrics)
+func Handler(registry *prometheus.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+//numberCollector collects number counts per domain, cc & ndc (as Summary)
+type numberCollector struct {
+	store   *datastore.Store
+	numbers *prometheus.Desc
+	expired *prometheus.Desc
+}
+
+//newNumberCollector returns a collector of store number counts
+func newNumberCollector(store *datastore.Store) *numberCollector {
+	labels := []string{"domain", "cc", "ndc", "state"}
+	return &numberCollector{
+		store:   store,
+		numbers: prometheus.NewDesc(namespace+"_numbers", "Numbers by domain, cc, ndc & state (free, used, reserved, quarantined). Reserved numbers are also used, quarantined numbers also free.", labels, nil),
+		expired: prometheus.NewDesc(namespace+"_reservations_expired", "Reservations past their expiry not yet released, by domain, cc & ndc.", labels[:3], nil),
+	}
+}
+
+//Describe implements prometheus.Collector
+func (c *numberCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.numbers
+	ch <- c.expired
+}
+
+//Collect implements prometheus.Collector
+func (c *numberCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.store.NumberStats(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.numbers, err)
+		return
+	}
+	for _, st := range stats {
+		for state, count := range map[string]int64{"free": st.Free, "used": st.Used, "reserved": st.Reserved, "quarantined": st.Quarantined} {
+			ch <- prometheus.MustNewConstMetric(c.numbers, prometheus.GaugeValue, float64(count), st.Domain, st.Cc, st.Ndc, state)
+		}
+		ch <- prometheus.MustNewConstMetric(c.expired, prometheus.GaugeValue, float64(st.ExpiredReservations), st.Domain, st.Cc, st.Ndc)
+	}
+}
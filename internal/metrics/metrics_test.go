@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+)
+
+func TestHandler(t *testing.T) {
+	store, err := datastore.NewStore(":memory:", datastore.WithQueryObserver(ObserveQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	numbering := datastore.NewNumberingService(store)
+	for _, sn := range []string{"12345001", "12345002"} {
+		if err := numbering.Add(ctx, &numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: sn}, Domain: "test.com", Carrier: "carrier"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ownerID := int64(1)
+	if err := numbering.Allocate(ctx, &numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, &ownerID); err != nil {
+		t.Fatal(err)
+	}
+	ObserveRPC("/numan.Numbering/Allocate", "OK", time.Millisecond)
+
+	server := httptest.NewServer(Handler(NewRegistry(store)))
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`numan_numbers{cc="353",domain="test.com",ndc="01",state="free"} 1`,
+		`numan_numbers{cc="353",domain="test.com",ndc="01",state="used"} 1`,
+		`numan_numbers{cc="353",domain="test.com",ndc="01",state="reserved"} 0`,
+		`numan_reservations_expired{cc="353",domain="test.com",ndc="01"} 0`,
+		`numan_grpc_requests_total{code="OK",method="/numan.Numbering/Allocate"} 1`,
+		`numan_grpc_request_duration_seconds_count{method="/numan.Numbering/Allocate"} 1`,
+		`numan_db_query_duration_seconds_count{op="insert"} 5`, //2 numbers & 3 number events
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
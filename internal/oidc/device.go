@@ -0,0 +1,160 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//DeviceAuth is a started device code login (see RFC 8628), the user visits VerificationURI and enters UserCode
+type DeviceAuth struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"` //optional, includes the user code
+	ExpiresIn               int64  `json:"expires_in"`                //seconds
+	Interval                int64  `json:"interval"`                  //seconds between polls
+}
+
+//Token is a token response from the provider
+type Token struct {
+	IDToken      string `json:"id_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+//tokenError is an OAuth2 error response
+type tokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+//Raw returns the token sent to numd, the ID token OR the access token if the provider issued no ID token
+func (t Token) Raw() string {
+	if t.IDToken != "" {
+		return t.IDToken
+	}
+	return t.AccessToken
+}
+
+//StartDeviceLogin requests a device code for clientID
+func (p *Provider) StartDeviceLogin(ctx context.Context, clientID string, scopes []string) (DeviceAuth, error) {
+	var auth DeviceAuth
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return auth, err
+	}
+	if d.DeviceAuthorizationEndpoint == "" {
+		return auth, errors.New("OIDC provider does not support device login")
+	}
+	form := url.Values{"client_id": {clientID}, "scope": {strings.Join(scopes, " ")}}
+	if err := p.postForm(ctx, d.DeviceAuthorizationEndpoint, form, &auth); err != nil {
+		return auth, fmt.Errorf("device login failed: %w", err)
+	}
+	if auth.Interval <= 0 {
+		auth.Interval = 5
+	}
+	if auth.ExpiresIn <= 0 {
+		auth.ExpiresIn = 600
+	}
+	return auth, nil
+}
+
+//PollDeviceToken polls the token endpoint until the user approves (or denies) the device login, it expires or ctx is done
+func (p *Provider) PollDeviceToken(ctx context.Context, clientID string, auth DeviceAuth) (Token, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:device_code"}, "device_code": {auth.DeviceCode}, "client_id": {clientID}}
+	interval := time.Duration(auth.Interval) * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return Token{}, ctx.Err()
+		case <-time.After(interval):
+		}
+		var token Token
+		err := p.postForm(ctx, d.TokenEndpoint, form, &token)
+		var te *tokenError
+		switch {
+		case err == nil:
+			return token, nil
+		case errors.As(err, &te) && te.Code == "authorization_pending":
+		case errors.As(err, &te) && te.Code == "slow_down":
+			interval += 5 * time.Second
+		case errors.As(err, &te) && te.Code == "access_denied":
+			return Token{}, errors.New("device login denied")
+		case errors.As(err, &te) && te.Code == "expired_token":
+			return Token{}, errors.New("device login expired, login again")
+		default:
+			return Token{}, fmt.Errorf("device login failed: %w", err)
+		}
+	}
+}
+
+//Refresh exchanges a provider refresh token for new tokens
+func (p *Provider) Refresh(ctx context.Context, clientID string, refreshToken string) (Token, error) {
+	var token Token
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return token, err
+	}
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}, "client_id": {clientID}}
+	if err := p.postForm(ctx, d.TokenEndpoint, form, &token); err != nil {
+		return token, fmt.Errorf("OIDC refresh failed: %w", err)
+	}
+	if token.RefreshToken == "" { //refresh token not rotated
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+//postForm posts form to endpoint and decodes the JSON response into v. An OAuth2 error response is returned as *tokenError.
+func (p *Provider) postForm(ctx context.Context, endpoint string, form url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		te := &tokenError{}
+		if json.NewDecoder(resp.Body).Decode(te) == nil && te.Code != "" {
+			return te
+		}
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+//Error implements error
+func (e *tokenError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+//Expired returns true if a token is expired (or not a JWT), for client use, the token is parsed unverified.
+func Expired(rawToken string) bool {
+	var p jwt.Parser
+	claims := jwt.MapClaims{}
+	if _, _, err := p.ParseUnverified(rawToken, claims); err != nil {
+		return true
+	}
+	return !claims.VerifyExpiresAt(time.Now().Unix(), true)
+}
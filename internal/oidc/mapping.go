@@ -0,0 +1,137 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/footfish/numan"
+)
+
+//Mapping maps the claims of a verified token to a numan username, roles & scope
+type Mapping struct {
+	UsernameClaim string                 //claim holding the username of new users (the whole value, ex. an email), default preferred_username
+	GroupsClaim   string                 //claim listing the users groups, default groups
+	GroupRoles    map[string][]string    //roles granted by group
+	GroupScopes   map[string]numan.Scope //scope granted by group, merged for all of the users groups
+	DefaultRoles  []string               //roles of users in no mapped group, if empty they have no roles
+}
+
+//Identity is a token user mapped to numan
+type Identity struct {
+	Subject  string //sub claim, the provider account (linked to the user it adds)
+	Username string
+	Roles    []string //roles of the users mapped groups (sorted) OR DefaultRoles
+	Scope    numan.Scope
+	Mapped   bool //true if the user is in a mapped group (roles & scope come from the provider)
+}
+
+//Map returns the identity of a verified token
+func (m Mapping) Map(claims Claims) (Identity, error) {
+	usernameClaim, groupsClaim := m.UsernameClaim, m.GroupsClaim
+	if usernameClaim == "" {
+		usernameClaim = "preferred_username"
+	}
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	name := strings.ToLower(claims.String(usernameClaim))
+	id := Identity{Subject: claims.String("sub"), Username: name}
+	if id.Subject == "" {
+		return id, errors.New("OIDC token has no sub claim")
+	}
+	if !(&numan.User{Username: name}).ValidUsername() {
+		return id, fmt.Errorf("OIDC claim %s '%s' is not a valid username", usernameClaim, claims.String(usernameClaim))
+	}
+	roles := map[string]bool{}
+	for _, group := range claims.Strings(groupsClaim) {
+		groupRoles, hasRoles := m.GroupRoles[group]
+		groupScope, hasScope := m.GroupScopes[group]
+		if !hasRoles && !hasScope {
+			continue
+		}
+		id.Mapped = true
+		for _, role := range groupRoles {
+			roles[role] = true
+		}
+		id.Scope.Domains = append(id.Scope.Domains, groupScope.Domains...)
+		id.Scope.Carriers = append(id.Scope.Carriers, groupScope.Carriers...)
+		id.Scope.Prefixes = append(id.Scope.Prefixes, groupScope.Prefixes...)
+	}
+	if !id.Mapped {
+		id.Roles = m.DefaultRoles
+		return id, nil
+	}
+	for role := range roles {
+		id.Roles = append(id.Roles, role)
+	}
+	sort.Strings(id.Roles)
+	return id, nil
+}
+
+//ParseGroupRoles parses group roles from config, a comma separated list of group=role (repeat a group for several roles)
+//ex. numan-admins=admin,numan-ops=user,numan-ops=auditor
+func ParseGroupRoles(config string) (map[string][]string, error) {
+	pairs, err := groupValues(config)
+	if err != nil {
+		return nil, err
+	}
+	groupRoles := map[string][]string{}
+	for _, pair := range pairs {
+		group, value := pair[0], pair[1]
+		if !numan.ValidRoleName(value) {
+			return nil, fmt.Errorf("invalid role '%s' for group '%s'", value, group)
+		}
+		groupRoles[group] = append(groupRoles[group], value)
+	}
+	return groupRoles, nil
+}
+
+//ParseGroupScopes parses group scopes from config, a comma separated list of group=kind:value where kind is domain, carrier or prefix
+//ex. ireland=prefix:353,ireland=domain:ie.example.com
+func ParseGroupScopes(config string) (map[string]numan.Scope, error) {
+	pairs, err := groupValues(config)
+	if err != nil {
+		return nil, err
+	}
+	groupScopes := map[string]numan.Scope{}
+	for _, pair := range pairs {
+		group, value := pair[0], pair[1]
+		kindValue := strings.SplitN(value, ":", 2)
+		if len(kindValue) != 2 || kindValue[1] == "" {
+			return nil, fmt.Errorf("invalid scope '%s' for group '%s', use kind:value", value, group)
+		}
+		scope := groupScopes[group]
+		switch kindValue[0] {
+		case "domain":
+			scope.Domains = append(scope.Domains, kindValue[1])
+		case "carrier":
+			scope.Carriers = append(scope.Carriers, kindValue[1])
+		case "prefix":
+			scope.Prefixes = append(scope.Prefixes, kindValue[1])
+		default:
+			return nil, fmt.Errorf("invalid scope kind '%s' for group '%s' (use domain, carrier or prefix)", kindValue[0], group)
+		}
+		if err := scope.Valid(); err != nil {
+			return nil, err
+		}
+		groupScopes[group] = scope
+	}
+	return groupScopes, nil
+}
+
+//groupValues splits a comma separated list of group=value
+func groupValues(config string) (pairs [][2]string, err error) {
+	for _, item := range strings.Split(config, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		pair := strings.SplitN(item, "=", 2)
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" {
+			return nil, errors.New("invalid group mapping '" + item + "', use group=value")
+		}
+		pairs = append(pairs, [2]string{strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])})
+	}
+	return pairs, nil
+}
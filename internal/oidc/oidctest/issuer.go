@@ -0,0 +1,194 @@
+//Package oidctest is a local stand-in OpenID Connect provider for tests.
+//It serves discovery, a JWKS (one RS256 key), device code login and the token endpoint.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//ClientID is the client id (audience) tokens are issued for
+const ClientID = "numan"
+
+//Issuer is a stand-in identity provider, see NewIssuer
+type Issuer struct {
+	URL    string
+	server *httptest.Server
+	mu     sync.Mutex
+	kid    string
+	key    *rsa.PrivateKey
+	device map[string]map[string]interface{} //claims by device code, nil until approved
+	users  map[string]map[string]interface{} //claims by refresh token
+	seq    int
+}
+
+//NewIssuer starts a stand-in identity provider, it must be closed after use
+func NewIssuer() (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	iss := &Issuer{kid: "test-1", key: key, device: map[string]map[string]interface{}{}, users: map[string]map[string]interface{}{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", iss.discovery)
+	mux.HandleFunc("/jwks", iss.jwks)
+	mux.HandleFunc("/device", iss.deviceAuthorization)
+	mux.HandleFunc("/token", iss.token)
+	iss.server = httptest.NewServer(mux)
+	iss.URL = iss.server.URL
+	return iss, nil
+}
+
+//Close stops the issuer
+func (iss *Issuer) Close() {
+	iss.server.Close()
+}
+
+//Token returns a signed ID token for claims, iss, aud (ClientID), iat & exp (1 hour) are added unless set
+func (iss *Issuer) Token(claims map[string]interface{}) string {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	return iss.sign(claims)
+}
+
+//RotateKey replaces the signing key (and kid), tokens signed before are no longer valid
+func (iss *Issuer) RotateKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	iss.seq++
+	iss.key, iss.kid = key, fmt.Sprintf("test-%d", iss.seq+1)
+	return nil
+}
+
+//JWKS returns the JSON web key set served by the issuer
+func (iss *Issuer) JWKS() []byte {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	data, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": iss.kid,
+		"n":   base64.RawURLEncoding.EncodeToString(iss.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(iss.key.E)).Bytes()),
+	}}})
+	return data
+}
+
+//Approve approves all pending device logins as a user with claims
+func (iss *Issuer) Approve(claims map[string]interface{}) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	for code, c := range iss.device {
+		if c == nil {
+			iss.device[code] = claims
+		}
+	}
+}
+
+//sign signs claims with defaults added, caller must hold lock
+func (iss *Issuer) sign(claims map[string]interface{}) string {
+	mc := jwt.MapClaims{"iss": iss.URL, "aud": ClientID, "iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix()}
+	for k, v := range claims {
+		mc[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mc)
+	token.Header["kid"] = iss.kid
+	signed, _ := token.SignedString(iss.key)
+	return signed
+}
+
+func (iss *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                        iss.URL,
+		"jwks_uri":                      iss.URL + "/jwks",
+		"token_endpoint":                iss.URL + "/token",
+		"device_authorization_endpoint": iss.URL + "/device",
+	})
+}
+
+func (iss *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(iss.JWKS())
+}
+
+func (iss *Issuer) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	iss.seq++
+	code := fmt.Sprintf("device-%d", iss.seq)
+	iss.device[code] = nil
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"device_code":      code,
+		"user_code":        fmt.Sprintf("ABCD-%04d", iss.seq),
+		"verification_uri": iss.URL + "/activate",
+		"expires_in":       600,
+		"interval":         1,
+	})
+}
+
+func (iss *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	if r.FormValue("client_id") != ClientID {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	var claims map[string]interface{}
+	switch r.FormValue("grant_type") {
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		c, ok := iss.device[r.FormValue("device_code")]
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expired_token"})
+			return
+		}
+		if c == nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "authorization_pending"})
+			return
+		}
+		delete(iss.device, r.FormValue("device_code"))
+		claims = c
+	case "refresh_token":
+		c, ok := iss.users[r.FormValue("refresh_token")]
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(iss.users, r.FormValue("refresh_token"))
+		claims = c
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	iss.seq++
+	refresh := fmt.Sprintf("refresh-%d", iss.seq)
+	iss.users[refresh] = claims
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id_token":      iss.sign(claims),
+		"access_token":  fmt.Sprintf("opaque-%d", iss.seq),
+		"refresh_token": refresh,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+//writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,320 @@
+//Package oidc verifies tokens from an external OpenID Connect identity provider (SSO) and runs the device code login flow.
+//Only what numan needs is implemented: discovery, JWKS (RS256, ES256 & EdDSA keys), token verification, device code & refresh grants.
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/footfish/numan"
+)
+
+//jwksRefetch is the minimum time between JWKS fetches, an unknown kid triggers a fetch (key rotation at the provider)
+var jwksRefetch = time.Minute
+
+//Config configures an identity provider
+type Config struct {
+	Issuer   string //issuer URL, discovery is at <Issuer>/.well-known/openid-configuration
+	Audience string //client id the tokens must be issued for (aud claim), not needed for device login
+	JWKSURL  string //optional, overrides the discovered jwks_uri
+	JWKSFile string //optional, keys are loaded from a file (no fetching)
+}
+
+//Discovery is the provider metadata (see OpenID Connect Discovery)
+type Discovery struct {
+	Issuer                      string `json:"issuer"`
+	JWKSURI                     string `json:"jwks_uri"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+//Provider is an identity provider, it is safe for concurrent use
+type Provider struct {
+	config    Config
+	client    *http.Client
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      map[string]interface{} //public keys by kid
+	fetched   time.Time              //last JWKS fetch
+}
+
+//Claims are the claims of a verified token
+type Claims map[string]interface{}
+
+//NewProvider instantiates a Provider. Keys are loaded from config.JWKSFile if set, otherwise fetched on first use.
+func NewProvider(config Config) (*Provider, error) {
+	if config.Issuer == "" {
+		return nil, errors.New("OIDC issuer required")
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	p := &Provider{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+	if config.JWKSFile != "" {
+		data, err := ioutil.ReadFile(config.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read JWKS file: %w", err)
+		}
+		if p.keys, err = parseJWKS(data); err != nil {
+			return nil, fmt.Errorf("invalid JWKS file %s: %w", config.JWKSFile, err)
+		}
+	}
+	return p, nil
+}
+
+//Issuer returns the provider issuer URL
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+//Discover returns the provider metadata, fetched once
+func (p *Provider) Discover(ctx context.Context) (Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return *p.discovery, nil
+	}
+	var d Discovery
+	if err := p.getJSON(ctx, p.config.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return d, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.config.Issuer {
+		return d, fmt.Errorf("OIDC discovery issuer '%s' does not match '%s'", d.Issuer, p.config.Issuer)
+	}
+	p.discovery = &d
+	return d, nil
+}
+
+//Verify checks the signature, issuer, audience & expiry of an ID or access token (JWT) and returns its claims
+func (p *Provider) Verify(ctx context.Context, rawToken string) (Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.Alg() {
+		case "RS256", "ES256", numan.AlgEdDSA:
+		default:
+			return nil, fmt.Errorf("unsupported signing method %s", token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Auth error: invalid OIDC token: %w", err)
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) { //expiry required
+		return nil, errors.New("Auth error: OIDC token has no expiry")
+	}
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("Auth error: OIDC token issuer '%s' not accepted", iss)
+	}
+	if p.config.Audience != "" && !Claims(claims).hasAudience(p.config.Audience) {
+		return nil, errors.New("Auth error: OIDC token not issued for this audience")
+	}
+	return Claims(claims), nil
+}
+
+//key returns the public key for kid (or the only key if the token has no kid), fetching the JWKS if kid is unknown
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	key, ok := p.findKey(kid)
+	canFetch := p.config.JWKSFile == "" && time.Since(p.fetched) > jwksRefetch
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !canFetch {
+		return nil, fmt.Errorf("unknown signing key '%s'", kid)
+	}
+	if err := p.fetchKeys(ctx); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok = p.findKey(kid); !ok {
+		return nil, fmt.Errorf("unknown signing key '%s'", kid)
+	}
+	return key, nil
+}
+
+//findKey returns the key for kid, caller must hold lock
+func (p *Provider) findKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+//fetchKeys replaces the keys from the JWKS URL (configured or discovered)
+func (p *Provider) fetchKeys(ctx context.Context) error {
+	url := p.config.JWKSURL
+	if url == "" {
+		d, err := p.Discover(ctx)
+		if err != nil {
+			return err
+		}
+		url = d.JWKSURI
+	}
+	p.mu.Lock()
+	p.fetched = time.Now()
+	p.mu.Unlock()
+	var jwks json.RawMessage
+	if err := p.getJSON(ctx, url, &jwks); err != nil {
+		return fmt.Errorf("JWKS fetch failed: %w", err)
+	}
+	keys, err := parseJWKS(jwks)
+	if err != nil {
+		return fmt.Errorf("invalid JWKS from %s: %w", url, err)
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	return nil
+}
+
+//getJSON fetches url and decodes the JSON response into v
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+//jwk is a JSON web key, only the public key members used are decoded
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+//parseJWKS returns the signature keys of a JWKS document by kid. Unsupported key types are skipped.
+func parseJWKS(data []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no supported signing keys")
+	}
+	return keys, nil
+}
+
+//publicKey returns the public key OR nil if the key type is not supported
+func (k jwk) publicKey() (interface{}, error) {
+	switch {
+	case k.Kty == "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+//decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+//String returns a string claim OR empty
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+//Strings returns a claim which is a list of strings (or a single string) OR nil
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+//hasAudience returns true if the aud claim (string or list) includes audience
+func (c Claims) hasAudience(audience string) bool {
+	for _, aud := range c.Strings("aud") {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package oidc
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/oidc/oidctest"
+)
+
+func TestProvider(t *testing.T) {
+	iss, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iss.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	p, err := NewProvider(Config{Issuer: iss.URL + "/", Audience: oidctest.ClientID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OkVerify", func(t *testing.T) {
+		claims, err := p.Verify(ctx, iss.Token(map[string]interface{}{"preferred_username": "jane", "groups": []string{"ops", "sales"}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.String("preferred_username") != "jane" || !reflect.DeepEqual(claims.Strings("groups"), []string{"ops", "sales"}) {
+			t.Fatalf("Verify got %v", claims)
+		}
+	})
+
+	t.Run("OkKeyRotation", func(t *testing.T) {
+		defer func(d time.Duration) { jwksRefetch = d }(jwksRefetch)
+		jwksRefetch = 0
+		if err := iss.RotateKey(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Verify(ctx, iss.Token(map[string]interface{}{"preferred_username": "jane"})); err != nil {
+			t.Fatal("Token signed with rotated key refused:", err)
+		}
+	})
+
+	t.Run("ErrVerify", func(t *testing.T) {
+		for name, claims := range map[string]map[string]interface{}{
+			"audience": {"aud": "other"},
+			"issuer":   {"iss": "https://evil.example.com"},
+			"expired":  {"exp": time.Now().Add(-time.Minute).Unix()},
+		} {
+			if _, err := p.Verify(ctx, iss.Token(claims)); err == nil {
+				t.Errorf("Verified token with bad %s", name)
+			}
+		}
+		if _, err := p.Verify(ctx, "not.a.token"); err == nil {
+			t.Error("Verified garbage token")
+		}
+	})
+
+	t.Run("OkJWKSFile", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "jwks.json")
+		if err := ioutil.WriteFile(file, iss.JWKS(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		fp, err := NewProvider(Config{Issuer: iss.URL, Audience: oidctest.ClientID, JWKSFile: file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fp.Verify(ctx, iss.Token(nil)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("OkDeviceLogin", func(t *testing.T) {
+		auth, err := p.StartDeviceLogin(ctx, oidctest.ClientID, []string{"openid"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth.UserCode == "" || auth.VerificationURI == "" {
+			t.Fatalf("StartDeviceLogin got %+v", auth)
+		}
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			iss.Approve(map[string]interface{}{"preferred_username": "jane"})
+		}()
+		token, err := p.PollDeviceToken(ctx, oidctest.ClientID, auth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Expired(token.Raw()) || token.RefreshToken == "" {
+			t.Fatalf("PollDeviceToken got %+v", token)
+		}
+		refreshed, err := p.Refresh(ctx, oidctest.ClientID, token.RefreshToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims, err := p.Verify(ctx, refreshed.Raw()); err != nil || claims.String("preferred_username") != "jane" {
+			t.Fatalf("Refresh got %v, %v", claims, err)
+		}
+	})
+}
+
+func TestMapping(t *testing.T) {
+	groupRoles, err := ParseGroupRoles("ops=user, ops=viewer,admins=admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupScopes, err := ParseGroupScopes("ireland=prefix:353,ireland=domain:ie.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Mapping{GroupRoles: groupRoles, GroupScopes: groupScopes, DefaultRoles: []string{numan.RoleViewer}}
+
+	t.Run("OkGroups", func(t *testing.T) {
+		id, err := m.Map(Claims{"sub": "u-1", "preferred_username": "Jane@example.com", "groups": []interface{}{"ops", "ireland", "unmapped"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Identity{Subject: "u-1", Username: "jane@example.com", Roles: []string{"user", "viewer"}, Scope: numan.Scope{Domains: []string{"ie.example.com"}, Prefixes: []string{"353"}}, Mapped: true}
+		if !reflect.DeepEqual(id, want) {
+			t.Fatalf("Map got %+v, want %+v", id, want)
+		}
+	})
+
+	t.Run("OkDefaultRoles", func(t *testing.T) {
+		id, err := m.Map(Claims{"sub": "u-1", "preferred_username": "jane", "groups": "unmapped"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.Mapped || !reflect.DeepEqual(id.Roles, []string{numan.RoleViewer}) {
+			t.Fatalf("Map got %+v", id)
+		}
+	})
+
+	t.Run("ErrUsername", func(t *testing.T) {
+		if _, err := m.Map(Claims{"sub": "u-1", "preferred_username": "j"}); err == nil {
+			t.Fatal("Mapped invalid username")
+		}
+		if _, err := m.Map(Claims{"preferred_username": "jane"}); err == nil {
+			t.Fatal("Mapped token without sub")
+		}
+	})
+
+	t.Run("ErrParse", func(t *testing.T) {
+		if _, err := ParseGroupRoles("ops"); err == nil {
+			t.Error("Parsed group roles without role")
+		}
+		if _, err := ParseGroupScopes("ops=country:ie"); err == nil {
+			t.Error("Parsed group scope with unknown kind")
+		}
+	})
+}
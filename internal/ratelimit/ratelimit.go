@@ -0,0 +1,159 @@
+//Package ratelimit limits calls per caller & method with token buckets (see numan.RateLimit).
+package ratelimit
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+//idleAfter is how long the bucket of an idle caller is kept (& its usage listed)
+const idleAfter = 10 * time.Minute
+
+//Limiter applies rate limits to callers, each caller has a token bucket per method
+type Limiter struct {
+	mu      sync.Mutex
+	limits  []numan.RateLimit
+	buckets map[bucketKey]*bucket
+	pruned  time.Time
+	now     func() time.Time
+}
+
+//bucketKey identifies the bucket of a caller for a method
+type bucketKey struct {
+	key    string
+	method string
+}
+
+//bucket is a token bucket, tokens are added at limit.Rate up to limit.Burst
+type bucket struct {
+	limit    numan.RateLimit
+	tokens   float64
+	updated  time.Time
+	allowed  int64
+	limited  int64
+	lastSeen time.Time
+}
+
+//NewLimiter returns a Limiter of limits (none, calls are not limited)
+func NewLimiter(limits []numan.RateLimit) *Limiter {
+	return &Limiter{limits: limits, buckets: map[bucketKey]*bucket{}, now: time.Now}
+}
+
+//SetLimits replaces the limits (ex. on config reload), buckets start full
+func (l *Limiter) SetLimits(limits []numan.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.buckets = map[bucketKey]*bucket{}
+}
+
+//Method returns the limited method name of a gRPC full method name, the service without package (ex. /numan.v1.Numbering/List -> Numbering/List).
+//Versions of a service share limits & buckets.
+func Method(fullMethod string) string {
+	method := strings.TrimPrefix(fullMethod, "/")
+	slash := strings.Index(method, "/")
+	if dot := strings.LastIndex(method[:slash+1], "."); dot >= 0 {
+		return method[dot+1:]
+	}
+	return method
+}
+
+//limitOf returns the limit of method, Service/Method before Service/* before *. False if not limited.
+func (l *Limiter) limitOf(method string) (numan.RateLimit, bool) {
+	service := strings.SplitN(method, "/", 2)[0]
+	for _, name := range []string{method, service + "/*", "*"} {
+		for _, limit := range l.limits {
+			if limit.Method == name {
+				return limit, true
+			}
+		}
+	}
+	return numan.RateLimit{}, false
+}
+
+//Allow takes a token from the bucket of caller key for method (see Method).
+//Returns false and the wait until a token is available if the bucket is empty. Methods without a limit are allowed.
+func (l *Limiter) Allow(key string, method string) (bool, time.Duration) {
+	return l.use(key, method, true)
+}
+
+//Ready is Allow without taking the token, ex. to refuse authentication attempts of a caller whose failures are limited (see Allow)
+func (l *Limiter) Ready(key string, method string) (bool, time.Duration) {
+	return l.use(key, method, false)
+}
+
+//use checks the bucket of caller key for method has a token, taking it if take is set
+func (l *Limiter) use(key string, method string, take bool) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.prune(now)
+	limit, ok := l.limitOf(method)
+	if !ok {
+		return true, 0
+	}
+	b := l.buckets[bucketKey{key, method}]
+	if b == nil {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), updated: now}
+		l.buckets[bucketKey{key, method}] = b
+	}
+	b.refill(now)
+	b.lastSeen = now
+	if b.tokens < 1 {
+		b.limited++
+		return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	if take {
+		b.tokens--
+		b.allowed++
+	}
+	return true, 0
+}
+
+//refill adds the tokens due since the last update
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.updated).Seconds() * b.limit.Rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.updated = now
+}
+
+//prune removes the buckets of callers idle for idleAfter (full again), at most once a minute. Caller must hold lock.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+	for k, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleAfter {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+//ListRateLimits implements RateLimitService.ListRateLimits(), usage is sorted by caller & method
+func (l *Limiter) ListRateLimits(ctx context.Context) ([]numan.RateLimit, []numan.RateLimitUsage, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.prune(now)
+	usage := []numan.RateLimitUsage{}
+	for k, b := range l.buckets {
+		b.refill(now)
+		usage = append(usage, numan.RateLimitUsage{Key: k.key, Method: k.method, Limit: b.limit.Method, Tokens: b.tokens,
+			Allowed: b.allowed, Limited: b.limited, LastSeen: b.lastSeen.Unix()})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Key != usage[j].Key {
+			return usage[i].Key < usage[j].Key
+		}
+		return usage[i].Method < usage[j].Method
+	})
+	return append([]numan.RateLimit{}, l.limits...), usage, nil
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+func TestMethod(t *testing.T) {
+	for fullMethod, want := range map[string]string{
+		"/numan.v1.Numbering/List":               "Numbering/List",
+		"/grpc.Numbering/List":                   "Numbering/List",
+		"/Numbering/List":                        "Numbering/List",
+		"/grpc.health.v1.Health/Check":           "Health/Check",
+		"/numan.v1.RateLimit/ListRateLimits":     "RateLimit/ListRateLimits",
+		"/grpc.reflection.v1.ServerReflection/X": "ServerReflection/X",
+	} {
+		if got := Method(fullMethod); got != want {
+			t.Errorf("%s got %s, want %s", fullMethod, got, want)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := NewLimiter([]numan.RateLimit{{Method: "*", Rate: 10, Burst: 10}, {Method: "Numbering/*", Rate: 5, Burst: 5}, {Method: "Numbering/List", Rate: 1, Burst: 2}})
+	l.now = func() time.Time { return now }
+
+	//most specific limit applies, per caller & method
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("user:alice", "Numbering/List"); !ok {
+			t.Fatalf("call %d limited", i)
+		}
+	}
+	ok, wait := l.Allow("user:alice", "Numbering/List")
+	if ok || wait != time.Second {
+		t.Fatalf("got %v wait %v, want limited for 1s", ok, wait)
+	}
+	if ok, _ := l.Allow("user:bob", "Numbering/List"); !ok {
+		t.Fatal("other caller limited")
+	}
+	for i := 0; i < 5; i++ {
+		if ok, _ := l.Allow("user:alice", "Numbering/View"); !ok {
+			t.Fatalf("Numbering/View call %d limited", i)
+		}
+	}
+	if ok, _ := l.Allow("user:alice", "Numbering/View"); ok {
+		t.Fatal("Numbering/* not applied")
+	}
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("user:alice", "User/ListUsers"); !ok {
+			t.Fatalf("User/ListUsers call %d limited", i)
+		}
+	}
+	if ok, _ := l.Allow("user:alice", "User/ListUsers"); ok {
+		t.Fatal("* not applied")
+	}
+
+	//ready doesn't take tokens
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Ready("source:10.0.0.1", "Numbering/List"); !ok {
+			t.Fatalf("ready %d refused", i)
+		}
+	}
+	l.Allow("source:10.0.0.1", "Numbering/List")
+	l.Allow("source:10.0.0.1", "Numbering/List")
+	if ok, wait := l.Ready("source:10.0.0.1", "Numbering/List"); ok || wait != time.Second {
+		t.Fatalf("ready got %v wait %v, want refused for 1s", ok, wait)
+	}
+
+	//refills at rate up to burst
+	now = now.Add(500 * time.Millisecond)
+	if ok, wait := l.Allow("user:alice", "Numbering/List"); ok || wait != 500*time.Millisecond {
+		t.Fatalf("got %v wait %v, want limited for 500ms", ok, wait)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("user:alice", "Numbering/List"); !ok {
+		t.Fatal("not refilled")
+	}
+	now = now.Add(time.Hour)
+	limits, usage, err := l.ListRateLimits(context.Background())
+	if err != nil || len(limits) != 3 {
+		t.Fatalf("got limits %+v (err %v)", limits, err)
+	}
+	if len(usage) != 0 {
+		t.Fatalf("idle callers not pruned, got %+v", usage)
+	}
+
+	//methods without a limit are allowed
+	l.SetLimits([]numan.RateLimit{{Method: "Numbering/List", Rate: 1, Burst: 1}})
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("user:alice", "User/ListUsers"); !ok {
+			t.Fatal("unlimited method limited")
+		}
+	}
+}
+
+func TestListRateLimits(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := NewLimiter([]numan.RateLimit{{Method: "Numbering/*", Rate: 1, Burst: 3}})
+	l.now = func() time.Time { return now }
+	l.Allow("user:bob", "Numbering/View")
+	for i := 0; i < 4; i++ {
+		l.Allow("apikey:7", "Numbering/List")
+	}
+	now = now.Add(500 * time.Millisecond)
+	_, usage, err := l.ListRateLimits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []numan.RateLimitUsage{
+		{Key: "apikey:7", Method: "Numbering/List", Limit: "Numbering/*", Tokens: 0.5, Allowed: 3, Limited: 1, LastSeen: 1600000000},
+		{Key: "user:bob", Method: "Numbering/View", Limit: "Numbering/*", Tokens: 2.5, Allowed: 1, LastSeen: 1600000000},
+	}
+	if len(usage) != len(want) {
+		t.Fatalf("got %+v, want %+v", usage, want)
+	}
+	for i := range want {
+		if usage[i] != want[i] {
+			t.Errorf("got %+v, want %+v", usage[i], want[i])
+		}
+	}
+}
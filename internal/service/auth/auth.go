@@ -2,20 +2,78 @@ package auth
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
 )
 
-//checkUserRole checks given role against the user role extracted from JWT token (in context)
-func checkUserRole(requiredRole string, ctx context.Context) error {
-	user := &numan.User{}
+//errOutOfScope is returned for numbers outside the users scope
+var errOutOfScope = numan.Errorf(numan.ErrPermissionDenied, "Number outside user scope")
+
+//authorizer checks the access token in context
+type authorizer struct {
+	tokens  *datastore.TokenStore  //revocation list
+	numbers numan.NumberingService //number lookup for scope checks
+}
+
+//newAuthorizer instantiates an authorizer
+func newAuthorizer(store *datastore.Store) authorizer {
+	return authorizer{tokens: datastore.NewTokenStore(store), numbers: datastore.NewNumberingService(store)}
+}
+
+//checkPermission checks the user extracted from JWT token (in context) has permission through their roles. Revoked tokens are rejected.
+func (a authorizer) checkPermission(permission string, ctx context.Context) error {
+	_, err := a.authorize(permission, ctx)
+	return err
+}
+
+//authorize returns the user extracted from JWT token (in context) if they have permission through their roles. Revoked tokens are rejected.
+//Scoped users are refused permissions not limited to numbers (user, key & webhook admin, history retention, rate limits).
+func (a authorizer) authorize(permission string, ctx context.Context) (numan.User, error) {
+	user := numan.User{}
 	if err := user.SetUserFromToken(fmt.Sprintf("%s", ctx.Value("token"))); err != nil { //Get authenticated user data from token
-		return errors.New("Unexpected Auth error")
+		return user, numan.Errorf(numan.ErrUnauthenticated, "Unexpected Auth error")
+	}
+	if err := a.tokens.CheckToken(user); err != nil {
+		return user, numan.Errorf(numan.ErrUnauthenticated, "Auth error, %w", err)
+	}
+	permissions, err := a.tokens.Permissions(user)
+	if err != nil {
+		return user, numan.Errorf(numan.ErrUnauthenticated, "Auth error, %w", err)
+	}
+	if !numan.HasPermission(permissions, permission) {
+		return user, numan.Errorf(numan.ErrPermissionDenied, "Insufficient user privileges, %s required", permission)
+	}
+	if !user.Scope.Unrestricted() && (permission == numan.PermUsersAdmin || permission == numan.PermKeysAdmin || permission == numan.PermHistoryArchive || permission == numan.PermWebhooksAdmin || permission == numan.PermRateLimitsRead) {
+		return user, numan.Errorf(numan.ErrPermissionDenied, "Insufficient user privileges, %s requires an unscoped user", permission)
+	}
+	return user, nil
+}
+
+//checkScope checks a stored number is in scope.
+//Unknown (ex. deleted) numbers are out of scope, unless the scope only restricts prefixes.
+func (a authorizer) checkScope(ctx context.Context, scope numan.Scope, number *numan.E164) error {
+	if scope.Unrestricted() || number == nil {
+		return nil
+	}
+	if !scope.AllowsE164(*number) {
+		return errOutOfScope
+	}
+	list, err := a.numbers.List(ctx, &numan.NumberFilter{E164: *number})
+	if err != nil {
+		return err
+	}
+	for _, n := range list {
+		if n.E164 == *number {
+			if !scope.Allows(n) {
+				return errOutOfScope
+			}
+			return nil
+		}
 	}
-	if user.Role != requiredRole {
-		return errors.New("Insufficient user privileges")
+	if len(scope.Domains) > 0 || len(scope.Carriers) > 0 {
+		return errOutOfScope
 	}
 	return nil
 }
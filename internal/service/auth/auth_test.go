@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+)
+
+func TestAuthorize(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	users, tokens := datastore.NewUserService(store), datastore.NewTokenStore(store)
+	ctx := context.Background()
+	for _, u := range []numan.User{
+		{Username: "viewer", Password: "x", Roles: []string{numan.RoleViewer}},
+		{Username: "admin", Password: "x", Roles: []string{numan.RoleAdmin}},
+		{Username: "scoped", Password: "x", Roles: []string{numan.RoleAdmin}, Scope: numan.Scope{Domains: []string{"a.com"}}},
+		{Username: "robot", Password: "x", Roles: []string{numan.RoleUser, numan.RoleViewer}},
+	} {
+		if err := users.AddUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//token returns an access token of the stored user username, changed by modify (if set)
+	token := func(username string, modify func(*numan.User)) string {
+		user, err := users.Auth(ctx, username, "")
+		if err != nil || user.UID == 0 {
+			t.Fatalf("user %s not found (err %v)", username, err)
+		}
+		if modify != nil {
+			modify(&user)
+		}
+		if err := user.SetNewAccessToken(); err != nil {
+			t.Fatal(err)
+		}
+		return user.AccessToken
+	}
+	internal := numan.User{Username: "numd", Roles: []string{numan.RoleAdmin}}
+	if err := internal.SetNewAccessToken(); err != nil {
+		t.Fatal(err)
+	}
+	revoked := token("admin", nil)
+	var revokedUser numan.User
+	if err := revokedUser.SetUserFromToken(revoked); err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.RevokeToken(revokedUser.TokenID, time.Now().Add(time.Hour).Unix()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		permission string
+		wantErr    error
+	}{
+		{"role permission", token("viewer", nil), numan.PermNumbersRead, nil},
+		{"no role permission", token("viewer", nil), numan.PermNumbersAdd, numan.ErrPermissionDenied},
+		{"admin all permissions", token("admin", nil), numan.PermUsersAdmin, nil},
+		{"internal token roles", internal.AccessToken, numan.PermKeysAdmin, nil},
+		{"limited roles", token("robot", func(u *numan.User) { u.Roles, u.RolesLimited = []string{numan.RoleViewer}, true }), numan.PermNumbersAdd, numan.ErrPermissionDenied},
+		{"limited roles held", token("robot", func(u *numan.User) { u.Roles, u.RolesLimited = []string{numan.RoleViewer}, true }), numan.PermNumbersRead, nil},
+		{"limited roles not held", token("viewer", func(u *numan.User) { u.Roles, u.RolesLimited = []string{numan.RoleAdmin}, true }), numan.PermNumbersAdd, numan.ErrPermissionDenied},
+		{"scoped number permission", token("scoped", nil), numan.PermNumbersAdd, nil},
+		{"scoped users admin", token("scoped", nil), numan.PermUsersAdmin, numan.ErrPermissionDenied},
+		{"scoped keys admin", token("scoped", nil), numan.PermKeysAdmin, numan.ErrPermissionDenied},
+		{"scoped history archive", token("scoped", nil), numan.PermHistoryArchive, numan.ErrPermissionDenied},
+		{"scoped webhooks admin", token("scoped", nil), numan.PermWebhooksAdmin, numan.ErrPermissionDenied},
+		{"scoped rate limits", token("scoped", nil), numan.PermRateLimitsRead, numan.ErrPermissionDenied},
+		{"revoked token", revoked, numan.PermNumbersRead, numan.ErrUnauthenticated},
+		{"old token version", token("admin", func(u *numan.User) { u.TokenVersion-- }), numan.PermNumbersRead, numan.ErrUnauthenticated},
+		{"deleted user", token("admin", func(u *numan.User) { u.UID = 999 }), numan.PermNumbersRead, numan.ErrUnauthenticated},
+		{"invalid token", "not.a.token", numan.PermNumbersRead, numan.ErrUnauthenticated},
+	}
+	a := newAuthorizer(store)
+	for _, tt := range tests {
+		_, err := a.authorize(tt.permission, context.WithValue(ctx, numan.AuthTokenField, tt.token))
+		if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+			t.Errorf("%s: %s got %v, want %v", tt.name, tt.permission, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// eventService implements the EventService interface
+type eventService struct {
+	next numan.EventService
+	authorizer
+}
+
+// NewEventService instantiates a new EventService.
+func NewEventService(store *datastore.Store) numan.EventService {
+	return &eventService{
+		next:       datastore.NewEventService(store),
+		authorizer: newAuthorizer(store),
+	}
+}
+
+//ListEvents implements EventService.ListEvents()
+//Scoped users only get events of numbers in scope.
+func (s *eventService) ListEvents(ctx context.Context, filter *numan.EventFilter, limit int) ([]numan.NumberEvent, error) {
+	ctx, span := tracing.Start(ctx, "auth.Event.ListEvents")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersRead, ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.next.ListEvents(ctx, filter, limit)
+	if err != nil || user.Scope.Unrestricted() {
+		return events, err
+	}
+	var inScope []numan.NumberEvent
+	for _, e := range events {
+		if eventInScope(user.Scope, e) {
+			inScope = append(inScope, e)
+		}
+	}
+	return inScope, nil
+}
+
+//Watch implements EventService.Watch()
+//Scoped users only get events of numbers in scope.
+func (s *eventService) Watch(ctx context.Context, filter *numan.EventFilter, send func(numan.NumberEvent) error) error {
+	ctx, span := tracing.Start(ctx, "auth.Event.Watch")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersRead, ctx)
+	if err != nil {
+		return err
+	}
+	return s.next.Watch(ctx, filter, func(e numan.NumberEvent) error {
+		if !eventInScope(user.Scope, e) {
+			return nil
+		}
+		return send(e)
+	})
+}
+
+//eventInScope returns true if the number of event e is in scope
+func eventInScope(scope numan.Scope, e numan.NumberEvent) bool {
+	return scope.Allows(numan.Numbering{E164: e.E164, Domain: e.Domain, Carrier: e.Carrier})
+}
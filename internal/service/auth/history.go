@@ -5,40 +5,90 @@ import (
 
 	"github.com/footfish/numan"
 	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 // historyService implements the HistoryService interface
 type historyService struct {
 	next numan.HistoryService
+	authorizer
 }
 
 // NewHistoryService instantiates a new HistoryService.
 func NewHistoryService(store *datastore.Store) numan.HistoryService {
 	return &historyService{
-		next: datastore.NewHistoryService(store),
+		next:       datastore.NewHistoryService(store),
+		authorizer: newAuthorizer(store),
 	}
 }
 
 //AddHistory  implements HistoryService.AddHistory()
 func (s *historyService) AddHistory(ctx context.Context, historyEntry numan.History) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.History.AddHistory")
+	defer span.End()
+	user, err := s.authorize(numan.PermHistoryWrite, ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.checkScope(ctx, user.Scope, &historyEntry.E164); err != nil {
 		return err
 	}
 	return s.next.AddHistory(ctx, historyEntry)
 }
 
 //ListHistoryByNumber implements HistoryService.ListHistoryByNumber()
-func (s *historyService) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164) (history []numan.History, err error) {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+func (s *historyService) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164, archived bool) (history []numan.History, err error) {
+	ctx, span := tracing.Start(ctx, "auth.History.ListHistoryByNumber")
+	defer span.End()
+	user, err := s.authorize(numan.PermHistoryRead, ctx)
+	if err != nil {
 		return history, err
 	}
-	return s.next.ListHistoryByNumber(ctx, phoneNumber)
+	if err := s.checkScope(ctx, user.Scope, &phoneNumber); err != nil {
+		return history, err
+	}
+	return s.next.ListHistoryByNumber(ctx, phoneNumber, archived)
 }
 
 //ListHistoryByOwnerID implements HistoryService.ListHistoryByUserId()
-func (s *historyService) ListHistoryByOwnerID(ctx context.Context, ownerID int64) (history []numan.History, err error) {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+//Scoped users only get entries for numbers in scope.
+func (s *historyService) ListHistoryByOwnerID(ctx context.Context, ownerID int64, archived bool) (history []numan.History, err error) {
+	ctx, span := tracing.Start(ctx, "auth.History.ListHistoryByOwnerID")
+	defer span.End()
+	user, err := s.authorize(numan.PermHistoryRead, ctx)
+	if err != nil {
+		return history, err
+	}
+	history, err = s.next.ListHistoryByOwnerID(ctx, ownerID, archived)
+	if err != nil || user.Scope.Unrestricted() {
 		return history, err
 	}
-	return s.next.ListHistoryByOwnerID(ctx, ownerID)
+	var filtered []numan.History
+	inScope := map[numan.E164]bool{}
+	for _, h := range history {
+		allowed, checked := inScope[h.E164]
+		if !checked {
+			number := h.E164
+			err := s.checkScope(ctx, user.Scope, &number)
+			if err != nil && err != errOutOfScope {
+				return nil, err
+			}
+			allowed = err == nil
+			inScope[h.E164] = allowed
+		}
+		if allowed {
+			filtered = append(filtered, h)
+		}
+	}
+	return filtered, nil
+}
+
+//ArchiveHistory implements HistoryService.ArchiveHistory()
+func (s *historyService) ArchiveHistory(ctx context.Context, policy numan.RetentionPolicy) (int64, error) {
+	ctx, span := tracing.Start(ctx, "auth.History.ArchiveHistory")
+	defer span.End()
+	if err := s.checkPermission(numan.PermHistoryArchive, ctx); err != nil {
+		return 0, err
+	}
+	return s.next.ArchiveHistory(ctx, policy)
 }
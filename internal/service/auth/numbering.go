@@ -5,75 +5,125 @@ import (
 
 	"github.com/footfish/numan"
 	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 // numberingService implements the NumberingService interface
 type numberingService struct {
 	next numan.NumberingService
+	authorizer
 }
 
 // NewNumberService instantiates a new NumberService.
 func NewNumberingService(store *datastore.Store) numan.NumberingService {
 	return &numberingService{
-		next: datastore.NewNumberingService(store),
+		next:       datastore.NewNumberingService(store),
+		authorizer: newAuthorizer(store),
 	}
 }
 
 // Add implements NumberingService.Add()
 func (s *numberingService) Add(ctx context.Context, number *numan.Numbering) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Add")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersAdd, ctx)
+	if err != nil {
 		return err
 	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if number != nil && !user.Scope.Allows(*number) {
+		return errOutOfScope
+	}
 	return s.next.Add(ctx, number) //storage
 }
 
-//AddGroup not implemented
-func (s *numberingService) AddGroup(ctx context.Context) {
-}
-
 //List implements NumberingService.List()
 func (s *numberingService) List(ctx context.Context, filter *numan.NumberFilter) ([]numan.Numbering, error) {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.List")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersRead, ctx)
+	if err != nil {
 		return []numan.Numbering{}, err
 	}
-	return s.next.List(ctx, filter)
+	list, err := s.next.List(ctx, filter)
+	return user.Scope.Filter(list), err
 }
 
 //ListOwnerID implements NumberingService.ListOwnerID()
 func (s *numberingService) ListOwnerID(ctx context.Context, oid int64) ([]numan.Numbering, error) {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.ListOwnerID")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersRead, ctx)
+	if err != nil {
 		return []numan.Numbering{}, err
 	}
-	return s.next.ListOwnerID(ctx, oid)
+	list, err := s.next.ListOwnerID(ctx, oid)
+	return user.Scope.Filter(list), err
 }
 
 //Summary implements NumberingService.Summary()
+//Scoped users get a summary of the numbers in scope.
 func (s *numberingService) Summary(ctx context.Context) (string, error) {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Summary")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersRead, ctx)
+	if err != nil {
 		return err.Error(), err
 	}
-	return s.next.Summary(ctx)
+	if user.Scope.Unrestricted() {
+		return s.next.Summary(ctx)
+	}
+	list, err := s.next.List(ctx, &numan.NumberFilter{})
+	if err != nil {
+		return "", err
+	}
+	return numan.FormatSummary(user.Scope.Filter(list)), nil
 }
 
 //Delete implements NumberingService.Delete()
 func (s *numberingService) Delete(ctx context.Context, phonenumber *numan.E164) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Delete")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersDelete, ctx)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if err := s.checkScope(ctx, user.Scope, phonenumber); err != nil {
 		return err
 	}
 	return s.next.Delete(ctx, phonenumber)
 }
 
 //View implements NumberingService.View()
+//Scoped users only see the numbers in scope.
 func (s *numberingService) View(ctx context.Context, number *numan.E164) (string, error) {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.View")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersRead, ctx)
+	if err != nil {
 		return err.Error(), err
 	}
-	return s.next.View(ctx, number)
+	if user.Scope.Unrestricted() || number == nil {
+		return s.next.View(ctx, number)
+	}
+	list, err := s.next.List(ctx, &numan.NumberFilter{E164: *number})
+	if err != nil {
+		return "", err
+	}
+	return numan.FormatView(user.Scope.Filter(list)), nil
 }
 
 //Reserve implements NumberingService.Reserve()
 func (s *numberingService) Reserve(ctx context.Context, number *numan.E164, ownerID *int64, untilTS *int64) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Reserve")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersAllocate, ctx)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if err := s.checkScope(ctx, user.Scope, number); err != nil {
 		return err
 	}
 	return s.next.Reserve(ctx, number, ownerID, untilTS)
@@ -81,7 +131,14 @@ func (s *numberingService) Reserve(ctx context.Context, number *numan.E164, owne
 
 //Allocate implements NumberingService.Allocate()
 func (s *numberingService) Allocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Allocate")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersAllocate, ctx)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if err := s.checkScope(ctx, user.Scope, number); err != nil {
 		return err
 	}
 	return s.next.Allocate(ctx, number, ownerID)
@@ -89,7 +146,14 @@ func (s *numberingService) Allocate(ctx context.Context, number *numan.E164, own
 
 //DeAllocate implements NumberingService.DeAllocate()
 func (s *numberingService) DeAllocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.DeAllocate")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersAllocate, ctx)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if err := s.checkScope(ctx, user.Scope, number); err != nil {
 		return err
 	}
 	return s.next.DeAllocate(ctx, number, ownerID)
@@ -97,7 +161,14 @@ func (s *numberingService) DeAllocate(ctx context.Context, number *numan.E164, o
 
 //Portout implements NumberingService.Portout()
 func (s *numberingService) Portout(ctx context.Context, number *numan.E164, PortoutTS *int64) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Portout")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersPort, ctx)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if err := s.checkScope(ctx, user.Scope, number); err != nil {
 		return err
 	}
 	return s.next.Portout(ctx, number, PortoutTS)
@@ -105,8 +176,43 @@ func (s *numberingService) Portout(ctx context.Context, number *numan.E164, Port
 
 //Portin implements NumberingService.Portin()
 func (s *numberingService) Portin(ctx context.Context, number *numan.E164, PortinTS *int64) error {
-	if err := checkUserRole(numan.RoleUser, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Portin")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersPort, ctx)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if err := s.checkScope(ctx, user.Scope, number); err != nil {
 		return err
 	}
 	return s.next.Portin(ctx, number, PortinTS)
 }
+
+//Update implements NumberingService.Update()
+//Scoped users can only update numbers in scope, and the updated number must stay in scope.
+func (s *numberingService) Update(ctx context.Context, number *numan.Numbering, fields []string) (numan.Numbering, error) {
+	ctx, span := tracing.Start(ctx, "auth.Numbering.Update")
+	defer span.End()
+	user, err := s.authorize(numan.PermNumbersAdd, ctx)
+	if err != nil {
+		return numan.Numbering{}, err
+	}
+	ctx = context.WithValue(ctx, numan.ActorField, user.Username) //recorded with the number event
+	if number == nil || user.Scope.Unrestricted() {
+		return s.next.Update(ctx, number, fields)
+	}
+	if err := s.checkScope(ctx, user.Scope, &number.E164); err != nil {
+		return numan.Numbering{}, err
+	}
+	list, err := s.next.List(ctx, &numan.NumberFilter{E164: number.E164})
+	if err != nil {
+		return numan.Numbering{}, err
+	}
+	for _, n := range list {
+		if n.E164 == number.E164 && !user.Scope.Allows(n.Apply(*number, fields)) {
+			return numan.Numbering{}, errOutOfScope
+		}
+	}
+	return s.next.Update(ctx, number, fields)
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// rateLimitService implements the RateLimitService interface
+type rateLimitService struct {
+	next numan.RateLimitService
+	authorizer
+}
+
+// NewRateLimitService instantiates a new RateLimitService of limiter (the server's limits), store is used for authorization.
+func NewRateLimitService(store *datastore.Store, limiter numan.RateLimitService) numan.RateLimitService {
+	return &rateLimitService{
+		next:       limiter,
+		authorizer: newAuthorizer(store),
+	}
+}
+
+//ListRateLimits implements RateLimitService.ListRateLimits()
+func (s *rateLimitService) ListRateLimits(ctx context.Context) ([]numan.RateLimit, []numan.RateLimitUsage, error) {
+	ctx, span := tracing.Start(ctx, "auth.RateLimit.ListRateLimits")
+	defer span.End()
+	if err := s.checkPermission(numan.PermRateLimitsRead, ctx); err != nil {
+		return nil, nil, err
+	}
+	return s.next.ListRateLimits(ctx)
+}
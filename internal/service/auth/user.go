@@ -5,28 +5,35 @@ import (
 
 	"github.com/footfish/numan"
 	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 //userService implements the UserService interface
 type userService struct {
 	next numan.UserService
+	authorizer
 }
 
 // NewUserService instantiates a new UserService.
 func NewUserService(store *datastore.Store) numan.UserService {
 	return &userService{
-		next: datastore.NewUserService(store),
+		next:       datastore.NewUserService(store),
+		authorizer: newAuthorizer(store),
 	}
 }
 
 //Auth implements UserService.Auth()
 func (s *userService) Auth(ctx context.Context, username string, password string) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.Auth")
+	defer span.End()
 	return s.next.Auth(ctx, username, password)
 }
 
 //AddUser implements UserService.AddUser()
 func (s *userService) AddUser(ctx context.Context, user numan.User) (err error) {
-	if err := checkUserRole(numan.RoleAdmin, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.User.AddUser")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
 		return err
 	}
 	return s.next.AddUser(ctx, user)
@@ -34,7 +41,9 @@ func (s *userService) AddUser(ctx context.Context, user numan.User) (err error)
 
 //DeleteUser  implements UserService.DeleteUser
 func (s *userService) DeleteUser(ctx context.Context, username string) error {
-	if err := checkUserRole(numan.RoleAdmin, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.User.DeleteUser")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
 		return err
 	}
 	return s.next.DeleteUser(ctx, username)
@@ -42,16 +51,189 @@ func (s *userService) DeleteUser(ctx context.Context, username string) error {
 
 //ListUsers  implements UserService.DeleteUser
 func (s *userService) ListUsers(ctx context.Context, userfilter string) ([]numan.User, error) {
-	if err := checkUserRole(numan.RoleAdmin, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.User.ListUsers")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
 		return []numan.User{}, err
 	}
 	return s.next.ListUsers(ctx, userfilter)
 }
 
-//ChangePassword implements UserService.ChangePassword
+//SetPassword implements UserService.SetPassword
 func (s *userService) SetPassword(ctx context.Context, username string, newPassword string) error {
-	if err := checkUserRole(numan.RoleAdmin, ctx); err != nil {
+	ctx, span := tracing.Start(ctx, "auth.User.SetPassword")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
 		return err
 	}
 	return s.next.SetPassword(ctx, username, newPassword)
 }
+
+//ChangePassword implements UserService.ChangePassword
+//No role is required, the old password authenticates.
+func (s *userService) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "auth.User.ChangePassword")
+	defer span.End()
+	return s.next.ChangePassword(ctx, username, oldPassword, newPassword)
+}
+
+//SetStatus implements UserService.SetStatus
+func (s *userService) SetStatus(ctx context.Context, username string, status numan.AccountStatus) error {
+	ctx, span := tracing.Start(ctx, "auth.User.SetStatus")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.SetStatus(ctx, username, status)
+}
+
+//RotateKey implements UserService.RotateKey
+func (s *userService) RotateKey(ctx context.Context, algorithm string) (numan.SigningKey, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.RotateKey")
+	defer span.End()
+	if err := s.checkPermission(numan.PermKeysAdmin, ctx); err != nil {
+		return numan.SigningKey{}, err
+	}
+	return s.next.RotateKey(ctx, algorithm)
+}
+
+//ListKeys implements UserService.ListKeys
+//No role is required, the public keys are published (as a JWKS endpoint) so clients can verify tokens.
+func (s *userService) ListKeys(ctx context.Context) ([]numan.SigningKey, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.ListKeys")
+	defer span.End()
+	return s.next.ListKeys(ctx)
+}
+
+//Refresh implements UserService.Refresh
+//No role is required, the refresh token authenticates.
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.Refresh")
+	defer span.End()
+	return s.next.Refresh(ctx, refreshToken)
+}
+
+//Logout implements UserService.Logout
+func (s *userService) Logout(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "auth.User.Logout")
+	defer span.End()
+	return s.next.Logout(ctx, refreshToken)
+}
+
+//SetRole implements UserService.SetRole
+func (s *userService) SetRole(ctx context.Context, role numan.Role) error {
+	ctx, span := tracing.Start(ctx, "auth.User.SetRole")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.SetRole(ctx, role)
+}
+
+//DeleteRole implements UserService.DeleteRole
+func (s *userService) DeleteRole(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "auth.User.DeleteRole")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.DeleteRole(ctx, name)
+}
+
+//ListRoles implements UserService.ListRoles
+func (s *userService) ListRoles(ctx context.Context) ([]numan.Role, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.ListRoles")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListRoles(ctx)
+}
+
+//GrantRole implements UserService.GrantRole
+func (s *userService) GrantRole(ctx context.Context, username string, role string) error {
+	ctx, span := tracing.Start(ctx, "auth.User.GrantRole")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.GrantRole(ctx, username, role)
+}
+
+//RevokeRole implements UserService.RevokeRole
+func (s *userService) RevokeRole(ctx context.Context, username string, role string) error {
+	ctx, span := tracing.Start(ctx, "auth.User.RevokeRole")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.RevokeRole(ctx, username, role)
+}
+
+//SetScope implements UserService.SetScope
+func (s *userService) SetScope(ctx context.Context, username string, scope numan.Scope) error {
+	ctx, span := tracing.Start(ctx, "auth.User.SetScope")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.SetScope(ctx, username, scope)
+}
+
+//AddAPIKey implements UserService.AddAPIKey
+func (s *userService) AddAPIKey(ctx context.Context, key numan.APIKey) (numan.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.AddAPIKey")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return numan.APIKey{}, err
+	}
+	return s.next.AddAPIKey(ctx, key)
+}
+
+//ListAPIKeys implements UserService.ListAPIKeys
+func (s *userService) ListAPIKeys(ctx context.Context, userfilter string) ([]numan.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.ListAPIKeys")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListAPIKeys(ctx, userfilter)
+}
+
+//DeleteAPIKey implements UserService.DeleteAPIKey
+func (s *userService) DeleteAPIKey(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "auth.User.DeleteAPIKey")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.DeleteAPIKey(ctx, id)
+}
+
+//AuthAPIKey implements UserService.AuthAPIKey
+//No role is required, the API key authenticates.
+func (s *userService) AuthAPIKey(ctx context.Context, key string) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.AuthAPIKey")
+	defer span.End()
+	return s.next.AuthAPIKey(ctx, key)
+}
+
+//Unlock implements UserService.Unlock
+func (s *userService) Unlock(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "auth.User.Unlock")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.Unlock(ctx, name)
+}
+
+//ListAudit implements UserService.ListAudit
+func (s *userService) ListAudit(ctx context.Context, userfilter string) ([]numan.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "auth.User.ListAudit")
+	defer span.End()
+	if err := s.checkPermission(numan.PermUsersAdmin, ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListAudit(ctx, userfilter)
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// webhookService implements the WebhookService interface
+type webhookService struct {
+	next numan.WebhookService
+	authorizer
+}
+
+// NewWebhookService instantiates a new WebhookService.
+func NewWebhookService(store *datastore.Store) numan.WebhookService {
+	return &webhookService{
+		next:       datastore.NewWebhookService(store),
+		authorizer: newAuthorizer(store),
+	}
+}
+
+//AddWebhook implements WebhookService.AddWebhook()
+func (s *webhookService) AddWebhook(ctx context.Context, hook numan.Webhook) (numan.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "auth.Webhook.AddWebhook")
+	defer span.End()
+	if err := s.checkPermission(numan.PermWebhooksAdmin, ctx); err != nil {
+		return numan.Webhook{}, err
+	}
+	return s.next.AddWebhook(ctx, hook)
+}
+
+//ListWebhooks implements WebhookService.ListWebhooks()
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]numan.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "auth.Webhook.ListWebhooks")
+	defer span.End()
+	if err := s.checkPermission(numan.PermWebhooksAdmin, ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListWebhooks(ctx)
+}
+
+//DeleteWebhook implements WebhookService.DeleteWebhook()
+func (s *webhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "auth.Webhook.DeleteWebhook")
+	defer span.End()
+	if err := s.checkPermission(numan.PermWebhooksAdmin, ctx); err != nil {
+		return err
+	}
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+//ListDeadLetters implements WebhookService.ListDeadLetters()
+func (s *webhookService) ListDeadLetters(ctx context.Context, webhookID int64) ([]numan.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "auth.Webhook.ListDeadLetters")
+	defer span.End()
+	if err := s.checkPermission(numan.PermWebhooksAdmin, ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListDeadLetters(ctx, webhookID)
+}
+
+//RedeliverDeadLetters implements WebhookService.RedeliverDeadLetters()
+func (s *webhookService) RedeliverDeadLetters(ctx context.Context, webhookID int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "auth.Webhook.RedeliverDeadLetters")
+	defer span.End()
+	if err := s.checkPermission(numan.PermWebhooksAdmin, ctx); err != nil {
+		return 0, err
+	}
+	return s.next.RedeliverDeadLetters(ctx, webhookID)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+//CertAuthenticator authenticates users by a verified TLS client certificate (mutual TLS).
+//It is used by the server, the certificate must already be verified against the client CA.
+type CertAuthenticator struct {
+	users numan.UserService
+}
+
+//NewCertAuthenticator instantiates a new CertAuthenticator
+func NewCertAuthenticator(store *datastore.Store) *CertAuthenticator {
+	return &CertAuthenticator{
+		users: datastore.NewUserService(store), //stored user lookup only, no password
+	}
+}
+
+//AuthCert returns the user named by the certificate with a new access token.
+//The username is the whole value of one field (see numan.SetCertUsername), a certificate with several different values is refused.
+func (a *CertAuthenticator) AuthCert(ctx context.Context, cert *x509.Certificate) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "service.CertAuthenticator.AuthCert")
+	defer span.End()
+	field := numan.CurrentCertUsername()
+	names := certNames(cert, field)
+	switch {
+	case len(names) == 0:
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "client certificate '%s' has no %s for the username", cert.Subject.CommonName, field)
+	case len(names) > 1:
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "client certificate '%s' is ambiguous, %s has several values (%s)", cert.Subject.CommonName, field, strings.Join(names, ", "))
+	}
+	if candidate := (numan.User{Username: names[0]}); !candidate.ValidUsername() {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "client certificate %s '%s' is not a valid username", field, names[0])
+	}
+	user, err := a.users.Auth(ctx, names[0], "")
+	if err != nil {
+		return numan.User{}, err
+	}
+	if user.UID == 0 {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "no user for client certificate %s '%s'", field, names[0])
+	}
+	if err = checkStatus(user.Status); err != nil {
+		return numan.User{}, err
+	}
+	user.Password = ""
+	return user, user.SetNewAccessToken()
+}
+
+//certNames returns the distinct values (lower case) of a certificate field, see numan.CertUsername fields.
+func certNames(cert *x509.Certificate, field string) (names []string) {
+	var values []string
+	switch field {
+	case numan.CertUsernameCN:
+		values = []string{cert.Subject.CommonName}
+	case numan.CertUsernameDNS:
+		values = cert.DNSNames
+	case numan.CertUsernameEmail:
+		values = cert.EmailAddresses
+	}
+	for _, v := range values {
+		if v = strings.ToLower(v); v != "" && !contains(names, v) {
+			names = append(names, v)
+		}
+	}
+	return names
+}
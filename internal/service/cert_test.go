@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	. "github.com/footfish/numan/internal/service"
+)
+
+func TestCertAuthenticator(t *testing.T) {
+	store := HelperNewStore(t)
+	defer store.Close()
+	users, nu, certs := NewUserService(store), NewNumberingService(store), NewCertAuthenticator(store)
+	adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+	defer cancel()
+	if err := users.AddUser(adminCtx, numan.User{Username: "robot", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OkCommonName", func(t *testing.T) {
+		user, err := certs.AuthCert(context.Background(), &x509.Certificate{Subject: pkix.Name{CommonName: "Robot"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Username != "robot" || user.Password != "" {
+			t.Fatalf("AuthCert got %+v", user)
+		}
+		ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+		if _, err := nu.Summary(ctx); err != nil {
+			t.Fatal("Certificate user can't read numbers:", err)
+		}
+		if err := nu.Add(ctx, &numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}); err == nil {
+			t.Fatal("Certificate user with viewer role added number")
+		}
+	})
+
+	t.Run("OkSAN", func(t *testing.T) {
+		if err := users.AddUser(adminCtx, numan.User{Username: "billing.example.com", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := users.AddUser(adminCtx, numan.User{Username: "robot@example.com", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+			t.Fatal(err)
+		}
+		defer numan.SetCertUsername(numan.CertUsernameCN)
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing service"}, DNSNames: []string{"Billing.example.com"}, EmailAddresses: []string{"robot@example.com"}}
+		for field, want := range map[string]string{numan.CertUsernameDNS: "billing.example.com", numan.CertUsernameEmail: "robot@example.com"} {
+			if err := numan.SetCertUsername(field); err != nil {
+				t.Fatal(err)
+			}
+			if user, err := certs.AuthCert(context.Background(), cert); err != nil {
+				t.Fatal(err)
+			} else if user.Username != want {
+				t.Fatalf("AuthCert by %s got %+v, want %s", field, user, want)
+			}
+		}
+		if err := numan.SetCertUsername("san"); err == nil {
+			t.Fatal("Set invalid certificate username field")
+		}
+	})
+
+	t.Run("ErrOtherFields", func(t *testing.T) {
+		//an admin@ mailbox or a host named robot is not the user admin or robot, only the configured field (common name) is used
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "mail service"}, DNSNames: []string{"robot"}, EmailAddresses: []string{"admin@example.com"}}
+		if user, err := certs.AuthCert(context.Background(), cert); err == nil {
+			t.Fatalf("Authenticated certificate by other fields as %s", user.Username)
+		}
+		defer numan.SetCertUsername(numan.CertUsernameCN)
+		if err := numan.SetCertUsername(numan.CertUsernameEmail); err != nil {
+			t.Fatal(err)
+		}
+		if user, err := certs.AuthCert(context.Background(), cert); err == nil {
+			t.Fatalf("Authenticated certificate by email local part as %s", user.Username)
+		}
+	})
+
+	t.Run("ErrAmbiguous", func(t *testing.T) {
+		defer numan.SetCertUsername(numan.CertUsernameCN)
+		if err := numan.SetCertUsername(numan.CertUsernameEmail); err != nil {
+			t.Fatal(err)
+		}
+		cert := &x509.Certificate{EmailAddresses: []string{"robot@example.com", "ROBOT@example.com", "other@example.com"}}
+		if _, err := certs.AuthCert(context.Background(), cert); !errors.Is(err, numan.ErrUnauthenticated) || !strings.Contains(err.Error(), "ambiguous") {
+			t.Fatalf("AuthCert of certificate with several emails got %v, want ambiguous", err)
+		}
+		//the same value twice is not ambiguous
+		cert.EmailAddresses = cert.EmailAddresses[:2]
+		if user, err := certs.AuthCert(context.Background(), cert); err != nil || user.Username != "robot@example.com" {
+			t.Fatalf("AuthCert got %+v (err %v)", user, err)
+		}
+	})
+
+	t.Run("ErrUnknownUser", func(t *testing.T) {
+		if _, err := certs.AuthCert(context.Background(), &x509.Certificate{Subject: pkix.Name{CommonName: "nobody"}}); err == nil {
+			t.Fatal("Authenticated certificate of unknown user")
+		}
+	})
+
+	t.Run("ErrStatus", func(t *testing.T) {
+		for _, status := range []numan.AccountStatus{{MustChangePassword: true}, {PasswordExpires: time.Now().Add(-time.Hour).Unix()}, {Disabled: true}} {
+			if err := users.SetStatus(adminCtx, "robot", status); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := certs.AuthCert(context.Background(), &x509.Certificate{Subject: pkix.Name{CommonName: "robot"}}); err == nil {
+				t.Fatalf("Authenticated certificate of user with status %+v", status)
+			}
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/footfish/numan"
+	. "github.com/footfish/numan/internal/service"
+	"github.com/footfish/numan/numantest"
+)
+
+func TestConformance(t *testing.T) {
+	numantest.TestServices(t, func(t *testing.T) numantest.Backend {
+		store := HelperNewStore(t)
+		t.Cleanup(func() { store.Close() })
+		userCtx, cancelUser := HelperContext(t, numan.RoleOperator)
+		t.Cleanup(cancelUser)
+		adminCtx, cancelAdmin := HelperContext(t, numan.RoleAdmin)
+		t.Cleanup(cancelAdmin)
+		return numantest.Backend{
+			Numbering: NewNumberingService(store),
+			History:   NewHistoryService(store),
+			User:      NewUserService(store),
+			Events:    NewEventService(store),
+			UserCtx:   userCtx,
+			AdminCtx:  adminCtx,
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package datastore
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+//lastUsedPrecision is how out of date the last used time of a key can be, a key used again within it isn't written (busy keys don't write at each call)
+const lastUsedPrecision = time.Minute
+
+//AddAPIKey implements UserService.AddAPIKey
+//The key roles & scope must be within the user's.
+func (s *userService) AddAPIKey(ctx context.Context, key numan.APIKey) (numan.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.AddAPIKey")
+	defer span.End()
+	owner, err := s.Auth(ctx, key.Username, "")
+	if err != nil {
+		return numan.APIKey{}, err
+	}
+	if owner.UID == 0 {
+		return numan.APIKey{}, numan.Errorf(numan.ErrNotFound, "Unable to add API key, check the username exists")
+	}
+	if err = key.Within(owner); err != nil {
+		return numan.APIKey{}, err
+	}
+	key.Created = time.Now().Unix()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return numan.APIKey{}, err
+	}
+	defer tx.Rollback()
+	if _, err = s.store.txExec(tx, "INSERT INTO api_key(id, user_id, key_hash, expires, created) values(?,?,?,?,?)", key.ID, owner.UID, numan.HashToken(key.Key), key.Expires, key.Created); err != nil {
+		return numan.APIKey{}, err
+	}
+	for _, role := range key.Roles {
+		if _, err = s.store.txExec(tx, "INSERT INTO api_key_role(key_id, role) values(?,?)", key.ID, role); err != nil {
+			return numan.APIKey{}, err
+		}
+	}
+	for kind, values := range scopeKinds(key.Scope) {
+		for _, value := range values {
+			if _, err = s.store.txExec(tx, "INSERT INTO api_key_scope(key_id, kind, value) values(?,?,?)", key.ID, kind, value); err != nil {
+				return numan.APIKey{}, err
+			}
+		}
+	}
+	return key, tx.Commit()
+}
+
+//ListAPIKeys implements UserService.ListAPIKeys
+func (s *userService) ListAPIKeys(ctx context.Context, userfilter string) (keys []numan.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.ListAPIKeys")
+	defer span.End()
+	rows, err := s.store.query("SELECT k.id, u.username, k.expires, k.created, k.last_used FROM api_key k JOIN \"user\" u ON u.id=k.user_id WHERE u.username like ? ORDER BY u.username, k.created", userfilter+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key numan.APIKey
+		if err = rows.Scan(&key.ID, &key.Username, &key.Expires, &key.Created, &key.LastUsed); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	for i := range keys {
+		if err = s.keyAccess(&keys[i]); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+//keyAccess reads the roles & scope of key
+func (s *userService) keyAccess(key *numan.APIKey) error {
+	rows, err := s.store.query("SELECT role FROM api_key_role WHERE key_id=? ORDER BY role", key.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role string
+		if err = rows.Scan(&role); err != nil {
+			return err
+		}
+		key.Roles = append(key.Roles, role)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	key.Scope, err = s.queryScope("SELECT kind, value FROM api_key_scope WHERE key_id=? ORDER BY kind, value", key.ID)
+	return err
+}
+
+//DeleteAPIKey implements UserService.DeleteAPIKey
+func (s *userService) DeleteAPIKey(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.DeleteAPIKey")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	row, err := s.store.txExec(tx, "DELETE FROM api_key WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to delete, check the API key exists")
+	}
+	if _, err = s.store.txExec(tx, "DELETE FROM api_key_role WHERE key_id=?", id); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "DELETE FROM api_key_scope WHERE key_id=?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//AuthAPIKey implements UserService.AuthAPIKey
+//Returns the stored user the key belongs to limited to the key roles & scope, the key last used time is recorded (see lastUsedPrecision).
+func (s *userService) AuthAPIKey(ctx context.Context, key string) (userdata numan.User, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.AuthAPIKey")
+	defer span.End()
+	id, _, err := numan.ParseAPIKey(key)
+	if err != nil {
+		return userdata, err
+	}
+	var hash string
+	var expires int64
+	row := s.store.queryRow("SELECT k.key_hash, k.expires, u.id, u.username, u.token_version, u.disabled, u.password_expires, u.must_change_password FROM api_key k JOIN \"user\" u ON u.id=k.user_id WHERE k.id=?", id)
+	if err = row.Scan(&hash, &expires, &userdata.UID, &userdata.Username, &userdata.TokenVersion, &userdata.Status.Disabled, &userdata.Status.PasswordExpires, &userdata.Status.MustChangePassword); err != nil {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Invalid or expired API key")
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(numan.HashToken(key))) != 1 || (expires != 0 && expires < time.Now().Unix()) {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Invalid or expired API key")
+	}
+	now := time.Now()
+	if _, err = s.store.exec("UPDATE api_key SET last_used=? WHERE id=? AND last_used<?", now.Unix(), id, now.Add(-lastUsedPrecision).Unix()); err != nil {
+		return numan.User{}, err
+	}
+	if userdata.Roles, err = s.userRoles(userdata.UID); err != nil {
+		return numan.User{}, err
+	}
+	if userdata.Scope, err = s.userScope(userdata.UID); err != nil {
+		return numan.User{}, err
+	}
+	apiKey := numan.APIKey{ID: id}
+	if err = s.keyAccess(&apiKey); err != nil {
+		return numan.User{}, err
+	}
+	return apiKey.Limit(userdata)
+}
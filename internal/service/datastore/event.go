@@ -0,0 +1,204 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+//eventPollInterval is how often watchers check for events stored by other servers (sharing the database)
+var eventPollInterval = 2 * time.Second
+
+//watchBatch is the number of events a watcher reads at a time
+const watchBatch = 100
+
+//notifier wakes up waiting watchers
+type notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+//wait returns a channel closed at the next notify
+func (n *notifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+//notify wakes up waiting watchers
+func (n *notifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
+
+//change executes query (with '?' placeholders) changing number in a transaction with the number event. Returns false if no row was changed (no event).
+func (s *numberingService) change(ctx context.Context, eventType string, number *numan.E164, query string, args ...interface{}) (bool, error) {
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	before, err := s.store.selectNumber(tx, number)
+	if err != nil {
+		return false, err
+	}
+	row, err := s.store.txExec(tx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	after, err := s.store.selectNumber(tx, number)
+	if err != nil {
+		return false, err
+	}
+	actor, _ := ctx.Value(numan.ActorField).(string)
+	event := numan.NewNumberEvent(eventType, *number, before, after, actor)
+	if s.store.driver.eventLock != "" {
+		if _, err := tx.Exec(s.store.driver.eventLock); err != nil {
+			return false, err
+		}
+	}
+	if _, err := s.store.txExec(tx, "INSERT INTO number_event (type, cc, ndc, sn, domain, carrier, old_state, new_state, ownerID, actor, timestamp) values(?,?,?,?,?,?,?,?,?,?,?)",
+		event.Type, event.E164.Cc, event.E164.Ndc, event.E164.Sn, event.Domain, event.Carrier, event.OldState, event.NewState, event.OwnerID, event.Actor, event.Timestamp); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	s.store.events.notify()
+	return true, nil
+}
+
+//selectNumber returns the stored number in transaction tx OR nil
+func (s *Store) selectNumber(tx *sql.Tx, number *numan.E164) (*numan.Numbering, error) {
+	var n numan.Numbering
+	err := s.txQueryRow(tx, "SELECT id, cc, ndc, sn, used, domain, carrier, ownerID, allocated, reserved, deallocated, portedIn, portedOut FROM number where cc=? and ndc=? and sn=?", number.Cc, number.Ndc, number.Sn).Scan(
+		&n.ID, &n.E164.Cc, &n.E164.Ndc, &n.E164.Sn, &n.Used, &n.Domain, &n.Carrier, &n.OwnerID, &n.Allocated, &n.Reserved, &n.DeAllocated, &n.PortedIn, &n.PortedOut)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// eventService implements the EventService interface
+type eventService struct {
+	store Store
+}
+
+// NewEventService instantiates a EventService
+func NewEventService(store *Store) numan.EventService {
+	return &eventService{
+		store: *store,
+	}
+}
+
+//ListEvents implements EventService.ListEvents()
+func (s *eventService) ListEvents(ctx context.Context, filter *numan.EventFilter, limit int) ([]numan.NumberEvent, error) {
+	ctx, span := tracing.Start(ctx, "datastore.Event.ListEvents")
+	defer span.End()
+	return s.store.listEvents(filter, limit)
+}
+
+//listEvents returns up to limit events matching filter, oldest first
+func (s *Store) listEvents(filter *numan.EventFilter, limit int) ([]numan.NumberEvent, error) {
+	where, args := []string{"seq > ?"}, []interface{}{filter.AfterSeq}
+	if v := filter.Domain; v != "" {
+		where, args = append(where, "domain = ?"), append(args, v)
+	}
+	if v := filter.Carrier; v != "" {
+		where, args = append(where, "carrier = ?"), append(args, v)
+	}
+	if v := filter.Prefix; v != "" {
+		p := strings.SplitN(v, "-", 3)
+		where, args = append(where, "cc = ?"), append(args, p[0])
+		if len(p) > 1 {
+			where, args = append(where, "ndc = ?"), append(args, p[1])
+		}
+		if len(p) > 2 {
+			where, args = append(where, "sn like ?"), append(args, p[2]+"%")
+		}
+	}
+	if len(filter.Types) > 0 {
+		where = append(where, "type in (?"+strings.Repeat(",?", len(filter.Types)-1)+")")
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+	rows, err := s.query("SELECT seq, type, cc, ndc, sn, domain, carrier, old_state, new_state, ownerID, actor, timestamp FROM number_event where "+strings.Join(where, " AND ")+" order by seq limit ?", append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []numan.NumberEvent
+	for rows.Next() {
+		var e numan.NumberEvent
+		if err := rows.Scan(&e.Seq, &e.Type, &e.E164.Cc, &e.E164.Ndc, &e.E164.Sn, &e.Domain, &e.Carrier, &e.OldState, &e.NewState, &e.OwnerID, &e.Actor, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+//Watch implements EventService.Watch()
+//New events are read when stored by this server, or every eventPollInterval.
+func (s *eventService) Watch(ctx context.Context, filter *numan.EventFilter, send func(numan.NumberEvent) error) error {
+	next := *filter
+	for {
+		wait := s.store.events.wait()                        //before reading, so no event is missed
+		events, err := s.store.listEvents(&next, watchBatch) //not traced, watching can last for hours
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := send(e); err != nil {
+				return err
+			}
+			next.AfterSeq = e.Seq
+		}
+		if len(events) == watchBatch {
+			continue
+		}
+		timer := time.NewTimer(eventPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-wait:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+//EventsStored returns a channel closed when this Store next stores a number event
+func (s *Store) EventsStored() <-chan struct{} {
+	return s.events.wait()
+}
+
+//PurgeEvents deletes number events stored before timestamp, returns the number deleted.
+//Events not yet relayed to all webhooks are kept.
+func (s *Store) PurgeEvents(before int64) (int64, error) {
+	row, err := s.exec("DELETE FROM number_event where timestamp < ? and seq <= coalesce((SELECT min(last_seq) FROM webhook), seq)", before)
+	if err != nil {
+		return 0, err
+	}
+	return row.RowsAffected()
+}
@@ -3,9 +3,11 @@ package datastore
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 // historyService implements the HistoryService interface
@@ -22,7 +24,9 @@ func NewHistoryService(store *Store) numan.HistoryService {
 
 //AddHistory  implements HistoryService.AddHistory()
 func (s *historyService) AddHistory(ctx context.Context, historyEntry numan.History) error {
-	_, err := s.store.db.Exec("INSERT INTO history( cc, ndc, sn, action, timestamp, ownerID, notes) values(?,?,?,?,?,?,?)", historyEntry.E164.Cc, historyEntry.E164.Ndc, historyEntry.E164.Sn, historyEntry.Action, time.Now().Unix(), historyEntry.OwnerID, historyEntry.Notes)
+	ctx, span := tracing.Start(ctx, "datastore.History.AddHistory")
+	defer span.End()
+	_, err := s.store.exec("INSERT INTO history( cc, ndc, sn, action, timestamp, ownerID, notes) values(?,?,?,?,?,?,?)", historyEntry.E164.Cc, historyEntry.E164.Ndc, historyEntry.E164.Sn, historyEntry.Action, time.Now().Unix(), historyEntry.OwnerID, historyEntry.Notes)
 	if err != nil {
 		err = errors.New("could not record " + historyEntry.Action + " in history")
 	}
@@ -30,52 +34,65 @@ func (s *historyService) AddHistory(ctx context.Context, historyEntry numan.Hist
 }
 
 //ListHistoryByNumber implements HistoryService.ListHistoryByNumber()
-func (s *historyService) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164) ([]numan.History, error) {
+func (s *historyService) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164, archived bool) ([]numan.History, error) {
+	ctx, span := tracing.Start(ctx, "datastore.History.ListHistoryByNumber")
+	defer span.End()
 	if phoneNumber.ValidE164() != nil {
-		return nil, errors.New("Incorrect number format")
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "Incorrect number format")
 	}
+	return s.listHistory(ctx, "cc=? and ndc=? and sn=?", archived, phoneNumber.Cc, phoneNumber.Ndc, phoneNumber.Sn)
+}
 
-	var result numan.History
-	var resultList []numan.History
+//ListHistoryByOwnerID implements HistoryService.ListHistoryByUserId()
+func (s *historyService) ListHistoryByOwnerID(ctx context.Context, ownerID int64, archived bool) ([]numan.History, error) {
+	ctx, span := tracing.Start(ctx, "datastore.History.ListHistoryByOwnerID")
+	defer span.End()
+	if numan.ValidOwnerID(&ownerID) != nil {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "Incorrect Owner ID format")
+	}
+	return s.listHistory(ctx, "ownerID=?", archived, ownerID)
+}
 
-	rows, err := s.store.db.Query("SELECT timestamp, cc, ndc, sn, ownerID, action, ifnull(notes,'') FROM history where cc=? and ndc=? and sn=? order by timestamp asc", phoneNumber.Cc, phoneNumber.Ndc, phoneNumber.Sn)
+//ArchiveHistory implements HistoryService.ArchiveHistory()
+//Entries are copied to history_archive then removed from history. The retention run is logged in history (same transaction).
+func (s *historyService) ArchiveHistory(ctx context.Context, policy numan.RetentionPolicy) (int64, error) {
+	ctx, span := tracing.Start(ctx, "datastore.History.ArchiveHistory")
+	defer span.End()
+	tx, err := s.store.db.Begin()
 	if err != nil {
-		return resultList, err
+		return 0, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	for rows.Next() {
-		err = rows.Scan(
-			&result.Timestamp,
-			&result.E164.Cc,
-			&result.E164.Ndc,
-			&result.E164.Sn,
-			&result.OwnerID,
-			&result.Action,
-			&result.Notes,
-		)
-		if err != nil {
-			return resultList, err
-		}
-		resultList = append(resultList, result)
-	}
-	err = rows.Err()
+	//entries before policy.Before, with at least KeepLast newer entries for the same number. Only the entries copied are deleted.
+	const expired = `FROM history h WHERE timestamp < ? AND (SELECT count(*) FROM history n WHERE n.cc=h.cc and n.ndc=h.ndc and n.sn=h.sn and n.id>h.id) >= ?`
+	row, err := s.store.txExec(tx, `INSERT INTO history_archive (id, timestamp, cc, ndc, sn, ownerID, action, notes)
+		SELECT id, timestamp, cc, ndc, sn, ownerID, action, notes `+expired, policy.Before, policy.KeepLast)
 	if err != nil {
-		return resultList, err
+		return 0, err
 	}
-	return resultList, nil
-}
-
-//ListHistoryByOwnerID implements HistoryService.ListHistoryByUserId()
-func (s *historyService) ListHistoryByOwnerID(ctx context.Context, ownerID int64) ([]numan.History, error) {
-	if numan.ValidOwnerID(&ownerID) != nil {
-		return nil, errors.New("Incorrect Owner ID format")
+	archived, _ := row.RowsAffected()
+	if _, err = s.store.txExec(tx, "DELETE FROM history WHERE id IN (SELECT id "+expired+")", policy.Before, policy.KeepLast); err != nil {
+		return 0, err
+	}
+	notes := fmt.Sprintf("Archived %d entries before %v, keep last %d", archived, time.Unix(policy.Before, 0).Format(numan.DATEPRINTFORMAT), policy.KeepLast)
+	if _, err = s.store.txExec(tx, "INSERT INTO history( cc, ndc, sn, action, timestamp, ownerID, notes) values('','','',?,?,0,?)", "retention", time.Now().Unix(), notes); err != nil {
+		return 0, errors.New("could not record retention in history")
 	}
+	return archived, tx.Commit()
+}
 
+//listHistory returns history entries matching where clause, ordered by timestamp. Archive is included if set.
+func (s *historyService) listHistory(ctx context.Context, where string, archived bool, args ...interface{}) ([]numan.History, error) {
 	var result numan.History
 	var resultList []numan.History
 
-	rows, err := s.store.db.Query("SELECT timestamp, cc, ndc, sn, ownerID, action, ifnull(notes,'') FROM history where ownerID=? order by timestamp asc", ownerID)
+	query := "SELECT timestamp, cc, ndc, sn, ownerID, action, coalesce(notes,'') FROM history where " + where
+	if archived {
+		query += " UNION ALL SELECT timestamp, cc, ndc, sn, ownerID, action, coalesce(notes,'') FROM history_archive where " + where
+		args = append(args, args...)
+	}
+	rows, err := s.store.query(query+" order by timestamp asc", args...)
 	if err != nil {
 		return resultList, err
 	}
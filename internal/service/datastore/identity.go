@@ -0,0 +1,48 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// IdentityStore links users to the external identity provider (OIDC) account that added them
+type IdentityStore struct {
+	store *Store
+}
+
+// NewIdentityStore instantiates an IdentityStore
+func NewIdentityStore(store *Store) *IdentityStore {
+	return &IdentityStore{store: store}
+}
+
+// Username returns the user linked to the provider account issuer & subject OR "" if none
+func (s *IdentityStore) Username(issuer string, subject string) (username string, err error) {
+	err = s.store.queryRow("SELECT u.username FROM user_identity i JOIN \"user\" u ON u.id=i.user_id WHERE i.issuer=? AND i.subject=?", issuer, subject).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return username, err
+}
+
+// AddUser adds user (see UserService.AddUser) linked to the provider account issuer & subject.
+// The user and link are added in one transaction, so a user is never left without the link.
+func (s *IdentityStore) AddUser(ctx context.Context, user numan.User, issuer string, subject string) error {
+	_, span := tracing.Start(ctx, "datastore.Identity.AddUser")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	users := userService{store: *s.store}
+	if err = users.addUser(tx, user); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "INSERT INTO user_identity (issuer, subject, user_id) SELECT ?, ?, id FROM \"user\" WHERE username=?", issuer, subject, user.Username); err != nil {
+		return uniqueViolation(err, "Unable to link user, the OIDC account is already linked")
+	}
+	return tx.Commit()
+}
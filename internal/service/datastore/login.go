@@ -0,0 +1,150 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+//Failed login counter kinds
+const (
+	LoginUser   = "user"   //counted by username
+	LoginSource = "source" //counted by client address
+)
+
+//LoginStore stores failed login counters, lockouts, password history and the audit log
+type LoginStore struct {
+	store Store
+}
+
+//NewLoginStore instantiates a LoginStore
+func NewLoginStore(store *Store) *LoginStore {
+	return &LoginStore{
+		store: *store,
+	}
+}
+
+//LockedUntil returns the timestamp the lockout of kind value ends OR 0 if not locked
+func (s *LoginStore) LockedUntil(kind string, value string) (int64, error) {
+	var lockedUntil int64
+	err := s.store.queryRow("SELECT locked_until FROM login_failure WHERE kind=? AND value=?", kind, value).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil || lockedUntil < time.Now().Unix() {
+		return 0, err
+	}
+	return lockedUntil, nil
+}
+
+//AddFailure counts a failed login of kind value and returns the timestamp the resulting lockout ends OR 0 if not locked.
+//Failures older than policy.MaxLockout are forgotten.
+func (s *LoginStore) AddFailure(kind string, value string, policy numan.LoginPolicy) (lockedUntil int64, err error) {
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	now := time.Now()
+	var failures int
+	var lastFailure int64
+	err = s.store.txQueryRow(tx, "SELECT failures, last_failure FROM login_failure WHERE kind=? AND value=?", kind, value).Scan(&failures, &lastFailure)
+	switch {
+	case err == sql.ErrNoRows:
+		if _, err = s.store.txExec(tx, "INSERT INTO login_failure(kind, value) VALUES(?,?)", kind, value); err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, err
+	case lastFailure < now.Add(-policy.MaxLockout).Unix():
+		failures = 0
+	}
+	failures++
+	if lockout := policy.LockoutFor(failures); lockout > 0 {
+		lockedUntil = now.Add(lockout).Unix()
+	}
+	if _, err = s.store.txExec(tx, "UPDATE login_failure SET failures=?, last_failure=?, locked_until=? WHERE kind=? AND value=?", failures, now.Unix(), lockedUntil, kind, value); err != nil {
+		return 0, err
+	}
+	return lockedUntil, tx.Commit()
+}
+
+//ClearFailures forgets the failed logins of kind value
+func (s *LoginStore) ClearFailures(kind string, value string) error {
+	_, err := s.store.exec("DELETE FROM login_failure WHERE kind=? AND value=?", kind, value)
+	return err
+}
+
+//SetLastLogin records the last login time of user uid
+func (s *LoginStore) SetLastLogin(uid int64) error {
+	_, err := s.store.exec("UPDATE \"user\" SET last_login=? WHERE id=?", time.Now().Unix(), uid)
+	return err
+}
+
+//PasswordHistory returns up to n of the users password hashes (current first)
+func (s *LoginStore) PasswordHistory(username string, n int) (hashes []string, err error) {
+	rows, err := s.store.query("SELECT h.passwordhash FROM password_history h JOIN \"user\" u ON u.id=h.user_id WHERE u.username=? ORDER BY h.id DESC LIMIT ?", username, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash string
+		if err = rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+//addPasswordHistory records the users current password hash in transaction tx
+func (s *userService) addPasswordHistory(tx *sql.Tx, username string) error {
+	_, err := s.store.txExec(tx, "INSERT INTO password_history(user_id, passwordhash, created) SELECT id, passwordhash, ? FROM \"user\" WHERE username=?", time.Now().Unix(), username)
+	return err
+}
+
+//AddAudit adds an entry to the audit log
+func (s *LoginStore) AddAudit(entry numan.AuditEntry) error {
+	if entry.Timestamp == 0 {
+		entry.Timestamp = time.Now().Unix()
+	}
+	_, err := s.store.exec("INSERT INTO audit(timestamp, username, source, action, notes) VALUES(?,?,?,?,?)", entry.Timestamp, entry.Username, entry.Source, entry.Action, entry.Notes)
+	return err
+}
+
+//Unlock implements UserService.Unlock
+func (s *userService) Unlock(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.Unlock")
+	defer span.End()
+	row, err := s.store.exec("DELETE FROM login_failure WHERE value=?", name)
+	if err != nil {
+		return err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to unlock, no failed logins for '%s'", name)
+	}
+	return nil
+}
+
+//ListAudit implements UserService.ListAudit
+func (s *userService) ListAudit(ctx context.Context, userfilter string) (entries []numan.AuditEntry, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.ListAudit")
+	defer span.End()
+	rows, err := s.store.query("SELECT timestamp, username, source, action, notes FROM audit WHERE username like ? ORDER BY id DESC", userfilter+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry numan.AuditEntry
+		if err = rows.Scan(&entry.Timestamp, &entry.Username, &entry.Source, &entry.Action, &entry.Notes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
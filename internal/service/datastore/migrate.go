@@ -0,0 +1,245 @@
+package datastore
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//Migration scripts are stored per driver as migrations/<driver>/<version>_<description>.<up|down>.sql
+//Versions must be consecutive from 1. A new migration needs scripts for every driver.
+//go:embed migrations
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+//migration is a versioned schema change
+type migration struct {
+	version     int
+	description string
+	up          string //script path
+	down        string //script path
+}
+
+//MigrationStatus describes a migration and when it was applied
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     int64 //timestamp migration was applied OR 0 if pending
+}
+
+//latestVersion is the schema version required by this build
+var latestVersion = len(loadMigrations(sqliteDriver))
+
+//loadMigrations reads the ordered migration list for driver
+func loadMigrations(d *dbDriver) []migration {
+	dir := path.Join("migrations", d.name)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		panic(err)
+	}
+	byVersion := map[int]*migration{}
+	for _, e := range entries {
+		m := migrationFileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			panic(fmt.Errorf("invalid migration file name %s", e.Name()))
+		}
+		version, _ := strconv.Atoi(m[1])
+		if byVersion[version] == nil {
+			byVersion[version] = &migration{version: version, description: m[2]}
+		}
+		if m[3] == "up" {
+			byVersion[version].up = path.Join(dir, e.Name())
+		} else {
+			byVersion[version].down = path.Join(dir, e.Name())
+		}
+	}
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i, m := range migrations {
+		if m.version != i+1 || m.up == "" || m.down == "" {
+			panic(fmt.Errorf("migration %d (%s) missing or incomplete", i+1, d.name))
+		}
+	}
+	return migrations
+}
+
+//Migrator applies versioned schema migrations
+type Migrator struct {
+	store *Store
+}
+
+//NewMigrator opens the database for DSN (see NewStore) to migrate the schema.
+func NewMigrator(dsn string, opts ...Option) (*Migrator, error) {
+	s, err := openStore(dsn, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{store: s}, nil
+}
+
+//Close closes db connection
+func (m *Migrator) Close() error {
+	return m.store.Close()
+}
+
+//LatestVersion returns the schema version required by this build
+func (m *Migrator) LatestVersion() int {
+	return latestVersion
+}
+
+//Version returns the current schema version of the database (0 if unmigrated)
+func (m *Migrator) Version() (int, error) {
+	return m.store.schemaVersion()
+}
+
+//Status lists all migrations with the time they were applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.store.createSchemaVersion(); err != nil {
+		return nil, err
+	}
+	applied := map[int]int64{}
+	rows, err := m.store.query("SELECT version, applied FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var ts int64
+		if err := rows.Scan(&version, &ts); err != nil {
+			return nil, err
+		}
+		applied[version] = ts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var status []MigrationStatus
+	for _, mig := range loadMigrations(m.store.driver) {
+		status = append(status, MigrationStatus{Version: mig.version, Description: mig.description, Applied: applied[mig.version]})
+	}
+	return status, nil
+}
+
+//Up applies pending migrations up to target version (0 for latest), returns the migrations applied.
+func (m *Migrator) Up(target int) ([]MigrationStatus, error) {
+	if target == 0 {
+		target = latestVersion
+	}
+	return m.store.migrateUp(target)
+}
+
+//Down reverts migrations down to target version (-1 for the previous version), returns the migrations reverted.
+func (m *Migrator) Down(target int) ([]MigrationStatus, error) {
+	return m.store.migrateDown(target)
+}
+
+//checkSchema returns an error unless the database is migrated to the latest version
+func (s *Store) checkSchema() error {
+	version, err := s.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if version != latestVersion {
+		return fmt.Errorf("database schema is version %d, version %d required (run 'numd migrate up')", version, latestVersion)
+	}
+	return nil
+}
+
+//createSchemaVersion creates the schema_version table if it does not exist
+func (s *Store) createSchemaVersion() error {
+	_, err := s.exec(`CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			description TEXT NOT NULL,
+			applied BIGINT NOT NULL
+		)`)
+	return err
+}
+
+//schemaVersion returns the current schema version (0 if unmigrated)
+func (s *Store) schemaVersion() (version int, err error) {
+	if err = s.createSchemaVersion(); err != nil {
+		return 0, err
+	}
+	err = s.queryRow("SELECT coalesce(max(version),0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+//migrateUp applies migrations from the current version up to target, each in a transaction.
+func (s *Store) migrateUp(target int) (applied []MigrationStatus, err error) {
+	if target < 0 || target > latestVersion {
+		return nil, fmt.Errorf("unknown schema version %d", target)
+	}
+	version, err := s.schemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	for _, mig := range loadMigrations(s.driver) {
+		if mig.version <= version || mig.version > target {
+			continue
+		}
+		ts := time.Now().Unix()
+		if err = s.runMigration(mig.up, "INSERT INTO schema_version (version, description, applied) values(?,?,?)", mig.version, mig.description, ts); err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", mig.version, mig.description, err)
+		}
+		applied = append(applied, MigrationStatus{Version: mig.version, Description: mig.description, Applied: ts})
+	}
+	return applied, nil
+}
+
+//migrateDown reverts migrations from the current version down to target (-1 for previous), each in a transaction.
+func (s *Store) migrateDown(target int) (reverted []MigrationStatus, err error) {
+	version, err := s.schemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if target == -1 {
+		target = version - 1
+	}
+	if target < 0 || target > version {
+		return nil, fmt.Errorf("can't migrate down from version %d to %d", version, target)
+	}
+	migrations := loadMigrations(s.driver)
+	for v := version; v > target; v-- {
+		mig := migrations[v-1]
+		if err = s.runMigration(mig.down, "DELETE FROM schema_version WHERE version=?", mig.version); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s revert failed: %w", mig.version, mig.description, err)
+		}
+		reverted = append(reverted, MigrationStatus{Version: mig.version, Description: mig.description})
+	}
+	return reverted, nil
+}
+
+//runMigration runs a migration script and records the version change in the same transaction
+func (s *Store) runMigration(script string, versionQuery string, args ...interface{}) error {
+	sqlScript, err := migrationFiles.ReadFile(script)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(string(sqlScript)); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(s.driver.rebind(versionQuery), args...); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.New("commit failed: " + err.Error())
+	}
+	return nil
+}
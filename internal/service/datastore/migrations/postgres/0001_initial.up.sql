@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS number (
+	id BIGSERIAL PRIMARY KEY,
+	cc VARCHAR(3) NOT NULL,
+	ndc VARCHAR(5) NOT NULL,
+	sn VARCHAR(13) NOT NULL,
+	used BOOLEAN NOT NULL DEFAULT false,
+	domain TEXT NOT NULL,
+	carrier TEXT NOT NULL,
+	ownerID BIGINT NOT NULL DEFAULT 0,
+	allocated BIGINT NOT NULL DEFAULT 0,
+	reserved BIGINT NOT NULL DEFAULT 0,
+	deallocated BIGINT NOT NULL DEFAULT 0,
+	portedIn BIGINT NOT NULL DEFAULT 0,
+	portedOut BIGINT NOT NULL DEFAULT 0,
+	CONSTRAINT unq UNIQUE (cc, ndc, sn)
+);
+
+CREATE TABLE IF NOT EXISTS "user" (
+	id BIGSERIAL PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	passwordhash TEXT NOT NULL,
+	role TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS history (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp BIGINT NOT NULL,
+	cc VARCHAR(3) NOT NULL,
+	ndc VARCHAR(5) NOT NULL,
+	sn VARCHAR(13) NOT NULL,
+	ownerID BIGINT NOT NULL DEFAULT 0,
+	action TEXT NOT NULL,
+	notes TEXT
+);
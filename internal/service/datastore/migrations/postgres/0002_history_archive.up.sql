@@ -0,0 +1,15 @@
+CREATE INDEX IF NOT EXISTS history_number ON history (cc, ndc, sn);
+
+-- entries moved here by history retention keep their history id
+CREATE TABLE IF NOT EXISTS history_archive (
+	id BIGINT PRIMARY KEY,
+	timestamp BIGINT NOT NULL,
+	cc VARCHAR(3) NOT NULL,
+	ndc VARCHAR(5) NOT NULL,
+	sn VARCHAR(13) NOT NULL,
+	ownerID BIGINT NOT NULL DEFAULT 0,
+	action TEXT NOT NULL,
+	notes TEXT
+);
+
+CREATE INDEX IF NOT EXISTS history_archive_number ON history_archive (cc, ndc, sn);
@@ -0,0 +1,3 @@
+DROP TABLE revoked_token;
+DROP TABLE refresh_token;
+ALTER TABLE "user" DROP COLUMN token_version;
@@ -0,0 +1,18 @@
+-- token_version is incremented to invalidate all of a user's access tokens (password change)
+ALTER TABLE "user" ADD COLUMN token_version BIGINT NOT NULL DEFAULT 0;
+
+-- refresh tokens are stored hashed
+CREATE TABLE refresh_token (
+	token_hash TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	expires BIGINT NOT NULL,
+	created BIGINT NOT NULL
+);
+
+CREATE INDEX refresh_token_user ON refresh_token (user_id);
+
+-- access tokens revoked before expiry (logout)
+CREATE TABLE revoked_token (
+	jti TEXT PRIMARY KEY,
+	expires BIGINT NOT NULL
+);
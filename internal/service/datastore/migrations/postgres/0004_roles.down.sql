@@ -0,0 +1,7 @@
+-- users keep a single role, admin if granted
+ALTER TABLE "user" ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+UPDATE "user" SET role='admin' WHERE id IN (SELECT user_id FROM user_role WHERE role='admin');
+
+DROP TABLE user_role;
+DROP TABLE role_permission;
+DROP TABLE role;
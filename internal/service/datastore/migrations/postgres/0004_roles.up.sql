@@ -0,0 +1,39 @@
+-- roles are named sets of permissions, users can have multiple roles
+CREATE TABLE role (
+	name TEXT PRIMARY KEY
+);
+
+CREATE TABLE role_permission (
+	role TEXT NOT NULL,
+	permission TEXT NOT NULL,
+	PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE user_role (
+	user_id BIGINT NOT NULL,
+	role TEXT NOT NULL,
+	PRIMARY KEY (user_id, role)
+);
+
+-- built in roles, 'operator' can also port & delete numbers
+INSERT INTO role (name) VALUES ('admin'), ('operator'), ('user'), ('viewer');
+INSERT INTO role_permission (role, permission) VALUES
+	('admin', '*'),
+	('operator', 'numbers:read'),
+	('operator', 'numbers:add'),
+	('operator', 'numbers:allocate'),
+	('operator', 'numbers:port'),
+	('operator', 'numbers:delete'),
+	('operator', 'history:read'),
+	('operator', 'history:write'),
+	('user', 'numbers:read'),
+	('user', 'numbers:add'),
+	('user', 'numbers:allocate'),
+	('user', 'history:read'),
+	('user', 'history:write'),
+	('viewer', 'numbers:read'),
+	('viewer', 'history:read');
+
+INSERT INTO user_role (user_id, role) SELECT id, role FROM "user";
+
+ALTER TABLE "user" DROP COLUMN role;
@@ -0,0 +1,11 @@
+-- API keys for service accounts, the key acts as user_id. Keys are stored hashed.
+CREATE TABLE api_key (
+	id TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	key_hash TEXT NOT NULL,
+	expires BIGINT NOT NULL DEFAULT 0,
+	created BIGINT NOT NULL,
+	last_used BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX api_key_user ON api_key (user_id);
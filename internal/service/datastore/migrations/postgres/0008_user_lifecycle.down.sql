@@ -0,0 +1,4 @@
+ALTER TABLE "user" DROP COLUMN last_login;
+ALTER TABLE "user" DROP COLUMN must_change_password;
+ALTER TABLE "user" DROP COLUMN password_expires;
+ALTER TABLE "user" DROP COLUMN disabled;
@@ -0,0 +1,12 @@
+-- previous password hashes, a new password can't reuse the last N (see numan.PasswordPolicy)
+CREATE TABLE password_history (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	passwordhash TEXT NOT NULL,
+	created BIGINT NOT NULL
+);
+
+CREATE INDEX password_history_user ON password_history (user_id);
+
+-- existing passwords start the history
+INSERT INTO password_history (user_id, passwordhash, created) SELECT id, passwordhash, 0 FROM "user";
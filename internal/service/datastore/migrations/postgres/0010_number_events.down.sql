@@ -0,0 +1 @@
+DROP TABLE number_event;
@@ -0,0 +1,17 @@
+-- number changes, written in the same transaction as the change (see numan.NumberEvent). Watchers resume by sequence number.
+CREATE TABLE number_event (
+	seq BIGSERIAL PRIMARY KEY,
+	type TEXT NOT NULL,
+	cc VARCHAR(3) NOT NULL,
+	ndc VARCHAR(4) NOT NULL,
+	sn VARCHAR(13) NOT NULL,
+	domain TEXT NOT NULL,
+	carrier TEXT NOT NULL,
+	old_state TEXT NOT NULL,
+	new_state TEXT NOT NULL,
+	ownerID BIGINT NOT NULL DEFAULT 0,
+	actor TEXT NOT NULL,
+	timestamp BIGINT NOT NULL
+);
+
+CREATE INDEX number_event_timestamp ON number_event (timestamp);
@@ -0,0 +1 @@
+-- fixes the roles seeded by 0004, nothing to revert (0004 down drops the roles)
@@ -0,0 +1,6 @@
+-- 'user' could port & delete numbers when seeded before 0004 was fixed, these need the 'operator' role now
+DELETE FROM role_permission WHERE role='user' AND permission IN ('numbers:port', 'numbers:delete');
+INSERT INTO role_permission (role, permission) SELECT 'operator', p FROM (SELECT 'numbers:read' AS p UNION ALL SELECT 'numbers:add' UNION ALL SELECT 'numbers:allocate'
+	UNION ALL SELECT 'numbers:port' UNION ALL SELECT 'numbers:delete' UNION ALL SELECT 'history:read' UNION ALL SELECT 'history:write') perms
+	WHERE NOT EXISTS (SELECT 1 FROM role WHERE name='operator');
+INSERT INTO role (name) SELECT 'operator' WHERE NOT EXISTS (SELECT 1 FROM role WHERE name='operator');
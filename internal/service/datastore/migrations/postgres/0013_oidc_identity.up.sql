@@ -0,0 +1,10 @@
+-- users added by OIDC login are linked to the provider account (issuer & subject) that added them.
+-- Logins are matched by the link, never by username, so a provider account can't take over a local user.
+CREATE TABLE user_identity (
+	issuer TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	user_id BIGINT NOT NULL,
+	PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX user_identity_user ON user_identity (user_id);
@@ -0,0 +1,2 @@
+DROP TABLE api_key_scope;
+DROP TABLE api_key_role;
@@ -0,0 +1,3 @@
+DROP TABLE history;
+DROP TABLE "user";
+DROP TABLE number;
@@ -0,0 +1,2 @@
+DROP TABLE history_archive;
+DROP INDEX history_number;
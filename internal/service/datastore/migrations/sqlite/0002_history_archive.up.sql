@@ -0,0 +1,15 @@
+CREATE INDEX IF NOT EXISTS history_number ON history (cc, ndc, sn);
+
+-- entries moved here by history retention keep their history id
+CREATE TABLE IF NOT EXISTS history_archive (
+	id INTEGER PRIMARY KEY,
+	timestamp INTEGER NOT NULL,
+	cc NCHAR(3) NOT NULL,
+	ndc NCHAR(4) NOT NULL,
+	sn NCHAR(13) NOT NULL,
+	ownerID  INTEGER NOT NULL DEFAULT 0,
+	action TEXT NOT NULL,
+	notes TEXT
+);
+
+CREATE INDEX IF NOT EXISTS history_archive_number ON history_archive (cc, ndc, sn);
@@ -0,0 +1,13 @@
+DROP TABLE revoked_token;
+DROP TABLE refresh_token;
+
+-- sqlite can't drop columns, rebuild table
+CREATE TABLE user_v2 (
+	id INTEGER PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	passwordhash TEXT NOT NULL,
+	role TEXT NOT NULL
+);
+INSERT INTO user_v2 (id, username, passwordhash, role) SELECT id, username, passwordhash, role FROM "user";
+DROP TABLE "user";
+ALTER TABLE user_v2 RENAME TO "user";
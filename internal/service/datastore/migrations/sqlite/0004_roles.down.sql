@@ -0,0 +1,16 @@
+-- users keep a single role, admin if granted
+CREATE TABLE user_v2 (
+	id INTEGER PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	passwordhash TEXT NOT NULL,
+	role TEXT NOT NULL,
+	token_version INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO user_v2 (id, username, passwordhash, role, token_version)
+	SELECT id, username, passwordhash, COALESCE((SELECT min(role) FROM user_role WHERE user_id="user".id AND role IN ('admin', 'user')), 'user'), token_version FROM "user";
+DROP TABLE "user";
+ALTER TABLE user_v2 RENAME TO "user";
+
+DROP TABLE user_role;
+DROP TABLE role_permission;
+DROP TABLE role;
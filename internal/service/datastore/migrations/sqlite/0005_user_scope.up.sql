@@ -0,0 +1,8 @@
+-- user scope restricts the numbers a user can access, kind is domain, carrier or prefix.
+-- A user without entries of a kind is unrestricted for that kind.
+CREATE TABLE user_scope (
+	user_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (user_id, kind, value)
+);
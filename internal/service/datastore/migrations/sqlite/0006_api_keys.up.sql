@@ -0,0 +1,11 @@
+-- API keys for service accounts, the key acts as user_id. Keys are stored hashed.
+CREATE TABLE api_key (
+	id TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	key_hash TEXT NOT NULL,
+	expires INTEGER NOT NULL DEFAULT 0,
+	created INTEGER NOT NULL,
+	last_used INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX api_key_user ON api_key (user_id);
@@ -0,0 +1,2 @@
+DROP TABLE audit;
+DROP TABLE login_failure;
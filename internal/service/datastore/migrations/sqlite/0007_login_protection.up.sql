@@ -0,0 +1,21 @@
+-- failed login counters and lockouts, kind is 'user' (value username) or 'source' (value client address)
+CREATE TABLE login_failure (
+	kind TEXT NOT NULL,
+	value TEXT NOT NULL,
+	failures INTEGER NOT NULL DEFAULT 0,
+	last_failure INTEGER NOT NULL DEFAULT 0,
+	locked_until INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (kind, value)
+);
+
+-- security events (failed logins, lockouts)
+CREATE TABLE audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	source TEXT NOT NULL DEFAULT '',
+	action TEXT NOT NULL,
+	notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_username ON audit (username);
@@ -0,0 +1,10 @@
+-- sqlite can't drop columns, rebuild table
+CREATE TABLE user_v2 (
+	id INTEGER PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	passwordhash TEXT NOT NULL,
+	token_version INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO user_v2 (id, username, passwordhash, token_version) SELECT id, username, passwordhash, token_version FROM "user";
+DROP TABLE "user";
+ALTER TABLE user_v2 RENAME TO "user";
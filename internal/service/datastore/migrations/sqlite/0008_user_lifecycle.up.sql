@@ -0,0 +1,7 @@
+-- account status, disabled users can't login (tokens, refresh tokens & API keys are refused)
+ALTER TABLE "user" ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT 0;
+-- password expiry timestamp OR 0 never, an expired password must be changed before login
+ALTER TABLE "user" ADD COLUMN password_expires INTEGER NOT NULL DEFAULT 0;
+-- password must be changed before login
+ALTER TABLE "user" ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE "user" ADD COLUMN last_login INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- number changes, written in the same transaction as the change (see numan.NumberEvent). Watchers resume by sequence number.
+CREATE TABLE number_event (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT NOT NULL,
+	cc NCHAR(3) NOT NULL,
+	ndc NCHAR(4) NOT NULL,
+	sn NCHAR(13) NOT NULL,
+	domain TEXT NOT NULL,
+	carrier TEXT NOT NULL,
+	old_state TEXT NOT NULL,
+	new_state TEXT NOT NULL,
+	ownerID INTEGER NOT NULL DEFAULT 0,
+	actor TEXT NOT NULL,
+	timestamp INTEGER NOT NULL
+);
+
+CREATE INDEX number_event_timestamp ON number_event (timestamp);
@@ -0,0 +1,27 @@
+-- webhook subscriptions, number events after last_seq are relayed to webhook_delivery (the number_event table is the outbox)
+CREATE TABLE webhook (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	types TEXT NOT NULL DEFAULT '',
+	domain TEXT NOT NULL DEFAULT '',
+	carrier TEXT NOT NULL DEFAULT '',
+	prefix TEXT NOT NULL DEFAULT '',
+	secret TEXT NOT NULL,
+	last_seq INTEGER NOT NULL DEFAULT 0,
+	created INTEGER NOT NULL
+);
+
+-- queued deliveries, retried until delivered (deleted) or dead (dead-letter list)
+CREATE TABLE webhook_delivery (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	dead BOOLEAN NOT NULL DEFAULT false,
+	created INTEGER NOT NULL
+);
+
+CREATE INDEX webhook_delivery_next ON webhook_delivery (dead, next_attempt);
+CREATE INDEX webhook_delivery_webhook ON webhook_delivery (webhook_id);
@@ -0,0 +1,14 @@
+-- API key roles & scope limit a key to some of its user's roles & scope (kind is domain, carrier or prefix as for user_scope).
+-- A key without roles has all of its user's roles, a key without scope entries of a kind has the user's entries.
+CREATE TABLE api_key_role (
+	key_id TEXT NOT NULL,
+	role TEXT NOT NULL,
+	PRIMARY KEY (key_id, role)
+);
+
+CREATE TABLE api_key_scope (
+	key_id TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (key_id, kind, value)
+);
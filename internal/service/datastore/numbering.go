@@ -2,15 +2,12 @@ package datastore
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 
-	//"time"
 	"github.com/footfish/numan"
-	// register sqlite driver
-	_ "modernc.org/sqlite"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 // numberingService implements the NumberingService interface
@@ -27,19 +24,19 @@ func NewNumberingService(store *Store) numan.NumberingService {
 
 // Add implements NumberingService.Add()
 func (s *numberingService) Add(ctx context.Context, number *numan.Numbering) error {
-	_, err := s.store.db.Exec("INSERT INTO number(cc, ndc, sn, domain, carrier) values(?,?,?,?,?)", number.E164.Cc, number.E164.Ndc, number.E164.Sn, number.Domain, number.Carrier)
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Add")
+	defer span.End()
+	_, err := s.change(ctx, numan.EventAdded, &number.E164, "INSERT INTO number(cc, ndc, sn, domain, carrier) values(?,?,?,?,?)", number.E164.Cc, number.E164.Ndc, number.E164.Sn, number.Domain, number.Carrier)
 	if err != nil {
-		return err
+		return uniqueViolation(err, "Unable to add number, already exists")
 	}
 	return nil
 }
 
-// AddGroup not implemented
-func (s *numberingService) AddGroup(ctx context.Context) {
-}
-
 //List implements NumberingService.List()
 func (s *numberingService) List(ctx context.Context, filter *numan.NumberFilter) ([]numan.Numbering, error) {
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.List")
+	defer span.End()
 	//build WHERE args from filter
 	where, args := []string{"1 = 1"}, []interface{}{}
 	if v := filter.E164.Cc; len(v) != 0 {
@@ -67,7 +64,7 @@ func (s *numberingService) List(ctx context.Context, filter *numan.NumberFilter)
 	var result numan.Numbering
 	var resultList []numan.Numbering
 
-	rows, err := s.store.db.Query("SELECT * FROM number where "+strings.Join(where, " AND "), args...)
+	rows, err := s.store.query("SELECT id, cc, ndc, sn, used, domain, carrier, ownerID, allocated, reserved, deallocated, portedIn, portedOut FROM number where "+strings.Join(where, " AND "), args...)
 	if err != nil {
 		return resultList, err
 	}
@@ -103,14 +100,18 @@ func (s *numberingService) List(ctx context.Context, filter *numan.NumberFilter)
 
 //ListOwnerID implements NumberingService.ListOwnerID()
 func (s *numberingService) ListOwnerID(ctx context.Context, oid int64) ([]numan.Numbering, error) {
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.ListOwnerID")
+	defer span.End()
 	filter := &numan.NumberFilter{OwnerID: oid}
 	return s.List(ctx, filter)
 }
 
 //Summary implements NumberingService.Summary()
 func (s *numberingService) Summary(ctx context.Context) (string, error) {
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Summary")
+	defer span.End()
 	summary := fmt.Sprintf("%-15v %5v %5v %5v %5v %5v\n", "Domain", "CC", "NDC", "Used", "Free", "Total")
-	rows, err := s.store.db.Query("SELECT domain, cc, ndc, sum(used) as used, count(*)-sum(used) as free,  count(*) as total from number group by domain,cc,ndc; ")
+	rows, err := s.store.query("SELECT domain, cc, ndc, sum(case when used then 1 else 0 end) as used, sum(case when used then 0 else 1 end) as free, count(*) as total from number group by domain,cc,ndc order by domain,cc,ndc")
 	if err != nil {
 		return summary, err
 	}
@@ -145,58 +146,42 @@ func (s *numberingService) Summary(ctx context.Context) (string, error) {
 
 //Delete implements NumberingService.Delete()
 func (s *numberingService) Delete(ctx context.Context, phonenumber *numan.E164) error {
-	row, err := s.store.db.Exec("DELETE from number where used == 0 and cc=? and ndc=? and sn=?", phonenumber.Cc, phonenumber.Ndc, phonenumber.Sn)
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Delete")
+	defer span.End()
+	changed, err := s.change(ctx, numan.EventDeleted, phonenumber, "DELETE from number where used=? and cc=? and ndc=? and sn=?", false, phonenumber.Cc, phonenumber.Ndc, phonenumber.Sn)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to delete, check the number exists")
+	if !changed {
+		return s.stateError(ctx, phonenumber, "Unable to delete number", "number in use", false)
 	}
 	return nil
 }
 
 //View implements NumberingService.View()
 func (s *numberingService) View(ctx context.Context, number *numan.E164) (view string, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.View")
+	defer span.End()
 	result, err := s.List(ctx, &numan.NumberFilter{E164: *number})
 	if err != nil {
 		return "", err
 	}
 
-	for _, r := range result {
-		view += fmt.Sprintf("#%d) +%v-%v-%v, Domain: %v, Carrier: %v\n", r.ID, r.E164.Cc, r.E164.Ndc, r.E164.Sn, r.Domain, r.Carrier)
-		if r.Used { //Used can be reserved or allocated
-			if r.Allocated > 0 {
-				view += fmt.Sprintf("Allocated to OwnerID: %v on %v\n", r.OwnerID, time.Unix(int64(r.Allocated), 0).Format(numan.DATEPRINTFORMAT))
-			}
-			if r.Reserved > 0 {
-				view += fmt.Sprintf("Reserved %v\n", time.Unix(int64(r.Reserved), 0).Format(numan.DATEPRINTFORMAT))
-			}
-		} else if r.DeAllocated > 0 {
-			view += fmt.Sprintf("Last allocated %v\n", time.Unix(int64(r.DeAllocated), 0).Format(numan.DATEPRINTFORMAT))
-			if r.PortedOut > 0 {
-				view += fmt.Sprintf("Ported out %v\n", time.Unix(int64(r.PortedOut), 0).Format(numan.DATEPRINTFORMAT))
-			}
-		} else {
-			view += fmt.Sprintf("Never allocated\n")
-		}
-		if r.PortedIn > 0 {
-			view += fmt.Sprintf("Ported in %v\n", time.Unix(int64(r.PortedIn), 0).Format(numan.DATEPRINTFORMAT))
-		}
-	}
-
-	return view, nil
+	return numan.FormatView(result), nil
 }
 
 //Reserve implements NumberingService.Reserve()
 //Mark 'used' & set ownerID & reserved date.
 //Numbers must be out of quarantine
 func (s *numberingService) Reserve(ctx context.Context, number *numan.E164, ownerID *int64, untilTS *int64) error {
-	row, err := s.store.db.Exec("UPDATE number set used=1, deallocated=0, reserved=?, ownerID=? where reserved==0 and used==0 and ownerID==0 and cc=? and ndc=? and sn=? and deallocated<?", *untilTS, *ownerID, number.Cc, number.Ndc, number.Sn, time.Now().Unix()-numan.QUARANTINE)
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Reserve")
+	defer span.End()
+	changed, err := s.change(ctx, numan.EventReserved, number, "UPDATE number set used=?, deallocated=0, reserved=?, ownerID=? where reserved=0 and used=? and ownerID=0 and cc=? and ndc=? and sn=? and deallocated<?", true, *untilTS, *ownerID, false, number.Cc, number.Ndc, number.Sn, time.Now().Unix()-numan.QUARANTINE)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to reserve number (check number, already reserved?)")
+	if !changed {
+		return s.stateError(ctx, number, "Unable to reserve number", "already reserved or allocated", true)
 	}
 
 	return nil
@@ -206,12 +191,14 @@ func (s *numberingService) Reserve(ctx context.Context, number *numan.E164, owne
 //Mark 'used' & set ownerID & allocation date. Reset reservation & de-allocation flag
 //Numbers must be out of quarantine
 func (s *numberingService) Allocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
-	row, err := s.store.db.Exec("UPDATE number set used=1, deallocated=0, reserved=0, allocated=?, ownerID=? where used==0 and ownerID==0 and cc=? and ndc=? and sn=? and deallocated<?", time.Now().Unix(), *ownerID, number.Cc, number.Ndc, number.Sn, time.Now().Unix()-numan.QUARANTINE)
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Allocate")
+	defer span.End()
+	changed, err := s.change(ctx, numan.EventAllocated, number, "UPDATE number set used=?, deallocated=0, reserved=0, allocated=?, ownerID=? where used=? and ownerID=0 and cc=? and ndc=? and sn=? and deallocated<?", true, time.Now().Unix(), *ownerID, false, number.Cc, number.Ndc, number.Sn, time.Now().Unix()-numan.QUARANTINE)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to allocate number (check number, already allocated?)")
+	if !changed {
+		return s.stateError(ctx, number, "Unable to allocate number", "already reserved or allocated", true)
 	}
 	return nil
 }
@@ -219,36 +206,123 @@ func (s *numberingService) Allocate(ctx context.Context, number *numan.E164, own
 //DeAllocate implements NumberingService.DeAllocate()
 //Mark 'unused' & set de-allocation date (quarantine). Resets  ownerID, reservation & allocation dateflag.
 func (s *numberingService) DeAllocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
-	row, err := s.store.db.Exec("UPDATE number set used=0, deallocated=?, reserved=0, allocated=0, ownerID=0 where used==1 and cc=? and ndc=? and sn=? and ownerID=? and deallocated=0", time.Now().Unix(), number.Cc, number.Ndc, number.Sn, ownerID)
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.DeAllocate")
+	defer span.End()
+	changed, err := s.change(ctx, numan.EventDeAllocated, number, "UPDATE number set used=?, deallocated=?, reserved=0, allocated=0, ownerID=0 where used=? and cc=? and ndc=? and sn=? and ownerID=? and deallocated=0", false, time.Now().Unix(), true, number.Cc, number.Ndc, number.Sn, *ownerID)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to de-allocate number (Wrong owner?, already de-allocated?)")
+	if !changed {
+		return s.stateError(ctx, number, "Unable to de-allocate number", "wrong owner or already de-allocated", false)
 	}
 	return nil
 }
 
 //Portout implements NumberingService.Portout()
 func (s *numberingService) Portout(ctx context.Context, number *numan.E164, PortoutTS *int64) error {
-	row, err := s.store.db.Exec("UPDATE number set portedOut=? where  cc=? and ndc=? and sn=?", *PortoutTS, number.Cc, number.Ndc, number.Sn)
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Portout")
+	defer span.End()
+	changed, err := s.change(ctx, numan.EventPortedOut, number, "UPDATE number set portedOut=? where  cc=? and ndc=? and sn=?", *PortoutTS, number.Cc, number.Ndc, number.Sn)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to set ported out date. db update failed, check number ")
+	if !changed {
+		return s.stateError(ctx, number, "Unable to set ported out date", "", false)
 	}
 	return nil
 }
 
 //Portin implements NumberingService.Portin()
 func (s *numberingService) Portin(ctx context.Context, number *numan.E164, PortinTS *int64) error {
-	row, err := s.store.db.Exec("UPDATE number set portedIn=? where  cc=? and ndc=? and sn=?", *PortinTS, number.Cc, number.Ndc, number.Sn)
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Portin")
+	defer span.End()
+	changed, err := s.change(ctx, numan.EventPortedIn, number, "UPDATE number set portedIn=? where  cc=? and ndc=? and sn=?", *PortinTS, number.Cc, number.Ndc, number.Sn)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to set ported in date. db update failed, check number ")
+	if !changed {
+		return s.stateError(ctx, number, "Unable to set ported in date", "", false)
 	}
 	return nil
 }
+
+//Update implements NumberingService.Update()
+func (s *numberingService) Update(ctx context.Context, number *numan.Numbering, fields []string) (numan.Numbering, error) {
+	ctx, span := tracing.Start(ctx, "datastore.Numbering.Update")
+	defer span.End()
+	set, args := []string{}, []interface{}{}
+	for _, f := range fields {
+		switch f {
+		case "domain":
+			set, args = append(set, "domain=?"), append(args, number.Domain)
+		case "carrier":
+			set, args = append(set, "carrier=?"), append(args, number.Carrier)
+		}
+	}
+	if len(set) == 0 {
+		return numan.Numbering{}, numan.Errorf(numan.ErrInvalidArgument, "No fields to update")
+	}
+	e164 := number.E164
+	changed, err := s.change(ctx, numan.EventUpdated, &e164, "UPDATE number set "+strings.Join(set, ", ")+" where cc=? and ndc=? and sn=?", append(args, e164.Cc, e164.Ndc, e164.Sn)...)
+	if err != nil {
+		return numan.Numbering{}, err
+	}
+	if !changed {
+		return numan.Numbering{}, s.stateError(ctx, &e164, "Unable to update number", "", false)
+	}
+	list, err := s.List(ctx, &numan.NumberFilter{E164: e164})
+	if err != nil {
+		return numan.Numbering{}, err
+	}
+	for _, n := range list {
+		if n.E164 == e164 {
+			return n, nil
+		}
+	}
+	return numan.Numbering{}, s.stateError(ctx, &e164, "Unable to update number", "", false)
+}
+
+//stateError returns the error for an update of number which changed no rows (see numan.NumberStateError)
+func (s *numberingService) stateError(ctx context.Context, number *numan.E164, msg string, conflict string, quarantined bool) error {
+	list, err := s.List(ctx, &numan.NumberFilter{E164: *number})
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		if list[i].E164 == *number {
+			return numan.NumberStateError(&list[i], msg, conflict, quarantined)
+		}
+	}
+	return numan.NumberStateError(nil, msg, conflict, quarantined)
+}
+
+//NumberStats are the number counts of a domain, cc & ndc. Reserved numbers are also used, quarantined numbers also free.
+type NumberStats struct {
+	Domain              string
+	Cc                  string
+	Ndc                 string
+	Free                int64
+	Used                int64
+	Reserved            int64
+	Quarantined         int64
+	ExpiredReservations int64 //reservations past their expiry, not yet released
+}
+
+//NumberStats returns number counts per domain, cc & ndc (as Summary)
+func (s *Store) NumberStats(ctx context.Context) ([]NumberStats, error) {
+	now := time.Now().Unix()
+	rows, err := s.query("SELECT domain, cc, ndc, sum(case when used then 0 else 1 end), sum(case when used then 1 else 0 end), sum(case when used and reserved>0 then 1 else 0 end), sum(case when used then 0 when deallocated>=? then 1 else 0 end), sum(case when used and reserved>0 and reserved<? then 1 else 0 end) from number group by domain,cc,ndc order by domain,cc,ndc", now-numan.QUARANTINE, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stats []NumberStats
+	for rows.Next() {
+		var st NumberStats
+		if err := rows.Scan(&st.Domain, &st.Cc, &st.Ndc, &st.Free, &st.Used, &st.Reserved, &st.Quarantined, &st.ExpiredReservations); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,140 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+//SetRole implements UserService.SetRole
+func (s *userService) SetRole(ctx context.Context, role numan.Role) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.SetRole")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var exists int
+	if err = s.store.txQueryRow(tx, "SELECT count(*) FROM role WHERE name=?", role.Name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		if _, err = s.store.txExec(tx, "INSERT INTO role(name) values(?)", role.Name); err != nil {
+			return err
+		}
+	}
+	if _, err = s.store.txExec(tx, "DELETE FROM role_permission WHERE role=?", role.Name); err != nil {
+		return err
+	}
+	for _, permission := range role.Permissions {
+		if _, err = s.store.txExec(tx, "INSERT INTO role_permission(role, permission) values(?,?)", role.Name, permission); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//DeleteRole implements UserService.DeleteRole
+func (s *userService) DeleteRole(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.DeleteRole")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var granted int
+	if err = s.store.txQueryRow(tx, "SELECT count(*) FROM user_role WHERE role=?", name).Scan(&granted); err != nil {
+		return err
+	}
+	if granted > 0 {
+		return numan.Errorf(numan.ErrConflict, "Unable to delete, role is granted to users")
+	}
+	if _, err = s.store.txExec(tx, "DELETE FROM role_permission WHERE role=?", name); err != nil {
+		return err
+	}
+	row, err := s.store.txExec(tx, "DELETE FROM role WHERE name=?", name)
+	if err != nil {
+		return err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to delete, check the role exists")
+	}
+	return tx.Commit()
+}
+
+//ListRoles implements UserService.ListRoles
+func (s *userService) ListRoles(ctx context.Context) (roles []numan.Role, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.ListRoles")
+	defer span.End()
+	var name string
+	var permission sql.NullString
+	rows, err := s.store.query("SELECT r.name, rp.permission FROM role r LEFT JOIN role_permission rp ON rp.role=r.name ORDER BY r.name, rp.permission")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err = rows.Scan(&name, &permission); err != nil {
+			return nil, err
+		}
+		if len(roles) == 0 || roles[len(roles)-1].Name != name { //one row per role permission
+			roles = append(roles, numan.Role{Name: name})
+		}
+		if permission.Valid {
+			roles[len(roles)-1].Permissions = append(roles[len(roles)-1].Permissions, permission.String)
+		}
+	}
+	return roles, rows.Err()
+}
+
+//GrantRole implements UserService.GrantRole
+func (s *userService) GrantRole(ctx context.Context, username string, role string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.GrantRole")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = s.grantRole(tx, username, role); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//RevokeRole implements UserService.RevokeRole
+func (s *userService) RevokeRole(ctx context.Context, username string, role string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.RevokeRole")
+	defer span.End()
+	row, err := s.store.exec("DELETE FROM user_role WHERE role=? AND user_id IN (SELECT id FROM \"user\" WHERE username=?)", role, username)
+	if err != nil {
+		return err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to revoke role, check the user has the role")
+	}
+	return nil
+}
+
+//grantRole adds role to user in transaction tx
+func (s *userService) grantRole(tx *sql.Tx, username string, role string) error {
+	var granted int
+	if err := s.store.txQueryRow(tx, "SELECT count(*) FROM user_role ur JOIN \"user\" u ON u.id=ur.user_id WHERE u.username=? AND ur.role=?", username, role).Scan(&granted); err != nil {
+		return err
+	}
+	if granted > 0 {
+		return numan.Errorf(numan.ErrAlreadyExists, "Unable to grant role, already granted")
+	}
+	row, err := s.store.txExec(tx, "INSERT INTO user_role(user_id, role) SELECT u.id, r.name FROM \"user\" u, role r WHERE u.username=? AND r.name=?", username, role)
+	if err != nil {
+		return err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to grant role '%s', check the username & role exist", role)
+	}
+	return nil
+}
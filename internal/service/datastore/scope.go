@@ -0,0 +1,88 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+//Kinds of user_scope (& api_key_scope) entries
+const (
+	scopeDomain  = "domain"
+	scopeCarrier = "carrier"
+	scopePrefix  = "prefix"
+)
+
+//SetScope implements UserService.SetScope
+//The users access tokens are revoked (token version), the new scope applies from the next login/refresh.
+func (s *userService) SetScope(ctx context.Context, username string, scope numan.Scope) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.SetScope")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = s.setScope(tx, username, scope); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "UPDATE \"user\" SET token_version=token_version+1 WHERE username=?", username); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//setScope replaces the scope of user in transaction tx
+func (s *userService) setScope(tx *sql.Tx, username string, scope numan.Scope) error {
+	var uid int64
+	if err := s.store.txQueryRow(tx, "SELECT id FROM \"user\" WHERE username=?", username).Scan(&uid); err != nil {
+		return numan.Errorf(numan.ErrNotFound, "Unable to set scope, check the username exists")
+	}
+	if _, err := s.store.txExec(tx, "DELETE FROM user_scope WHERE user_id=?", uid); err != nil {
+		return err
+	}
+	for kind, values := range scopeKinds(scope) {
+		for _, value := range values {
+			if _, err := s.store.txExec(tx, "INSERT INTO user_scope(user_id, kind, value) values(?,?,?)", uid, kind, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//scopeKinds returns the lists of scope by entry kind
+func scopeKinds(scope numan.Scope) map[string][]string {
+	return map[string][]string{scopeDomain: scope.Domains, scopeCarrier: scope.Carriers, scopePrefix: scope.Prefixes}
+}
+
+//userScope returns the scope of user uid
+func (s *userService) userScope(uid int64) (scope numan.Scope, err error) {
+	return s.queryScope("SELECT kind, value FROM user_scope WHERE user_id=? ORDER BY kind, value", uid)
+}
+
+//queryScope returns the scope of the kind & value entries selected by query
+func (s *userService) queryScope(query string, args ...interface{}) (scope numan.Scope, err error) {
+	rows, err := s.store.query(query, args...)
+	if err != nil {
+		return scope, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind, value string
+		if err = rows.Scan(&kind, &value); err != nil {
+			return scope, err
+		}
+		switch kind {
+		case scopeDomain:
+			scope.Domains = append(scope.Domains, value)
+		case scopeCarrier:
+			scope.Carriers = append(scope.Carriers, value)
+		case scopePrefix:
+			scope.Prefixes = append(scope.Prefixes, value)
+		}
+	}
+	return scope, rows.Err()
+}
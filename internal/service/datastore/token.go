@@ -0,0 +1,105 @@
+package datastore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+//TokenStore stores refresh tokens and revoked access tokens
+type TokenStore struct {
+	store Store
+}
+
+//NewTokenStore instantiates a TokenStore
+func NewTokenStore(store *Store) *TokenStore {
+	return &TokenStore{
+		store: *store,
+	}
+}
+
+//AddRefreshToken stores a refresh token (hashed) for user uid
+func (s *TokenStore) AddRefreshToken(uid int64, refreshToken string, expires int64) error {
+	_, err := s.store.exec("INSERT INTO refresh_token(token_hash, user_id, expires, created) values(?,?,?,?)", numan.HashToken(refreshToken), uid, expires, time.Now().Unix())
+	return err
+}
+
+//DeleteRefreshTokens deletes the refresh tokens of user uid (ends the users sessions)
+func (s *TokenStore) DeleteRefreshTokens(uid int64) error {
+	_, err := s.store.exec("DELETE FROM refresh_token WHERE user_id=?", uid)
+	return err
+}
+
+//RevokeToken adds an access token id (jti) to the revocation list until it expires. Expired entries are removed.
+func (s *TokenStore) RevokeToken(tokenID string, expires int64) error {
+	if _, err := s.store.exec("DELETE FROM revoked_token WHERE expires<?", time.Now().Unix()); err != nil {
+		return err
+	}
+	_, err := s.store.exec("INSERT INTO revoked_token(jti, expires) values(?,?)", tokenID, expires)
+	return err
+}
+
+//CheckToken returns an error if the access token for user has been revoked.
+//The token is revoked if it is in the revocation list or the user was deleted or had all tokens revoked (token version).
+//Tokens without a user id are internal (minted by the server) and only checked against the revocation list.
+func (s *TokenStore) CheckToken(user numan.User) error {
+	var revoked int
+	if err := s.store.queryRow("SELECT count(*) FROM revoked_token WHERE jti=?", user.TokenID).Scan(&revoked); err != nil {
+		return err
+	}
+	if revoked > 0 {
+		return numan.Errorf(numan.ErrUnauthenticated, "token revoked")
+	}
+	if user.UID == 0 {
+		return nil
+	}
+	var version int64
+	if err := s.store.queryRow("SELECT token_version FROM \"user\" WHERE id=? and username=?", user.UID, user.Username).Scan(&version); err != nil {
+		return numan.Errorf(numan.ErrUnauthenticated, "token revoked, user not found")
+	}
+	if version != user.TokenVersion {
+		return numan.Errorf(numan.ErrUnauthenticated, "token revoked")
+	}
+	return nil
+}
+
+//Permissions returns the permissions granted to the access token user by their roles.
+//Roles are read from the database so changes apply immediately, internal tokens use the roles in the token.
+//Tokens with limited roles (API keys) only get the token roles the user still has.
+func (s *TokenStore) Permissions(user numan.User) (permissions []string, err error) {
+	var rows *sql.Rows
+	if user.UID == 0 || user.RolesLimited {
+		for _, role := range user.Roles {
+			if user.UID == 0 {
+				rows, err = s.store.query("SELECT permission FROM role_permission WHERE role=?", role)
+			} else {
+				rows, err = s.store.query("SELECT rp.permission FROM user_role ur JOIN role_permission rp ON rp.role=ur.role WHERE ur.user_id=? AND ur.role=?", user.UID, role)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if permissions, err = appendPermissions(permissions, rows); err != nil {
+				return nil, err
+			}
+		}
+		return permissions, nil
+	}
+	if rows, err = s.store.query("SELECT DISTINCT rp.permission FROM user_role ur JOIN role_permission rp ON rp.role=ur.role WHERE ur.user_id=?", user.UID); err != nil {
+		return nil, err
+	}
+	return appendPermissions(permissions, rows)
+}
+
+//appendPermissions appends the scanned permission rows to permissions, rows are closed
+func appendPermissions(permissions []string, rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, rows.Err()
+}
@@ -2,9 +2,11 @@ package datastore
 
 import (
 	"context"
-	"errors"
+	"database/sql"
+	"time"
 
 	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 // numberService implements the UserService interface
@@ -21,38 +23,124 @@ func NewUserService(store *Store) numan.UserService {
 
 //Auth implements UserService.Auth()
 func (s *userService) Auth(ctx context.Context, username string, password string) (userdata numan.User, err error) {
-	row := s.store.db.QueryRow("SELECT id, username, passwordhash, role FROM user where username=?", username)
-	row.Scan(&userdata.UID, &userdata.Username, &userdata.Password, &userdata.Role)
+	ctx, span := tracing.Start(ctx, "datastore.User.Auth")
+	defer span.End()
+	row := s.store.queryRow("SELECT id, username, passwordhash, token_version, disabled, password_expires, must_change_password, last_login FROM \"user\" where username=?", username)
+	if row.Scan(&userdata.UID, &userdata.Username, &userdata.Password, &userdata.TokenVersion, &userdata.Status.Disabled, &userdata.Status.PasswordExpires, &userdata.Status.MustChangePassword, &userdata.LastLogin) != nil {
+		return userdata, nil
+	}
+	if userdata.Roles, err = s.userRoles(userdata.UID); err != nil {
+		return userdata, err
+	}
+	userdata.Scope, err = s.userScope(userdata.UID)
 	return userdata, err
 }
 
+//userRoles returns the role names of user uid
+func (s *userService) userRoles(uid int64) (roles []string, err error) {
+	rows, err := s.store.query("SELECT role FROM user_role WHERE user_id=? ORDER BY role", uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role string
+		if err = rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
 //AddUser implements UserService.AddUser()
+//Roles must exist.
 func (s *userService) AddUser(ctx context.Context, user numan.User) error {
-	_, err := s.store.db.Exec("INSERT INTO user(username, passwordhash, role) values(?,?,?)", user.Username, user.Password, user.Role)
+	ctx, span := tracing.Start(ctx, "datastore.User.AddUser")
+	defer span.End()
+	tx, err := s.store.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+	if err = s.addUser(tx, user); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//addUser adds user with their roles & scope in transaction tx
+func (s *userService) addUser(tx *sql.Tx, user numan.User) error {
+	if _, err := s.store.txExec(tx, "INSERT INTO \"user\"(username, passwordhash, disabled, password_expires, must_change_password) values(?,?,?,?,?)", user.Username, user.Password, user.Status.Disabled, user.Status.PasswordExpires, user.Status.MustChangePassword); err != nil {
+		return uniqueViolation(err, "Unable to add user, already exists")
+	}
+	if err := s.addPasswordHistory(tx, user.Username); err != nil {
+		return err
+	}
+	for _, role := range user.Roles {
+		if err := s.grantRole(tx, user.Username, role); err != nil {
+			return err
+		}
+	}
+	return s.setScope(tx, user.Username, user.Scope)
 }
 
 //DeleteUser  implements UserService.DeleteUser
+//The users refresh tokens, API keys & password history are deleted, access tokens are revoked as the user no longer exists.
 func (s *userService) DeleteUser(ctx context.Context, username string) error {
-	row, err := s.store.db.Exec("DELETE from user WHERE username=?", username)
+	ctx, span := tracing.Start(ctx, "datastore.User.DeleteUser")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = s.store.txExec(tx, "DELETE from refresh_token WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "DELETE from user_role WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "DELETE from user_scope WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+		return err
+	}
+	for _, table := range []string{"api_key_role", "api_key_scope"} {
+		if _, err = s.store.txExec(tx, "DELETE from "+table+" WHERE key_id IN (SELECT k.id FROM api_key k JOIN \"user\" u ON u.id=k.user_id WHERE u.username=?)", username); err != nil {
+			return err
+		}
+	}
+	if _, err = s.store.txExec(tx, "DELETE from api_key WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "DELETE from password_history WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "DELETE from user_identity WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+		return err
+	}
+	row, err := s.store.txExec(tx, "DELETE from \"user\" WHERE username=?", username)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to delete, check the username exists")
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to delete, check the username exists")
 	}
-	return nil
+	return tx.Commit()
 }
 
 //ListUsers  implements UserService.DeleteUser
 func (s *userService) ListUsers(ctx context.Context, userfilter string) (userList []numan.User, err error) {
-	var result numan.User
+	ctx, span := tracing.Start(ctx, "datastore.User.ListUsers")
+	defer span.End()
+	var uid int64
+	var uids []int64
+	var username string
+	var status numan.AccountStatus
+	var lastLogin int64
+	var role sql.NullString
 	var resultList []numan.User
 	userfilter = userfilter + "%"
-	rows, err := s.store.db.Query("SELECT username, role FROM user where username like ? ", userfilter)
+	rows, err := s.store.query("SELECT u.id, u.username, u.disabled, u.password_expires, u.must_change_password, u.last_login, ur.role FROM \"user\" u LEFT JOIN user_role ur ON ur.user_id=u.id where u.username like ? ORDER BY u.id, ur.role", userfilter)
 	if err != nil {
 		return resultList, err
 	}
@@ -60,33 +148,165 @@ func (s *userService) ListUsers(ctx context.Context, userfilter string) (userLis
 
 	for rows.Next() {
 		err = rows.Scan(
-			&result.Username,
-			&result.Role,
+			&uid,
+			&username,
+			&status.Disabled,
+			&status.PasswordExpires,
+			&status.MustChangePassword,
+			&lastLogin,
+			&role,
 		)
 		if err != nil {
 			return resultList, err
 		}
-		resultList = append(resultList, result)
+		if len(resultList) == 0 || resultList[len(resultList)-1].Username != username { //one row per user role
+			resultList = append(resultList, numan.User{Username: username, Status: status, LastLogin: lastLogin})
+			uids = append(uids, uid)
+		}
+		if role.Valid {
+			resultList[len(resultList)-1].Roles = append(resultList[len(resultList)-1].Roles, role.String)
+		}
 	}
 	err = rows.Err()
 	if err != nil {
 		return resultList, err
 	}
+	rows.Close()
+	for i := range resultList {
+		if resultList[i].Scope, err = s.userScope(uids[i]); err != nil {
+			return resultList, err
+		}
+	}
 	return resultList, nil
 }
 
-//ChangePassword implements UserService.ChangePassword
+//SetPassword implements UserService.SetPassword
 func (s *userService) SetPassword(ctx context.Context, username string, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.SetPassword")
+	defer span.End()
+	return s.setPassword(username, newPassword, false)
+}
+
+//ChangePassword implements UserService.ChangePassword
+//The old password is checked by the caller (as for Auth). The password expiry and forced change are cleared.
+func (s *userService) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.ChangePassword")
+	defer span.End()
+	return s.setPassword(username, newPassword, true)
+}
+
+//setPassword hashes & stores a users password (added to the password history), outstanding tokens are revoked. clearStatus clears the password expiry and forced change.
+func (s *userService) setPassword(username string, newPassword string, clearStatus bool) error {
 	u := numan.User{Password: newPassword, Username: username}
 	if err := u.HashPassword(); err != nil {
 		return err
 	}
-	row, err := s.store.db.Exec("UPDATE user SET passwordhash=? WHERE username=?", u.Password, u.Username)
+	query := "UPDATE \"user\" SET passwordhash=?, token_version=token_version+1 WHERE username=?"
+	if clearStatus {
+		query = "UPDATE \"user\" SET passwordhash=?, token_version=token_version+1, password_expires=0, must_change_password=false WHERE username=?"
+	}
+	//revoke outstanding tokens
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	row, err := s.store.txExec(tx, query, u.Password, u.Username)
+	if err != nil {
+		return err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to set password, check the username exists")
+	}
+	if err = s.addPasswordHistory(tx, username); err != nil {
+		return err
+	}
+	if _, err = s.store.txExec(tx, "DELETE from refresh_token WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//SetStatus implements UserService.SetStatus
+//The users access tokens are revoked (token version), refresh tokens are deleted if disabled, the password expired or must be changed.
+func (s *userService) SetStatus(ctx context.Context, username string, status numan.AccountStatus) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.SetStatus")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	row, err := s.store.txExec(tx, "UPDATE \"user\" SET disabled=?, password_expires=?, must_change_password=?, token_version=token_version+1 WHERE username=?", status.Disabled, status.PasswordExpires, status.MustChangePassword, username)
 	if err != nil {
 		return err
 	}
-	if n, _ := row.RowsAffected(); n == 0 { //ok for sqlite. RowsAffected may not be supported with other drivers.
-		return errors.New("Unable to set password, check the username exists")
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to set status, check the username exists")
+	}
+	if status.Disabled || status.MustChangePassword || status.PasswordExpired() {
+		if _, err = s.store.txExec(tx, "DELETE from refresh_token WHERE user_id IN (SELECT id FROM \"user\" WHERE username=?)", username); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//RotateKey implements UserService.RotateKey
+//Signing keys are not stored in the database, they are held by the current numan.KeySet (key file).
+func (s *userService) RotateKey(ctx context.Context, algorithm string) (numan.SigningKey, error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.RotateKey")
+	defer span.End()
+	return numan.CurrentKeySet().Rotate(algorithm)
+}
+
+//ListKeys implements UserService.ListKeys
+func (s *userService) ListKeys(ctx context.Context) ([]numan.SigningKey, error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.ListKeys")
+	defer span.End()
+	return numan.CurrentKeySet().Keys(), nil
+}
+
+//Refresh implements UserService.Refresh
+//The refresh token is used up, returns the stored user it belongs to.
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (userdata numan.User, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.User.Refresh")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return userdata, err
 	}
-	return nil
+	defer tx.Rollback()
+	hash := numan.HashToken(refreshToken)
+	row := s.store.txQueryRow(tx, "SELECT u.id, u.username, u.passwordhash, u.token_version, u.disabled, u.password_expires, u.must_change_password, u.last_login FROM refresh_token t JOIN \"user\" u ON u.id=t.user_id WHERE t.token_hash=? and t.expires>?", hash, time.Now().Unix())
+	if err = row.Scan(&userdata.UID, &userdata.Username, &userdata.Password, &userdata.TokenVersion, &userdata.Status.Disabled, &userdata.Status.PasswordExpires, &userdata.Status.MustChangePassword, &userdata.LastLogin); err != nil {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Invalid or expired refresh token")
+	}
+	//used up by the delete, a concurrent refresh with the same token deletes nothing and fails
+	deleted, err := s.store.txExec(tx, "DELETE FROM refresh_token WHERE token_hash=?", hash)
+	if err != nil {
+		return numan.User{}, err
+	}
+	if n, err := deleted.RowsAffected(); err != nil || n != 1 {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Invalid or expired refresh token")
+	}
+	if _, err = s.store.txExec(tx, "DELETE FROM refresh_token WHERE expires<?", time.Now().Unix()); err != nil {
+		return numan.User{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return numan.User{}, err
+	}
+	if userdata.Roles, err = s.userRoles(userdata.UID); err != nil {
+		return userdata, err
+	}
+	userdata.Scope, err = s.userScope(userdata.UID)
+	return userdata, err
+}
+
+//Logout implements UserService.Logout
+func (s *userService) Logout(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "datastore.User.Logout")
+	defer span.End()
+	_, err := s.store.exec("DELETE FROM refresh_token WHERE token_hash=?", numan.HashToken(refreshToken))
+	return err
 }
@@ -0,0 +1,280 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// webhookService implements the WebhookService interface
+type webhookService struct {
+	store Store
+}
+
+// NewWebhookService instantiates a WebhookService
+func NewWebhookService(store *Store) numan.WebhookService {
+	return &webhookService{
+		store: *store,
+	}
+}
+
+//AddWebhook implements WebhookService.AddWebhook()
+//Events stored before the webhook is added are not delivered.
+func (s *webhookService) AddWebhook(ctx context.Context, hook numan.Webhook) (numan.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "datastore.Webhook.AddWebhook")
+	defer span.End()
+	hook.Created = time.Now().Unix()
+	id, err := s.store.insert("INSERT INTO webhook(url, types, domain, carrier, prefix, secret, last_seq, created) SELECT ?, ?, ?, ?, ?, ?, coalesce(max(seq), 0), ? FROM number_event",
+		hook.URL, strings.Join(hook.Filter.Types, ","), hook.Filter.Domain, hook.Filter.Carrier, hook.Filter.Prefix, hook.Secret, hook.Created)
+	if err != nil {
+		return numan.Webhook{}, err
+	}
+	hook.ID = id
+	return hook, nil
+}
+
+//ListWebhooks implements WebhookService.ListWebhooks()
+func (s *webhookService) ListWebhooks(ctx context.Context) (hooks []numan.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.Webhook.ListWebhooks")
+	defer span.End()
+	rows, err := s.store.query(`SELECT id, url, types, domain, carrier, prefix, created,
+		(SELECT count(*) FROM webhook_delivery d WHERE d.webhook_id=w.id AND d.dead=?),
+		(SELECT count(*) FROM webhook_delivery d WHERE d.webhook_id=w.id AND d.dead=?)
+		FROM webhook w ORDER BY id`, false, true)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hook numan.Webhook
+		var types string
+		if err = rows.Scan(&hook.ID, &hook.URL, &types, &hook.Filter.Domain, &hook.Filter.Carrier, &hook.Filter.Prefix, &hook.Created, &hook.Pending, &hook.Dead); err != nil {
+			return nil, err
+		}
+		hook.Filter.Types = splitTypes(types)
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+//DeleteWebhook implements WebhookService.DeleteWebhook()
+func (s *webhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "datastore.Webhook.DeleteWebhook")
+	defer span.End()
+	tx, err := s.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := s.store.txExec(tx, "DELETE FROM webhook_delivery WHERE webhook_id=?", id); err != nil {
+		return err
+	}
+	row, err := s.store.txExec(tx, "DELETE FROM webhook WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to delete, check the webhook exists")
+	}
+	return tx.Commit()
+}
+
+//ListDeadLetters implements WebhookService.ListDeadLetters()
+func (s *webhookService) ListDeadLetters(ctx context.Context, webhookID int64) (deliveries []numan.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "datastore.Webhook.ListDeadLetters")
+	defer span.End()
+	where, args := "dead=?", []interface{}{true}
+	if webhookID != 0 {
+		where, args = where+" AND webhook_id=?", append(args, webhookID)
+	}
+	rows, err := s.store.query("SELECT id, webhook_id, payload, attempts, last_error, created FROM webhook_delivery WHERE "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		d := numan.WebhookDelivery{Dead: true}
+		var payload string
+		if err = rows.Scan(&d.ID, &d.WebhookID, &payload, &d.Attempts, &d.LastError, &d.Created); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(payload), &d.Payload); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+//RedeliverDeadLetters implements WebhookService.RedeliverDeadLetters()
+//Dead deliveries are queued for immediate delivery with their attempts reset.
+func (s *webhookService) RedeliverDeadLetters(ctx context.Context, webhookID int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "datastore.Webhook.RedeliverDeadLetters")
+	defer span.End()
+	where, args := "dead=?", []interface{}{false, time.Now().Unix(), true}
+	if webhookID != 0 {
+		where, args = where+" AND webhook_id=?", append(args, webhookID)
+	}
+	row, err := s.store.exec("UPDATE webhook_delivery SET dead=?, attempts=0, next_attempt=? WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return row.RowsAffected()
+}
+
+//QueuedDelivery is a webhook delivery claimed for sending (see ClaimDeliveries)
+type QueuedDelivery struct {
+	numan.WebhookDelivery
+	URL    string
+	Secret string
+	Body   []byte //JSON payload as queued
+}
+
+//RelayEvents queues deliveries of the number events stored after each webhook's last relayed event (the number_event table is the outbox).
+//Deliveries & the webhook position are written in one transaction, so each event is queued once per webhook, also with several servers relaying.
+//Up to limit events are relayed per webhook, returns the number of deliveries queued.
+func (s *Store) RelayEvents(limit int) (int, error) {
+	rows, err := s.query("SELECT id, types, domain, carrier, prefix, last_seq FROM webhook ORDER BY id")
+	if err != nil {
+		return 0, err
+	}
+	type position struct {
+		id      int64
+		filter  numan.EventFilter
+		lastSeq int64
+	}
+	var hooks []position
+	for rows.Next() {
+		var p position
+		var types string
+		if err := rows.Scan(&p.id, &types, &p.filter.Domain, &p.filter.Carrier, &p.filter.Prefix, &p.lastSeq); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		p.filter.Types = splitTypes(types)
+		hooks = append(hooks, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, p := range hooks {
+		//all events up to maxSeq are committed (events are stored in sequence order), so the position can move past events not matching the filter
+		var maxSeq int64
+		if err := s.queryRow("SELECT coalesce(max(seq), 0) FROM number_event").Scan(&maxSeq); err != nil {
+			return queued, err
+		}
+		p.filter.AfterSeq = p.lastSeq
+		events, err := s.listEvents(&p.filter, limit)
+		if err != nil {
+			return queued, err
+		}
+		nextSeq := maxSeq
+		if len(events) == limit || (len(events) > 0 && events[len(events)-1].Seq > maxSeq) {
+			nextSeq = events[len(events)-1].Seq
+		}
+		if nextSeq <= p.lastSeq {
+			continue
+		}
+		n, err := s.relay(p.id, p.lastSeq, nextSeq, events)
+		if err != nil {
+			return queued, err
+		}
+		queued += n
+	}
+	return queued, nil
+}
+
+//relay queues deliveries of events to webhook id & moves its position from lastSeq to nextSeq in a transaction.
+//Nothing is queued if the position was moved by another server.
+func (s *Store) relay(id int64, lastSeq int64, nextSeq int64, events []numan.NumberEvent) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	row, err := s.txExec(tx, "UPDATE webhook SET last_seq=? WHERE id=? AND last_seq=?", nextSeq, id, lastSeq)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := row.RowsAffected(); n == 0 {
+		return 0, nil
+	}
+	now := time.Now().Unix()
+	for _, e := range events {
+		payload, err := json.Marshal(numan.NewWebhookPayload(e))
+		if err != nil {
+			return 0, err
+		}
+		if _, err := s.txExec(tx, "INSERT INTO webhook_delivery(webhook_id, payload, next_attempt, created) values(?,?,?,?)", id, string(payload), now, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), tx.Commit()
+}
+
+//ClaimDeliveries returns up to limit deliveries due at now, claimed for lease (not returned to other callers until the lease ends)
+func (s *Store) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]QueuedDelivery, error) {
+	rows, err := s.query(`SELECT d.id, d.webhook_id, d.payload, d.attempts, d.next_attempt, d.last_error, d.created, w.url, w.secret
+		FROM webhook_delivery d JOIN webhook w ON w.id=d.webhook_id WHERE d.dead=? AND d.next_attempt<=? ORDER BY d.id LIMIT ?`, false, now.Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	var due []QueuedDelivery
+	for rows.Next() {
+		var d QueuedDelivery
+		var payload string
+		if err := rows.Scan(&d.ID, &d.WebhookID, &payload, &d.Attempts, &d.NextAttempt, &d.LastError, &d.Created, &d.URL, &d.Secret); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		d.Body = []byte(payload)
+		if err := json.Unmarshal(d.Body, &d.Payload); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var claimed []QueuedDelivery
+	for _, d := range due {
+		row, err := s.exec("UPDATE webhook_delivery SET next_attempt=? WHERE id=? AND next_attempt=? AND dead=?", now.Add(lease).Unix(), d.ID, d.NextAttempt, false)
+		if err != nil {
+			return claimed, err
+		}
+		if n, _ := row.RowsAffected(); n == 1 {
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+//DeliverySucceeded removes a delivered delivery from the queue
+func (s *Store) DeliverySucceeded(id int64) error {
+	_, err := s.exec("DELETE FROM webhook_delivery WHERE id=?", id)
+	return err
+}
+
+//DeliveryFailed records a failed attempt of a delivery, it is retried at nextAttempt OR moved to the dead-letter list if dead
+func (s *Store) DeliveryFailed(id int64, attempts int, nextAttempt time.Time, lastError string, dead bool) error {
+	_, err := s.exec("UPDATE webhook_delivery SET attempts=?, next_attempt=?, last_error=?, dead=? WHERE id=?", attempts, nextAttempt.Unix(), lastError, dead, id)
+	return err
+}
+
+//splitTypes splits stored event types (comma separated)
+func splitTypes(types string) []string {
+	if types == "" {
+		return nil
+	}
+	return strings.Split(types, ",")
+}
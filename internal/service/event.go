@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/auth"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+//maxEventLimit is the maximum number of events listed at a time
+const maxEventLimit = 1000
+
+// eventService implements the EventService interface
+type eventService struct {
+	next numan.EventService
+}
+
+// NewEventService instantiates a new EventService.
+func NewEventService(store *datastore.Store) numan.EventService {
+	return &eventService{
+		next: auth.NewEventService(store),
+	}
+}
+
+//ListEvents implements EventService.ListEvents()
+func (s *eventService) ListEvents(ctx context.Context, filter *numan.EventFilter, limit int) ([]numan.NumberEvent, error) {
+	ctx, span := tracing.Start(ctx, "service.Event.ListEvents")
+	defer span.End()
+	if filter == nil {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if err := filter.Valid(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxEventLimit {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "limit must be 1 to %d", maxEventLimit)
+	}
+	return s.next.ListEvents(ctx, filter, limit)
+}
+
+//Watch implements EventService.Watch()
+func (s *eventService) Watch(ctx context.Context, filter *numan.EventFilter, send func(numan.NumberEvent) error) error {
+	ctx, span := tracing.Start(ctx, "service.Event.Watch")
+	defer span.End()
+	if filter == nil || send == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if err := filter.Valid(); err != nil {
+		return err
+	}
+	return s.next.Watch(ctx, filter, send)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/footfish/numan"
 	"github.com/footfish/numan/internal/service/auth"
 	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 // historyService implements the HistoryService interface
@@ -22,15 +23,34 @@ func NewHistoryService(store *datastore.Store) numan.HistoryService {
 
 //AddHistory  implements HistoryService.AddHistory()
 func (s *historyService) AddHistory(ctx context.Context, historyEntry numan.History) error {
+	ctx, span := tracing.Start(ctx, "service.History.AddHistory")
+	defer span.End()
 	return s.next.AddHistory(ctx, historyEntry)
 }
 
 //ListHistoryByNumber implements HistoryService.ListHistoryByNumber()
-func (s *historyService) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164) (history []numan.History, err error) {
-	return s.next.ListHistoryByNumber(ctx, phoneNumber)
+func (s *historyService) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164, archived bool) (history []numan.History, err error) {
+	ctx, span := tracing.Start(ctx, "service.History.ListHistoryByNumber")
+	defer span.End()
+	return s.next.ListHistoryByNumber(ctx, phoneNumber, archived)
 }
 
 //ListHistoryByOwnerID implements HistoryService.ListHistoryByUserId()
-func (s *historyService) ListHistoryByOwnerID(ctx context.Context, ownerID int64) (history []numan.History, err error) {
-	return s.next.ListHistoryByOwnerID(ctx, ownerID)
+func (s *historyService) ListHistoryByOwnerID(ctx context.Context, ownerID int64, archived bool) (history []numan.History, err error) {
+	ctx, span := tracing.Start(ctx, "service.History.ListHistoryByOwnerID")
+	defer span.End()
+	return s.next.ListHistoryByOwnerID(ctx, ownerID, archived)
+}
+
+//ArchiveHistory implements HistoryService.ArchiveHistory()
+func (s *historyService) ArchiveHistory(ctx context.Context, policy numan.RetentionPolicy) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.History.ArchiveHistory")
+	defer span.End()
+	if policy.Before <= 0 {
+		return 0, numan.Errorf(numan.ErrInvalidArgument, "Can't archive history, time out of bounds")
+	}
+	if policy.KeepLast < 0 {
+		return 0, numan.Errorf(numan.ErrInvalidArgument, "Can't archive history, keep last must not be negative")
+	}
+	return s.next.ArchiveHistory(ctx, policy)
 }
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	. "github.com/footfish/numan/internal/service"
+)
+
+func TestArchiveHistory(t *testing.T) {
+	t.Run("OkArchiveKeepLast", func(t *testing.T) {
+		nu, store := HelperNewNumberingService(t)
+		defer store.Close()
+		hist := NewHistoryService(store)
+
+		ctx, cancel := HelperContext(t, numan.RoleUser)
+		defer cancel()
+
+		//Add, allocate & deallocate (3 history entries)
+		ownerID := int64(99)
+		if err := nu.Add(ctx, &numan.Numbering{E164: validPhoneNumbers[0], Domain: "anydomain.com", Carrier: "anycarrier"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := nu.Allocate(ctx, &validPhoneNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		if err := nu.DeAllocate(ctx, &validPhoneNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+
+		//Archive all but the last entry
+		adminCtx, adminCancel := HelperContext(t, numan.RoleAdmin)
+		defer adminCancel()
+		if archived, err := hist.ArchiveHistory(adminCtx, numan.RetentionPolicy{Before: time.Now().Unix() + 1, KeepLast: 1}); err != nil {
+			t.Fatal(err)
+		} else if want, got := int64(2), archived; want != got {
+			t.Fatalf("Archived got %v, want %v", got, want)
+		}
+
+		//Read & check
+		if historyList, err := hist.ListHistoryByNumber(ctx, validPhoneNumbers[0], false); err != nil {
+			t.Fatal(err)
+		} else if want, got := 1, len(historyList); want != got {
+			t.Fatalf("History got %v, want %v", got, want)
+		} else if want, got := "deallocated", historyList[0].Action; want != got {
+			t.Fatalf("Kept entry got %v, want %v", got, want)
+		}
+		if historyList, err := hist.ListHistoryByNumber(ctx, validPhoneNumbers[0], true); err != nil {
+			t.Fatal(err)
+		} else if want, got := 3, len(historyList); want != got {
+			t.Fatalf("History with archive got %v, want %v", got, want)
+		}
+		if historyList, err := hist.ListHistoryByOwnerID(ctx, ownerID, true); err != nil {
+			t.Fatal(err)
+		} else if want, got := 2, len(historyList); want != got {
+			t.Fatalf("Owner history with archive got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("ErrNotAdmin", func(t *testing.T) {
+		_, store := HelperNewNumberingService(t)
+		defer store.Close()
+		hist := NewHistoryService(store)
+
+		ctx, cancel := HelperContext(t, numan.RoleUser)
+		defer cancel()
+
+		if _, err := hist.ArchiveHistory(ctx, numan.RetentionPolicy{Before: time.Now().Unix()}); err == nil {
+			t.Fatal("History archived without admin role")
+		}
+	})
+}
@@ -2,38 +2,41 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/footfish/numan"
 	"github.com/footfish/numan/internal/service/auth"
 	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 // numberingService implements the NumberingService interface
 type numberingService struct {
 	next numan.NumberingService
-	hist numan.HistoryService //used for logging
+	hist numan.HistoryService //used for logging, bypasses auth as the logged operation was authorized
 }
 
 // NewNumberService instantiates a new NumberService.
 func NewNumberingService(store *datastore.Store) numan.NumberingService {
 	return &numberingService{
 		next: auth.NewNumberingService(store),
-		hist: NewHistoryService(store),
+		hist: datastore.NewHistoryService(store),
 	}
 }
 
 // Add implements NumberingService.Add()
 func (s *numberingService) Add(ctx context.Context, number *numan.Numbering) error {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Add")
+	defer span.End()
 	if number == nil {
-		return errors.New("nil pointer")
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 	if err := number.E164.ValidE164(); err != nil {
 		return err
 	}
 	if len(number.Domain) == 0 || len(number.Carrier) == 0 {
-		return errors.New("Carrier & domain required")
+		return numan.Errorf(numan.ErrInvalidArgument, "Carrier & domain required")
 	}
 	newNumber := numan.Numbering{E164: number.E164, Domain: number.Domain, Carrier: number.Carrier} //clean
 
@@ -44,14 +47,12 @@ func (s *numberingService) Add(ctx context.Context, number *numan.Numbering) err
 	return err
 }
 
-//AddGroup not implemented
-func (s *numberingService) AddGroup(ctx context.Context) {
-}
-
 //List implements NumberingService.List()
 func (s *numberingService) List(ctx context.Context, filter *numan.NumberFilter) ([]numan.Numbering, error) {
+	ctx, span := tracing.Start(ctx, "service.Numbering.List")
+	defer span.End()
 	if filter == nil {
-		return nil, errors.New("nil pointer")
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 	filtered, err := s.next.List(ctx, filter)
 	if err != nil {
@@ -62,18 +63,24 @@ func (s *numberingService) List(ctx context.Context, filter *numan.NumberFilter)
 
 //ListOwnerID implements NumberingService.ListOwnerID()
 func (s *numberingService) ListOwnerID(ctx context.Context, oid int64) ([]numan.Numbering, error) {
+	ctx, span := tracing.Start(ctx, "service.Numbering.ListOwnerID")
+	defer span.End()
 	return s.next.ListOwnerID(ctx, oid)
 }
 
 //Summary implements NumberingService.Summary()
 func (s *numberingService) Summary(ctx context.Context) (string, error) {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Summary")
+	defer span.End()
 	return s.next.Summary(ctx)
 }
 
 //Delete implements NumberingService.Delete()
 func (s *numberingService) Delete(ctx context.Context, phonenumber *numan.E164) error {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Delete")
+	defer span.End()
 	if phonenumber == nil {
-		return errors.New("nil pointer")
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 	err := s.next.Delete(ctx, phonenumber)
 	if err == nil { //log history
@@ -84,8 +91,10 @@ func (s *numberingService) Delete(ctx context.Context, phonenumber *numan.E164)
 
 //View implements NumberingService.View()
 func (s *numberingService) View(ctx context.Context, number *numan.E164) (string, error) {
+	ctx, span := tracing.Start(ctx, "service.Numbering.View")
+	defer span.End()
 	if number == nil {
-		return "Run time error, nil pointer", errors.New("nil pointer")
+		return "Run time error, nil pointer", numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 
 	return s.next.View(ctx, number)
@@ -93,33 +102,37 @@ func (s *numberingService) View(ctx context.Context, number *numan.E164) (string
 
 //Reserve implements NumberingService.Reserve()
 func (s *numberingService) Reserve(ctx context.Context, number *numan.E164, ownerID *int64, untilTS *int64) error {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Reserve")
+	defer span.End()
 	if number == nil {
-		return errors.New("nil pointer")
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 
 	if *untilTS < time.Now().Unix() || *untilTS > (time.Now().Unix()+numan.MAXRESERVATIONTIME) {
-		return errors.New("Can't reserve number, time out of bounds")
+		return numan.Errorf(numan.ErrInvalidArgument, "Can't reserve number, time out of bounds")
 	}
 	if err := number.ValidE164(); err != nil {
-		return errors.New("Can't reserve number, " + err.Error())
+		return fmt.Errorf("Can't reserve number, %w", err)
 	}
 	if err := numan.ValidOwnerID(ownerID); err != nil {
-		return errors.New("Can't reserve number, " + err.Error())
+		return fmt.Errorf("Can't reserve number, %w", err)
 	}
 	return s.next.Reserve(ctx, number, ownerID, untilTS)
 }
 
 //Allocate implements NumberingService.Allocate()
 func (s *numberingService) Allocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Allocate")
+	defer span.End()
 	if number == nil || ownerID == nil {
-		return errors.New("nil pointer")
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 
 	if err := number.ValidE164(); err != nil {
-		return errors.New("Can't allocate number, " + err.Error())
+		return fmt.Errorf("Can't allocate number, %w", err)
 	}
 	if err := numan.ValidOwnerID(ownerID); err != nil {
-		return errors.New("Can't allocate number, " + err.Error())
+		return fmt.Errorf("Can't allocate number, %w", err)
 	}
 
 	err := s.next.Allocate(ctx, number, ownerID)
@@ -131,12 +144,14 @@ func (s *numberingService) Allocate(ctx context.Context, number *numan.E164, own
 
 //DeAllocate implements NumberingService.DeAllocate()
 func (s *numberingService) DeAllocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
+	ctx, span := tracing.Start(ctx, "service.Numbering.DeAllocate")
+	defer span.End()
 	if number == nil || ownerID == nil {
-		return errors.New("nil pointer")
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 
 	if err := number.ValidE164(); err != nil {
-		return errors.New("Can't deallocate number, " + err.Error())
+		return fmt.Errorf("Can't deallocate number, %w", err)
 	}
 	err := s.next.DeAllocate(ctx, number, ownerID)
 	if err == nil { //log history
@@ -147,16 +162,18 @@ func (s *numberingService) DeAllocate(ctx context.Context, number *numan.E164, o
 
 //Portout implements NumberingService.Portout()
 func (s *numberingService) Portout(ctx context.Context, number *numan.E164, PortoutTS *int64) error {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Portout")
+	defer span.End()
 	if number == nil || PortoutTS == nil {
-		return errors.New("nil pointer")
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 
 	if *PortoutTS < time.Now().Unix()-(365*24*60*60) || *PortoutTS > (time.Now().Unix()+(365*24*60*60)) {
-		return errors.New("Can't use date, time out of bounds (+-1 year)")
+		return numan.Errorf(numan.ErrInvalidArgument, "Can't use date, time out of bounds (+-1 year)")
 	}
 
 	if err := number.ValidE164(); err != nil {
-		return errors.New("Can't, " + err.Error())
+		return fmt.Errorf("Can't, %w", err)
 	}
 	err := s.next.Portout(ctx, number, PortoutTS)
 	if err == nil { //log history
@@ -167,16 +184,18 @@ func (s *numberingService) Portout(ctx context.Context, number *numan.E164, Port
 
 //Portin implements NumberingService.Portin()
 func (s *numberingService) Portin(ctx context.Context, number *numan.E164, PortinTS *int64) error {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Portin")
+	defer span.End()
 	if number == nil || PortinTS == nil {
-		return errors.New("nil pointer")
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
 	}
 
 	if *PortinTS < time.Now().Unix()-(365*24*60*60) || *PortinTS > (time.Now().Unix()+(365*24*60*60)) {
-		return errors.New("Can't use date, time out of bounds (+-1 year)")
+		return numan.Errorf(numan.ErrInvalidArgument, "Can't use date, time out of bounds (+-1 year)")
 	}
 
 	if err := number.ValidE164(); err != nil {
-		return errors.New("Can't, " + err.Error())
+		return fmt.Errorf("Can't, %w", err)
 	}
 	err := s.next.Portin(ctx, number, PortinTS)
 	if err == nil { //log history
@@ -184,3 +203,19 @@ func (s *numberingService) Portin(ctx context.Context, number *numan.E164, Porti
 	}
 	return err
 }
+
+//Update implements NumberingService.Update()
+func (s *numberingService) Update(ctx context.Context, number *numan.Numbering, fields []string) (numan.Numbering, error) {
+	ctx, span := tracing.Start(ctx, "service.Numbering.Update")
+	defer span.End()
+	if err := numan.ValidUpdate(number, fields); err != nil {
+		return numan.Numbering{}, err
+	}
+	update := numan.Numbering{E164: number.E164, Domain: number.Domain, Carrier: number.Carrier} //clean
+
+	updated, err := s.next.Update(ctx, &update, fields) //storage
+	if err == nil {                                     //log history
+		err = s.hist.AddHistory(ctx, numan.History{E164: number.E164, Action: "update", Notes: numan.UpdateNotes(*number, fields)})
+	}
+	return updated, err
+}
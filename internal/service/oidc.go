@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/oidc"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// OIDCAuthenticator authenticates users by a token from an external identity provider (SSO).
+// Users are mapped by claims, unknown users can be added on first login (auto-provisioning).
+type OIDCAuthenticator struct {
+	provider   *oidc.Provider
+	mapping    oidc.Mapping
+	provision  bool
+	users      numan.UserService
+	logins     *datastore.LoginStore
+	identities *datastore.IdentityStore
+}
+
+// NewOIDCAuthenticator instantiates a new OIDCAuthenticator, unknown users are added if provision is set
+func NewOIDCAuthenticator(store *datastore.Store, provider *oidc.Provider, mapping oidc.Mapping, provision bool) *OIDCAuthenticator {
+	return &OIDCAuthenticator{
+		provider:   provider,
+		mapping:    mapping,
+		provision:  provision,
+		users:      datastore.NewUserService(store), //stored user lookup only, no password
+		logins:     datastore.NewLoginStore(store),
+		identities: datastore.NewIdentityStore(store),
+	}
+}
+
+// AuthOIDC verifies an ID or access token and returns the mapped user with a new access token.
+// The user is the one added by the provider account (issuer & sub), never an existing user of the same name (ex. a local admin).
+// If the user is in a mapped group the provider is authoritative, stored roles & scope are updated to match.
+// Otherwise an existing user keeps their stored roles & scope, a new user gets the default roles.
+func (a *OIDCAuthenticator) AuthOIDC(ctx context.Context, rawToken string) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "service.OIDCAuthenticator.AuthOIDC")
+	defer span.End()
+	claims, err := a.provider.Verify(ctx, rawToken)
+	if err != nil {
+		return numan.User{}, err
+	}
+	id, err := a.mapping.Map(claims)
+	if err != nil {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Auth error: %w", err)
+	}
+	username, err := a.identities.Username(a.provider.Issuer(), id.Subject)
+	if err != nil {
+		return numan.User{}, err
+	}
+	if username == "" {
+		if err := a.addUser(ctx, id, loginSource(ctx)); err != nil {
+			return numan.User{}, err
+		}
+		username = id.Username
+	} else if id.Mapped {
+		user, err := a.users.Auth(ctx, username, "")
+		if err != nil {
+			return numan.User{}, err
+		}
+		if err := a.syncUser(ctx, user, id); err != nil {
+			return numan.User{}, err
+		}
+	}
+	user, err := a.users.Auth(ctx, username, "") //reload, sync may change token version
+	if err != nil {
+		return numan.User{}, err
+	}
+	if user.Status.Disabled {
+		return numan.User{}, numan.Errorf(numan.ErrPermissionDenied, "Account disabled")
+	}
+	if len(user.Roles) == 0 {
+		return numan.User{}, numan.Errorf(numan.ErrPermissionDenied, "Insufficient user privileges, no roles for '%s'", user.Username)
+	}
+	user.Password = ""
+	return user, user.SetNewAccessToken()
+}
+
+// addUser provisions a new user with a random password (login is by the provider only) linked to the provider account, provisioning is audited.
+// An existing user of the same name is refused, it wasn't added by this provider account.
+func (a *OIDCAuthenticator) addUser(ctx context.Context, id oidc.Identity, source string) error {
+	if existing, err := a.users.Auth(ctx, id.Username, ""); err != nil {
+		return err
+	} else if existing.UID != 0 {
+		return numan.Errorf(numan.ErrPermissionDenied, "user '%s' exists and was not added by this OIDC account", id.Username)
+	}
+	if !a.provision {
+		return numan.Errorf(numan.ErrPermissionDenied, "no user '%s' for OIDC login", id.Username)
+	}
+	if len(id.Roles) == 0 {
+		return numan.Errorf(numan.ErrPermissionDenied, "Insufficient user privileges, no roles mapped for '%s'", id.Username)
+	}
+	user := numan.User{Username: id.Username, Roles: id.Roles, Scope: uniqueScope(id.Scope)}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	user.Password = base64.RawURLEncoding.EncodeToString(secret)
+	if err := user.HashPassword(); err != nil {
+		return fmt.Errorf("can't hash password: %w", err)
+	}
+	if err := a.identities.AddUser(ctx, user, a.provider.Issuer(), id.Subject); err != nil {
+		return err
+	}
+	notes := fmt.Sprintf("issuer %s, subject %s, roles %s", a.provider.Issuer(), id.Subject, strings.Join(id.Roles, ","))
+	return a.logins.AddAudit(numan.AuditEntry{Username: id.Username, Source: source, Action: numan.AuditProvisioned, Notes: notes})
+}
+
+// syncUser updates the stored roles & scope of user to match the provider
+func (a *OIDCAuthenticator) syncUser(ctx context.Context, user numan.User, id oidc.Identity) error {
+	for _, role := range id.Roles {
+		if !contains(user.Roles, role) {
+			if err := a.users.GrantRole(ctx, user.Username, role); err != nil {
+				return err
+			}
+		}
+	}
+	for _, role := range user.Roles {
+		if !contains(id.Roles, role) {
+			if err := a.users.RevokeRole(ctx, user.Username, role); err != nil {
+				return err
+			}
+		}
+	}
+	scope := uniqueScope(id.Scope)
+	if reflect.DeepEqual(sortedScope(scope), sortedScope(user.Scope)) {
+		return nil
+	}
+	return a.users.SetScope(ctx, user.Username, scope)
+}
+
+// sortedScope returns a copy of scope with sorted lists, for comparison
+func sortedScope(scope numan.Scope) numan.Scope {
+	sorted := func(list []string) []string {
+		if len(list) == 0 {
+			return nil
+		}
+		s := append([]string(nil), list...)
+		sort.Strings(s)
+		return s
+	}
+	return numan.Scope{Domains: sorted(scope.Domains), Carriers: sorted(scope.Carriers), Prefixes: sorted(scope.Prefixes)}
+}
+
+// contains returns true if list contains v
+func contains(list []string, v string) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/oidc"
+	"github.com/footfish/numan/internal/oidc/oidctest"
+	. "github.com/footfish/numan/internal/service"
+)
+
+func TestOIDCAuthenticator(t *testing.T) {
+	store := HelperNewStore(t)
+	defer store.Close()
+	iss, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iss.Close()
+	provider, err := oidc.NewProvider(oidc.Config{Issuer: iss.URL, Audience: oidctest.ClientID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping := oidc.Mapping{
+		GroupRoles:   map[string][]string{"numan-ops": {numan.RoleUser}},
+		GroupScopes:  map[string]numan.Scope{"ireland": {Prefixes: []string{"353"}}},
+		DefaultRoles: []string{numan.RoleViewer},
+	}
+	users, nu, sso := NewUserService(store), NewNumberingService(store), NewOIDCAuthenticator(store, provider, mapping, true)
+	adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+	defer cancel()
+
+	t.Run("OkProvision", func(t *testing.T) {
+		user, err := sso.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-jane", "preferred_username": "jane@example.com", "groups": []string{"numan-ops", "ireland"}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Username != "jane@example.com" || !reflect.DeepEqual(user.Roles, []string{numan.RoleUser}) || !reflect.DeepEqual(user.Scope.Prefixes, []string{"353"}) {
+			t.Fatalf("AuthOIDC got %+v", user)
+		}
+		ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+		if err := nu.Add(ctx, &numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}); err != nil {
+			t.Fatal("Provisioned user can't add number:", err)
+		}
+		audit, err := users.ListAudit(adminCtx, "jane@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(audit) != 1 || audit[0].Action != numan.AuditProvisioned {
+			t.Fatalf("ListAudit got %+v", audit)
+		}
+		if _, err := users.Auth(context.Background(), "jane@example.com", "secret123"); err == nil {
+			t.Fatal("Provisioned user logged in with a password")
+		}
+	})
+
+	t.Run("OkSyncGroups", func(t *testing.T) {
+		//the user is found by sub, the username claim (changed here) only names new users
+		if _, err := sso.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-jane", "preferred_username": "jane", "groups": []string{"numan-ops"}})); err != nil {
+			t.Fatal(err)
+		}
+		list, err := users.ListUsers(adminCtx, "jane")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].Username != "jane@example.com" || !list[0].Scope.Unrestricted() {
+			t.Fatalf("Scope not synced from groups, got %+v", list)
+		}
+	})
+
+	t.Run("OkDefaultRoles", func(t *testing.T) {
+		user, err := sso.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-bob", "preferred_username": "bob"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(user.Roles, []string{numan.RoleViewer}) {
+			t.Fatalf("AuthOIDC got %+v", user)
+		}
+	})
+
+	t.Run("ErrLocalUser", func(t *testing.T) {
+		//a provider account named admin is not the local admin
+		if err := users.AddUser(adminCtx, numan.User{Username: "admin", Password: "secret123", Roles: []string{numan.RoleAdmin}}); err != nil {
+			t.Fatal(err)
+		}
+		admin := iss.Token(map[string]interface{}{"sub": "u-admin", "preferred_username": "admin", "groups": []string{"numan-ops"}})
+		if user, err := sso.AuthOIDC(context.Background(), admin); err == nil {
+			t.Fatalf("OIDC login took over local user %+v", user)
+		}
+		list, err := users.ListUsers(adminCtx, "admin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || !reflect.DeepEqual(list[0].Roles, []string{numan.RoleAdmin}) {
+			t.Fatalf("Local admin changed by OIDC login, got %+v", list)
+		}
+		//the provider account is added as its own user by email
+		user, err := sso.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-admin", "preferred_username": "admin@idp.example.com", "groups": []string{"numan-ops"}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Username != "admin@idp.example.com" || !reflect.DeepEqual(user.Roles, []string{numan.RoleUser}) {
+			t.Fatalf("AuthOIDC got %+v", user)
+		}
+		//a second provider account can't take over the first
+		if _, err := sso.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-other", "preferred_username": "admin@idp.example.com"})); err == nil {
+			t.Fatal("OIDC login took over a user added by another provider account")
+		}
+	})
+
+	t.Run("ErrDisabled", func(t *testing.T) {
+		if err := users.SetStatus(adminCtx, "bob", numan.AccountStatus{Disabled: true}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sso.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-bob", "preferred_username": "bob"})); err == nil {
+			t.Fatal("Disabled user authenticated")
+		}
+	})
+
+	t.Run("ErrNoProvision", func(t *testing.T) {
+		noProvision := NewOIDCAuthenticator(store, provider, mapping, false)
+		if _, err := noProvision.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-carol", "preferred_username": "carol"})); err == nil {
+			t.Fatal("Unknown user provisioned")
+		}
+	})
+
+	t.Run("ErrToken", func(t *testing.T) {
+		if _, err := sso.AuthOIDC(context.Background(), iss.Token(map[string]interface{}{"sub": "u-jane", "preferred_username": "jane", "aud": "other"})); err == nil {
+			t.Fatal("Token for other audience accepted")
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/auth"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// rateLimitService implements the RateLimitService interface
+type rateLimitService struct {
+	next numan.RateLimitService
+}
+
+// NewRateLimitService instantiates a new RateLimitService of limiter (the server's limits).
+func NewRateLimitService(store *datastore.Store, limiter numan.RateLimitService) numan.RateLimitService {
+	return &rateLimitService{
+		next: auth.NewRateLimitService(store, limiter),
+	}
+}
+
+//ListRateLimits implements RateLimitService.ListRateLimits()
+func (s *rateLimitService) ListRateLimits(ctx context.Context) ([]numan.RateLimit, []numan.RateLimitUsage, error) {
+	ctx, span := tracing.Start(ctx, "service.RateLimit.ListRateLimits")
+	defer span.End()
+	return s.next.ListRateLimits(ctx)
+}
@@ -2,64 +2,161 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/footfish/numan"
 	"github.com/footfish/numan/internal/service/auth"
 	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
 )
 
 //userService implements the UserService interface
 type userService struct {
-	next numan.UserService
+	next   numan.UserService
+	tokens *datastore.TokenStore
+	logins *datastore.LoginStore
 }
 
 // NewUserService instantiates a new UserService.
 func NewUserService(store *datastore.Store) numan.UserService {
 	return &userService{
-		next: auth.NewUserService(store),
+		next:   auth.NewUserService(store),
+		tokens: datastore.NewTokenStore(store),
+		logins: datastore.NewLoginStore(store),
 	}
 }
 
 //Auth implements UserService.Auth()
+//Failed logins are counted by username and source, a locked out username or source is refused (see numan.LoginPolicy).
+//Disabled accounts and passwords which are expired or must be changed are refused.
 func (s *userService) Auth(ctx context.Context, username string, password string) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "service.User.Auth")
+	defer span.End()
 	//sanity checks
-	enteredUser := numan.User{Username: strings.ToLower(username), Password: strings.ToLower(password)}
+	enteredUser := numan.User{Username: strings.ToLower(username), Password: password}
 	if !enteredUser.ValidRawPassword() {
-		return enteredUser, errors.New("Invalid password")
+		return enteredUser, numan.Errorf(numan.ErrInvalidArgument, "Invalid password")
 	}
 	if !enteredUser.ValidUsername() {
-		return enteredUser, errors.New("Invalid Username")
+		return enteredUser, numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	source := loginSource(ctx)
+	if err := s.checkLockout(enteredUser.Username, source); err != nil {
+		return enteredUser, err
 	}
 	//Fetch User from store (password ignored)
 	storedUser, err := s.next.Auth(ctx, enteredUser.Username, enteredUser.Password)
+	if err != nil {
+		return storedUser, err
+	}
 	//Authenticate
-	if err == nil {
-		err = storedUser.ComparePassword(enteredUser.Password)
-		if err != nil {
-			return enteredUser, errors.New("Username/password mismatch")
+	if err = storedUser.ComparePassword(enteredUser.Password); err != nil {
+		if err = s.loginFailed(enteredUser.Username, source); err != nil {
+			return enteredUser, err
 		}
-		storedUser.SetNewAccessToken()
+		//Note: if public login should be obfiscating error here
+		return enteredUser, numan.Errorf(numan.ErrUnauthenticated, "Username/password mismatch")
+	}
+	if err = s.logins.ClearFailures(datastore.LoginUser, storedUser.Username); err != nil {
+		return storedUser, err
+	}
+	if err = checkStatus(storedUser.Status); err != nil {
+		return enteredUser, err
 	}
-	//Note: if public login should be obfiscating error here
+	if err = s.logins.SetLastLogin(storedUser.UID); err != nil {
+		return storedUser, err
+	}
+	err = s.issueTokens(&storedUser)
 	return storedUser, err
 }
 
+//checkStatus returns an error if the account status refuses login
+func checkStatus(status numan.AccountStatus) error {
+	switch {
+	case status.Disabled:
+		return numan.Errorf(numan.ErrPermissionDenied, "Account disabled")
+	case status.MustChangePassword:
+		return numan.Errorf(numan.ErrPasswordChangeRequired, "Password change required (see change_password)")
+	case status.PasswordExpired():
+		return numan.Errorf(numan.ErrPasswordChangeRequired, "Password expired (see change_password)")
+	}
+	return nil
+}
+
+//checkLockout returns an error if the username or source is locked out
+func (s *userService) checkLockout(username string, source string) error {
+	for _, login := range [][2]string{{datastore.LoginUser, username}, {datastore.LoginSource, source}} {
+		if login[1] == "" {
+			continue
+		}
+		lockedUntil, err := s.logins.LockedUntil(login[0], login[1])
+		if err != nil {
+			return err
+		}
+		if lockedUntil > 0 {
+			return numan.Errorf(numan.ErrLocked, "Too many failed logins, %s locked until %s", login[0], time.Unix(lockedUntil, 0).Format(numan.TIMESTAMPPRINTFORMAT))
+		}
+	}
+	return nil
+}
+
+//loginFailed counts a failed login for username and source, locking them out by policy. Failures and lockouts are audited.
+func (s *userService) loginFailed(username string, source string) error {
+	if err := s.logins.AddAudit(numan.AuditEntry{Username: username, Source: source, Action: numan.AuditLoginFailed}); err != nil {
+		return err
+	}
+	policy := numan.CurrentLoginPolicy()
+	for _, login := range [][2]string{{datastore.LoginUser, username}, {datastore.LoginSource, source}} {
+		if login[1] == "" {
+			continue
+		}
+		lockedUntil, err := s.logins.AddFailure(login[0], login[1], policy)
+		if err != nil {
+			return err
+		}
+		if lockedUntil > 0 {
+			notes := fmt.Sprintf("%s '%s' locked until %s", login[0], login[1], time.Unix(lockedUntil, 0).Format(numan.TIMESTAMPPRINTFORMAT))
+			if err := s.logins.AddAudit(numan.AuditEntry{Username: username, Source: source, Action: numan.AuditLockout, Notes: notes}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//loginSource returns the client address set in ctx by the server OR empty if local
+func loginSource(ctx context.Context) string {
+	source, _ := ctx.Value(numan.SourceField).(string)
+	return source
+}
+
 //AddUser implements UserService.AddUser()
+//A raw password is checked against the password policy.
 func (s *userService) AddUser(ctx context.Context, user numan.User) (err error) {
+	ctx, span := tracing.Start(ctx, "service.User.AddUser")
+	defer span.End()
 	//sanity checks
-	if !(user.Role == numan.RoleAdmin || user.Role == numan.RoleUser) {
-		return errors.New("incompatible role")
+	if len(user.Roles) == 0 {
+		return numan.Errorf(numan.ErrInvalidArgument, "role required")
 	}
+	for _, role := range user.Roles {
+		if !numan.ValidRoleName(role) {
+			return numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+		}
+	}
+	if err := user.Scope.Valid(); err != nil {
+		return err
+	}
+	user.Scope = uniqueScope(user.Scope)
 	if !user.ValidUsername() {
-		return errors.New("bad username")
+		return numan.Errorf(numan.ErrInvalidArgument, "bad username")
 	}
 	//Hash password if needed
 	if !user.PasswordIsHashed() {
-		if !user.ValidRawPassword() {
-			return errors.New("bad password")
+		if err = numan.CurrentPasswordPolicy().Check(user.Password); err != nil {
+			return err
 		}
 		if err = user.HashPassword(); err != nil {
 			return fmt.Errorf("can't hash password: %w", err)
@@ -71,19 +168,342 @@ func (s *userService) AddUser(ctx context.Context, user numan.User) (err error)
 
 //DeleteUser  implements UserService.DeleteUser
 func (s *userService) DeleteUser(ctx context.Context, username string) error {
+	ctx, span := tracing.Start(ctx, "service.User.DeleteUser")
+	defer span.End()
 	u := numan.User{Username: username}
 	if !u.ValidUsername() {
-		return errors.New("Invalid Username")
+		return numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
 	}
 	return s.next.DeleteUser(ctx, username)
 }
 
 //ListUsers  implements UserService.DeleteUser
 func (s *userService) ListUsers(ctx context.Context, userfilter string) ([]numan.User, error) {
+	ctx, span := tracing.Start(ctx, "service.User.ListUsers")
+	defer span.End()
 	return s.next.ListUsers(ctx, userfilter)
 }
 
-//ChangePassword implements UserService.ChangePassword
+//SetPassword implements UserService.SetPassword
+//The new password is checked against the password policy.
 func (s *userService) SetPassword(ctx context.Context, username string, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "service.User.SetPassword")
+	defer span.End()
+	if user := (numan.User{Username: username}); !user.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad username")
+	}
+	if err := s.checkPassword(username, newPassword); err != nil {
+		return err
+	}
 	return s.next.SetPassword(ctx, username, newPassword)
 }
+
+//checkPassword returns an error if password does not meet the password policy (including the users password history)
+func (s *userService) checkPassword(username string, password string) error {
+	policy := numan.CurrentPasswordPolicy()
+	if err := policy.Check(password); err != nil {
+		return err
+	}
+	if policy.History <= 0 {
+		return nil
+	}
+	hashes, err := s.logins.PasswordHistory(username, policy.History)
+	if err != nil {
+		return err
+	}
+	return policy.CheckHistory(password, hashes)
+}
+
+//ChangePassword implements UserService.ChangePassword
+//The old password authenticates, failed attempts count towards lockout (as for Auth). The new password is checked against the password policy.
+func (s *userService) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "service.User.ChangePassword")
+	defer span.End()
+	enteredUser := numan.User{Username: strings.ToLower(username), Password: oldPassword}
+	if !enteredUser.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	if err := numan.CurrentPasswordPolicy().Check(newPassword); err != nil {
+		return err
+	}
+	source := loginSource(ctx)
+	if err := s.checkLockout(enteredUser.Username, source); err != nil {
+		return err
+	}
+	storedUser, err := s.next.Auth(ctx, enteredUser.Username, enteredUser.Password)
+	if err != nil {
+		return err
+	}
+	if err = storedUser.ComparePassword(enteredUser.Password); err != nil {
+		if err = s.loginFailed(enteredUser.Username, source); err != nil {
+			return err
+		}
+		return numan.Errorf(numan.ErrUnauthenticated, "Username/password mismatch")
+	}
+	if storedUser.Status.Disabled {
+		return numan.Errorf(numan.ErrPermissionDenied, "Account disabled")
+	}
+	if storedUser.ComparePassword(newPassword) == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "new password must be different")
+	}
+	if err = s.checkPassword(storedUser.Username, newPassword); err != nil {
+		return err
+	}
+	return s.next.ChangePassword(ctx, enteredUser.Username, oldPassword, newPassword)
+}
+
+//SetStatus implements UserService.SetStatus
+func (s *userService) SetStatus(ctx context.Context, username string, status numan.AccountStatus) error {
+	ctx, span := tracing.Start(ctx, "service.User.SetStatus")
+	defer span.End()
+	if user := (numan.User{Username: username}); !user.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad username")
+	}
+	return s.next.SetStatus(ctx, username, status)
+}
+
+//RotateKey implements UserService.RotateKey
+func (s *userService) RotateKey(ctx context.Context, algorithm string) (numan.SigningKey, error) {
+	ctx, span := tracing.Start(ctx, "service.User.RotateKey")
+	defer span.End()
+	if !(algorithm == "" || algorithm == numan.AlgHS256 || algorithm == numan.AlgRS256 || algorithm == numan.AlgEdDSA) {
+		return numan.SigningKey{}, numan.Errorf(numan.ErrInvalidArgument, "unsupported signing algorithm")
+	}
+	return s.next.RotateKey(ctx, algorithm)
+}
+
+//ListKeys implements UserService.ListKeys
+func (s *userService) ListKeys(ctx context.Context) ([]numan.SigningKey, error) {
+	ctx, span := tracing.Start(ctx, "service.User.ListKeys")
+	defer span.End()
+	return s.next.ListKeys(ctx)
+}
+
+//Refresh implements UserService.Refresh
+//The account status is checked as by Auth, a refused account loses all its refresh tokens.
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "service.User.Refresh")
+	defer span.End()
+	if refreshToken == "" {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Invalid or expired refresh token")
+	}
+	user, err := s.next.Refresh(ctx, refreshToken)
+	if err != nil {
+		return numan.User{}, err
+	}
+	if err = checkStatus(user.Status); err != nil {
+		//ex. the password expired since the last login, the other sessions end too
+		if delErr := s.tokens.DeleteRefreshTokens(user.UID); delErr != nil {
+			return numan.User{}, delErr
+		}
+		return numan.User{}, err
+	}
+	err = s.issueTokens(&user)
+	return user, err
+}
+
+//Logout implements UserService.Logout
+//The access token in context is revoked (if valid) and the refresh token deleted.
+func (s *userService) Logout(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "service.User.Logout")
+	defer span.End()
+	user := numan.User{}
+	if err := user.SetUserFromToken(fmt.Sprintf("%s", ctx.Value(numan.AuthTokenField))); err == nil {
+		if err := s.tokens.RevokeToken(user.TokenID, user.TokenExpires); err != nil {
+			return err
+		}
+	}
+	return s.next.Logout(ctx, refreshToken)
+}
+
+//issueTokens sets a new access token & refresh token for user. The refresh token is stored.
+func (s *userService) issueTokens(user *numan.User) error {
+	if err := user.SetNewAccessToken(); err != nil {
+		return err
+	}
+	expires, err := user.SetNewRefreshToken()
+	if err != nil {
+		return err
+	}
+	return s.tokens.AddRefreshToken(user.UID, user.RefreshToken, expires)
+}
+
+//SetRole implements UserService.SetRole
+//The admin role can't be changed.
+func (s *userService) SetRole(ctx context.Context, role numan.Role) error {
+	ctx, span := tracing.Start(ctx, "service.User.SetRole")
+	defer span.End()
+	if !numan.ValidRoleName(role.Name) {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+	}
+	if role.Name == numan.RoleAdmin {
+		return numan.Errorf(numan.ErrPermissionDenied, "the admin role can't be changed")
+	}
+	if err := role.ValidPermissions(); err != nil {
+		return err
+	}
+	return s.next.SetRole(ctx, numan.Role{Name: role.Name, Permissions: uniqueStrings(role.Permissions)})
+}
+
+//DeleteRole implements UserService.DeleteRole
+func (s *userService) DeleteRole(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "service.User.DeleteRole")
+	defer span.End()
+	if !numan.ValidRoleName(name) {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+	}
+	if name == numan.RoleAdmin {
+		return numan.Errorf(numan.ErrPermissionDenied, "the admin role can't be deleted")
+	}
+	return s.next.DeleteRole(ctx, name)
+}
+
+//ListRoles implements UserService.ListRoles
+func (s *userService) ListRoles(ctx context.Context) ([]numan.Role, error) {
+	ctx, span := tracing.Start(ctx, "service.User.ListRoles")
+	defer span.End()
+	return s.next.ListRoles(ctx)
+}
+
+//GrantRole implements UserService.GrantRole
+func (s *userService) GrantRole(ctx context.Context, username string, role string) error {
+	ctx, span := tracing.Start(ctx, "service.User.GrantRole")
+	defer span.End()
+	u := numan.User{Username: username}
+	if !u.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	if !numan.ValidRoleName(role) {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+	}
+	return s.next.GrantRole(ctx, username, role)
+}
+
+//RevokeRole implements UserService.RevokeRole
+func (s *userService) RevokeRole(ctx context.Context, username string, role string) error {
+	ctx, span := tracing.Start(ctx, "service.User.RevokeRole")
+	defer span.End()
+	u := numan.User{Username: username}
+	if !u.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	if !numan.ValidRoleName(role) {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+	}
+	return s.next.RevokeRole(ctx, username, role)
+}
+
+//SetScope implements UserService.SetScope
+func (s *userService) SetScope(ctx context.Context, username string, scope numan.Scope) error {
+	ctx, span := tracing.Start(ctx, "service.User.SetScope")
+	defer span.End()
+	u := numan.User{Username: username}
+	if !u.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	if err := scope.Valid(); err != nil {
+		return err
+	}
+	return s.next.SetScope(ctx, username, uniqueScope(scope))
+}
+
+//AddAPIKey implements UserService.AddAPIKey
+//A new key id & secret key are generated. The key roles & scope must be within the user's (checked by the store).
+func (s *userService) AddAPIKey(ctx context.Context, key numan.APIKey) (numan.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "service.User.AddAPIKey")
+	defer span.End()
+	u := numan.User{Username: key.Username}
+	if !u.ValidUsername() {
+		return numan.APIKey{}, numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	if key.Expires != 0 && key.Expires < time.Now().Unix() {
+		return numan.APIKey{}, numan.Errorf(numan.ErrInvalidArgument, "Can't add API key, expiry in the past")
+	}
+	for _, role := range key.Roles {
+		if !numan.ValidRoleName(role) {
+			return numan.APIKey{}, numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+		}
+	}
+	if err := key.Scope.Valid(); err != nil {
+		return numan.APIKey{}, err
+	}
+	newKey := numan.APIKey{Username: key.Username, Expires: key.Expires, Roles: uniqueStrings(key.Roles), Scope: uniqueScope(key.Scope)} //clean
+	if err := newKey.SetNewKey(); err != nil {
+		return numan.APIKey{}, err
+	}
+	return s.next.AddAPIKey(ctx, newKey)
+}
+
+//ListAPIKeys implements UserService.ListAPIKeys
+func (s *userService) ListAPIKeys(ctx context.Context, userfilter string) ([]numan.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "service.User.ListAPIKeys")
+	defer span.End()
+	return s.next.ListAPIKeys(ctx, userfilter)
+}
+
+//DeleteAPIKey implements UserService.DeleteAPIKey
+func (s *userService) DeleteAPIKey(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "service.User.DeleteAPIKey")
+	defer span.End()
+	if id == "" {
+		return numan.Errorf(numan.ErrInvalidArgument, "API key id required")
+	}
+	return s.next.DeleteAPIKey(ctx, id)
+}
+
+//AuthAPIKey implements UserService.AuthAPIKey
+func (s *userService) AuthAPIKey(ctx context.Context, key string) (numan.User, error) {
+	ctx, span := tracing.Start(ctx, "service.User.AuthAPIKey")
+	defer span.End()
+	if _, _, err := numan.ParseAPIKey(key); err != nil {
+		return numan.User{}, err
+	}
+	user, err := s.next.AuthAPIKey(ctx, key)
+	if err != nil {
+		return numan.User{}, err
+	}
+	if err = checkStatus(user.Status); err != nil {
+		return numan.User{}, err
+	}
+	err = user.SetNewAccessToken()
+	return user, err
+}
+
+//Unlock implements UserService.Unlock, unlocks are audited
+func (s *userService) Unlock(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "service.User.Unlock")
+	defer span.End()
+	if name == "" {
+		return numan.Errorf(numan.ErrInvalidArgument, "username or source required")
+	}
+	if err := s.next.Unlock(ctx, name); err != nil {
+		return err
+	}
+	admin := numan.User{}
+	admin.SetUserFromToken(fmt.Sprintf("%s", ctx.Value(numan.AuthTokenField)))
+	return s.logins.AddAudit(numan.AuditEntry{Username: name, Source: loginSource(ctx), Action: numan.AuditUnlock, Notes: "by " + admin.Username})
+}
+
+//ListAudit implements UserService.ListAudit
+func (s *userService) ListAudit(ctx context.Context, userfilter string) ([]numan.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "service.User.ListAudit")
+	defer span.End()
+	return s.next.ListAudit(ctx, userfilter)
+}
+
+//uniqueScope returns scope without duplicate entries
+func uniqueScope(scope numan.Scope) numan.Scope {
+	return numan.Scope{Domains: uniqueStrings(scope.Domains), Carriers: uniqueStrings(scope.Carriers), Prefixes: uniqueStrings(scope.Prefixes)}
+}
+
+//uniqueStrings returns list without duplicates (order kept)
+func uniqueStrings(list []string) (unique []string) {
+	seen := map[string]bool{}
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
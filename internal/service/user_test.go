@@ -0,0 +1,410 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	. "github.com/footfish/numan/internal/service"
+)
+
+func TestTokenRevocation(t *testing.T) {
+	//helperLogin adds user alice & returns authenticated user
+	helperLogin := func(t *testing.T, users numan.UserService) numan.User {
+		t.Helper()
+		adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+		defer cancel()
+		if err := users.AddUser(adminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		user, err := users.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	t.Run("OkLogout", func(t *testing.T) {
+		store := HelperNewStore(t)
+		defer store.Close()
+		users, nu := NewUserService(store), NewNumberingService(store)
+		user := helperLogin(t, users)
+
+		ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+		if _, err := nu.Summary(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := users.Logout(ctx, user.RefreshToken); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := nu.Summary(ctx); !errors.Is(err, numan.ErrUnauthenticated) {
+			t.Fatal("Access token valid after logout")
+		}
+	})
+
+	t.Run("OkSetPassword", func(t *testing.T) {
+		store := HelperNewStore(t)
+		defer store.Close()
+		users, nu := NewUserService(store), NewNumberingService(store)
+		user := helperLogin(t, users)
+
+		adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+		defer cancel()
+		if err := users.SetPassword(adminCtx, "alice", "newsecret123"); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+		if _, err := nu.Summary(ctx); err == nil {
+			t.Fatal("Access token valid after password change")
+		}
+		//new login is valid
+		user, err := users.Auth(context.Background(), "alice", "newsecret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx = context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+		if _, err := nu.Summary(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("OkDeleteUser", func(t *testing.T) {
+		store := HelperNewStore(t)
+		defer store.Close()
+		users, nu := NewUserService(store), NewNumberingService(store)
+		user := helperLogin(t, users)
+
+		adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+		defer cancel()
+		if err := users.DeleteUser(adminCtx, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+		if _, err := nu.Summary(ctx); err == nil {
+			t.Fatal("Access token valid after user deleted")
+		}
+	})
+	t.Run("OkDisable", func(t *testing.T) {
+		store := HelperNewStore(t)
+		defer store.Close()
+		users, nu := NewUserService(store), NewNumberingService(store)
+		user := helperLogin(t, users)
+
+		adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+		defer cancel()
+		key, err := users.AddAPIKey(adminCtx, numan.APIKey{Username: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := users.SetStatus(adminCtx, "alice", numan.AccountStatus{Disabled: true}); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+		if _, err := nu.Summary(ctx); err == nil {
+			t.Fatal("Access token valid after user disabled")
+		}
+		if _, err := users.AuthAPIKey(context.Background(), key.Key); err == nil {
+			t.Fatal("API key valid after user disabled")
+		}
+	})
+
+	t.Run("OkMustChangePassword", func(t *testing.T) {
+		store := HelperNewStore(t)
+		defer store.Close()
+		users := NewUserService(store)
+		user := helperLogin(t, users)
+
+		adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+		defer cancel()
+		key, err := users.AddAPIKey(adminCtx, numan.APIKey{Username: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := users.SetStatus(adminCtx, "alice", numan.AccountStatus{MustChangePassword: true}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := users.Refresh(context.Background(), user.RefreshToken); !errors.Is(err, numan.ErrUnauthenticated) {
+			t.Fatalf("Refresh after forced password change got %v, want refresh token revoked", err)
+		}
+		if _, err := users.AuthAPIKey(context.Background(), key.Key); !errors.Is(err, numan.ErrPasswordChangeRequired) {
+			t.Fatalf("API key after forced password change got %v, want ErrPasswordChangeRequired", err)
+		}
+	})
+
+	t.Run("OkPasswordExpired", func(t *testing.T) {
+		store := HelperNewStore(t)
+		defer store.Close()
+		users := NewUserService(store)
+		user := helperLogin(t, users)
+		other, err := users.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+		defer cancel()
+		key, err := users.AddAPIKey(adminCtx, numan.APIKey{Username: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		//expires after the sessions started
+		expires := time.Now().Add(time.Second)
+		if err := users.SetStatus(adminCtx, "alice", numan.AccountStatus{PasswordExpires: expires.Unix()}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Until(expires.Truncate(time.Second).Add(1100 * time.Millisecond)))
+		if _, err := users.Refresh(context.Background(), user.RefreshToken); !errors.Is(err, numan.ErrPasswordChangeRequired) {
+			t.Fatalf("Refresh after password expired got %v, want ErrPasswordChangeRequired", err)
+		}
+		if _, err := users.Refresh(context.Background(), other.RefreshToken); !errors.Is(err, numan.ErrUnauthenticated) {
+			t.Fatalf("Refresh of other session after password expired got %v, want refresh token revoked", err)
+		}
+		if _, err := users.AuthAPIKey(context.Background(), key.Key); !errors.Is(err, numan.ErrPasswordChangeRequired) {
+			t.Fatalf("API key after password expired got %v, want ErrPasswordChangeRequired", err)
+		}
+	})
+
+	t.Run("OkRefreshOnce", func(t *testing.T) {
+		store := HelperNewStore(t)
+		defer store.Close()
+		users := NewUserService(store)
+		user := helperLogin(t, users)
+
+		//concurrent refreshes with the same token, only one gets new tokens
+		var wg sync.WaitGroup
+		var refreshed int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := users.Refresh(context.Background(), user.RefreshToken); err == nil {
+					atomic.AddInt32(&refreshed, 1)
+				} else if !errors.Is(err, numan.ErrUnauthenticated) {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		if refreshed != 1 {
+			t.Fatalf("refresh token used %d times, want once", refreshed)
+		}
+	})
+}
+
+func TestPermissions(t *testing.T) {
+	store := HelperNewStore(t)
+	defer store.Close()
+	users, nu := NewUserService(store), NewNumberingService(store)
+	adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+	defer cancel()
+	if err := users.AddUser(adminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := users.Auth(context.Background(), "alice", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+	number := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}
+
+	if _, err := nu.Summary(adminCtx); err != nil {
+		t.Fatal("Admin can't read numbers:", err)
+	}
+	if _, err := nu.Summary(ctx); err != nil {
+		t.Fatal("Viewer can't read numbers:", err)
+	}
+	if err := nu.Add(ctx, &number); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("Viewer added number")
+	}
+	if _, err := users.ListUsers(ctx, ""); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("Viewer listed users")
+	}
+	webhooks := NewWebhookService(store)
+	if _, err := webhooks.ListWebhooks(ctx); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("Viewer listed webhooks")
+	}
+	if _, err := webhooks.AddWebhook(adminCtx, numan.Webhook{URL: "ftp://example.com"}); !errors.Is(err, numan.ErrInvalidArgument) {
+		t.Fatal("Added webhook with invalid URL")
+	}
+	if hook, err := webhooks.AddWebhook(adminCtx, numan.Webhook{URL: "https://example.com/hook"}); err != nil || len(hook.Secret) != 43 {
+		t.Fatalf("Admin add webhook got %+v (err %v), want generated secret", hook, err)
+	}
+	//only operators port & delete numbers
+	userCtx, cancelUser := HelperContext(t, numan.RoleUser)
+	defer cancelUser()
+	operatorCtx, cancelOperator := HelperContext(t, numan.RoleOperator)
+	defer cancelOperator()
+	if err := nu.Add(adminCtx, &number); err != nil {
+		t.Fatal(err)
+	}
+	portTS := time.Now().Unix()
+	if err := nu.Portin(userCtx, &number.E164, &portTS); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("User set port in date")
+	}
+	if err := nu.Delete(userCtx, &number.E164); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("User deleted number")
+	}
+	if err := nu.Portin(operatorCtx, &number.E164, &portTS); err != nil {
+		t.Fatal("Operator can't set port in date:", err)
+	}
+	if err := nu.Delete(operatorCtx, &number.E164); err != nil {
+		t.Fatal("Operator can't delete number:", err)
+	}
+	//role changes apply to issued tokens
+	if err := users.SetRole(adminCtx, numan.Role{Name: "adder", Permissions: []string{numan.PermNumbersAdd}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.GrantRole(adminCtx, "alice", "adder"); err != nil {
+		t.Fatal(err)
+	}
+	if err := nu.Add(ctx, &number); err != nil {
+		t.Fatal("Granted role not applied:", err)
+	}
+	if err := users.RevokeRole(adminCtx, "alice", numan.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nu.Summary(ctx); err == nil {
+		t.Fatal("Revoked role still applied")
+	}
+}
+
+func TestScope(t *testing.T) {
+	store := HelperNewStore(t)
+	defer store.Close()
+	users, nu, hist := NewUserService(store), NewNumberingService(store), NewHistoryService(store)
+	adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+	defer cancel()
+	numbers := []numan.Numbering{
+		{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "a.com", Carrier: "carrier"},
+		{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345002"}, Domain: "b.com", Carrier: "carrier"},
+	}
+	for i := range numbers {
+		if err := nu.Add(adminCtx, &numbers[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := users.AddUser(adminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleAdmin}, Scope: numan.Scope{Domains: []string{"a.com"}}}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := users.Auth(context.Background(), "alice", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+
+	if list, err := nu.List(ctx, &numan.NumberFilter{}); err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 || list[0].Domain != "a.com" {
+		t.Fatalf("List got %+v, want a.com number only", list)
+	}
+	if summary, err := nu.Summary(ctx); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(summary, "a.com") || strings.Contains(summary, "b.com") {
+		t.Fatalf("Summary not scoped:\n%v", summary)
+	}
+	if view, err := nu.View(ctx, &numbers[1].E164); err != nil {
+		t.Fatal(err)
+	} else if view != "" {
+		t.Fatalf("View of number outside scope got %v", view)
+	}
+	ownerID := int64(1)
+	if err := nu.Allocate(ctx, &numbers[1].E164, &ownerID); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("Allocated number outside scope")
+	}
+	if err := nu.Allocate(ctx, &numbers[0].E164, &ownerID); err != nil {
+		t.Fatal(err)
+	}
+	if events, err := NewEventService(store).ListEvents(ctx, &numan.EventFilter{}, 100); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 2 || events[0].Domain != "a.com" || events[1].Type != numan.EventAllocated || events[1].Actor != "alice" {
+		t.Fatalf("ListEvents got %+v, want a.com events only, allocated by alice", events)
+	}
+	outside := numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345003"}, Domain: "b.com", Carrier: "carrier"}
+	if err := nu.Add(ctx, &outside); err == nil {
+		t.Fatal("Added number outside scope")
+	}
+	if _, err := nu.Update(ctx, &numan.Numbering{E164: numbers[1].E164, Carrier: "other"}, []string{"carrier"}); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("Updated number outside scope")
+	}
+	if _, err := nu.Update(ctx, &numan.Numbering{E164: numbers[0].E164, Domain: "b.com"}, []string{"domain"}); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("Updated number out of scope")
+	}
+	if _, err := nu.Update(ctx, &numan.Numbering{E164: numbers[0].E164, Carrier: "other"}, []string{"carrier"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hist.ListHistoryByNumber(ctx, numbers[1].E164, false); err == nil {
+		t.Fatal("Listed history of number outside scope")
+	}
+	//scoped users can't manage users, even with the permission
+	if _, err := users.ListUsers(ctx, ""); err == nil {
+		t.Fatal("Scoped user listed users")
+	}
+	//changing scope revokes access tokens
+	if err := users.SetScope(adminCtx, "alice", numan.Scope{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nu.Summary(ctx); err == nil {
+		t.Fatal("Access token valid after scope change")
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	store := HelperNewStore(t)
+	defer store.Close()
+	users, nu := NewUserService(store), NewNumberingService(store)
+	adminCtx, cancel := HelperContext(t, numan.RoleAdmin)
+	defer cancel()
+	if err := users.AddUser(adminCtx, numan.User{Username: "robot", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+		t.Fatal(err)
+	}
+	key, err := users.AddAPIKey(adminCtx, numan.APIKey{Username: "robot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := users.AuthAPIKey(context.Background(), key.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+
+	//the key acts with the roles of its user
+	if _, err := nu.Summary(ctx); err != nil {
+		t.Fatal("API key can't read numbers:", err)
+	}
+	if err := nu.Add(ctx, &numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}); err == nil {
+		t.Fatal("API key of viewer added number")
+	}
+	if _, err := users.AddAPIKey(ctx, numan.APIKey{Username: "robot"}); err == nil {
+		t.Fatal("API key of viewer issued API key")
+	}
+
+	//a key limited to some roles of its user acts with those roles only
+	if err := users.AddUser(adminCtx, numan.User{Username: "robot2", Password: "secret123", Roles: []string{numan.RoleViewer, numan.RoleUser}}); err != nil {
+		t.Fatal(err)
+	}
+	key, err = users.AddAPIKey(adminCtx, numan.APIKey{Username: "robot2", Roles: []string{numan.RoleViewer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, err = users.AuthAPIKey(context.Background(), key.Key); err != nil {
+		t.Fatal(err)
+	}
+	ctx = context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken)
+	if _, err := nu.Summary(ctx); err != nil {
+		t.Fatal("viewer limited API key can't read numbers:", err)
+	}
+	if err := nu.Add(ctx, &numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "test.com", Carrier: "carrier"}); !errors.Is(err, numan.ErrPermissionDenied) {
+		t.Fatal("viewer limited API key added number, expected permission denied got", err)
+	}
+	if err := users.RevokeRole(adminCtx, "robot2", numan.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nu.Summary(ctx); err == nil {
+		t.Fatal("role revoked from user still applied to API key")
+	}
+}
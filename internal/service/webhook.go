@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/auth"
+	"github.com/footfish/numan/internal/service/datastore"
+	"github.com/footfish/numan/internal/tracing"
+)
+
+// webhookService implements the WebhookService interface
+type webhookService struct {
+	next numan.WebhookService
+}
+
+// NewWebhookService instantiates a new WebhookService.
+func NewWebhookService(store *datastore.Store) numan.WebhookService {
+	return &webhookService{
+		next: auth.NewWebhookService(store),
+	}
+}
+
+//AddWebhook implements WebhookService.AddWebhook()
+//A signing secret is generated if not set.
+func (s *webhookService) AddWebhook(ctx context.Context, hook numan.Webhook) (numan.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "service.Webhook.AddWebhook")
+	defer span.End()
+	if err := hook.Valid(); err != nil {
+		return numan.Webhook{}, err
+	}
+	newHook := numan.Webhook{URL: hook.URL, Secret: hook.Secret, Filter: numan.EventFilter{Domain: hook.Filter.Domain, Carrier: hook.Filter.Carrier, Prefix: hook.Filter.Prefix, Types: hook.Filter.Types}} //clean
+	if newHook.Secret == "" {
+		if err := newHook.SetNewSecret(); err != nil {
+			return numan.Webhook{}, err
+		}
+	} else if len(newHook.Secret) < 16 {
+		return numan.Webhook{}, numan.Errorf(numan.ErrInvalidArgument, "Webhook secret must be at least 16 characters")
+	}
+	return s.next.AddWebhook(ctx, newHook)
+}
+
+//ListWebhooks implements WebhookService.ListWebhooks()
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]numan.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "service.Webhook.ListWebhooks")
+	defer span.End()
+	return s.next.ListWebhooks(ctx)
+}
+
+//DeleteWebhook implements WebhookService.DeleteWebhook()
+func (s *webhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "service.Webhook.DeleteWebhook")
+	defer span.End()
+	if id <= 0 {
+		return numan.Errorf(numan.ErrInvalidArgument, "Webhook id required")
+	}
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+//ListDeadLetters implements WebhookService.ListDeadLetters()
+func (s *webhookService) ListDeadLetters(ctx context.Context, webhookID int64) ([]numan.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "service.Webhook.ListDeadLetters")
+	defer span.End()
+	if webhookID < 0 {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "Invalid webhook id")
+	}
+	return s.next.ListDeadLetters(ctx, webhookID)
+}
+
+//RedeliverDeadLetters implements WebhookService.RedeliverDeadLetters()
+func (s *webhookService) RedeliverDeadLetters(ctx context.Context, webhookID int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.Webhook.RedeliverDeadLetters")
+	defer span.End()
+	if webhookID < 0 {
+		return 0, numan.Errorf(numan.ErrInvalidArgument, "Invalid webhook id")
+	}
+	return s.next.RedeliverDeadLetters(ctx, webhookID)
+}
@@ -0,0 +1,51 @@
+//Package tracing sets up OpenTelemetry tracing. Spans are recorded only after Setup (otherwise the global tracer is a no-op).
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//instrumentationName names the numan tracer
+const instrumentationName = "github.com/footfish/numan"
+
+//Start starts a span (ex. "service.Numbering.Add"), the caller must End it
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+//Setup sets the global tracer provider exporting spans to exporter, 'stdout' or 'otlp' (gRPC to an OpenTelemetry collector at endpoint, without TLS).
+//sampleRatio is the fraction of new traces recorded, spans of a sampled remote parent are always recorded.
+//The returned shutdown function flushes pending spans.
+func Setup(ctx context.Context, exporter string, endpoint string, sampleRatio float64) (shutdown func(context.Context) error, err error) {
+	var spanExporter sdktrace.SpanExporter
+	switch exporter {
+	case "stdout":
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		spanExporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter '%s' (stdout or otlp)", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("numd"))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,166 @@
+//Package webhook delivers number events to webhooks: events stored in the number_event table (the outbox) are queued per webhook, then POSTed as signed JSON with retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+)
+
+//batchSize is the maximum number of events relayed & deliveries sent at a time
+const batchSize = 100
+
+//Policy sets delivery timeout & retries, a failed delivery is retried after Backoff, doubling each attempt up to MaxBackoff.
+//After MaxAttempts it is moved to the dead-letter list.
+type Policy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration //per attempt
+}
+
+//Observer records the result of a delivery attempt (delivered, failed, dead)
+type Observer func(result string)
+
+//Dispatcher relays & sends webhook deliveries
+type Dispatcher struct {
+	store    *datastore.Store
+	policy   Policy
+	client   *http.Client
+	observer Observer
+}
+
+//NewDispatcher returns a Dispatcher of deliveries queued in store, observer may be nil
+func NewDispatcher(store *datastore.Store, policy Policy, observer Observer) *Dispatcher {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.MaxBackoff < policy.Backoff {
+		policy.MaxBackoff = policy.Backoff
+	}
+	if observer == nil {
+		observer = func(string) {}
+	}
+	return &Dispatcher{store: store, policy: policy, client: &http.Client{Timeout: policy.Timeout}, observer: observer}
+}
+
+//Run dispatches until ctx is done, when events are stored by this server OR every interval (events stored by other servers & retries)
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stored := d.store.EventsStored()
+		if err := d.Dispatch(ctx); err != nil {
+			log.Printf("Webhook dispatch failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-stored:
+		case <-ticker.C:
+		}
+	}
+}
+
+//Dispatch queues deliveries of stored events, then sends the deliveries due until none are left
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		n, err := d.store.RelayEvents(batchSize)
+		if err != nil {
+			return err
+		}
+		if n < batchSize {
+			break
+		}
+	}
+	for ctx.Err() == nil {
+		//lease covers all attempts of the batch, so a delivery is not sent again by another server while in flight
+		deliveries, err := d.store.ClaimDeliveries(time.Now(), 2*d.policy.Timeout+time.Minute, batchSize)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery datastore.QueuedDelivery) {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+	}
+	return nil
+}
+
+//deliver sends a delivery & records the result
+func (d *Dispatcher) deliver(ctx context.Context, delivery datastore.QueuedDelivery) {
+	err := d.send(ctx, delivery)
+	if err == nil {
+		if err := d.store.DeliverySucceeded(delivery.ID); err != nil {
+			log.Printf("Webhook delivery %d: %v", delivery.ID, err)
+		}
+		d.observer("delivered")
+		return
+	}
+	if ctx.Err() != nil { //shutting down, retried when the lease ends
+		return
+	}
+	attempts := delivery.Attempts + 1
+	dead := attempts >= d.policy.MaxAttempts
+	if err := d.store.DeliveryFailed(delivery.ID, attempts, time.Now().Add(d.backoff(attempts)), err.Error(), dead); err != nil {
+		log.Printf("Webhook delivery %d: %v", delivery.ID, err)
+	}
+	if dead {
+		log.Printf("Webhook delivery %d to %s dead after %d attempts: %v", delivery.ID, delivery.URL, attempts, err)
+		d.observer("dead")
+		return
+	}
+	d.observer("failed")
+}
+
+//backoff returns the delay after a number of failed attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.policy.Backoff
+	for i := 1; i < attempts && delay < d.policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.policy.MaxBackoff {
+		delay = d.policy.MaxBackoff
+	}
+	return delay
+}
+
+//send POSTs the signed delivery, any 2xx response is success
+func (d *Dispatcher) send(ctx context.Context, delivery datastore.QueuedDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "numan-webhook")
+	req.Header.Set(numan.WebhookSignatureHeader, numan.SignWebhook(delivery.Secret, timestamp, delivery.Body))
+	req.Header.Set(numan.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(numan.WebhookEventHeader, delivery.Payload.Type)
+	req.Header.Set(numan.WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16)) //allows connection reuse
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP status %s", resp.Status)
+	}
+	return nil
+}
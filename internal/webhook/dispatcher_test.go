@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+	"github.com/footfish/numan/internal/service/datastore"
+)
+
+func TestDispatch(t *testing.T) {
+	store, err := datastore.NewStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	//receiver verifies signatures, failing while down
+	var mu sync.Mutex
+	var down bool
+	var received []numan.WebhookPayload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(numan.WebhookTimestampHeader), 10, 64)
+		if r.Header.Get(numan.WebhookSignatureHeader) != numan.SignWebhook("0123456789abcdef", timestamp, body) {
+			t.Errorf("bad signature %s", r.Header.Get(numan.WebhookSignatureHeader))
+		}
+		var payload numan.WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil || r.Header.Get(numan.WebhookEventHeader) != payload.Type {
+			t.Errorf("bad payload %s (err %v)", body, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, payload)
+	}))
+	defer receiver.Close()
+
+	ctx := context.Background()
+	if _, err := datastore.NewWebhookService(store).AddWebhook(ctx, numan.Webhook{URL: receiver.URL, Secret: "0123456789abcdef"}); err != nil {
+		t.Fatal(err)
+	}
+	var results []string
+	d := NewDispatcher(store, Policy{MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, Timeout: time.Second}, func(result string) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+	})
+	number := numan.E164{Cc: "353", Ndc: "01", Sn: "12345001"}
+	if err := datastore.NewNumberingService(store).Add(ctx, &numan.Numbering{E164: number, Domain: "test.com", Carrier: "carrier"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0].Type != numan.EventAdded || received[0].Number != "353-01-12345001" {
+		t.Fatalf("received %+v", received)
+	}
+
+	//retried with backoff, then dead
+	mu.Lock()
+	down = true
+	mu.Unlock()
+	if err := datastore.NewNumberingService(store).Delete(ctx, &number); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if err := d.Dispatch(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"delivered", "failed", "dead"}; len(results) != 3 || results[1] != want[1] || results[2] != want[2] {
+		t.Fatalf("results %v, want %v", results, want)
+	}
+	dead, err := datastore.NewWebhookService(store).ListDeadLetters(ctx, 0)
+	if err != nil || len(dead) != 1 || dead[0].Payload.Type != numan.EventDeleted || dead[0].LastError == "" {
+		t.Fatalf("dead letters %+v (err %v)", dead, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(nil, Policy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}, nil)
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) got %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package numan
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//JWT signing algorithms
+const (
+	AlgHS256 = "HS256" //shared secret, tokens can only be verified by the server
+	AlgRS256 = "RS256" //RSA 2048 bit
+	AlgEdDSA = "EdDSA" //Ed25519
+)
+
+//SigningKey is a JWT signing key identified by kid (sent in the token header).
+type SigningKey struct {
+	Kid       string
+	Algorithm string
+	Created   int64
+	Retired   int64  //time the key was replaced by a newer key, 0 for the key in use
+	PublicKey string //PEM encoded public key for verifying tokens (RS256/EdDSA only)
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+//KeySet holds the keys used to sign and verify access tokens.
+//The newest key signs, older keys are kept to verify tokens until they expire so rotation doesn't log everyone out.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []SigningKey //oldest first
+	file string       //key file, keys are saved on rotation if set
+}
+
+//keySet is the KeySet used for access tokens, defaults to a random key which is lost on exit.
+var (
+	keySet   = mustEphemeralKeySet()
+	keySetMu sync.RWMutex //keySet can be replaced while serving (config reload)
+)
+
+//SetKeySet sets the KeySet used to sign and verify access tokens.
+func SetKeySet(ks *KeySet) {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	keySet = ks
+}
+
+//CurrentKeySet returns the KeySet used to sign and verify access tokens.
+func CurrentKeySet() *KeySet {
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+	return keySet
+}
+
+//mustEphemeralKeySet returns a keyset with a random HS256 key
+func mustEphemeralKeySet() *KeySet {
+	key, err := GenerateSigningKey(AlgHS256)
+	if err != nil {
+		panic(err)
+	}
+	return &KeySet{keys: []SigningKey{key}}
+}
+
+//NewSecretKeySet returns a KeySet with a single HS256 key from a configured secret. It can't be rotated.
+func NewSecretKeySet(secret string) (*KeySet, error) {
+	if len(secret) < 16 {
+		return nil, errors.New("signing secret must be at least 16 characters")
+	}
+	return &KeySet{keys: []SigningKey{{Kid: "secret", Algorithm: AlgHS256, signKey: []byte(secret), verifyKey: []byte(secret)}}}, nil
+}
+
+//LoadKeySet loads keys from a key file. If the file doesn't exist it is created with a new key for algorithm.
+func LoadKeySet(file string, algorithm string) (*KeySet, error) {
+	ks := &KeySet{file: file}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		key, err := GenerateSigningKey(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys = []SigningKey{key}
+		return ks, ks.save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", file, err)
+	}
+	for _, k := range kf.Keys {
+		key, err := k.signingKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s in %s: %w", k.Kid, file, err)
+		}
+		ks.keys = append(ks.keys, key)
+	}
+	if len(ks.keys) == 0 {
+		return nil, fmt.Errorf("no keys in key file %s", file)
+	}
+	return ks, nil
+}
+
+//NewKeySetFromConfig returns a KeySet from secret if set, otherwise from the key file (see LoadKeySet).
+func NewKeySetFromConfig(secret string, file string, algorithm string) (*KeySet, error) {
+	if secret != "" {
+		return NewSecretKeySet(secret)
+	}
+	return LoadKeySet(file, algorithm)
+}
+
+//NewPublicKeySet returns a KeySet for verifying tokens only, from keys with a PublicKey (see KeySet.Keys()).
+func NewPublicKeySet(keys []SigningKey) (*KeySet, error) {
+	ks := &KeySet{}
+	for _, k := range keys {
+		block, _ := pem.Decode([]byte(k.PublicKey))
+		if block == nil {
+			return nil, fmt.Errorf("key %s has no public key", k.Kid)
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+		k.signKey, k.verifyKey = nil, pub
+		ks.keys = append(ks.keys, k)
+	}
+	return ks, nil
+}
+
+//GenerateSigningKey generates a new random key for algorithm.
+func GenerateSigningKey(algorithm string) (key SigningKey, err error) {
+	kid := make([]byte, 8)
+	if _, err = rand.Read(kid); err != nil {
+		return key, err
+	}
+	key = SigningKey{Kid: hex.EncodeToString(kid), Algorithm: algorithm, Created: time.Now().Unix()}
+	switch algorithm {
+	case AlgHS256:
+		secret := make([]byte, 32)
+		if _, err = rand.Read(secret); err != nil {
+			return key, err
+		}
+		key.signKey, key.verifyKey = secret, secret
+	case AlgRS256:
+		var priv *rsa.PrivateKey
+		if priv, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			return key, err
+		}
+		key.signKey, key.verifyKey = priv, &priv.PublicKey
+	case AlgEdDSA:
+		var pub ed25519.PublicKey
+		var priv ed25519.PrivateKey
+		if pub, priv, err = ed25519.GenerateKey(rand.Reader); err != nil {
+			return key, err
+		}
+		key.signKey, key.verifyKey = priv, pub
+	default:
+		return key, fmt.Errorf("unsupported signing algorithm '%s' (use %s, %s or %s)", algorithm, AlgHS256, AlgRS256, AlgEdDSA)
+	}
+	return key, key.setPublicKey()
+}
+
+//Rotate adds a new signing key for algorithm (empty for the current algorithm).
+//The previous key is retired, it still verifies tokens until they expire. Expired retired keys are removed.
+//Only this KeySet & its file change, other processes using the file must reload it.
+func (ks *KeySet) Rotate(algorithm string) (SigningKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if ks.file == "" {
+		return SigningKey{}, Errorf(ErrConflict, "Can't rotate keys, signing keys must be loaded from a key file")
+	}
+	current := ks.keys[len(ks.keys)-1]
+	if algorithm == "" {
+		algorithm = current.Algorithm
+	}
+	key, err := GenerateSigningKey(algorithm)
+	if err != nil {
+		return key, err
+	}
+
+	now := time.Now().Unix()
+	var keys []SigningKey
+	for _, k := range ks.keys {
+		if k.Kid == current.Kid {
+			k.Retired = now
+		}
+		if k.Retired == 0 || k.Retired > now-int64(tokenDuration.Seconds()) {
+			keys = append(keys, k)
+		}
+	}
+	previous := ks.keys
+	ks.keys = append(keys, key)
+	if err := ks.save(); err != nil {
+		ks.keys = previous
+		return SigningKey{}, err
+	}
+	return key.public(), nil
+}
+
+//Keys lists the keys (without private keys), oldest first.
+func (ks *KeySet) Keys() []SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	keys := make([]SigningKey, len(ks.keys))
+	for i, k := range ks.keys {
+		keys[i] = k.public()
+	}
+	return keys
+}
+
+//sign signs claims with the newest key
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	ks.mu.RLock()
+	key := ks.keys[len(ks.keys)-1]
+	ks.mu.RUnlock()
+	if key.signKey == nil {
+		return "", errors.New("no private key for signing")
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.signKey)
+}
+
+//verifyKey implements jwt.Keyfunc, returns the key for the token kid. The token algorithm must match the key.
+func (ks *KeySet) verifyKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, k := range ks.keys {
+		if k.Kid == kid {
+			if token.Method.Alg() != k.Algorithm {
+				return nil, fmt.Errorf("Auth error: unexpected token signing method")
+			}
+			return k.verifyKey, nil
+		}
+	}
+	return nil, fmt.Errorf("Auth error: unknown signing key")
+}
+
+//save writes the keys to the key file (replaced atomically), caller must hold lock
+func (ks *KeySet) save() error {
+	var kf keyFile
+	for _, k := range ks.keys {
+		fk, err := k.fileKey()
+		if err != nil {
+			return err
+		}
+		kf.Keys = append(kf.Keys, fk)
+	}
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(ks.file), filepath.Base(ks.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), ks.file) //TempFile is created 0600
+}
+
+//public returns a copy of the key without the private key
+func (k SigningKey) public() SigningKey {
+	k.signKey, k.verifyKey = nil, nil
+	return k
+}
+
+//setPublicKey sets the PEM public key from the verify key (asymmetric algorithms)
+func (k *SigningKey) setPublicKey() error {
+	if k.Algorithm == AlgHS256 {
+		return nil
+	}
+	der, err := x509.MarshalPKIXPublicKey(k.verifyKey)
+	if err != nil {
+		return err
+	}
+	k.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return nil
+}
+
+//keyFile is the key file format
+type keyFile struct {
+	Keys []fileKey `json:"keys"`
+}
+
+//fileKey is a key in the key file. Key is base64 for HS256, otherwise a PEM PKCS8 private key.
+type fileKey struct {
+	Kid       string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Created   int64  `json:"created"`
+	Retired   int64  `json:"retired,omitempty"`
+	Key       string `json:"key"`
+}
+
+//fileKey converts k to key file format
+func (k SigningKey) fileKey() (fileKey, error) {
+	fk := fileKey{Kid: k.Kid, Algorithm: k.Algorithm, Created: k.Created, Retired: k.Retired}
+	if k.Algorithm == AlgHS256 {
+		fk.Key = base64.StdEncoding.EncodeToString(k.signKey.([]byte))
+		return fk, nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k.signKey)
+	if err != nil {
+		return fk, err
+	}
+	fk.Key = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	return fk, nil
+}
+
+//signingKey converts key file format to SigningKey
+func (fk fileKey) signingKey() (SigningKey, error) {
+	k := SigningKey{Kid: fk.Kid, Algorithm: fk.Algorithm, Created: fk.Created, Retired: fk.Retired}
+	if k.Kid == "" {
+		return k, errors.New("missing kid")
+	}
+	if k.Algorithm == AlgHS256 {
+		secret, err := base64.StdEncoding.DecodeString(fk.Key)
+		if err != nil {
+			return k, err
+		}
+		k.signKey, k.verifyKey = secret, secret
+		return k, nil
+	}
+	block, _ := pem.Decode([]byte(fk.Key))
+	if block == nil {
+		return k, errors.New("key is not PEM encoded")
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return k, err
+	}
+	switch priv := priv.(type) {
+	case *rsa.PrivateKey:
+		if k.Algorithm != AlgRS256 {
+			return k, errors.New("RSA key for " + k.Algorithm)
+		}
+		k.signKey, k.verifyKey = priv, &priv.PublicKey
+	case ed25519.PrivateKey:
+		if k.Algorithm != AlgEdDSA {
+			return k, errors.New("Ed25519 key for " + k.Algorithm)
+		}
+		k.signKey, k.verifyKey = priv, priv.Public()
+	default:
+		return k, errors.New("unsupported key type")
+	}
+	return k, k.setPublicKey()
+}
+
+//signingMethodEdDSA implements the EdDSA (Ed25519) JWT signing method, which jwt-go doesn't provide.
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(AlgEdDSA, func() jwt.SigningMethod { return signingMethodEdDSA{} })
+}
+
+//Alg implements jwt.SigningMethod
+func (signingMethodEdDSA) Alg() string {
+	return AlgEdDSA
+}
+
+//Sign implements jwt.SigningMethod
+func (signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	sig, err := priv.Sign(rand.Reader, []byte(signingString), crypto.Hash(0))
+	if err != nil {
+		return "", err
+	}
+	return jwt.EncodeSegment(sig), nil
+}
+
+//Verify implements jwt.SigningMethod
+func (signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, []byte(signingString), sig) {
+		return errors.New("EdDSA signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package numan
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKeySet(t *testing.T) {
+	for _, alg := range []string{AlgHS256, AlgRS256, AlgEdDSA} {
+		t.Run("OkSignVerify"+alg, func(t *testing.T) {
+			ks, err := LoadKeySet(filepath.Join(t.TempDir(), "keys.json"), alg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			SetKeySet(ks)
+			defer SetKeySet(mustEphemeralKeySet())
+
+			user := User{UID: 1, Username: "tester", Roles: []string{RoleAdmin}}
+			if err := user.SetNewAccessToken(); err != nil {
+				t.Fatal(err)
+			}
+			var got User
+			if err := got.SetUserFromToken(user.AccessToken); err != nil {
+				t.Fatal(err)
+			}
+			if got.Username != user.Username || !reflect.DeepEqual(got.Roles, user.Roles) || got.UID != user.UID {
+				t.Fatalf("SetUserFromToken got %+v, want %+v", got, user)
+			}
+			if alg == AlgHS256 {
+				return
+			}
+			//clients verify with public keys only
+			public, err := NewPublicKeySet(ks.Keys())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := got.SetUserFromTokenWithKeys(user.AccessToken, public); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("OkRotate", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "keys.json")
+		ks, err := LoadKeySet(file, AlgHS256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetKeySet(ks)
+		defer SetKeySet(mustEphemeralKeySet())
+
+		user := User{Username: "tester", Roles: []string{RoleUser}}
+		if err := user.SetNewAccessToken(); err != nil {
+			t.Fatal(err)
+		}
+		key, err := ks.Rotate(AlgEdDSA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.PublicKey == "" {
+			t.Fatal("Rotated EdDSA key has no public key")
+		}
+		//old token still valid
+		if err := (&User{}).SetUserFromToken(user.AccessToken); err != nil {
+			t.Fatal(err)
+		}
+		//reloaded from file
+		reloaded, err := LoadKeySet(file, AlgHS256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := reloaded.Keys()
+		if want, got := 2, len(keys); want != got {
+			t.Fatalf("Keys got %v, want %v", got, want)
+		} else if keys[0].Retired == 0 || keys[1].Kid != key.Kid || keys[1].Algorithm != AlgEdDSA {
+			t.Fatalf("Keys got %+v", keys)
+		}
+		if err := (&User{}).SetUserFromTokenWithKeys(user.AccessToken, reloaded); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrUnknownKey", func(t *testing.T) {
+		user := User{Username: "tester", Roles: []string{RoleAdmin}}
+		if err := user.SetNewAccessToken(); err != nil {
+			t.Fatal(err)
+		}
+		other, err := NewSecretKeySet("0123456789abcdef")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := (&User{}).SetUserFromTokenWithKeys(user.AccessToken, other); err == nil {
+			t.Fatal("Token verified with wrong keys")
+		}
+		if _, err := other.Rotate(""); err == nil {
+			t.Fatal("Rotated keys without key file")
+		}
+	})
+
+	t.Run("ErrAlgorithmMismatch", func(t *testing.T) {
+		ks, err := LoadKeySet(filepath.Join(t.TempDir(), "keys.json"), AlgEdDSA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		//HS256 token signed with the public key bytes & the EdDSA kid must not verify
+		key := ks.Keys()[0]
+		forged := &KeySet{keys: []SigningKey{{Kid: key.Kid, Algorithm: AlgHS256, signKey: []byte(key.PublicKey)}}}
+		token, err := forged.sign(userClaims{Username: "tester", Roles: []string{RoleAdmin}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := (&User{}).SetUserFromTokenWithKeys(token, ks); err == nil {
+			t.Fatal("Token verified with mismatched algorithm")
+		}
+	})
+}
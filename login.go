@@ -0,0 +1,106 @@
+package numan
+
+import (
+	"sync"
+	"time"
+)
+
+//SourceField is the ctx field holding the client address (set by the server), used to count failed logins by source
+const SourceField = "source"
+
+//Audit actions
+const (
+	AuditLoginFailed = "login_failed" //wrong password or unknown user
+	AuditLockout     = "lockout"      //username or source locked after failed logins
+	AuditUnlock      = "unlock"       //lockout cleared by an admin
+	AuditProvisioned = "provisioned"  //user added on first OIDC login
+)
+
+//AuditEntry is a logged security event
+type AuditEntry struct {
+	Timestamp int64
+	Username  string //user (or username attempted)
+	Source    string //client address OR empty if local
+	Action    string //see Audit actions
+	Notes     string
+}
+
+//LoginPolicy limits failed logins. After MaxFailures consecutive failures (per username and per source)
+//logins are locked for Lockout, doubling with each further failure up to MaxLockout.
+//Failures are forgotten after a successful login (username only) or MaxLockout without failures.
+type LoginPolicy struct {
+	MaxFailures int           //failures before lockout, 0 disables lockout
+	Lockout     time.Duration //first lockout period
+	MaxLockout  time.Duration //longest lockout period
+}
+
+//DefaultLoginPolicy locks after 5 failures for 1 minute, up to an hour
+var DefaultLoginPolicy = LoginPolicy{MaxFailures: 5, Lockout: time.Minute, MaxLockout: time.Hour}
+
+//loginPolicy is the LoginPolicy used by Auth
+var (
+	loginPolicy   = DefaultLoginPolicy
+	loginPolicyMu sync.RWMutex //loginPolicy can be replaced while serving (config reload)
+)
+
+//SetLoginPolicy sets the LoginPolicy used to lock out failed logins.
+func SetLoginPolicy(policy LoginPolicy) {
+	loginPolicyMu.Lock()
+	defer loginPolicyMu.Unlock()
+	loginPolicy = policy
+}
+
+//CurrentLoginPolicy returns the LoginPolicy used to lock out failed logins.
+func CurrentLoginPolicy() LoginPolicy {
+	loginPolicyMu.RLock()
+	defer loginPolicyMu.RUnlock()
+	return loginPolicy
+}
+
+//Client certificate fields mapped to a username (mutual TLS), the whole value is the username
+const (
+	CertUsernameCN    = "cn"    //subject common name
+	CertUsernameDNS   = "dns"   //DNS SAN (host name)
+	CertUsernameEmail = "email" //email SAN (address)
+)
+
+//certUsername is the client certificate field mapped to a username
+var (
+	certUsername   = CertUsernameCN
+	certUsernameMu sync.RWMutex //certUsername can be replaced while serving (config reload)
+)
+
+//SetCertUsername sets the client certificate field mapped to a username, one of the CertUsername fields.
+func SetCertUsername(field string) error {
+	switch field {
+	case CertUsernameCN, CertUsernameDNS, CertUsernameEmail:
+	default:
+		return Errorf(ErrInvalidArgument, "invalid client certificate username field '%s' (%s, %s or %s)", field, CertUsernameCN, CertUsernameDNS, CertUsernameEmail)
+	}
+	certUsernameMu.Lock()
+	defer certUsernameMu.Unlock()
+	certUsername = field
+	return nil
+}
+
+//CurrentCertUsername returns the client certificate field mapped to a username.
+func CurrentCertUsername() string {
+	certUsernameMu.RLock()
+	defer certUsernameMu.RUnlock()
+	return certUsername
+}
+
+//LockoutFor returns how long logins are locked after failures consecutive failures OR 0 if not locked
+func (p LoginPolicy) LockoutFor(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures {
+		return 0
+	}
+	lockout := p.Lockout
+	for i := p.MaxFailures; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > p.MaxLockout {
+		return p.MaxLockout
+	}
+	return lockout
+}
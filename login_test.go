@@ -0,0 +1,18 @@
+package numan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutFor(t *testing.T) {
+	policy := LoginPolicy{MaxFailures: 3, Lockout: time.Minute, MaxLockout: 10 * time.Minute}
+	for failures, want := range []time.Duration{0, 0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute} {
+		if got := policy.LockoutFor(failures); got != want {
+			t.Errorf("LockoutFor(%d) got %v, want %v", failures, got, want)
+		}
+	}
+	if got := (LoginPolicy{}).LockoutFor(100); got != 0 {
+		t.Errorf("Disabled policy LockoutFor got %v, want 0", got)
+	}
+}
@@ -0,0 +1,110 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+//AddAPIKey implements UserService.AddAPIKey
+func (s *userService) AddAPIKey(ctx context.Context, key numan.APIKey) (numan.APIKey, error) {
+	if key.Expires != 0 && key.Expires < time.Now().Unix() {
+		return numan.APIKey{}, numan.Errorf(numan.ErrInvalidArgument, "Can't add API key, expiry in the past")
+	}
+	newKey := numan.APIKey{Username: key.Username, Expires: key.Expires, Created: time.Now().Unix(), Roles: key.Roles, Scope: key.Scope}
+	if err := newKey.SetNewKey(); err != nil {
+		return numan.APIKey{}, err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findUser(key.Username)
+	if i < 0 {
+		return numan.APIKey{}, numan.Errorf(numan.ErrNotFound, "Unable to add API key, check the username exists")
+	}
+	if err := newKey.Within(s.store.users[i]); err != nil {
+		return numan.APIKey{}, err
+	}
+	stored := newKey
+	stored.Key = ""
+	s.store.apiKeys = append(s.store.apiKeys, apiKey{APIKey: stored, uid: s.store.users[i].UID, hash: numan.HashToken(newKey.Key)})
+	return newKey, nil
+}
+
+//ListAPIKeys implements UserService.ListAPIKeys
+func (s *userService) ListAPIKeys(ctx context.Context, userfilter string) ([]numan.APIKey, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	var keys []numan.APIKey
+	for _, k := range s.store.apiKeys {
+		if strings.HasPrefix(k.Username, userfilter) {
+			keys = append(keys, k.APIKey)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].Username < keys[j].Username })
+	return keys, nil
+}
+
+//DeleteAPIKey implements UserService.DeleteAPIKey
+func (s *userService) DeleteAPIKey(ctx context.Context, id string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	for i, k := range s.store.apiKeys {
+		if k.ID == id {
+			s.store.apiKeys = append(s.store.apiKeys[:i], s.store.apiKeys[i+1:]...)
+			return nil
+		}
+	}
+	return numan.Errorf(numan.ErrNotFound, "Unable to delete, check the API key exists")
+}
+
+//AuthAPIKey implements UserService.AuthAPIKey
+func (s *userService) AuthAPIKey(ctx context.Context, key string) (numan.User, error) {
+	id, _, err := numan.ParseAPIKey(key)
+	if err != nil {
+		return numan.User{}, err
+	}
+	s.store.mu.Lock()
+	var user numan.User
+	var found numan.APIKey
+	for i, k := range s.store.apiKeys {
+		if k.ID == id && k.hash == numan.HashToken(key) && (k.Expires == 0 || k.Expires > time.Now().Unix()) {
+			if k.LastUsed < time.Now().Add(-time.Minute).Unix() { //to the minute, as the datastore
+				s.store.apiKeys[i].LastUsed = time.Now().Unix()
+			}
+			found = k.APIKey
+			for _, u := range s.store.users {
+				if u.UID == k.uid {
+					user = u
+				}
+			}
+		}
+	}
+	s.store.mu.Unlock()
+
+	if user.UID == 0 {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Invalid or expired API key")
+	}
+	if err := checkStatus(user.Status); err != nil {
+		return numan.User{}, err
+	}
+	user, err = found.Limit(user)
+	if err != nil {
+		return numan.User{}, err
+	}
+	user.Password = ""
+	return user, user.SetNewAccessToken()
+}
+
+//deleteAPIKeys deletes API keys of user uid, caller must hold lock
+func (s *Store) deleteAPIKeys(uid int64) {
+	var keys []apiKey
+	for _, k := range s.apiKeys {
+		if k.uid != uid {
+			keys = append(keys, k)
+		}
+	}
+	s.apiKeys = keys
+}
@@ -0,0 +1,97 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/footfish/numan"
+)
+
+//maxEventLimit is the maximum number of events listed at a time
+const maxEventLimit = 1000
+
+//addEvent stores the event for a change of number from before to after (nil if not stored) & wakes up watchers, caller must hold lock
+func (s *Store) addEvent(ctx context.Context, eventType string, number numan.E164, before *numan.Numbering, after *numan.Numbering) {
+	actor, _ := ctx.Value(numan.ActorField).(string)
+	event := numan.NewNumberEvent(eventType, number, before, after, actor)
+	s.nextSeq++
+	event.Seq = s.nextSeq
+	s.events = append(s.events, event)
+	if s.eventsAdded != nil {
+		close(s.eventsAdded)
+		s.eventsAdded = nil
+	}
+}
+
+// eventService implements the EventService interface
+type eventService struct {
+	store *Store
+}
+
+// NewEventService instantiates an EventService.
+func NewEventService(store *Store) numan.EventService {
+	return &eventService{
+		store: store,
+	}
+}
+
+//ListEvents implements EventService.ListEvents()
+func (s *eventService) ListEvents(ctx context.Context, filter *numan.EventFilter, limit int) ([]numan.NumberEvent, error) {
+	if filter == nil {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if err := filter.Valid(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxEventLimit {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "limit must be 1 to %d", maxEventLimit)
+	}
+	events, _ := s.listEvents(filter, limit)
+	return events, nil
+}
+
+//listEvents returns up to limit events matching filter & a channel closed when an event is added
+func (s *eventService) listEvents(filter *numan.EventFilter, limit int) ([]numan.NumberEvent, <-chan struct{}) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	var events []numan.NumberEvent
+	for _, e := range s.store.events {
+		if e.Seq > filter.AfterSeq && filter.Matches(e) {
+			events = append(events, e)
+			if len(events) == limit {
+				break
+			}
+		}
+	}
+	if s.store.eventsAdded == nil {
+		s.store.eventsAdded = make(chan struct{})
+	}
+	return events, s.store.eventsAdded
+}
+
+//Watch implements EventService.Watch()
+func (s *eventService) Watch(ctx context.Context, filter *numan.EventFilter, send func(numan.NumberEvent) error) error {
+	if filter == nil || send == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if err := filter.Valid(); err != nil {
+		return err
+	}
+	next := *filter
+	for {
+		events, added := s.listEvents(&next, maxEventLimit)
+		for _, e := range events {
+			if err := send(e); err != nil {
+				return err
+			}
+			next.AfterSeq = e.Seq
+		}
+		if len(events) == maxEventLimit {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-added:
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+// historyService implements the HistoryService interface
+type historyService struct {
+	store *Store
+}
+
+// NewHistoryService instantiates a HistoryService.
+func NewHistoryService(store *Store) numan.HistoryService {
+	return &historyService{
+		store: store,
+	}
+}
+
+//AddHistory  implements HistoryService.AddHistory()
+func (s *historyService) AddHistory(ctx context.Context, historyEntry numan.History) error {
+	historyEntry.Timestamp = time.Now().Unix()
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.addHistory(historyEntry)
+	return nil
+}
+
+//ListHistoryByNumber implements HistoryService.ListHistoryByNumber()
+func (s *historyService) ListHistoryByNumber(ctx context.Context, phoneNumber numan.E164, archived bool) ([]numan.History, error) {
+	if phoneNumber.ValidE164() != nil {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "Incorrect number format")
+	}
+	return s.listHistory(func(h numan.History) bool { return h.E164 == phoneNumber }, archived), nil
+}
+
+//ListHistoryByOwnerID implements HistoryService.ListHistoryByUserId()
+func (s *historyService) ListHistoryByOwnerID(ctx context.Context, ownerID int64, archived bool) ([]numan.History, error) {
+	if numan.ValidOwnerID(&ownerID) != nil {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "Incorrect Owner ID format")
+	}
+	return s.listHistory(func(h numan.History) bool { return h.OwnerID == ownerID }, archived), nil
+}
+
+//ArchiveHistory implements HistoryService.ArchiveHistory()
+//Entries before policy.Before, with at least KeepLast newer entries for the same number, are moved to the archive.
+func (s *historyService) ArchiveHistory(ctx context.Context, policy numan.RetentionPolicy) (int64, error) {
+	if policy.Before <= 0 {
+		return 0, numan.Errorf(numan.ErrInvalidArgument, "Can't archive history, time out of bounds")
+	}
+	if policy.KeepLast < 0 {
+		return 0, numan.Errorf(numan.ErrInvalidArgument, "Can't archive history, keep last must not be negative")
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	newer := map[numan.E164]int{} //history is in id order, count newer entries walking backwards
+	keep := make([]bool, len(s.store.history))
+	for i := len(s.store.history) - 1; i >= 0; i-- {
+		h := s.store.history[i]
+		keep[i] = h.Timestamp >= policy.Before || newer[h.E164] < policy.KeepLast
+		newer[h.E164]++
+	}
+	var archived int64
+	var history []historyEntry
+	for i, h := range s.store.history {
+		if keep[i] {
+			history = append(history, h)
+			continue
+		}
+		s.store.archive = append(s.store.archive, h)
+		archived++
+	}
+	s.store.history = history
+
+	notes := fmt.Sprintf("Archived %d entries before %v, keep last %d", archived, time.Unix(policy.Before, 0).Format(numan.DATEPRINTFORMAT), policy.KeepLast)
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), Action: "retention", Notes: notes})
+	return archived, nil
+}
+
+//listHistory returns history entries matching filter, ordered by timestamp. Archive is included if set.
+func (s *historyService) listHistory(match func(numan.History) bool, archived bool) []numan.History {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	entries := s.store.history
+	if archived {
+		entries = append(append([]historyEntry{}, s.store.archive...), entries...)
+	}
+	var resultList []numan.History
+	for _, h := range entries {
+		if match(h.History) {
+			resultList = append(resultList, h.History)
+		}
+	}
+	sort.SliceStable(resultList, func(i, j int) bool { return resultList[i].Timestamp < resultList[j].Timestamp })
+	return resultList
+}
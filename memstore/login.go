@@ -0,0 +1,99 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+//failed login counter kinds
+const (
+	loginUser   = "user"
+	loginSource = "source"
+)
+
+//loginKey is a failed login counter key
+type loginKey struct {
+	kind  string //loginUser or loginSource
+	value string //username or source address
+}
+
+//loginFailure is a failed login counter
+type loginFailure struct {
+	failures    int
+	lastFailure int64
+	lockedUntil int64
+}
+
+//checkLockout returns an error if the username or source is locked out, caller must hold lock
+func (s *Store) checkLockout(username string, source string) error {
+	for _, key := range []loginKey{{loginUser, username}, {loginSource, source}} {
+		if f, ok := s.logins[key]; ok && key.value != "" && f.lockedUntil >= time.Now().Unix() {
+			return numan.Errorf(numan.ErrLocked, "Too many failed logins, %s locked until %s", key.kind, time.Unix(f.lockedUntil, 0).Format(numan.TIMESTAMPPRINTFORMAT))
+		}
+	}
+	return nil
+}
+
+//loginFailed counts a failed login for username and source, locking them out by policy, caller must hold lock
+func (s *Store) loginFailed(username string, source string) {
+	now := time.Now()
+	policy := numan.CurrentLoginPolicy()
+	s.audit = append(s.audit, numan.AuditEntry{Timestamp: now.Unix(), Username: username, Source: source, Action: numan.AuditLoginFailed})
+	for _, key := range []loginKey{{loginUser, username}, {loginSource, source}} {
+		if key.value == "" {
+			continue
+		}
+		f := s.logins[key]
+		if f.lastFailure < now.Add(-policy.MaxLockout).Unix() {
+			f.failures = 0
+		}
+		f.failures++
+		f.lastFailure = now.Unix()
+		f.lockedUntil = 0
+		if lockout := policy.LockoutFor(f.failures); lockout > 0 {
+			f.lockedUntil = now.Add(lockout).Unix()
+			notes := fmt.Sprintf("%s '%s' locked until %s", key.kind, key.value, time.Unix(f.lockedUntil, 0).Format(numan.TIMESTAMPPRINTFORMAT))
+			s.audit = append(s.audit, numan.AuditEntry{Timestamp: now.Unix(), Username: username, Source: source, Action: numan.AuditLockout, Notes: notes})
+		}
+		s.logins[key] = f
+	}
+}
+
+//Unlock implements UserService.Unlock
+func (s *userService) Unlock(ctx context.Context, name string) error {
+	if name == "" {
+		return numan.Errorf(numan.ErrInvalidArgument, "username or source required")
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	unlocked := false
+	for key := range s.store.logins {
+		if key.value == name {
+			delete(s.store.logins, key)
+			unlocked = true
+		}
+	}
+	if !unlocked {
+		return numan.Errorf(numan.ErrNotFound, "Unable to unlock, no failed logins for '%s'", name)
+	}
+	source, _ := ctx.Value(numan.SourceField).(string)
+	s.store.audit = append(s.store.audit, numan.AuditEntry{Timestamp: time.Now().Unix(), Username: name, Source: source, Action: numan.AuditUnlock})
+	return nil
+}
+
+//ListAudit implements UserService.ListAudit
+func (s *userService) ListAudit(ctx context.Context, userfilter string) ([]numan.AuditEntry, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	var entries []numan.AuditEntry
+	for i := len(s.store.audit) - 1; i >= 0; i-- {
+		if strings.HasPrefix(s.store.audit[i].Username, userfilter) {
+			entries = append(entries, s.store.audit[i])
+		}
+	}
+	return entries, nil
+}
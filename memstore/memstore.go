@@ -0,0 +1,72 @@
+//Package memstore is an in-memory implementation of the numan services.
+//It has the same semantics as the database backed services (validation, quarantine, reservation rules, uniqueness & history logging)
+//but does not check permissions or tokens. It is intended as a lightweight fake for tests.
+package memstore
+
+import (
+	"sync"
+
+	"github.com/footfish/numan"
+)
+
+//Store holds numbers, history, number events and users in memory. It is shared by the services.
+type Store struct {
+	mu        sync.Mutex
+	numbers   []numan.Numbering
+	nextID    int64
+	history   []historyEntry
+	archive   []historyEntry
+	nextHist  int64
+	users     []numan.User
+	nextUID   int64
+	refresh   map[string]refreshToken //by token hash
+	roles     map[string][]string     //permissions by role name
+	apiKeys   []apiKey
+	logins    map[loginKey]loginFailure //failed logins by username & source
+	audit     []numan.AuditEntry
+	passwords map[int64][]string //password hashes by uid, newest first
+
+	events      []numan.NumberEvent
+	nextSeq     int64
+	eventsAdded chan struct{} //closed when an event is added (watchers wait on it)
+}
+
+//apiKey is a stored API key
+type apiKey struct {
+	numan.APIKey
+	uid  int64
+	hash string
+}
+
+//refreshToken is a stored refresh token
+type refreshToken struct {
+	uid     int64
+	expires int64
+}
+
+//historyEntry is a history log entry with its (insertion ordered) id
+type historyEntry struct {
+	id int64
+	numan.History
+}
+
+//NewStore instantiates an empty in-memory store
+func NewStore() *Store {
+	return &Store{refresh: map[string]refreshToken{}, roles: defaultRoles(), logins: map[loginKey]loginFailure{}, passwords: map[int64][]string{}}
+}
+
+//addHistory appends a history entry, caller must hold lock
+func (s *Store) addHistory(h numan.History) {
+	s.nextHist++
+	s.history = append(s.history, historyEntry{id: s.nextHist, History: h})
+}
+
+//findNumber returns index of number or -1, caller must hold lock
+func (s *Store) findNumber(number numan.E164) int {
+	for i, n := range s.numbers {
+		if n.E164 == number {
+			return i
+		}
+	}
+	return -1
+}
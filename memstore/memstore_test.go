@@ -0,0 +1,23 @@
+package memstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/footfish/numan/memstore"
+	"github.com/footfish/numan/numantest"
+)
+
+func TestConformance(t *testing.T) {
+	numantest.TestServices(t, func(t *testing.T) numantest.Backend {
+		store := memstore.NewStore()
+		return numantest.Backend{
+			Numbering: memstore.NewNumberingService(store),
+			History:   memstore.NewHistoryService(store),
+			User:      memstore.NewUserService(store),
+			Events:    memstore.NewEventService(store),
+			UserCtx:   context.Background(),
+			AdminCtx:  context.Background(),
+		}
+	})
+}
@@ -0,0 +1,275 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+// numberingService implements the NumberingService interface
+type numberingService struct {
+	store *Store
+}
+
+// NewNumberingService instantiates a NumberingService.
+func NewNumberingService(store *Store) numan.NumberingService {
+	return &numberingService{
+		store: store,
+	}
+}
+
+// Add implements NumberingService.Add()
+func (s *numberingService) Add(ctx context.Context, number *numan.Numbering) error {
+	if number == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if err := number.E164.ValidE164(); err != nil {
+		return err
+	}
+	if len(number.Domain) == 0 || len(number.Carrier) == 0 {
+		return numan.Errorf(numan.ErrInvalidArgument, "Carrier & domain required")
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	if s.store.findNumber(number.E164) >= 0 {
+		return numan.Errorf(numan.ErrAlreadyExists, "Unable to add number, already exists")
+	}
+	s.store.nextID++
+	s.store.numbers = append(s.store.numbers, numan.Numbering{ID: s.store.nextID, E164: number.E164, Domain: number.Domain, Carrier: number.Carrier})
+	s.store.addEvent(ctx, numan.EventAdded, number.E164, nil, &s.store.numbers[len(s.store.numbers)-1])
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), E164: number.E164, Action: "added", Notes: "Domain:" + number.Domain + ", Carrier:" + number.Carrier})
+	return nil
+}
+
+//List implements NumberingService.List()
+func (s *numberingService) List(ctx context.Context, filter *numan.NumberFilter) ([]numan.Numbering, error) {
+	if filter == nil {
+		return nil, numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	var resultList []numan.Numbering
+	for _, n := range s.store.numbers {
+		if (filter.E164.Cc != "" && n.E164.Cc != filter.E164.Cc) ||
+			(filter.E164.Ndc != "" && n.E164.Ndc != filter.E164.Ndc) ||
+			(filter.E164.Sn != "" && !strings.HasPrefix(n.E164.Sn, filter.E164.Sn)) ||
+			(filter.ID != 0 && n.ID != filter.ID) ||
+			(filter.OwnerID != 0 && n.OwnerID != filter.OwnerID) ||
+			(filter.State != 0 && n.Used != (filter.State != 1)) ||
+			(filter.Domain != "" && n.Domain != filter.Domain) {
+			continue
+		}
+		resultList = append(resultList, n)
+	}
+	return resultList, nil
+}
+
+//ListOwnerID implements NumberingService.ListOwnerID()
+func (s *numberingService) ListOwnerID(ctx context.Context, oid int64) ([]numan.Numbering, error) {
+	return s.List(ctx, &numan.NumberFilter{OwnerID: oid})
+}
+
+//Summary implements NumberingService.Summary()
+func (s *numberingService) Summary(ctx context.Context) (string, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	return numan.FormatSummary(s.store.numbers), nil
+}
+
+//Delete implements NumberingService.Delete()
+func (s *numberingService) Delete(ctx context.Context, phonenumber *numan.E164) error {
+	if phonenumber == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	i := s.store.findNumber(*phonenumber)
+	if i < 0 || s.store.numbers[i].Used {
+		return s.store.stateError(i, "Unable to delete number", "number in use", false)
+	}
+	before := s.store.numbers[i]
+	s.store.numbers = append(s.store.numbers[:i], s.store.numbers[i+1:]...)
+	s.store.addEvent(ctx, numan.EventDeleted, *phonenumber, &before, nil)
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), E164: *phonenumber, Action: "deleted"})
+	return nil
+}
+
+//View implements NumberingService.View()
+func (s *numberingService) View(ctx context.Context, number *numan.E164) (view string, err error) {
+	if number == nil {
+		return "Run time error, nil pointer", numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	result, err := s.List(ctx, &numan.NumberFilter{E164: *number})
+	if err != nil {
+		return "", err
+	}
+
+	return numan.FormatView(result), nil
+}
+
+//Reserve implements NumberingService.Reserve()
+//Mark 'used' & set ownerID & reserved date.
+//Numbers must be out of quarantine
+func (s *numberingService) Reserve(ctx context.Context, number *numan.E164, ownerID *int64, untilTS *int64) error {
+	if number == nil || ownerID == nil || untilTS == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if *untilTS < time.Now().Unix() || *untilTS > (time.Now().Unix()+numan.MAXRESERVATIONTIME) {
+		return numan.Errorf(numan.ErrInvalidArgument, "Can't reserve number, time out of bounds")
+	}
+	if err := number.ValidE164(); err != nil {
+		return fmt.Errorf("Can't reserve number, %w", err)
+	}
+	if err := numan.ValidOwnerID(ownerID); err != nil {
+		return fmt.Errorf("Can't reserve number, %w", err)
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findNumber(*number)
+	if i < 0 || !s.store.available(i) || s.store.numbers[i].Reserved != 0 {
+		return s.store.stateError(i, "Unable to reserve number", "already reserved or allocated", true)
+	}
+	n, before := &s.store.numbers[i], s.store.numbers[i]
+	n.Used, n.DeAllocated, n.Reserved, n.OwnerID = true, 0, *untilTS, *ownerID
+	s.store.addEvent(ctx, numan.EventReserved, *number, &before, n)
+	return nil
+}
+
+//Allocate implements NumberingService.Allocate()
+//Mark 'used' & set ownerID & allocation date. Reset reservation & de-allocation flag
+//Numbers must be out of quarantine
+func (s *numberingService) Allocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
+	if number == nil || ownerID == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if err := number.ValidE164(); err != nil {
+		return fmt.Errorf("Can't allocate number, %w", err)
+	}
+	if err := numan.ValidOwnerID(ownerID); err != nil {
+		return fmt.Errorf("Can't allocate number, %w", err)
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findNumber(*number)
+	if i < 0 || !s.store.available(i) {
+		return s.store.stateError(i, "Unable to allocate number", "already reserved or allocated", true)
+	}
+	n, before := &s.store.numbers[i], s.store.numbers[i]
+	n.Used, n.DeAllocated, n.Reserved, n.Allocated, n.OwnerID = true, 0, 0, time.Now().Unix(), *ownerID
+	s.store.addEvent(ctx, numan.EventAllocated, *number, &before, n)
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), E164: *number, Action: "allocated", OwnerID: *ownerID})
+	return nil
+}
+
+//DeAllocate implements NumberingService.DeAllocate()
+//Mark 'unused' & set de-allocation date (quarantine). Resets  ownerID, reservation & allocation dateflag.
+func (s *numberingService) DeAllocate(ctx context.Context, number *numan.E164, ownerID *int64) error {
+	if number == nil || ownerID == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if err := number.ValidE164(); err != nil {
+		return fmt.Errorf("Can't deallocate number, %w", err)
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findNumber(*number)
+	if i < 0 || !s.store.numbers[i].Used || s.store.numbers[i].OwnerID != *ownerID || s.store.numbers[i].DeAllocated != 0 {
+		return s.store.stateError(i, "Unable to de-allocate number", "wrong owner or already de-allocated", false)
+	}
+	n, before := &s.store.numbers[i], s.store.numbers[i]
+	n.Used, n.DeAllocated, n.Reserved, n.Allocated, n.OwnerID = false, time.Now().Unix(), 0, 0, 0
+	s.store.addEvent(ctx, numan.EventDeAllocated, *number, &before, n)
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), E164: *number, Action: "deallocated", OwnerID: *ownerID})
+	return nil
+}
+
+//Portout implements NumberingService.Portout()
+func (s *numberingService) Portout(ctx context.Context, number *numan.E164, PortoutTS *int64) error {
+	if err := validPortDate(number, PortoutTS); err != nil {
+		return err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findNumber(*number)
+	if i < 0 {
+		return s.store.stateError(i, "Unable to set ported out date", "", false)
+	}
+	before := s.store.numbers[i]
+	s.store.numbers[i].PortedOut = *PortoutTS
+	s.store.addEvent(ctx, numan.EventPortedOut, *number, &before, &s.store.numbers[i])
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), E164: *number, Action: "port-out", Notes: "Scheduled: " + time.Unix(*PortoutTS, 0).Format(numan.TIMESTAMPPRINTFORMAT)})
+	return nil
+}
+
+//Portin implements NumberingService.Portin()
+func (s *numberingService) Portin(ctx context.Context, number *numan.E164, PortinTS *int64) error {
+	if err := validPortDate(number, PortinTS); err != nil {
+		return err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findNumber(*number)
+	if i < 0 {
+		return s.store.stateError(i, "Unable to set ported in date", "", false)
+	}
+	before := s.store.numbers[i]
+	s.store.numbers[i].PortedIn = *PortinTS
+	s.store.addEvent(ctx, numan.EventPortedIn, *number, &before, &s.store.numbers[i])
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), E164: *number, Action: "port-in", Notes: "Scheduled: " + time.Unix(*PortinTS, 0).Format(numan.TIMESTAMPPRINTFORMAT)})
+	return nil
+}
+
+//Update implements NumberingService.Update()
+func (s *numberingService) Update(ctx context.Context, number *numan.Numbering, fields []string) (numan.Numbering, error) {
+	if err := numan.ValidUpdate(number, fields); err != nil {
+		return numan.Numbering{}, err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findNumber(number.E164)
+	if i < 0 {
+		return numan.Numbering{}, s.store.stateError(i, "Unable to update number", "", false)
+	}
+	before := s.store.numbers[i]
+	s.store.numbers[i] = before.Apply(*number, fields)
+	s.store.addEvent(ctx, numan.EventUpdated, number.E164, &before, &s.store.numbers[i])
+	s.store.addHistory(numan.History{Timestamp: time.Now().Unix(), E164: number.E164, Action: "update", Notes: numan.UpdateNotes(*number, fields)})
+	return s.store.numbers[i], nil
+}
+
+//available returns true if number i is unused, unowned & out of quarantine, caller must hold lock
+func (s *Store) available(i int) bool {
+	n := s.numbers[i]
+	return !n.Used && n.OwnerID == 0 && n.DeAllocated < time.Now().Unix()-numan.QUARANTINE
+}
+
+//stateError returns the error for an update of number i (-1 if not stored) refused by its state (see numan.NumberStateError), caller must hold lock
+func (s *Store) stateError(i int, msg string, conflict string, quarantined bool) error {
+	if i < 0 {
+		return numan.NumberStateError(nil, msg, conflict, quarantined)
+	}
+	return numan.NumberStateError(&s.numbers[i], msg, conflict, quarantined)
+}
+
+//validPortDate checks port date is within +-1 year
+func validPortDate(number *numan.E164, portTS *int64) error {
+	if number == nil || portTS == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "nil pointer")
+	}
+	if *portTS < time.Now().Unix()-(365*24*60*60) || *portTS > (time.Now().Unix()+(365*24*60*60)) {
+		return numan.Errorf(numan.ErrInvalidArgument, "Can't use date, time out of bounds (+-1 year)")
+	}
+	if err := number.ValidE164(); err != nil {
+		return fmt.Errorf("Can't, %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/footfish/numan"
+)
+
+//defaultRoles returns the built in roles (as seeded by the database migrations)
+func defaultRoles() map[string][]string {
+	return map[string][]string{
+		numan.RoleAdmin:    {numan.PermAll},
+		numan.RoleOperator: {numan.PermNumbersRead, numan.PermNumbersAdd, numan.PermNumbersAllocate, numan.PermNumbersPort, numan.PermNumbersDelete, numan.PermHistoryRead, numan.PermHistoryWrite},
+		numan.RoleUser:     {numan.PermNumbersRead, numan.PermNumbersAdd, numan.PermNumbersAllocate, numan.PermHistoryRead, numan.PermHistoryWrite},
+		numan.RoleViewer:   {numan.PermNumbersRead, numan.PermHistoryRead},
+	}
+}
+
+//SetRole implements UserService.SetRole
+func (s *userService) SetRole(ctx context.Context, role numan.Role) error {
+	if !numan.ValidRoleName(role.Name) {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+	}
+	if role.Name == numan.RoleAdmin {
+		return numan.Errorf(numan.ErrPermissionDenied, "the admin role can't be changed")
+	}
+	if err := role.ValidPermissions(); err != nil {
+		return err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	permissions := []string{}
+	for _, p := range role.Permissions {
+		if !contains(permissions, p) {
+			permissions = append(permissions, p)
+		}
+	}
+	s.store.roles[role.Name] = permissions
+	return nil
+}
+
+//DeleteRole implements UserService.DeleteRole
+func (s *userService) DeleteRole(ctx context.Context, name string) error {
+	if !numan.ValidRoleName(name) {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad role name")
+	}
+	if name == numan.RoleAdmin {
+		return numan.Errorf(numan.ErrPermissionDenied, "the admin role can't be deleted")
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	if _, ok := s.store.roles[name]; !ok {
+		return numan.Errorf(numan.ErrNotFound, "Unable to delete, check the role exists")
+	}
+	for _, u := range s.store.users {
+		if contains(u.Roles, name) {
+			return numan.Errorf(numan.ErrConflict, "Unable to delete, role is granted to users")
+		}
+	}
+	delete(s.store.roles, name)
+	return nil
+}
+
+//ListRoles implements UserService.ListRoles
+func (s *userService) ListRoles(ctx context.Context) ([]numan.Role, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	var roles []numan.Role
+	for name, permissions := range s.store.roles {
+		roles = append(roles, numan.Role{Name: name, Permissions: sortedCopy(permissions)})
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+//GrantRole implements UserService.GrantRole
+func (s *userService) GrantRole(ctx context.Context, username string, role string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findUser(username)
+	if _, ok := s.store.roles[role]; i < 0 || !ok {
+		return numan.Errorf(numan.ErrNotFound, "Unable to grant role '%s', check the username & role exist", role)
+	}
+	if contains(s.store.users[i].Roles, role) {
+		return numan.Errorf(numan.ErrAlreadyExists, "Unable to grant role, already granted")
+	}
+	s.store.users[i].Roles = sortedCopy(append(s.store.users[i].Roles, role))
+	return nil
+}
+
+//RevokeRole implements UserService.RevokeRole
+func (s *userService) RevokeRole(ctx context.Context, username string, role string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findUser(username)
+	if i < 0 || !contains(s.store.users[i].Roles, role) {
+		return numan.Errorf(numan.ErrNotFound, "Unable to revoke role, check the user has the role")
+	}
+	var roles []string
+	for _, r := range s.store.users[i].Roles {
+		if r != role {
+			roles = append(roles, r)
+		}
+	}
+	s.store.users[i].Roles = roles
+	return nil
+}
+
+//contains returns true if list contains v
+func contains(list []string, v string) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+	return false
+}
+
+//sortedCopy returns a sorted copy of list
+func sortedCopy(list []string) []string {
+	c := append([]string(nil), list...)
+	sort.Strings(c)
+	return c
+}
@@ -0,0 +1,358 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+// userService implements the UserService interface
+type userService struct {
+	store *Store
+}
+
+// NewUserService instantiates a UserService.
+func NewUserService(store *Store) numan.UserService {
+	return &userService{
+		store: store,
+	}
+}
+
+//Auth implements UserService.Auth()
+func (s *userService) Auth(ctx context.Context, username string, password string) (numan.User, error) {
+	enteredUser := numan.User{Username: strings.ToLower(username), Password: password}
+	if !enteredUser.ValidRawPassword() {
+		return enteredUser, numan.Errorf(numan.ErrInvalidArgument, "Invalid password")
+	}
+	if !enteredUser.ValidUsername() {
+		return enteredUser, numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	source, _ := ctx.Value(numan.SourceField).(string)
+
+	s.store.mu.Lock()
+	if err := s.store.checkLockout(enteredUser.Username, source); err != nil {
+		s.store.mu.Unlock()
+		return enteredUser, err
+	}
+	i := s.store.findUser(enteredUser.Username)
+	var storedUser numan.User
+	if i >= 0 {
+		storedUser = s.store.users[i]
+	}
+	s.store.mu.Unlock()
+
+	if err := storedUser.ComparePassword(enteredUser.Password); err != nil {
+		s.store.mu.Lock()
+		s.store.loginFailed(enteredUser.Username, source)
+		s.store.mu.Unlock()
+		return enteredUser, numan.Errorf(numan.ErrUnauthenticated, "Username/password mismatch")
+	}
+	s.store.mu.Lock()
+	delete(s.store.logins, loginKey{kind: loginUser, value: storedUser.Username})
+	s.store.mu.Unlock()
+	if err := checkStatus(storedUser.Status); err != nil {
+		return enteredUser, err
+	}
+	s.store.mu.Lock()
+	if j := s.store.findUser(storedUser.Username); j >= 0 {
+		s.store.users[j].LastLogin = time.Now().Unix()
+	}
+	s.store.mu.Unlock()
+	return storedUser, s.issueTokens(&storedUser)
+}
+
+//checkStatus returns an error if the account status refuses login
+func checkStatus(status numan.AccountStatus) error {
+	switch {
+	case status.Disabled:
+		return numan.Errorf(numan.ErrPermissionDenied, "Account disabled")
+	case status.MustChangePassword:
+		return numan.Errorf(numan.ErrPasswordChangeRequired, "Password change required (see change_password)")
+	case status.PasswordExpired():
+		return numan.Errorf(numan.ErrPasswordChangeRequired, "Password expired (see change_password)")
+	}
+	return nil
+}
+
+//AddUser implements UserService.AddUser()
+func (s *userService) AddUser(ctx context.Context, user numan.User) (err error) {
+	if len(user.Roles) == 0 {
+		return numan.Errorf(numan.ErrInvalidArgument, "role required")
+	}
+	if err := user.Scope.Valid(); err != nil {
+		return err
+	}
+	if !user.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "bad username")
+	}
+	if !user.PasswordIsHashed() {
+		if err = numan.CurrentPasswordPolicy().Check(user.Password); err != nil {
+			return err
+		}
+		if err = user.HashPassword(); err != nil {
+			return fmt.Errorf("can't hash password: %w", err)
+		}
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	if s.store.findUser(user.Username) >= 0 {
+		return numan.Errorf(numan.ErrAlreadyExists, "Unable to add user, already exists")
+	}
+	for _, role := range user.Roles {
+		if _, ok := s.store.roles[role]; !ok {
+			return numan.Errorf(numan.ErrNotFound, "Unable to grant role '%s', check the username & role exist", role)
+		}
+	}
+	s.store.nextUID++
+	s.store.users = append(s.store.users, numan.User{UID: s.store.nextUID, Username: user.Username, Password: user.Password, Roles: sortedCopy(user.Roles), Scope: sortedScope(user.Scope), Status: user.Status})
+	s.store.addPasswordHistory(s.store.nextUID, user.Password)
+	return nil
+}
+
+//DeleteUser  implements UserService.DeleteUser
+func (s *userService) DeleteUser(ctx context.Context, username string) error {
+	u := numan.User{Username: username}
+	if !u.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findUser(username)
+	if i < 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to delete, check the username exists")
+	}
+	s.store.deleteRefreshTokens(s.store.users[i].UID)
+	s.store.deleteAPIKeys(s.store.users[i].UID)
+	delete(s.store.passwords, s.store.users[i].UID)
+	s.store.users = append(s.store.users[:i], s.store.users[i+1:]...)
+	return nil
+}
+
+//ListUsers  implements UserService.ListUsers
+func (s *userService) ListUsers(ctx context.Context, userfilter string) ([]numan.User, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	var resultList []numan.User
+	for _, u := range s.store.users {
+		if strings.HasPrefix(u.Username, userfilter) {
+			resultList = append(resultList, numan.User{Username: u.Username, Roles: sortedCopy(u.Roles), Scope: u.Scope, Status: u.Status, LastLogin: u.LastLogin})
+		}
+	}
+	return resultList, nil
+}
+
+//SetPassword implements UserService.SetPassword
+func (s *userService) SetPassword(ctx context.Context, username string, newPassword string) error {
+	if err := numan.CurrentPasswordPolicy().Check(newPassword); err != nil {
+		return err
+	}
+	u := numan.User{Password: newPassword, Username: username}
+	if err := u.HashPassword(); err != nil {
+		return err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findUser(username)
+	if i < 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to set password, check the username exists")
+	}
+	if err := s.store.checkPasswordHistory(s.store.users[i].UID, newPassword); err != nil {
+		return err
+	}
+	s.store.users[i].Password = u.Password
+	s.store.addPasswordHistory(s.store.users[i].UID, u.Password)
+	s.store.users[i].TokenVersion++
+	s.store.deleteRefreshTokens(s.store.users[i].UID)
+	return nil
+}
+
+//ChangePassword implements UserService.ChangePassword
+func (s *userService) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) error {
+	enteredUser := numan.User{Username: strings.ToLower(username), Password: oldPassword}
+	if !enteredUser.ValidUsername() {
+		return numan.Errorf(numan.ErrInvalidArgument, "Invalid Username")
+	}
+	if err := numan.CurrentPasswordPolicy().Check(newPassword); err != nil {
+		return err
+	}
+	newUser := numan.User{Password: newPassword}
+	source, _ := ctx.Value(numan.SourceField).(string)
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	if err := s.store.checkLockout(enteredUser.Username, source); err != nil {
+		return err
+	}
+	i := s.store.findUser(enteredUser.Username)
+	var storedUser numan.User
+	if i >= 0 {
+		storedUser = s.store.users[i]
+	}
+	if err := storedUser.ComparePassword(enteredUser.Password); err != nil {
+		s.store.loginFailed(enteredUser.Username, source)
+		return numan.Errorf(numan.ErrUnauthenticated, "Username/password mismatch")
+	}
+	if storedUser.Status.Disabled {
+		return numan.Errorf(numan.ErrPermissionDenied, "Account disabled")
+	}
+	if storedUser.ComparePassword(newPassword) == nil {
+		return numan.Errorf(numan.ErrInvalidArgument, "new password must be different")
+	}
+	if err := s.store.checkPasswordHistory(storedUser.UID, newPassword); err != nil {
+		return err
+	}
+	if err := newUser.HashPassword(); err != nil {
+		return err
+	}
+	s.store.users[i].Password = newUser.Password
+	s.store.addPasswordHistory(storedUser.UID, newUser.Password)
+	s.store.users[i].Status.PasswordExpires = 0
+	s.store.users[i].Status.MustChangePassword = false
+	s.store.users[i].TokenVersion++
+	s.store.deleteRefreshTokens(s.store.users[i].UID)
+	return nil
+}
+
+//SetStatus implements UserService.SetStatus
+func (s *userService) SetStatus(ctx context.Context, username string, status numan.AccountStatus) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findUser(username)
+	if i < 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to set status, check the username exists")
+	}
+	s.store.users[i].Status = status
+	s.store.users[i].TokenVersion++
+	if status.Disabled || status.MustChangePassword || status.PasswordExpired() {
+		s.store.deleteRefreshTokens(s.store.users[i].UID)
+	}
+	return nil
+}
+
+//SetScope implements UserService.SetScope
+func (s *userService) SetScope(ctx context.Context, username string, scope numan.Scope) error {
+	if err := scope.Valid(); err != nil {
+		return err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	i := s.store.findUser(username)
+	if i < 0 {
+		return numan.Errorf(numan.ErrNotFound, "Unable to set scope, check the username exists")
+	}
+	s.store.users[i].Scope = sortedScope(scope)
+	s.store.users[i].TokenVersion++
+	return nil
+}
+
+//sortedScope returns a copy of scope with sorted, unique entries (as stored by the database)
+func sortedScope(scope numan.Scope) numan.Scope {
+	return numan.Scope{Domains: uniqueSorted(scope.Domains), Carriers: uniqueSorted(scope.Carriers), Prefixes: uniqueSorted(scope.Prefixes)}
+}
+
+//uniqueSorted returns a sorted copy of list without duplicates
+func uniqueSorted(list []string) (unique []string) {
+	for _, v := range sortedCopy(list) {
+		if len(unique) == 0 || unique[len(unique)-1] != v {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+//Refresh implements UserService.Refresh
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (numan.User, error) {
+	s.store.mu.Lock()
+	hash := numan.HashToken(refreshToken)
+	t, ok := s.store.refresh[hash]
+	delete(s.store.refresh, hash)
+	var user numan.User
+	if ok && t.expires > time.Now().Unix() {
+		for _, u := range s.store.users {
+			if u.UID == t.uid {
+				user = u
+			}
+		}
+	}
+	s.store.mu.Unlock()
+
+	if user.UID == 0 {
+		return numan.User{}, numan.Errorf(numan.ErrUnauthenticated, "Invalid or expired refresh token")
+	}
+	if err := checkStatus(user.Status); err != nil {
+		s.store.mu.Lock()
+		s.store.deleteRefreshTokens(user.UID)
+		s.store.mu.Unlock()
+		return numan.User{}, err
+	}
+	return user, s.issueTokens(&user)
+}
+
+//Logout implements UserService.Logout
+func (s *userService) Logout(ctx context.Context, refreshToken string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	delete(s.store.refresh, numan.HashToken(refreshToken))
+	return nil
+}
+
+//issueTokens sets a new access token & refresh token for user. The refresh token is stored.
+func (s *userService) issueTokens(user *numan.User) error {
+	if err := user.SetNewAccessToken(); err != nil {
+		return err
+	}
+	expires, err := user.SetNewRefreshToken()
+	if err != nil {
+		return err
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.refresh[numan.HashToken(user.RefreshToken)] = refreshToken{uid: user.UID, expires: expires}
+	return nil
+}
+
+//deleteRefreshTokens deletes refresh tokens of user uid, caller must hold lock
+func (s *Store) deleteRefreshTokens(uid int64) {
+	for hash, t := range s.refresh {
+		if t.uid == uid {
+			delete(s.refresh, hash)
+		}
+	}
+}
+
+//checkPasswordHistory returns an error if password is one of the last policy.History passwords of uid, caller must hold lock
+func (s *Store) checkPasswordHistory(uid int64, password string) error {
+	policy := numan.CurrentPasswordPolicy()
+	if policy.History <= 0 {
+		return nil
+	}
+	return policy.CheckHistory(password, s.passwords[uid])
+}
+
+//addPasswordHistory records hash as the newest password of uid, caller must hold lock
+func (s *Store) addPasswordHistory(uid int64, hash string) {
+	s.passwords[uid] = append([]string{hash}, s.passwords[uid]...)
+}
+
+//findUser returns index of user or -1, caller must hold lock
+func (s *Store) findUser(username string) int {
+	for i, u := range s.users {
+		if u.Username == username {
+			return i
+		}
+	}
+	return -1
+}
+
+//RotateKey implements UserService.RotateKey
+func (s *userService) RotateKey(ctx context.Context, algorithm string) (numan.SigningKey, error) {
+	return numan.CurrentKeySet().Rotate(algorithm)
+}
+
+//ListKeys implements UserService.ListKeys
+func (s *userService) ListKeys(ctx context.Context) ([]numan.SigningKey, error) {
+	return numan.CurrentKeySet().Keys(), nil
+}
@@ -0,0 +1,1183 @@
+//Package numantest is a conformance test suite for implementations of the numan services.
+//Every storage backend (sqlite, PostgreSQL, memstore) runs the same suite so they behave the same way.
+//It covers service semantics (validation, uniqueness, quarantine, reservation, history logging, number events, user & role management), not permission checks.
+package numantest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/footfish/numan"
+)
+
+//Backend is a set of services sharing one empty store, with contexts for calling them.
+type Backend struct {
+	Numbering numan.NumberingService
+	History   numan.HistoryService
+	User      numan.UserService
+	Events    numan.EventService //nil skips the event tests
+	UserCtx   context.Context    //authorised for numbering & history calls
+	AdminCtx  context.Context    //authorised for all calls
+}
+
+//NewBackendFunc returns a new Backend with an empty store for each test.
+type NewBackendFunc func(t *testing.T) Backend
+
+//TestServices runs the whole suite
+func TestServices(t *testing.T, newBackend NewBackendFunc) {
+	t.Run("Numbering", func(t *testing.T) { TestNumberingService(t, newBackend) })
+	t.Run("History", func(t *testing.T) { TestHistoryService(t, newBackend) })
+	t.Run("User", func(t *testing.T) { TestUserService(t, newBackend) })
+	t.Run("Events", func(t *testing.T) { TestEventService(t, newBackend) })
+}
+
+var testNumbers = []numan.E164{
+	{Cc: "353", Ndc: "01", Sn: "12345001"},
+	{Cc: "353", Ndc: "01", Sn: "12345002"},
+	{Cc: "353", Ndc: "01", Sn: "12346001"},
+	{Cc: "353", Ndc: "021", Sn: "12345001"},
+}
+
+//addNumbers adds testNumbers to b, first two in domain "one.com" and the rest in "two.com"
+func addNumbers(t *testing.T, b Backend) {
+	t.Helper()
+	for i, n := range testNumbers {
+		domain := "one.com"
+		if i >= 2 {
+			domain = "two.com"
+		}
+		if err := b.Numbering.Add(b.UserCtx, &numan.Numbering{E164: n, Domain: domain, Carrier: "anycarrier"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//TestNumberingService checks NumberingService semantics
+func TestNumberingService(t *testing.T, newBackend NewBackendFunc) {
+	ownerID := int64(99)
+
+	t.Run("OkAdd", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.Numbering.Add(b.UserCtx, &numan.Numbering{E164: testNumbers[0], Domain: "one.com", Carrier: "anycarrier", Used: true, OwnerID: 5}); err != nil {
+			t.Fatal(err)
+		}
+		list, err := b.Numbering.List(b.UserCtx, &numan.NumberFilter{E164: testNumbers[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := 1, len(list); want != got {
+			t.Fatalf("List got %v, want %v", got, want)
+		}
+		if want, got := (numan.Numbering{ID: list[0].ID, E164: testNumbers[0], Domain: "one.com", Carrier: "anycarrier"}), list[0]; want != got {
+			t.Fatalf("Add got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("ErrAdd", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		if err := b.Numbering.Add(b.UserCtx, &numan.Numbering{E164: testNumbers[0], Domain: "one.com", Carrier: "anycarrier"}); !errors.Is(err, numan.ErrAlreadyExists) {
+			t.Fatal("Added duplicate number")
+		}
+		if err := b.Numbering.Add(b.UserCtx, &numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "1234"}, Domain: "one.com", Carrier: "anycarrier"}); !errors.Is(err, numan.ErrInvalidArgument) {
+			t.Fatal("Added invalid number")
+		}
+		if err := b.Numbering.Add(b.UserCtx, &numan.Numbering{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "99999999"}, Carrier: "anycarrier"}); !errors.Is(err, numan.ErrInvalidArgument) {
+			t.Fatal("Added number without domain")
+		}
+	})
+
+	t.Run("OkList", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[1], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			name   string
+			filter numan.NumberFilter
+			want   int
+		}{
+			{"all", numan.NumberFilter{}, 4},
+			{"cc", numan.NumberFilter{E164: numan.E164{Cc: "353"}}, 4},
+			{"ndc", numan.NumberFilter{E164: numan.E164{Cc: "353", Ndc: "01"}}, 3},
+			{"sn prefix", numan.NumberFilter{E164: numan.E164{Cc: "353", Ndc: "01", Sn: "12345"}}, 2},
+			{"domain", numan.NumberFilter{Domain: "two.com"}, 2},
+			{"free", numan.NumberFilter{State: 1}, 3},
+			{"used", numan.NumberFilter{State: 2}, 1},
+			{"owner", numan.NumberFilter{OwnerID: ownerID}, 1},
+		} {
+			list, err := b.Numbering.List(b.UserCtx, &tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(list); got != tc.want {
+				t.Fatalf("List %s got %v, want %v", tc.name, got, tc.want)
+			}
+		}
+		if list, err := b.Numbering.ListOwnerID(b.UserCtx, ownerID); err != nil {
+			t.Fatal(err)
+		} else if want, got := 1, len(list); want != got {
+			t.Fatalf("ListOwnerID got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("OkReserve", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		untilTS := time.Now().Unix() + (60 * 15) //15mins
+		if err := b.Numbering.Reserve(b.UserCtx, &testNumbers[0], &ownerID, &untilTS); err != nil {
+			t.Fatal(err)
+		}
+		list, err := b.Numbering.List(b.UserCtx, &numan.NumberFilter{E164: testNumbers[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := untilTS, list[0].Reserved; want != got {
+			t.Fatalf("Reserved got %v, want %v", got, want)
+		} else if want, got := ownerID, list[0].OwnerID; want != got {
+			t.Fatalf("OwnerID got %v, want %v", got, want)
+		} else if !list[0].Used {
+			t.Fatal("Reserved number not used")
+		}
+		if err := b.Numbering.Reserve(b.UserCtx, &testNumbers[0], &ownerID, &untilTS); !errors.Is(err, numan.ErrConflict) {
+			t.Fatal("Reserved number twice")
+		}
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &ownerID); err == nil {
+			t.Fatal("Allocated reserved number")
+		}
+	})
+
+	t.Run("ErrReserve", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		tooLong := time.Now().Unix() + numan.MAXRESERVATIONTIME + 60
+		if err := b.Numbering.Reserve(b.UserCtx, &testNumbers[0], &ownerID, &tooLong); !errors.Is(err, numan.ErrInvalidArgument) {
+			t.Fatal("Reserved beyond maximum reservation time")
+		}
+		past := time.Now().Unix() - 60
+		if err := b.Numbering.Reserve(b.UserCtx, &testNumbers[0], &ownerID, &past); err == nil {
+			t.Fatal("Reserved until past time")
+		}
+		untilTS := time.Now().Unix() + 60
+		missing := numan.E164{Cc: "353", Ndc: "01", Sn: "99999999"}
+		if err := b.Numbering.Reserve(b.UserCtx, &missing, &ownerID, &untilTS); !errors.Is(err, numan.ErrNotFound) {
+			t.Fatal("Reserved missing number")
+		}
+	})
+
+	t.Run("OkAllocateQuarantine", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		otherID := int64(100)
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &otherID); !errors.Is(err, numan.ErrConflict) {
+			t.Fatal("Allocated number twice")
+		}
+		if err := b.Numbering.DeAllocate(b.UserCtx, &testNumbers[0], &otherID); !errors.Is(err, numan.ErrConflict) {
+			t.Fatal("De-allocated number of other owner")
+		}
+		if err := b.Numbering.DeAllocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		list, err := b.Numbering.List(b.UserCtx, &numan.NumberFilter{E164: testNumbers[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list[0].Used || list[0].OwnerID != 0 || list[0].Allocated != 0 || list[0].DeAllocated == 0 {
+			t.Fatalf("De-allocated number got %+v", list[0])
+		}
+		if err := b.Numbering.DeAllocate(b.UserCtx, &testNumbers[0], &ownerID); err == nil {
+			t.Fatal("De-allocated number twice")
+		}
+		//in quarantine
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &otherID); !errors.Is(err, numan.ErrQuarantined) {
+			t.Fatal("Allocated number in quarantine")
+		}
+		untilTS := time.Now().Unix() + 60
+		if err := b.Numbering.Reserve(b.UserCtx, &testNumbers[0], &otherID, &untilTS); !errors.Is(err, numan.ErrQuarantined) {
+			t.Fatal("Reserved number in quarantine")
+		}
+	})
+
+	t.Run("OkDelete", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[1], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Numbering.Delete(b.UserCtx, &testNumbers[1]); !errors.Is(err, numan.ErrConflict) {
+			t.Fatal("Deleted used number")
+		}
+		if err := b.Numbering.Delete(b.UserCtx, &testNumbers[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Numbering.Delete(b.UserCtx, &testNumbers[0]); !errors.Is(err, numan.ErrNotFound) {
+			t.Fatal("Deleted number twice")
+		}
+		if list, err := b.Numbering.List(b.UserCtx, &numan.NumberFilter{}); err != nil {
+			t.Fatal(err)
+		} else if want, got := len(testNumbers)-1, len(list); want != got {
+			t.Fatalf("List after delete got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("OkPort", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		portTS := time.Now().Unix() + 24*60*60
+		if err := b.Numbering.Portin(b.UserCtx, &testNumbers[0], &portTS); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Numbering.Portout(b.UserCtx, &testNumbers[0], &portTS); err != nil {
+			t.Fatal(err)
+		}
+		list, err := b.Numbering.List(b.UserCtx, &numan.NumberFilter{E164: testNumbers[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list[0].PortedIn != portTS || list[0].PortedOut != portTS {
+			t.Fatalf("Ported got in %v out %v, want %v", list[0].PortedIn, list[0].PortedOut, portTS)
+		}
+		tooLate := time.Now().Unix() + 2*365*24*60*60
+		if err := b.Numbering.Portin(b.UserCtx, &testNumbers[0], &tooLate); err == nil {
+			t.Fatal("Port in date out of bounds accepted")
+		}
+		missing := numan.E164{Cc: "353", Ndc: "01", Sn: "99999999"}
+		if err := b.Numbering.Portout(b.UserCtx, &missing, &portTS); !errors.Is(err, numan.ErrNotFound) {
+			t.Fatal("Ported out missing number")
+		}
+	})
+
+	t.Run("OkUpdate", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		updated, err := b.Numbering.Update(b.UserCtx, &numan.Numbering{E164: testNumbers[0], Domain: "three.com", Carrier: "ignored"}, []string{"domain"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.E164 != testNumbers[0] || updated.Domain != "three.com" || updated.Carrier != "anycarrier" {
+			t.Fatalf("Update got %+v", updated)
+		}
+		list, err := b.Numbering.List(b.UserCtx, &numan.NumberFilter{Domain: "three.com"})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(list) != 1 || list[0] != updated {
+			t.Fatalf("List after update got %+v, want %+v", list, updated)
+		}
+		if b.Events != nil {
+			events, err := b.Events.ListEvents(b.UserCtx, &numan.EventFilter{Types: []string{numan.EventUpdated}}, 10)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(events) != 1 || events[0].Domain != "three.com" || events[0].E164 != testNumbers[0] {
+				t.Fatalf("Update events got %+v", events)
+			}
+		}
+		missing := numan.E164{Cc: "353", Ndc: "01", Sn: "99999999"}
+		for _, tc := range []struct {
+			number numan.Numbering
+			fields []string
+			want   error
+		}{
+			{numan.Numbering{E164: testNumbers[0], Domain: "x.com"}, nil, numan.ErrInvalidArgument},
+			{numan.Numbering{E164: testNumbers[0], Domain: "x.com"}, []string{"domain", "ownerID"}, numan.ErrInvalidArgument},
+			{numan.Numbering{E164: testNumbers[0]}, []string{"carrier"}, numan.ErrInvalidArgument},
+			{numan.Numbering{E164: missing, Domain: "x.com"}, []string{"domain"}, numan.ErrNotFound},
+		} {
+			if _, err := b.Numbering.Update(b.UserCtx, &tc.number, tc.fields); !errors.Is(err, tc.want) {
+				t.Errorf("Update %+v %v got %v, want %v", tc.number, tc.fields, err, tc.want)
+			}
+		}
+	})
+
+	t.Run("OkViewSummary", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		view, err := b.Numbering.View(b.UserCtx, &testNumbers[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(view, "+353-01-12345001, Domain: one.com, Carrier: anycarrier") || !strings.Contains(view, "Allocated to OwnerID: 99") {
+			t.Fatalf("View got %q", view)
+		}
+		summary, err := b.Numbering.Summary(b.UserCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(summary), "\n")
+		if want, got := 4, len(lines); want != got { //heading + 3 domain/cc/ndc groups
+			t.Fatalf("Summary got %v lines, want %v\n%s", got, want, summary)
+		}
+		if want, got := strings.Fields("one.com 353 01 1 1 2"), strings.Fields(lines[1]); strings.Join(want, " ") != strings.Join(got, " ") {
+			t.Fatalf("Summary got %v, want %v", got, want)
+		}
+	})
+}
+
+//TestHistoryService checks HistoryService semantics, including history logged by NumberingService
+func TestHistoryService(t *testing.T, newBackend NewBackendFunc) {
+	ownerID := int64(99)
+
+	t.Run("OkLogged", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b)
+		portTS := time.Now().Unix() + 24*60*60
+		untilTS := time.Now().Unix() + 60
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		} else if err := b.Numbering.DeAllocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		} else if err := b.Numbering.Portout(b.UserCtx, &testNumbers[0], &portTS); err != nil {
+			t.Fatal(err)
+		} else if err := b.Numbering.Portin(b.UserCtx, &testNumbers[0], &portTS); err != nil {
+			t.Fatal(err)
+		} else if err := b.Numbering.Delete(b.UserCtx, &testNumbers[0]); err != nil {
+			t.Fatal(err)
+		} else if err := b.Numbering.Reserve(b.UserCtx, &testNumbers[1], &ownerID, &untilTS); err != nil {
+			t.Fatal(err)
+		}
+
+		history, err := b.History.ListHistoryByNumber(b.UserCtx, testNumbers[0], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actions []string
+		for _, h := range history {
+			actions = append(actions, h.Action)
+		}
+		if want, got := "added allocated deallocated port-out port-in deleted", strings.Join(actions, " "); want != got {
+			t.Fatalf("History got %v, want %v", got, want)
+		}
+		if want, got := "Domain:one.com, Carrier:anycarrier", history[0].Notes; want != got {
+			t.Fatalf("Notes got %v, want %v", got, want)
+		}
+		//reservations are not logged
+		if history, err := b.History.ListHistoryByOwnerID(b.UserCtx, ownerID, false); err != nil {
+			t.Fatal(err)
+		} else if want, got := 2, len(history); want != got {
+			t.Fatalf("History by owner got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("OkAddHistory", func(t *testing.T) {
+		b := newBackend(t)
+		before := time.Now().Unix()
+		if err := b.History.AddHistory(b.UserCtx, numan.History{E164: testNumbers[0], Action: "note", OwnerID: ownerID, Notes: "any"}); err != nil {
+			t.Fatal(err)
+		}
+		history, err := b.History.ListHistoryByNumber(b.UserCtx, testNumbers[0], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := 1, len(history); want != got {
+			t.Fatalf("History got %v, want %v", got, want)
+		}
+		if history[0].Timestamp < before || history[0].Action != "note" || history[0].Notes != "any" || history[0].OwnerID != ownerID {
+			t.Fatalf("History got %+v", history[0])
+		}
+	})
+
+	t.Run("ErrList", func(t *testing.T) {
+		b := newBackend(t)
+		if _, err := b.History.ListHistoryByNumber(b.UserCtx, numan.E164{Cc: "353", Ndc: "01", Sn: "1"}, false); err == nil {
+			t.Fatal("Listed history for invalid number")
+		}
+		if _, err := b.History.ListHistoryByOwnerID(b.UserCtx, 0, false); err == nil {
+			t.Fatal("Listed history for invalid owner")
+		}
+	})
+
+	t.Run("OkArchive", func(t *testing.T) {
+		b := newBackend(t)
+		addNumbers(t, b) //1 entry per number
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		archived, err := b.History.ArchiveHistory(b.AdminCtx, numan.RetentionPolicy{Before: time.Now().Unix() + 1, KeepLast: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := int64(1), archived; want != got {
+			t.Fatalf("Archived got %v, want %v", got, want)
+		}
+		if history, err := b.History.ListHistoryByNumber(b.UserCtx, testNumbers[0], false); err != nil {
+			t.Fatal(err)
+		} else if want, got := 1, len(history); want != got {
+			t.Fatalf("History got %v, want %v", got, want)
+		}
+		if history, err := b.History.ListHistoryByNumber(b.UserCtx, testNumbers[0], true); err != nil {
+			t.Fatal(err)
+		} else if want, got := 2, len(history); want != got {
+			t.Fatalf("History with archive got %v, want %v", got, want)
+		}
+		if _, err := b.History.ArchiveHistory(b.AdminCtx, numan.RetentionPolicy{Before: time.Now().Unix(), KeepLast: -1}); err == nil {
+			t.Fatal("Archived with negative keep last")
+		}
+	})
+}
+
+//TestEventService checks EventService semantics, number changes are stored as events with the states before & after
+func TestEventService(t *testing.T, newBackend NewBackendFunc) {
+	ownerID := int64(99)
+	b := newBackend(t)
+	if b.Events == nil {
+		t.Skip("no EventService")
+	}
+	addNumbers(t, b)
+	portTS := time.Now().Unix() + 24*60*60
+	untilTS := time.Now().Unix() + 60
+	if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+		t.Fatal(err)
+	} else if err := b.Numbering.DeAllocate(b.UserCtx, &testNumbers[0], &ownerID); err != nil {
+		t.Fatal(err)
+	} else if err := b.Numbering.Reserve(b.UserCtx, &testNumbers[1], &ownerID, &untilTS); err != nil {
+		t.Fatal(err)
+	} else if err := b.Numbering.Portin(b.UserCtx, &testNumbers[2], &portTS); err != nil {
+		t.Fatal(err)
+	} else if err := b.Numbering.Delete(b.UserCtx, &testNumbers[3]); err != nil {
+		t.Fatal(err)
+	} else if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[1], &ownerID); err == nil { //refused, no event
+		t.Fatal("reserved number allocated")
+	}
+
+	t.Run("OkList", func(t *testing.T) {
+		events, err := b.Events.ListEvents(b.UserCtx, &numan.EventFilter{}, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []numan.NumberEvent{
+			{Type: numan.EventAdded, E164: testNumbers[0], Domain: "one.com", NewState: numan.StateFree},
+			{Type: numan.EventAdded, E164: testNumbers[1], Domain: "one.com", NewState: numan.StateFree},
+			{Type: numan.EventAdded, E164: testNumbers[2], Domain: "two.com", NewState: numan.StateFree},
+			{Type: numan.EventAdded, E164: testNumbers[3], Domain: "two.com", NewState: numan.StateFree},
+			{Type: numan.EventAllocated, E164: testNumbers[0], Domain: "one.com", OldState: numan.StateFree, NewState: numan.StateAllocated, OwnerID: ownerID},
+			{Type: numan.EventDeAllocated, E164: testNumbers[0], Domain: "one.com", OldState: numan.StateAllocated, NewState: numan.StateQuarantined, OwnerID: ownerID},
+			{Type: numan.EventReserved, E164: testNumbers[1], Domain: "one.com", OldState: numan.StateFree, NewState: numan.StateReserved, OwnerID: ownerID},
+			{Type: numan.EventPortedIn, E164: testNumbers[2], Domain: "two.com", OldState: numan.StateFree, NewState: numan.StateFree},
+			{Type: numan.EventDeleted, E164: testNumbers[3], Domain: "two.com", OldState: numan.StateFree},
+		}
+		if len(events) != len(want) {
+			t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+		}
+		for i, e := range events {
+			if i > 0 && e.Seq <= events[i-1].Seq {
+				t.Errorf("event %d seq %d not increasing", i, e.Seq)
+			}
+			if e.Timestamp == 0 || e.Carrier != "anycarrier" {
+				t.Errorf("event %d timestamp %d, carrier %s", i, e.Timestamp, e.Carrier)
+			}
+			e.Seq, e.Timestamp, e.Carrier, e.Actor = 0, 0, "", ""
+			if !reflect.DeepEqual(e, want[i]) {
+				t.Errorf("event %d got %+v, want %+v", i, e, want[i])
+			}
+		}
+	})
+
+	t.Run("OkFilter", func(t *testing.T) {
+		all, err := b.Events.ListEvents(b.UserCtx, &numan.EventFilter{}, 100)
+		if err != nil || len(all) != 9 {
+			t.Fatal(len(all), err)
+		}
+		for _, tc := range []struct {
+			filter numan.EventFilter
+			limit  int
+			want   int
+		}{
+			{numan.EventFilter{Domain: "two.com"}, 100, 4},
+			{numan.EventFilter{Carrier: "anycarrier"}, 100, 9},
+			{numan.EventFilter{Carrier: "othercarrier"}, 100, 0},
+			{numan.EventFilter{Prefix: "353-021"}, 100, 2},
+			{numan.EventFilter{Prefix: "353-01-12345"}, 100, 5},
+			{numan.EventFilter{Types: []string{numan.EventAllocated, numan.EventDeAllocated}}, 100, 2},
+			{numan.EventFilter{AfterSeq: all[4].Seq}, 100, 4},
+			{numan.EventFilter{}, 2, 2},
+		} {
+			if events, err := b.Events.ListEvents(b.UserCtx, &tc.filter, tc.limit); err != nil || len(events) != tc.want {
+				t.Errorf("filter %+v got %d events, want %d (err %v)", tc.filter, len(events), tc.want, err)
+			}
+		}
+	})
+
+	t.Run("FailInvalid", func(t *testing.T) {
+		if _, err := b.Events.ListEvents(b.UserCtx, &numan.EventFilter{Prefix: "x"}, 10); !errors.Is(err, numan.ErrInvalidArgument) {
+			t.Errorf("invalid prefix got %v", err)
+		}
+		if _, err := b.Events.ListEvents(b.UserCtx, &numan.EventFilter{Types: []string{"bogus"}}, 10); !errors.Is(err, numan.ErrInvalidArgument) {
+			t.Errorf("invalid type got %v", err)
+		}
+		if _, err := b.Events.ListEvents(b.UserCtx, &numan.EventFilter{}, 0); !errors.Is(err, numan.ErrInvalidArgument) {
+			t.Errorf("invalid limit got %v", err)
+		}
+		if _, err := b.Events.ListEvents(b.UserCtx, nil, 10); !errors.Is(err, numan.ErrInvalidArgument) {
+			t.Errorf("nil filter got %v", err)
+		}
+	})
+
+	t.Run("OkWatchResume", func(t *testing.T) {
+		all, err := b.Events.ListEvents(b.UserCtx, &numan.EventFilter{}, 100)
+		if err != nil || len(all) != 9 {
+			t.Fatal(len(all), err)
+		}
+		ctx, cancel := context.WithCancel(b.UserCtx)
+		defer cancel()
+		received := make(chan numan.NumberEvent, 10)
+		done := make(chan error, 1)
+		go func() {
+			done <- b.Events.Watch(ctx, &numan.EventFilter{AfterSeq: all[6].Seq, Domain: "two.com"}, func(e numan.NumberEvent) error {
+				received <- e
+				return nil
+			})
+		}()
+		next := func() numan.NumberEvent {
+			t.Helper()
+			select {
+			case e := <-received:
+				return e
+			case err := <-done:
+				t.Fatalf("watch ended: %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("no event")
+			}
+			return numan.NumberEvent{}
+		}
+		//stored events after the sequence number, then new events
+		if e := next(); e.Seq != all[7].Seq || e.Type != numan.EventPortedIn {
+			t.Errorf("got %+v, want event %d", e, all[7].Seq)
+		}
+		if e := next(); e.Seq != all[8].Seq || e.Type != numan.EventDeleted {
+			t.Errorf("got %+v, want event %d", e, all[8].Seq)
+		}
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[0], &ownerID); err == nil { //quarantined & filtered, no event
+			t.Fatal("quarantined number allocated")
+		}
+		if err := b.Numbering.Allocate(b.UserCtx, &testNumbers[2], &ownerID); err != nil {
+			t.Fatal(err)
+		}
+		if e := next(); e.Seq <= all[8].Seq || e.Type != numan.EventAllocated || e.E164 != testNumbers[2] || e.NewState != numan.StateAllocated {
+			t.Errorf("got %+v, want allocated %v", e, testNumbers[2])
+		}
+		cancel()
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("watch ended with %v, want context.Canceled", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch not ended by cancel")
+		}
+	})
+
+	t.Run("OkWatchSendError", func(t *testing.T) {
+		stop := errors.New("stop")
+		if err := b.Events.Watch(b.UserCtx, &numan.EventFilter{}, func(e numan.NumberEvent) error { return stop }); err != stop {
+			t.Errorf("got %v, want send error", err)
+		}
+	})
+}
+
+//TestUserService checks UserService semantics
+func TestUserService(t *testing.T, newBackend NewBackendFunc) {
+	t.Run("OkAddAuth", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		user, err := b.User.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := []string{numan.RoleUser}, user.Roles; !reflect.DeepEqual(want, got) {
+			t.Fatalf("Roles got %v, want %v", got, want)
+		}
+		if user.AccessToken == "" {
+			t.Fatal("Auth returned no access token")
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "wrong123"); !errors.Is(err, numan.ErrUnauthenticated) {
+			t.Fatal("Auth accepted wrong password")
+		}
+		if _, err := b.User.Auth(context.Background(), "nobody", "secret123"); !errors.Is(err, numan.ErrUnauthenticated) {
+			t.Fatal("Auth accepted unknown user")
+		}
+	})
+
+	t.Run("ErrAddUser", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); !errors.Is(err, numan.ErrAlreadyExists) {
+			t.Fatal("Added duplicate user")
+		}
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "bob", Password: "secret123", Roles: []string{"superuser"}}); !errors.Is(err, numan.ErrNotFound) {
+			t.Fatal("Added user with unknown role")
+		}
+	})
+
+	t.Run("OkListDelete", func(t *testing.T) {
+		b := newBackend(t)
+		for _, name := range []string{"alice", "albert", "bob"} {
+			if err := b.User.AddUser(b.AdminCtx, numan.User{Username: name, Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if users, err := b.User.ListUsers(b.AdminCtx, "al"); err != nil {
+			t.Fatal(err)
+		} else if want, got := 2, len(users); want != got {
+			t.Fatalf("ListUsers got %v, want %v", got, want)
+		} else if users[0].Password != "" {
+			t.Fatal("ListUsers returned password")
+		}
+		if err := b.User.DeleteUser(b.AdminCtx, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.DeleteUser(b.AdminCtx, "alice"); !errors.Is(err, numan.ErrNotFound) {
+			t.Fatal("Deleted user twice")
+		}
+		if users, err := b.User.ListUsers(b.AdminCtx, ""); err != nil {
+			t.Fatal(err)
+		} else if want, got := 2, len(users); want != got {
+			t.Fatalf("ListUsers got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("OkSetPassword", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.SetPassword(b.AdminCtx, "alice", "newsecret123"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "newsecret123"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err == nil {
+			t.Fatal("Auth accepted old password")
+		}
+		if err := b.User.SetPassword(b.AdminCtx, "nobody", "newsecret123"); !errors.Is(err, numan.ErrNotFound) {
+			t.Fatal("Set password for unknown user")
+		}
+	})
+	t.Run("OkRefresh", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		user, err := b.User.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.RefreshToken == "" {
+			t.Fatal("Auth returned no refresh token")
+		}
+		refreshed, err := b.User.Refresh(context.Background(), user.RefreshToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if refreshed.Username != "alice" || !reflect.DeepEqual(refreshed.Roles, []string{numan.RoleUser}) || refreshed.AccessToken == "" {
+			t.Fatalf("Refresh got %+v", refreshed)
+		}
+		if refreshed.RefreshToken == "" || refreshed.RefreshToken == user.RefreshToken {
+			t.Fatal("Refresh did not return a new refresh token")
+		}
+		//refresh tokens are single use
+		if _, err := b.User.Refresh(context.Background(), user.RefreshToken); err == nil {
+			t.Fatal("Refresh token used twice")
+		}
+		if _, err := b.User.Refresh(context.Background(), "unknown"); !errors.Is(err, numan.ErrUnauthenticated) {
+			t.Fatal("Refresh accepted unknown token")
+		}
+	})
+
+	t.Run("OkLogout", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		user, err := b.User.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.Logout(context.WithValue(context.Background(), numan.AuthTokenField, user.AccessToken), user.RefreshToken); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Refresh(context.Background(), user.RefreshToken); err == nil {
+			t.Fatal("Refresh token valid after logout")
+		}
+	})
+
+	t.Run("OkRevokeRefreshTokens", func(t *testing.T) {
+		b := newBackend(t)
+		for _, name := range []string{"alice", "bob"} {
+			if err := b.User.AddUser(b.AdminCtx, numan.User{Username: name, Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		alice, err := b.User.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bob, err := b.User.Auth(context.Background(), "bob", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.SetPassword(b.AdminCtx, "alice", "newsecret123"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.DeleteUser(b.AdminCtx, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Refresh(context.Background(), alice.RefreshToken); err == nil {
+			t.Fatal("Refresh token valid after password change")
+		}
+		if _, err := b.User.Refresh(context.Background(), bob.RefreshToken); err == nil {
+			t.Fatal("Refresh token valid after user deleted")
+		}
+	})
+	t.Run("OkRoles", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.SetRole(b.AdminCtx, numan.Role{Name: "porter", Permissions: []string{numan.PermNumbersRead, numan.PermNumbersPort}}); err != nil {
+			t.Fatal(err)
+		}
+		roles, err := b.User.ListRoles(b.AdminCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := []string{numan.RoleAdmin, numan.RoleOperator, "porter", numan.RoleUser, numan.RoleViewer}, roleNames(roles); !reflect.DeepEqual(want, got) {
+			t.Fatalf("ListRoles got %v, want %v", got, want)
+		}
+		if want, got := []string{numan.PermNumbersPort, numan.PermNumbersRead}, roles[2].Permissions; !reflect.DeepEqual(want, got) {
+			t.Fatalf("Permissions got %v, want %v", got, want)
+		}
+		if err := b.User.GrantRole(b.AdminCtx, "alice", "porter"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.GrantRole(b.AdminCtx, "alice", "porter"); err == nil {
+			t.Fatal("Granted role twice")
+		}
+		if users, err := b.User.ListUsers(b.AdminCtx, "alice"); err != nil {
+			t.Fatal(err)
+		} else if want, got := []string{"porter", numan.RoleViewer}, users[0].Roles; !reflect.DeepEqual(want, got) {
+			t.Fatalf("Roles got %v, want %v", got, want)
+		}
+		if err := b.User.DeleteRole(b.AdminCtx, "porter"); err == nil {
+			t.Fatal("Deleted granted role")
+		}
+		if err := b.User.RevokeRole(b.AdminCtx, "alice", "porter"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.RevokeRole(b.AdminCtx, "alice", "porter"); err == nil {
+			t.Fatal("Revoked role twice")
+		}
+		if err := b.User.DeleteRole(b.AdminCtx, "porter"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.DeleteRole(b.AdminCtx, "porter"); err == nil {
+			t.Fatal("Deleted role twice")
+		}
+	})
+
+	t.Run("OkScope", func(t *testing.T) {
+		b := newBackend(t)
+		scope := numan.Scope{Domains: []string{"a.com"}, Prefixes: []string{"353-01"}}
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}, Scope: scope}); err != nil {
+			t.Fatal(err)
+		}
+		user, err := b.User.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(scope, user.Scope) {
+			t.Fatalf("Scope got %+v, want %+v", user.Scope, scope)
+		}
+		scope = numan.Scope{Carriers: []string{"carrier1", "carrier2"}}
+		if err := b.User.SetScope(b.AdminCtx, "alice", scope); err != nil {
+			t.Fatal(err)
+		}
+		if users, err := b.User.ListUsers(b.AdminCtx, "alice"); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(scope, users[0].Scope) {
+			t.Fatalf("Scope got %+v, want %+v", users[0].Scope, scope)
+		}
+		//tokens are refreshed with the new scope
+		refreshed, err := b.User.Refresh(context.Background(), user.RefreshToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(scope, refreshed.Scope) {
+			t.Fatalf("Refreshed scope got %+v, want %+v", refreshed.Scope, scope)
+		}
+		if err := b.User.SetScope(b.AdminCtx, "alice", numan.Scope{Prefixes: []string{"0353"}}); err == nil {
+			t.Fatal("Set scope with invalid prefix")
+		}
+		if err := b.User.SetScope(b.AdminCtx, "nobody", scope); err == nil {
+			t.Fatal("Set scope for unknown user")
+		}
+	})
+
+	t.Run("OkAPIKeys", func(t *testing.T) {
+		b := newBackend(t)
+		scope := numan.Scope{Domains: []string{"a.com"}}
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "robot", Password: "secret123", Roles: []string{numan.RoleViewer}, Scope: scope}); err != nil {
+			t.Fatal(err)
+		}
+		key, err := b.User.AddAPIKey(b.AdminCtx, numan.APIKey{Username: "robot"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(key.Key, key.ID+".") {
+			t.Fatalf("API key %v doesn't start with id %v", key.Key, key.ID)
+		}
+		user, err := b.User.AuthAPIKey(context.Background(), key.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Username != "robot" || !reflect.DeepEqual([]string{numan.RoleViewer}, user.Roles) || !reflect.DeepEqual(scope, user.Scope) || user.AccessToken == "" {
+			t.Fatalf("AuthAPIKey got %+v", user)
+		}
+		keys, err := b.User.ListAPIKeys(b.AdminCtx, "rob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 || keys[0].ID != key.ID || keys[0].Key != "" || keys[0].LastUsed == 0 {
+			t.Fatalf("ListAPIKeys got %+v", keys)
+		}
+		if _, err := b.User.AuthAPIKey(context.Background(), key.ID+".wrongsecret"); err == nil {
+			t.Fatal("Authenticated API key with wrong secret")
+		}
+		if err := b.User.DeleteAPIKey(b.AdminCtx, key.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.AuthAPIKey(context.Background(), key.Key); err == nil {
+			t.Fatal("Authenticated deleted API key")
+		}
+		if err := b.User.DeleteAPIKey(b.AdminCtx, key.ID); err == nil {
+			t.Fatal("Deleted API key twice")
+		}
+	})
+
+	t.Run("OkAPIKeyAccess", func(t *testing.T) {
+		b := newBackend(t)
+		scope := numan.Scope{Domains: []string{"a.com"}, Prefixes: []string{"353"}}
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "robot", Password: "secret123", Roles: []string{numan.RoleUser, numan.RoleViewer}, Scope: scope}); err != nil {
+			t.Fatal(err)
+		}
+		key, err := b.User.AddAPIKey(b.AdminCtx, numan.APIKey{Username: "robot", Roles: []string{numan.RoleViewer}, Scope: numan.Scope{Prefixes: []string{"353-01"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		user, err := b.User.AuthAPIKey(context.Background(), key.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := numan.Scope{Domains: []string{"a.com"}, Prefixes: []string{"353-01"}}
+		if !reflect.DeepEqual([]string{numan.RoleViewer}, user.Roles) || !reflect.DeepEqual(want, user.Scope) {
+			t.Fatalf("AuthAPIKey got roles %v scope %+v, want %v %+v", user.Roles, user.Scope, []string{numan.RoleViewer}, want)
+		}
+		keys, err := b.User.ListAPIKeys(b.AdminCtx, "robot")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 || !reflect.DeepEqual([]string{numan.RoleViewer}, keys[0].Roles) || !reflect.DeepEqual([]string{"353-01"}, keys[0].Scope.Prefixes) {
+			t.Fatalf("ListAPIKeys got %+v", keys)
+		}
+		//a key can't have more than its user
+		if _, err := b.User.AddAPIKey(b.AdminCtx, numan.APIKey{Username: "robot", Roles: []string{numan.RoleAdmin}}); err == nil {
+			t.Fatal("Added API key with a role its user doesn't have")
+		}
+		if _, err := b.User.AddAPIKey(b.AdminCtx, numan.APIKey{Username: "robot", Scope: numan.Scope{Prefixes: []string{"44"}}}); err == nil {
+			t.Fatal("Added API key with a scope outside its users")
+		}
+		//nor keep what its user loses
+		if err := b.User.RevokeRole(b.AdminCtx, "robot", numan.RoleViewer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.AuthAPIKey(context.Background(), key.Key); err == nil {
+			t.Fatal("Authenticated API key with a role its user no longer has")
+		}
+		if err := b.User.GrantRole(b.AdminCtx, "robot", numan.RoleViewer); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.SetScope(b.AdminCtx, "robot", numan.Scope{Prefixes: []string{"353-021"}}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.AuthAPIKey(context.Background(), key.Key); err == nil {
+			t.Fatal("Authenticated API key with a scope outside its users")
+		}
+	})
+
+	t.Run("ErrAPIKeys", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "robot", Password: "secret123", Roles: []string{numan.RoleViewer}}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.AddAPIKey(b.AdminCtx, numan.APIKey{Username: "nobody"}); err == nil {
+			t.Fatal("Added API key for unknown user")
+		}
+		if _, err := b.User.AddAPIKey(b.AdminCtx, numan.APIKey{Username: "robot", Expires: time.Now().Add(-time.Hour).Unix()}); err == nil {
+			t.Fatal("Added API key with expiry in the past")
+		}
+		if _, err := b.User.AuthAPIKey(context.Background(), "notakey"); err == nil {
+			t.Fatal("Authenticated malformed API key")
+		}
+		//keys are deleted with their user
+		key, err := b.User.AddAPIKey(b.AdminCtx, numan.APIKey{Username: "robot"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.DeleteUser(b.AdminCtx, "robot"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.AuthAPIKey(context.Background(), key.Key); err == nil {
+			t.Fatal("Authenticated API key of deleted user")
+		}
+	})
+
+	t.Run("OkLockout", func(t *testing.T) {
+		b := newBackend(t)
+		defer numan.SetLoginPolicy(numan.CurrentLoginPolicy())
+		numan.SetLoginPolicy(numan.LoginPolicy{MaxFailures: 3, Lockout: time.Minute, MaxLockout: time.Hour})
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		sourceCtx := context.WithValue(context.Background(), numan.SourceField, "192.0.2.1")
+		for i := 0; i < 3; i++ {
+			if _, err := b.User.Auth(sourceCtx, "alice", "wrongpass"); err == nil {
+				t.Fatal("Authenticated with wrong password")
+			}
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err == nil {
+			t.Fatal("Authenticated locked username")
+		}
+		if err := b.User.Unlock(b.AdminCtx, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(sourceCtx, "alice", "secret123"); err == nil {
+			t.Fatal("Authenticated from locked source")
+		}
+		if err := b.User.Unlock(b.AdminCtx, "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(sourceCtx, "alice", "secret123"); err != nil {
+			t.Fatal(err)
+		}
+		//a successful login clears the username failures
+		if err := b.User.Unlock(b.AdminCtx, "alice"); err == nil {
+			t.Fatal("Unlocked username without failed logins")
+		}
+		entries, err := b.User.ListAudit(b.AdminCtx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		actions := map[string]int{}
+		for _, e := range entries {
+			actions[e.Action]++
+		}
+		if want := map[string]int{numan.AuditLoginFailed: 3, numan.AuditLockout: 2, numan.AuditUnlock: 1}; !reflect.DeepEqual(want, actions) {
+			t.Fatalf("Audit actions got %v, want %v", actions, want)
+		}
+		if entries[0].Action != numan.AuditUnlock || entries[len(entries)-1].Action != numan.AuditLoginFailed || entries[len(entries)-1].Source != "192.0.2.1" {
+			t.Fatalf("Audit log not most recent first %+v", entries)
+		}
+	})
+
+	t.Run("OkLifecycle", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		user, err := b.User.Auth(context.Background(), "alice", "secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if users, err := b.User.ListUsers(b.AdminCtx, "alice"); err != nil {
+			t.Fatal(err)
+		} else if users[0].LastLogin == 0 || users[0].Status != (numan.AccountStatus{}) {
+			t.Fatalf("ListUsers got %+v, want active with last login", users[0])
+		}
+		//disabled
+		if err := b.User.SetStatus(b.AdminCtx, "alice", numan.AccountStatus{Disabled: true}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err == nil {
+			t.Fatal("Authenticated disabled user")
+		}
+		if _, err := b.User.Refresh(context.Background(), user.RefreshToken); err == nil {
+			t.Fatal("Refreshed token of disabled user")
+		}
+		if err := b.User.SetStatus(b.AdminCtx, "alice", numan.AccountStatus{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err != nil {
+			t.Fatal(err)
+		}
+		//forced change
+		if err := b.User.SetStatus(b.AdminCtx, "alice", numan.AccountStatus{MustChangePassword: true}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err == nil {
+			t.Fatal("Authenticated user who must change password")
+		}
+		if err := b.User.ChangePassword(context.Background(), "alice", "secret123", "secret456"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret456"); err != nil {
+			t.Fatal(err)
+		}
+		//expired password
+		if err := b.User.SetStatus(b.AdminCtx, "alice", numan.AccountStatus{PasswordExpires: time.Now().Add(-time.Hour).Unix()}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret456"); err == nil {
+			t.Fatal("Authenticated expired password")
+		}
+		if err := b.User.ChangePassword(context.Background(), "alice", "secret456", "secret789"); err != nil {
+			t.Fatal(err)
+		}
+		if users, err := b.User.ListUsers(b.AdminCtx, "alice"); err != nil {
+			t.Fatal(err)
+		} else if users[0].Status != (numan.AccountStatus{}) {
+			t.Fatalf("Status after password change got %+v, want cleared", users[0].Status)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret789"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrLifecycle", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}, Status: numan.AccountStatus{Disabled: true}}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err == nil {
+			t.Fatal("Authenticated user added disabled")
+		}
+		if err := b.User.ChangePassword(context.Background(), "alice", "secret123", "secret456"); err == nil {
+			t.Fatal("Changed password of disabled user")
+		}
+		if err := b.User.SetStatus(b.AdminCtx, "alice", numan.AccountStatus{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.ChangePassword(context.Background(), "alice", "wrongpass", "secret456"); err == nil {
+			t.Fatal("Changed password with wrong old password")
+		}
+		if err := b.User.ChangePassword(context.Background(), "alice", "secret123", "secret123"); err == nil {
+			t.Fatal("Changed password to the same password")
+		}
+		if err := b.User.ChangePassword(context.Background(), "nobody", "secret123", "secret456"); err == nil {
+			t.Fatal("Changed password of unknown user")
+		}
+		if err := b.User.SetStatus(b.AdminCtx, "nobody", numan.AccountStatus{Disabled: true}); err == nil {
+			t.Fatal("Set status of unknown user")
+		}
+	})
+
+	t.Run("OkPasswordPolicy", func(t *testing.T) {
+		b := newBackend(t)
+		defer numan.SetPasswordPolicy(numan.CurrentPasswordPolicy())
+		numan.SetPasswordPolicy(numan.PasswordPolicy{MinLength: 8, MaxLength: 72, MinClasses: 2, History: 2})
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "Secret 123!", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		//compared exactly as entered
+		if _, err := b.User.Auth(context.Background(), "alice", "secret 123!"); err == nil {
+			t.Fatal("Authenticated with password of different case")
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "Secret 123!"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.ChangePassword(context.Background(), "alice", "Secret 123!", "Secret456"); err != nil {
+			t.Fatal(err)
+		}
+		//history of 2, the first password can't be reused yet
+		if err := b.User.ChangePassword(context.Background(), "alice", "Secret456", "Secret 123!"); err == nil {
+			t.Fatal("Changed password to a recent password")
+		}
+		if err := b.User.SetPassword(b.AdminCtx, "alice", "Secret 123!"); err == nil {
+			t.Fatal("Set password to a recent password")
+		}
+		if err := b.User.SetPassword(b.AdminCtx, "alice", "Secret789"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.SetPassword(b.AdminCtx, "alice", "Secret 123!"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "Secret 123!"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrPasswordPolicy", func(t *testing.T) {
+		b := newBackend(t)
+		defer numan.SetPasswordPolicy(numan.CurrentPasswordPolicy())
+		numan.SetPasswordPolicy(numan.PasswordPolicy{MinLength: 8, MaxLength: 72, MinClasses: 2, Denylist: map[string]bool{"password1": true}})
+		for _, password := range []string{"short1", "onlyletters", "Password1"} {
+			if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "bob", Password: password, Roles: []string{numan.RoleUser}}); err == nil {
+				t.Fatalf("Added user with password %q", password)
+			}
+		}
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.SetPassword(b.AdminCtx, "alice", "short1"); err == nil {
+			t.Fatal("Set password shorter than policy")
+		}
+		if err := b.User.ChangePassword(context.Background(), "alice", "secret123", "password1"); err == nil {
+			t.Fatal("Changed password to denylisted password")
+		}
+		if _, err := b.User.Auth(context.Background(), "alice", "secret123"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrRoles", func(t *testing.T) {
+		b := newBackend(t)
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "alice", Password: "secret123", Roles: []string{numan.RoleUser}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.User.SetRole(b.AdminCtx, numan.Role{Name: numan.RoleAdmin}); err == nil {
+			t.Fatal("Changed admin role")
+		}
+		if err := b.User.DeleteRole(b.AdminCtx, numan.RoleAdmin); err == nil {
+			t.Fatal("Deleted admin role")
+		}
+		if err := b.User.SetRole(b.AdminCtx, numan.Role{Name: "porter", Permissions: []string{"numbers:fly"}}); err == nil {
+			t.Fatal("Set role with unknown permission")
+		}
+		if err := b.User.SetRole(b.AdminCtx, numan.Role{Name: "Bad Name"}); err == nil {
+			t.Fatal("Set role with bad name")
+		}
+		if err := b.User.GrantRole(b.AdminCtx, "alice", "superuser"); err == nil {
+			t.Fatal("Granted unknown role")
+		}
+		if err := b.User.GrantRole(b.AdminCtx, "nobody", numan.RoleUser); err == nil {
+			t.Fatal("Granted role to unknown user")
+		}
+		if err := b.User.AddUser(b.AdminCtx, numan.User{Username: "bob", Password: "secret123"}); err == nil {
+			t.Fatal("Added user without role")
+		}
+	})
+}
+
+//roleNames returns the names of roles
+func roleNames(roles []numan.Role) (names []string) {
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
@@ -2,8 +2,11 @@ package numan
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 )
 
 const (
@@ -59,8 +62,6 @@ type NumberingService interface {
 	//Adds a new unused number to database.
 	//params E164, Domain & Carrier must be included, others (supplied or not) are initialised.
 	Add(ctx context.Context, number *Numbering) error
-	//AddGroup adds a series of new unused numbers
-	AddGroup(ctx context.Context)
 	//List returns a filtered list of numbers
 	List(ctx context.Context, filter *NumberFilter) ([]Numbering, error)
 	//ListOwnerID gets list of numbers attached to specific OwnerID
@@ -81,26 +82,154 @@ type NumberingService interface {
 	View(ctx context.Context, number *E164) (string, error)
 	//Summary formatted table of usage stats
 	Summary(ctx context.Context) (string, error)
+	//Update sets fields (see UpdatableFields) of a stored number to those of number (selected by E164), returns the updated number.
+	Update(ctx context.Context, number *Numbering, fields []string) (Numbering, error)
+}
+
+//UpdatableFields lists the Numbering fields NumberingService.Update can set
+var UpdatableFields = []string{"domain", "carrier"}
+
+//Apply returns n with fields (see UpdatableFields) set to those of update, unknown fields are ignored
+func (n Numbering) Apply(update Numbering, fields []string) Numbering {
+	for _, f := range fields {
+		switch f {
+		case "domain":
+			n.Domain = update.Domain
+		case "carrier":
+			n.Carrier = update.Carrier
+		}
+	}
+	return n
 }
 
 //ValidE164 validates an phonenumber is E164
 func (phoneNumber E164) ValidE164() error {
 	if ok, _ := regexp.MatchString(`^[1-9][0-9]{0,2}$`, phoneNumber.Cc); !ok {
-		return errors.New("Invalid country code in phone number")
+		return Errorf(ErrInvalidArgument, "Invalid country code in phone number")
 	}
 	if ok, _ := regexp.MatchString(`^[01][1-9][0-9]{0,3}$`, phoneNumber.Ndc); !ok {
-		return errors.New("Invalid destination code in phone number")
+		return Errorf(ErrInvalidArgument, "Invalid destination code in phone number")
 	}
 	if ok, _ := regexp.MatchString(`^[0-9]{5,13}$`, phoneNumber.Sn); !ok {
-		return errors.New("Invalid subscriber number in phone number")
+		return Errorf(ErrInvalidArgument, "Invalid subscriber number in phone number")
 	}
 	return nil
 }
 
+//ValidUpdate validates an update of fields (see UpdatableFields) of number, updated fields can't be empty.
+func ValidUpdate(number *Numbering, fields []string) error {
+	if number == nil {
+		return Errorf(ErrInvalidArgument, "nil pointer")
+	}
+	if err := number.E164.ValidE164(); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return Errorf(ErrInvalidArgument, "No fields to update (%s)", strings.Join(UpdatableFields, ", "))
+	}
+	for _, f := range fields {
+		if !contains(UpdatableFields, f) {
+			return Errorf(ErrInvalidArgument, "Field '%s' can't be updated (%s)", f, strings.Join(UpdatableFields, ", "))
+		}
+	}
+	if updated := number.Apply(*number, fields); (contains(fields, "domain") && updated.Domain == "") || (contains(fields, "carrier") && updated.Carrier == "") {
+		return Errorf(ErrInvalidArgument, "Carrier & domain can't be empty")
+	}
+	return nil
+}
+
+//UpdateNotes returns the history notes of an update of fields to those of number
+func UpdateNotes(number Numbering, fields []string) string {
+	var notes []string
+	for _, f := range fields {
+		switch f {
+		case "domain":
+			notes = append(notes, "Domain:"+number.Domain)
+		case "carrier":
+			notes = append(notes, "Carrier:"+number.Carrier)
+		}
+	}
+	return strings.Join(notes, ", ")
+}
+
 //ValidOwnerID validates ownerID format.
 func ValidOwnerID(ownerID *int64) error {
 	if *ownerID == 0 {
-		return errors.New("User id invalid")
+		return Errorf(ErrInvalidArgument, "User id invalid")
 	}
 	return nil
 }
+
+//Quarantined returns true if the number was de-allocated within QUARANTINE (can't be reserved or allocated)
+func (n Numbering) Quarantined() bool {
+	return !n.Used && n.DeAllocated >= time.Now().Unix()-QUARANTINE
+}
+
+//NumberStateError returns the error for an update refused by the state of number n (nil if not stored).
+//ErrNotFound if n is nil, ErrQuarantined if quarantined applies and n is in quarantine, otherwise ErrConflict (conflict is the reason).
+func NumberStateError(n *Numbering, msg string, conflict string, quarantined bool) error {
+	switch {
+	case n == nil:
+		return Errorf(ErrNotFound, "%s, number not found", msg)
+	case quarantined && n.Quarantined():
+		return Errorf(ErrQuarantined, "%s, number in quarantine until %s", msg, time.Unix(n.DeAllocated+QUARANTINE, 0).Format(TIMESTAMPPRINTFORMAT))
+	}
+	return Errorf(ErrConflict, "%s, %s", msg, conflict)
+}
+
+//FormatView formats number details as returned by NumberingService.View
+func FormatView(numbers []Numbering) (view string) {
+	for _, r := range numbers {
+		view += fmt.Sprintf("#%d) +%v-%v-%v, Domain: %v, Carrier: %v\n", r.ID, r.E164.Cc, r.E164.Ndc, r.E164.Sn, r.Domain, r.Carrier)
+		if r.Used { //Used can be reserved or allocated
+			if r.Allocated > 0 {
+				view += fmt.Sprintf("Allocated to OwnerID: %v on %v\n", r.OwnerID, time.Unix(r.Allocated, 0).Format(DATEPRINTFORMAT))
+			}
+			if r.Reserved > 0 {
+				view += fmt.Sprintf("Reserved %v\n", time.Unix(r.Reserved, 0).Format(DATEPRINTFORMAT))
+			}
+		} else if r.DeAllocated > 0 {
+			view += fmt.Sprintf("Last allocated %v\n", time.Unix(r.DeAllocated, 0).Format(DATEPRINTFORMAT))
+			if r.PortedOut > 0 {
+				view += fmt.Sprintf("Ported out %v\n", time.Unix(r.PortedOut, 0).Format(DATEPRINTFORMAT))
+			}
+		} else {
+			view += "Never allocated\n"
+		}
+		if r.PortedIn > 0 {
+			view += fmt.Sprintf("Ported in %v\n", time.Unix(r.PortedIn, 0).Format(DATEPRINTFORMAT))
+		}
+	}
+	return view
+}
+
+//FormatSummary formats usage stats of numbers (per domain, cc & ndc) as returned by NumberingService.Summary
+func FormatSummary(numbers []Numbering) string {
+	type row struct {
+		domain, cc, ndc   string
+		used, free, total int
+	}
+	rows := map[string]*row{}
+	var keys []string
+	for _, n := range numbers {
+		key := n.Domain + "\x00" + n.E164.Cc + "\x00" + n.E164.Ndc
+		if rows[key] == nil {
+			rows[key] = &row{domain: n.Domain, cc: n.E164.Cc, ndc: n.E164.Ndc}
+			keys = append(keys, key)
+		}
+		if n.Used {
+			rows[key].used++
+		} else {
+			rows[key].free++
+		}
+		rows[key].total++
+	}
+	sort.Strings(keys)
+
+	summary := fmt.Sprintf("%-15v %5v %5v %5v %5v %5v\n", "Domain", "CC", "NDC", "Used", "Free", "Total")
+	for _, key := range keys {
+		r := rows[key]
+		summary += fmt.Sprintf("%-15v %5v %5v %5v %5v %5v\n", r.domain, r.cc, r.ndc, r.used, r.free, r.total)
+	}
+	return summary
+}
@@ -0,0 +1,131 @@
+package numan
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//PasswordPolicy is checked when a password is set (AddUser, SetPassword & ChangePassword).
+//Existing passwords are not checked at login.
+type PasswordPolicy struct {
+	MinLength  int             //minimum length in characters
+	MaxLength  int             //maximum length in bytes, bcrypt ignores anything after 72
+	MinClasses int             //minimum character classes used (lower case, upper case, digit, other)
+	Denylist   map[string]bool //refused passwords, e.g. known breached passwords (lower case)
+	History    int             //a new password can't be one of the last History passwords, 0 disables
+}
+
+//DefaultPasswordPolicy requires 8 characters of at least 2 classes
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 8, MaxLength: 72, MinClasses: 2}
+
+//passwordPolicy is the PasswordPolicy used when setting passwords
+var (
+	passwordPolicy   = DefaultPasswordPolicy
+	passwordPolicyMu sync.RWMutex //passwordPolicy can be replaced while serving (config reload)
+)
+
+//SetPasswordPolicy sets the PasswordPolicy used when setting passwords.
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicyMu.Lock()
+	defer passwordPolicyMu.Unlock()
+	passwordPolicy = policy
+}
+
+//CurrentPasswordPolicy returns the PasswordPolicy used when setting passwords.
+func CurrentPasswordPolicy() PasswordPolicy {
+	passwordPolicyMu.RLock()
+	defer passwordPolicyMu.RUnlock()
+	return passwordPolicy
+}
+
+//NewPasswordPolicyFromConfig returns the default PasswordPolicy overridden by the non zero settings.
+//denylistFile (optional) holds one refused password per line.
+func NewPasswordPolicyFromConfig(minLength int, minClasses int, history int, denylistFile string) (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy
+	if minLength > 0 {
+		policy.MinLength = minLength
+	}
+	if minClasses > 0 {
+		policy.MinClasses = minClasses
+	}
+	if history > 0 {
+		policy.History = history
+	}
+	if denylistFile != "" {
+		denylist, err := LoadPasswordDenylist(denylistFile)
+		if err != nil {
+			return policy, err
+		}
+		policy.Denylist = denylist
+	}
+	return policy, nil
+}
+
+//LoadPasswordDenylist reads a file of refused passwords, one per line. Blank lines & lines starting '#' are skipped.
+func LoadPasswordDenylist(filename string) (map[string]bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("can't read password denylist: %w", err)
+	}
+	defer f.Close()
+	denylist := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denylist[strings.ToLower(line)] = true
+	}
+	return denylist, scanner.Err()
+}
+
+//Check returns an error describing why password does not meet the policy OR nil.
+//History is checked separately (see CheckHistory) as it needs the stored hashes.
+func (p PasswordPolicy) Check(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return Errorf(ErrInvalidArgument, "password must be at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return Errorf(ErrInvalidArgument, "password must be at most %d bytes", p.MaxLength)
+	}
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	if lower+upper+digit+other < p.MinClasses {
+		return Errorf(ErrInvalidArgument, "password must use at least %d of lower case, upper case, digits & symbols", p.MinClasses)
+	}
+	if p.Denylist[strings.ToLower(password)] {
+		return Errorf(ErrInvalidArgument, "password is too common, choose another")
+	}
+	return nil
+}
+
+//CheckHistory returns an error if password matches one of hashes (the users previous password hashes, newest first).
+//Only the last p.History hashes are checked.
+func (p PasswordPolicy) CheckHistory(password string, hashes []string) error {
+	for i, hash := range hashes {
+		if i >= p.History {
+			break
+		}
+		u := User{Password: hash}
+		if u.ComparePassword(password) == nil {
+			return Errorf(ErrInvalidArgument, "password was used recently, the last %d passwords can't be reused", p.History)
+		}
+	}
+	return nil
+}
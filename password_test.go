@@ -0,0 +1,63 @@
+package numan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 72, MinClasses: 3, Denylist: map[string]bool{"password123!": true}}
+	for password, ok := range map[string]bool{
+		"Secret12":                true,
+		"secret 12":               true, //space counts as symbol
+		"Sécret-pässe":            true,
+		"Sec12":                   false, //too short
+		"secret123":               false, //two classes
+		"PASSWORD123!":            false, //denylisted (any case)
+		strings.Repeat("Ab1", 25): false, //too long for bcrypt
+	} {
+		if err := policy.Check(password); (err == nil) != ok {
+			t.Errorf("Check(%q) got %v, want ok %v", password, err, ok)
+		}
+	}
+}
+
+func TestPasswordPolicyCheckHistory(t *testing.T) {
+	var hashes []string
+	for _, password := range []string{"Secret3", "Secret2", "Secret1"} { //newest first
+		u := User{Password: password}
+		if err := u.HashPassword(); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, u.Password)
+	}
+	policy := PasswordPolicy{History: 2}
+	if err := policy.CheckHistory("Secret2", hashes); err == nil {
+		t.Error("CheckHistory accepted a recent password")
+	}
+	if err := policy.CheckHistory("Secret1", hashes); err != nil {
+		t.Errorf("CheckHistory refused a password older than history: %v", err)
+	}
+	if err := (PasswordPolicy{}).CheckHistory("Secret3", hashes); err != nil {
+		t.Errorf("Disabled history refused password: %v", err)
+	}
+}
+
+func TestNewPasswordPolicyFromConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "denylist.txt")
+	if err := os.WriteFile(file, []byte("# breached\nPassword1\n\nletmein99\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPasswordPolicyFromConfig(10, 0, 3, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.MinLength != 10 || policy.MinClasses != DefaultPasswordPolicy.MinClasses || policy.History != 3 || len(policy.Denylist) != 2 || !policy.Denylist["password1"] {
+		t.Fatalf("Policy got %+v", policy)
+	}
+	if _, err := NewPasswordPolicyFromConfig(0, 0, 0, filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("Loaded missing denylist file")
+	}
+}
@@ -0,0 +1,79 @@
+package numan
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//RetryAfterField is the gRPC metadata field (trailer) with the seconds to wait before retrying a rate limited call
+const RetryAfterField = "retry-after"
+
+//PatternRateLimitMethod matches RateLimit.Method, * (any method), Service/* or Service/Method (service without package, ex. Numbering/List)
+const PatternRateLimitMethod = `^(\*|[A-Za-z][A-Za-z0-9_]*/(\*|[A-Za-z][A-Za-z0-9_]*))$`
+
+//RateLimit is a token bucket limit of the calls to a method by each caller (user, API key or source address if not authenticated).
+//A bucket holds up to Burst calls and refills at Rate calls per second.
+type RateLimit struct {
+	Method string  //see PatternRateLimitMethod, the most specific limit of a method applies
+	Rate   float64 //calls per second
+	Burst  int     //calls at once
+}
+
+//RateLimitUsage is the token bucket of a caller for a method
+type RateLimitUsage struct {
+	Key      string  //caller, user:<username>, apikey:<id> or source:<address>
+	Method   string  //Service/Method called
+	Limit    string  //RateLimit.Method applied
+	Tokens   float64 //calls available now
+	Allowed  int64   //calls allowed
+	Limited  int64   //calls refused
+	LastSeen int64   //timestamp of the last call
+}
+
+//RateLimitService exposes the rate limits of the server
+type RateLimitService interface {
+	//ListRateLimits returns the configured limits and the usage of recent callers
+	ListRateLimits(ctx context.Context) ([]RateLimit, []RateLimitUsage, error)
+}
+
+//ParseRateLimits parses rate limits from config, method=rate[:burst],... (ex. *=20:40,Numbering/List=2:10).
+//Burst defaults to the rate (at least 1).
+func ParseRateLimits(config string) ([]RateLimit, error) {
+	var limits []RateLimit
+	for _, entry := range strings.Split(config, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, Errorf(ErrInvalidArgument, "invalid rate limit '%s' (method=rate[:burst])", entry)
+		}
+		limit := RateLimit{Method: strings.TrimSpace(parts[0])}
+		if ok, _ := regexp.MatchString(PatternRateLimitMethod, limit.Method); !ok {
+			return nil, Errorf(ErrInvalidArgument, "invalid rate limit method '%s' (*, Service/* or Service/Method)", limit.Method)
+		}
+		values := strings.SplitN(strings.TrimSpace(parts[1]), ":", 2)
+		rate, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || rate <= 0 {
+			return nil, Errorf(ErrInvalidArgument, "invalid rate limit '%s', rate must be a number of calls per second > 0", entry)
+		}
+		limit.Rate, limit.Burst = rate, int(rate)
+		if len(values) == 2 {
+			if limit.Burst, err = strconv.Atoi(values[1]); err != nil || limit.Burst < 1 {
+				return nil, Errorf(ErrInvalidArgument, "invalid rate limit '%s', burst must be a number of calls > 0", entry)
+			}
+		}
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		for _, l := range limits {
+			if l.Method == limit.Method {
+				return nil, Errorf(ErrInvalidArgument, "rate limit of '%s' set twice", limit.Method)
+			}
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
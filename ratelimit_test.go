@@ -0,0 +1,26 @@
+package numan
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits(" *=20:40, Numbering/List=2:10,User/*=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RateLimit{{Method: "*", Rate: 20, Burst: 40}, {Method: "Numbering/List", Rate: 2, Burst: 10}, {Method: "User/*", Rate: 0.5, Burst: 1}}
+	if !reflect.DeepEqual(limits, want) {
+		t.Fatalf("got %+v, want %+v", limits, want)
+	}
+	if limits, err := ParseRateLimits(""); err != nil || len(limits) != 0 {
+		t.Fatalf("empty config got %+v (err %v)", limits, err)
+	}
+	for _, config := range []string{"Numbering/List", "List=1", "/numan.v1.Numbering/List=1", "*=0", "*=-1", "*=x", "*=1:0", "*=1:x", "*=1,*=2"} {
+		if _, err := ParseRateLimits(config); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("%s got err %v, want ErrInvalidArgument", config, err)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package numan
+
+//RequestIDField is the gRPC metadata field (and ctx key) for the request ID, sent by the client (or set by the server) and logged with each request
+const RequestIDField = "x-request-id"
@@ -0,0 +1,76 @@
+package numan
+
+import (
+	"regexp"
+)
+
+//Permissions are checked by the services, roles are named sets of permissions assigned to users.
+const (
+	PermNumbersRead     = "numbers:read"     //list, view, summary & watch
+	PermNumbersAdd      = "numbers:add"      //add & update numbers
+	PermNumbersAllocate = "numbers:allocate" //reserve, allocate & de-allocate
+	PermNumbersPort     = "numbers:port"     //set port in/out dates
+	PermNumbersDelete   = "numbers:delete"   //delete numbers
+	PermHistoryRead     = "history:read"     //list history
+	PermHistoryWrite    = "history:write"    //add history entries
+	PermHistoryArchive  = "history:archive"  //run history retention
+	PermUsersAdmin      = "users:admin"      //manage users & roles
+	PermKeysAdmin       = "keys:admin"       //manage token signing keys
+	PermWebhooksAdmin   = "webhooks:admin"   //manage webhooks
+	PermRateLimitsRead  = "ratelimits:read"  //view rate limits & usage
+	PermAll             = "*"                //all permissions
+	//Built in roles (see migrations), admin can't be changed or deleted
+	RoleAdmin    = "admin"
+	RoleOperator = "operator" //user, also ports & deletes numbers
+	RoleUser     = "user"
+	RoleViewer   = "viewer"
+	PatternRole  = "^[a-z][a-z0-9_-]{1,31}$"
+)
+
+//Permissions lists the known permissions
+var Permissions = []string{
+	PermNumbersRead,
+	PermNumbersAdd,
+	PermNumbersAllocate,
+	PermNumbersPort,
+	PermNumbersDelete,
+	PermHistoryRead,
+	PermHistoryWrite,
+	PermHistoryArchive,
+	PermUsersAdmin,
+	PermKeysAdmin,
+	PermWebhooksAdmin,
+	PermRateLimitsRead,
+}
+
+//Role is a named set of permissions
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+//ValidRoleName checks if the format of a role name is valid
+func ValidRoleName(name string) bool {
+	res, _ := regexp.MatchString(PatternRole, name)
+	return res
+}
+
+//ValidPermissions checks Role.Permissions are known permissions
+func (r *Role) ValidPermissions() error {
+	for _, p := range r.Permissions {
+		if p != PermAll && !contains(Permissions, p) {
+			return Errorf(ErrInvalidArgument, "unknown permission '%v'", p)
+		}
+	}
+	return nil
+}
+
+//HasPermission returns true if permissions include permission (or all permissions)
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission || p == PermAll {
+			return true
+		}
+	}
+	return false
+}
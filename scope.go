@@ -0,0 +1,131 @@
+package numan
+
+import (
+	"regexp"
+	"strings"
+)
+
+//PatternPrefix is a number prefix of a scope, cc[-ndc[-sn]] ex. 353-01
+const PatternPrefix = `^[1-9][0-9]{0,2}(-[01][1-9][0-9]{0,3}(-[0-9]{1,13})?)?$`
+
+//Scope restricts a user to numbers matching all of its lists, an empty list is unrestricted.
+//Scoped users only see numbers in scope and can't change numbers outside it.
+type Scope struct {
+	Domains  []string `json:"domains,omitempty"`
+	Carriers []string `json:"carriers,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"` //see PatternPrefix
+}
+
+//Unrestricted returns true if the scope allows all numbers
+func (s Scope) Unrestricted() bool {
+	return len(s.Domains) == 0 && len(s.Carriers) == 0 && len(s.Prefixes) == 0
+}
+
+//Allows returns true if number is in scope
+func (s Scope) Allows(number Numbering) bool {
+	return (len(s.Domains) == 0 || contains(s.Domains, number.Domain)) &&
+		(len(s.Carriers) == 0 || contains(s.Carriers, number.Carrier)) &&
+		s.AllowsE164(number.E164)
+}
+
+//AllowsE164 returns true if the number matches a scope prefix
+func (s Scope) AllowsE164(number E164) bool {
+	if len(s.Prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range s.Prefixes {
+		p := strings.SplitN(prefix, "-", 3)
+		if p[0] != number.Cc || (len(p) > 1 && p[1] != number.Ndc) || (len(p) > 2 && !strings.HasPrefix(number.Sn, p[2])) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+//Filter returns the numbers in scope
+func (s Scope) Filter(numbers []Numbering) []Numbering {
+	if s.Unrestricted() {
+		return numbers
+	}
+	var filtered []Numbering
+	for _, n := range numbers {
+		if s.Allows(n) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
+//Narrow returns outer narrowed to s, false if s allows numbers outside outer.
+//Lists s doesn't restrict (empty) keep the outer list.
+func (s Scope) Narrow(outer Scope) (Scope, bool) {
+	narrowed := outer
+	if len(s.Domains) > 0 {
+		if len(outer.Domains) > 0 && !subset(s.Domains, outer.Domains) {
+			return outer, false
+		}
+		narrowed.Domains = s.Domains
+	}
+	if len(s.Carriers) > 0 {
+		if len(outer.Carriers) > 0 && !subset(s.Carriers, outer.Carriers) {
+			return outer, false
+		}
+		narrowed.Carriers = s.Carriers
+	}
+	if len(s.Prefixes) > 0 {
+		for _, prefix := range s.Prefixes {
+			if len(outer.Prefixes) > 0 && !outer.coversPrefix(prefix) {
+				return outer, false
+			}
+		}
+		narrowed.Prefixes = s.Prefixes
+	}
+	return narrowed, true
+}
+
+//coversPrefix returns true if all numbers of prefix match a scope prefix (ex. 353 covers 353-01)
+func (s Scope) coversPrefix(prefix string) bool {
+	p := strings.SplitN(prefix, "-", 3)
+	for _, outer := range s.Prefixes {
+		o := strings.SplitN(outer, "-", 3)
+		if len(o) > len(p) || o[0] != p[0] || (len(o) > 1 && o[1] != p[1]) || (len(o) > 2 && !strings.HasPrefix(p[2], o[2])) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+//Valid checks the scope prefixes are valid
+func (s Scope) Valid() error {
+	for _, prefix := range s.Prefixes {
+		if ok, _ := regexp.MatchString(PatternPrefix, prefix); !ok {
+			return Errorf(ErrInvalidArgument, "invalid scope prefix '%s'", prefix)
+		}
+	}
+	if contains(s.Domains, "") || contains(s.Carriers, "") {
+		return Errorf(ErrInvalidArgument, "empty scope domain or carrier")
+	}
+	return nil
+}
+
+//subset returns true if all of list are in of
+func subset(list []string, of []string) bool {
+	for _, v := range list {
+		if !contains(of, v) {
+			return false
+		}
+	}
+	return true
+}
+
+//contains returns true if list contains v
+func contains(list []string, v string) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package numan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	number := Numbering{E164: E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "a.com", Carrier: "carrier1"}
+	tests := []struct {
+		name  string
+		scope Scope
+		want  bool
+	}{
+		{"Unrestricted", Scope{}, true},
+		{"Domain", Scope{Domains: []string{"b.com", "a.com"}}, true},
+		{"OtherDomain", Scope{Domains: []string{"b.com"}}, false},
+		{"Carrier", Scope{Carriers: []string{"carrier1"}}, true},
+		{"OtherCarrier", Scope{Domains: []string{"a.com"}, Carriers: []string{"carrier2"}}, false},
+		{"PrefixCc", Scope{Prefixes: []string{"353"}}, true},
+		{"PrefixNdc", Scope{Prefixes: []string{"44-20", "353-01"}}, true},
+		{"PrefixSn", Scope{Prefixes: []string{"353-01-123"}}, true},
+		{"OtherPrefixNdc", Scope{Prefixes: []string{"353-021"}}, false},
+		{"OtherPrefixSn", Scope{Prefixes: []string{"353-01-9"}}, false},
+		{"PartialCc", Scope{Prefixes: []string{"35"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.Allows(number); got != tt.want {
+				t.Fatalf("Allows got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := (Scope{Prefixes: []string{"353-01"}}).Valid(); err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{"", "0353", "353-1", "353-01-", "a"} {
+		if err := (Scope{Prefixes: []string{prefix}}).Valid(); err == nil {
+			t.Fatalf("Valid accepted prefix '%v'", prefix)
+		}
+	}
+}
+
+func TestScopeNarrow(t *testing.T) {
+	outer := Scope{Domains: []string{"a.com", "b.com"}, Prefixes: []string{"353-01", "44"}}
+	tests := []struct {
+		name  string
+		scope Scope
+		want  Scope
+		ok    bool
+	}{
+		{"Unrestricted", Scope{}, outer, true},
+		{"Domain", Scope{Domains: []string{"b.com"}}, Scope{Domains: []string{"b.com"}, Prefixes: outer.Prefixes}, true},
+		{"Carrier", Scope{Carriers: []string{"carrier1"}}, Scope{Domains: outer.Domains, Carriers: []string{"carrier1"}, Prefixes: outer.Prefixes}, true},
+		{"PrefixSn", Scope{Prefixes: []string{"353-01-123", "44-20"}}, Scope{Domains: outer.Domains, Prefixes: []string{"353-01-123", "44-20"}}, true},
+		{"OtherDomain", Scope{Domains: []string{"a.com", "c.com"}}, outer, false},
+		{"WiderPrefix", Scope{Prefixes: []string{"353"}}, outer, false},
+		{"OtherPrefix", Scope{Prefixes: []string{"353-021"}}, outer, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.scope.Narrow(outer)
+			if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+				t.Fatalf("Narrow got %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+#! /bin/bash 
+# Creates a client certificate for mutual TLS, signed by a client CA (created if missing). 
+# usage: ./gen_client_certs.sh <username> [days]
+# The certificate common name is the numan user (or service account) it authenticates as.
+# Set TLS_CLIENT_CA = client-ca.pem in numd.env and TLS_CLIENT_CERT/TLS_CLIENT_KEY in num.env/numa.env 
+if [ -z "$1" ]; then
+	echo "usage: $0 <username> [days]"
+	exit 1
+fi
+DIR=../examples
+DAYS=${2:-365}
+# Client CA
+if [ ! -f $DIR/client-ca.pem ]; then
+	openssl req -x509 -newkey rsa:4096 -keyout $DIR/client-ca-key.pem -out $DIR/client-ca.pem -days 3650 -nodes -subj "/CN=numan client CA"
+fi
+# Client cert 
+openssl req -newkey rsa:2048 -keyout $DIR/$1-key.pem -out $DIR/$1.csr -nodes -subj "/CN=$1"
+openssl x509 -req -in $DIR/$1.csr -CA $DIR/client-ca.pem -CAkey $DIR/client-ca-key.pem -CAcreateserial -out $DIR/$1-cert.pem -days $DAYS -extfile <(printf "extendedKeyUsage = clientAuth")
+rm $DIR/$1.csr
@@ -2,8 +2,10 @@ package numan
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"regexp"
 	"time"
 
@@ -16,22 +18,41 @@ import (
 //auth a user
 //validate a token (internal)
 const (
-	secretKey     = "secret"
 	tokenDuration = 15 * time.Minute
 	//tokenDuration  = 1 * time.Minute //TODO testing
-	AuthTokenField     = "token" //field name to use in ctx and meta data for storing auth token
-	RoleUser           = "user"
-	RoleAdmin          = "admin"
-	PatternUser        = "^[1-9a-z]{3,13}$"
-	PatternRawPassword = "^[1-9a-zA-Z._%-]{5,13}$"
+	refreshTokenDuration = 30 * 24 * time.Hour
+	AuthTokenField       = "token"        //field name to use in ctx and meta data for storing auth token
+	OIDCTokenField       = "x-oidc-token" //field name in ctx and meta data for sending an external identity provider (OIDC) token instead
+	PatternUser          = "^[1-9a-z][0-9a-z._@-]{2,63}$"
+	PatternRawPassword   = "^.{1,72}$" //any password bcrypt can hash, strength is checked by PasswordPolicy
 )
 
 type User struct {
-	UID         int64
-	Username    string
-	Password    string //can be hashed or raw
-	Role        string
-	AccessToken string
+	UID          int64
+	Username     string
+	Password     string   //can be hashed or raw
+	Roles        []string //role names (see Role)
+	Scope        Scope    //numbers the user can access
+	AccessToken  string
+	RefreshToken string //long lived token to get a new access token (see UserService.Refresh)
+	TokenVersion int64  //incremented to invalidate all of a users access tokens
+	TokenID      string //set from access token, unique id (jti) for revocation
+	TokenExpires int64  //set from access token
+	RolesLimited bool   //Roles are a limit (ex. API key) on the users roles, otherwise the users current roles apply
+	Status       AccountStatus
+	LastLogin    int64 //timestamp of last password login OR 0 never
+}
+
+//AccountStatus is the lifecycle state of a user account
+type AccountStatus struct {
+	Disabled           bool  //disabled users can't login, their tokens, API keys & certificates are refused
+	PasswordExpires    int64 //timestamp the password expires OR 0 never, an expired password must be changed before login
+	MustChangePassword bool  //password must be changed before login (see UserService.ChangePassword)
+}
+
+//PasswordExpired returns true if the password has expired
+func (a AccountStatus) PasswordExpired() bool {
+	return a.PasswordExpires != 0 && a.PasswordExpires < time.Now().Unix()
 }
 
 //UserService exposes interface for managing users
@@ -46,61 +67,133 @@ type UserService interface {
 	ListUsers(ctx context.Context, userfilter string) ([]User, error)
 	//SetPassword changes a users password
 	SetPassword(ctx context.Context, username string, newPassword string) error
+	//ChangePassword changes a users own password, authenticated by the old password (not a token) so expired passwords can be changed
+	ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) error
+	//SetStatus sets a users account status (disable, password expiry, forced password change), the users access tokens are revoked
+	SetStatus(ctx context.Context, username string, status AccountStatus) error
+	//RotateKey adds a new token signing key for algorithm (empty for current algorithm), previous keys verify tokens until they expire.
+	RotateKey(ctx context.Context, algorithm string) (SigningKey, error)
+	//ListKeys lists the token signing keys (public part only), no authentication is required
+	ListKeys(ctx context.Context) ([]SigningKey, error)
+	//Refresh exchanges a refresh token for a new access token & refresh token (the old refresh token is used up)
+	Refresh(ctx context.Context, refreshToken string) (user User, err error)
+	//Logout revokes the refresh token and the access token in context
+	Logout(ctx context.Context, refreshToken string) error
+	//SetRole adds a role or replaces the permissions of an existing role
+	SetRole(ctx context.Context, role Role) error
+	//DeleteRole removes a role, it must not be granted to any users
+	DeleteRole(ctx context.Context, name string) error
+	//ListRoles returns all roles
+	ListRoles(ctx context.Context) ([]Role, error)
+	//GrantRole adds a role to a user
+	GrantRole(ctx context.Context, username string, role string) error
+	//RevokeRole removes a role from a user
+	RevokeRole(ctx context.Context, username string, role string) error
+	//SetScope sets the numbers a user can access, the users access tokens are revoked
+	SetScope(ctx context.Context, username string, scope Scope) error
+	//AddAPIKey issues an API key for key.Username with expiry key.Expires, limited to key.Roles & key.Scope (within the users). The secret key is only returned here
+	AddAPIKey(ctx context.Context, key APIKey) (APIKey, error)
+	//ListAPIKeys lists API keys of matching users (without secret keys)
+	ListAPIKeys(ctx context.Context, userfilter string) ([]APIKey, error)
+	//DeleteAPIKey revokes an API key
+	DeleteAPIKey(ctx context.Context, id string) error
+	//AuthAPIKey authenticates an API key and returns a copy of the key user data with JWT token (no refresh token)
+	AuthAPIKey(ctx context.Context, key string) (user User, err error)
+	//Unlock clears the failed logins and lockout of a username or source address (see LoginPolicy)
+	Unlock(ctx context.Context, name string) error
+	//ListAudit returns the audit log of matching users, most recent first
+	ListAudit(ctx context.Context, userfilter string) ([]AuditEntry, error)
 }
 
 //userClaims is JWT claims object
 type userClaims struct {
 	jwt.StandardClaims
-	UID      int64  `json:"uid"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UID      int64    `json:"uid"`
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+	Scope    Scope    `json:"scope"`
+	Version  int64    `json:"ver"`
+	Limited  bool     `json:"lim,omitempty"`
 }
 
 //SetGeneratedToken creates a JWT access token in UserAuth struct
 func (u *User) SetNewAccessToken() (err error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return err
+	}
 	// Set claims
 	claims := userClaims{
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: time.Now().Add(tokenDuration).Unix(),
 		},
 		UID:      u.UID,
 		Username: u.Username,
-		Role:     u.Role,
-	} // create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// Sign and store the complete encoded access token as a string
-	u.AccessToken, err = token.SignedString([]byte(secretKey))
+		Roles:    u.Roles,
+		Scope:    u.Scope,
+		Version:  u.TokenVersion,
+		Limited:  u.RolesLimited,
+	} // Sign and store the complete encoded access token as a string
+	u.AccessToken, err = CurrentKeySet().sign(claims)
 	return
 }
 
 //SetUserWithToken verifies & reads claims into userAuth from raw accessToken
 func (u *User) SetUserFromToken(accessToken string) (err error) {
+	return u.SetUserFromTokenWithKeys(accessToken, CurrentKeySet())
+}
+
+//SetUserFromTokenWithKeys verifies accessToken with keys & reads claims into user.
+//Clients can verify tokens with the server public keys (see NewPublicKeySet).
+func (u *User) SetUserFromTokenWithKeys(accessToken string, keys *KeySet) (err error) {
 	token, err := jwt.ParseWithClaims(
 		accessToken,
 		&userClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			_, ok := token.Method.(*jwt.SigningMethodHMAC)
-			if !ok {
-				return nil, fmt.Errorf("Auth error: unexpected token signing method")
-			}
-			return []byte(secretKey), nil
-		},
+		keys.verifyKey,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Auth error: invalid token: %w", err)
+		return Errorf(ErrUnauthenticated, "Auth error: invalid token: %w", err)
 	}
 
 	claims, ok := token.Claims.(*userClaims)
 	if !ok {
-		return fmt.Errorf("Auth error: invalid token claims")
+		return Errorf(ErrUnauthenticated, "Auth error: invalid token claims")
 	}
 	u.UID = claims.UID
 	u.Username = claims.Username
-	u.Role = claims.Role
+	u.Roles = claims.Roles
+	u.Scope = claims.Scope
+	u.TokenVersion = claims.Version
+	u.TokenID = claims.Id
+	u.TokenExpires = claims.ExpiresAt
+	u.RolesLimited = claims.Limited
 	return nil
 }
 
+//SetNewRefreshToken creates a random refresh token in User, it must be stored hashed (see HashToken) by the service.
+func (u *User) SetNewRefreshToken() (expires int64, err error) {
+	u.RefreshToken, err = randomToken(32)
+	return time.Now().Add(refreshTokenDuration).Unix(), err
+}
+
+//HashToken returns the hash a refresh token is stored as
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+//randomToken returns n random bytes, url safe base64 encoded
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 //AuthRefreshRequired returns true if UserAuth.AccessToken expired/invalid.
 //for client use, token is parsed unverified.
 func (u *User) AuthRefreshRequired() bool {
@@ -121,13 +214,13 @@ func (u *User) AuthRefreshRequired() bool {
 	return false
 }
 
-//ValidUsername checks if the format of User.Username is valid
+//ValidUsername checks if the format of User.Username is valid, a whole email address or host name is allowed (OIDC & certificate users)
 func (u *User) ValidUsername() bool {
 	res, _ := regexp.MatchString(PatternUser, u.Username)
 	return res
 }
 
-//ValidPasssword checks if User.Password is valid RAW password
+//ValidRawPassword checks if User.Password is a valid RAW password (see PasswordPolicy for strength)
 func (u *User) ValidRawPassword() bool {
 	res, _ := regexp.MatchString(PatternRawPassword, u.Password)
 	return res
@@ -155,7 +248,7 @@ func (u *User) ComparePassword(password string) error {
 		return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	}
 	if !(u.Password == password) {
-		return errors.New("password mismatch")
+		return Errorf(ErrUnauthenticated, "password mismatch")
 	}
 	return nil
 }
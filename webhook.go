@@ -0,0 +1,109 @@
+package numan
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//Webhook delivery HTTP headers. The signature is "sha256=" & the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret (see SignWebhook).
+const (
+	WebhookSignatureHeader = "X-Numan-Signature"
+	WebhookTimestampHeader = "X-Numan-Timestamp"
+	WebhookEventHeader     = "X-Numan-Event"
+	WebhookDeliveryHeader  = "X-Numan-Delivery"
+)
+
+//Webhook is a subscription to number events, delivered as signed JSON (see WebhookPayload) by HTTP POST to URL
+type Webhook struct {
+	ID      int64
+	URL     string
+	Filter  EventFilter //events delivered (AfterSeq not used)
+	Secret  string      //HMAC signing key, only set when added
+	Created int64
+	Pending int64 //deliveries waiting or being retried
+	Dead    int64 //deliveries in the dead-letter list
+}
+
+//Valid checks the webhook URL & filter are valid
+func (w Webhook) Valid() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return Errorf(ErrInvalidArgument, "invalid webhook URL '%s' (http[s]://host/path)", w.URL)
+	}
+	return w.Filter.Valid()
+}
+
+//WebhookPayload is the JSON body of a webhook delivery, a number event with the number formatted cc-ndc-sn
+type WebhookPayload struct {
+	Seq       int64  `json:"seq"`
+	Type      string `json:"type"`
+	Number    string `json:"number"`
+	Domain    string `json:"domain"`
+	Carrier   string `json:"carrier"`
+	OldState  string `json:"oldState"`
+	NewState  string `json:"newState"`
+	OwnerID   int64  `json:"ownerId"`
+	Actor     string `json:"actor"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+//NewWebhookPayload returns the payload of event e
+func NewWebhookPayload(e NumberEvent) WebhookPayload {
+	return WebhookPayload{Seq: e.Seq, Type: e.Type, Number: e.E164.Cc + "-" + e.E164.Ndc + "-" + e.E164.Sn, Domain: e.Domain, Carrier: e.Carrier,
+		OldState: e.OldState, NewState: e.NewState, OwnerID: e.OwnerID, Actor: e.Actor, Timestamp: e.Timestamp}
+}
+
+//Event returns the number event of the payload
+func (p WebhookPayload) Event() NumberEvent {
+	e := NumberEvent{Seq: p.Seq, Type: p.Type, Domain: p.Domain, Carrier: p.Carrier,
+		OldState: p.OldState, NewState: p.NewState, OwnerID: p.OwnerID, Actor: p.Actor, Timestamp: p.Timestamp}
+	if parts := strings.SplitN(p.Number, "-", 3); len(parts) == 3 {
+		e.E164 = E164{Cc: parts[0], Ndc: parts[1], Sn: parts[2]}
+	}
+	return e
+}
+
+//WebhookDelivery is a queued delivery of a payload to a webhook
+type WebhookDelivery struct {
+	ID          int64
+	WebhookID   int64
+	Payload     WebhookPayload
+	Attempts    int
+	NextAttempt int64  //timestamp of the next attempt (not set for dead letters)
+	LastError   string //error of the last attempt
+	Dead        bool   //attempts exhausted, not retried unless redelivered
+	Created     int64
+}
+
+//SignWebhook returns the signature header value of a delivery body sent at timestamp, receivers compare it with hmac.Equal
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+//SetNewSecret generates a new webhook signing secret
+func (w *Webhook) SetNewSecret() (err error) {
+	w.Secret, err = randomToken(32)
+	return err
+}
+
+//WebhookService manages webhook subscriptions & their dead-letter lists
+type WebhookService interface {
+	//AddWebhook subscribes URL to events matching the filter from now on, a secret is generated if not set
+	AddWebhook(ctx context.Context, hook Webhook) (Webhook, error)
+	//ListWebhooks returns the webhooks (without secrets) with their pending & dead delivery counts
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	//DeleteWebhook deletes a webhook & its queued deliveries
+	DeleteWebhook(ctx context.Context, id int64) error
+	//ListDeadLetters returns the dead deliveries of a webhook (0 all webhooks), oldest first
+	ListDeadLetters(ctx context.Context, webhookID int64) ([]WebhookDelivery, error)
+	//RedeliverDeadLetters queues the dead deliveries of a webhook (0 all webhooks) again, returns the number queued
+	RedeliverDeadLetters(ctx context.Context, webhookID int64) (int64, error)
+}
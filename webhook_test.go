@@ -0,0 +1,23 @@
+package numan
+
+import "testing"
+
+func TestSignWebhook(t *testing.T) {
+	//echo -n '1600000000.{"seq":1}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=a75b1e15906c8875a6c9bb7cb86edc3806d7656869feed7f06164463facc1e46"
+	if got := SignWebhook("secret", 1600000000, []byte(`{"seq":1}`)); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	e := NumberEvent{Seq: 3, Type: EventAllocated, E164: E164{Cc: "353", Ndc: "01", Sn: "12345001"}, Domain: "a.com", Carrier: "carrier",
+		OldState: StateFree, NewState: StateAllocated, OwnerID: 7, Actor: "alice", Timestamp: 1600000000}
+	payload := NewWebhookPayload(e)
+	if payload.Number != "353-01-12345001" {
+		t.Fatalf("got number %s", payload.Number)
+	}
+	if got := payload.Event(); got != e {
+		t.Fatalf("got %+v, want %+v", got, e)
+	}
+}